@@ -6,6 +6,12 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+
+	apiv2 "gitlab.com/nunet/device-management-service/api/v2"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
 )
 
 func SetupRouter() *gin.Engine {
@@ -14,82 +20,127 @@ func SetupRouter() *gin.Engine {
 
 	router := gin.Default()
 	router.Use(cors.New(getCustomCorsConfig()))
+	// starts a span per request, joining the trace of the caller if any
+	router.Use(otelgin.Middleware(config.GetConfig().Telemetry.Tracing.ServiceName))
+
+	// scopes required by the routes, see the internal/auth package
+	read := authorize(auth.ScopeRead)
+	operate := authorize(auth.ScopeOperator)
+	admin := authorize(auth.ScopeAdmin)
 
 	v1 := router.Group("/api/v1")
 
+	authGroup := v1.Group("/auth", admin)
+	{
+		authGroup.GET("/tokens", ListTokensHandler)
+		authGroup.POST("/tokens", CreateTokenHandler)
+		authGroup.DELETE("/tokens/:id", RevokeTokenHandler)
+	}
+
 	onboarding := v1.Group("/onboarding")
 	{
-		onboarding.GET("/metadata", GetMetadataHandler)
-		onboarding.GET("/provisioned", ProvisionedCapacityHandler)
-		onboarding.GET("/address/new", CreatePaymentAddressHandler)
-		onboarding.GET("/status", OnboardStatusHandler)
-		onboarding.POST("/onboard", OnboardHandler)
-		onboarding.POST("/resource-config", ResourceConfigHandler)
-		onboarding.POST("/offboard", OffboardHandler)
+		onboarding.GET("/metadata", read, GetMetadataHandler)
+		onboarding.GET("/provisioned", read, ProvisionedCapacityHandler)
+		onboarding.GET("/address/new", admin, CreatePaymentAddressHandler)
+		onboarding.GET("/status", read, OnboardStatusHandler)
+		onboarding.POST("/onboard", admin, OnboardHandler)
+		onboarding.POST("/resource-config", admin, ResourceConfigHandler)
+		onboarding.POST("/offboard", admin, OffboardHandler)
 	}
 
 	device := v1.Group("/device")
 	{
-		device.GET("/status", DeviceStatusHandler)
-		device.POST("/status", ChangeDeviceStatusHandler)
+		device.GET("/status", read, DeviceStatusHandler)
+		device.POST("/status", operate, ChangeDeviceStatusHandler)
 	}
 
 	vm := v1.Group("/vm")
 	{
-		vm.POST("/start-default", StartDefaultHandler)
-		vm.POST("/start-custom", StartCustomHandler)
+		vm.POST("/start-default", operate, StartDefaultHandler)
+		vm.POST("/start-custom", operate, StartCustomHandler)
 	}
 
 	run := v1.Group("/run")
 	{
-		run.GET("/deploy", DeploymentRequestHandler) // websocket
-		run.GET("/checkpoints", ListCheckpointHandler)
-		run.POST("/request-service", RequestServiceHandler)
+		run.GET("/deploy", operate, DeploymentRequestHandler) // websocket
+		run.GET("/checkpoints", read, ListCheckpointHandler)
+		run.POST("/request-service", operate, RequestServiceHandler)
 	}
 
 	tx := v1.Group("/transactions")
 	{
-		tx.GET("", GetJobTxHashesHandler)
-		tx.POST("/request-reward", RequestRewardHandler)
-		tx.POST("/send-status", SendTxStatusHandler)
-		tx.POST("/update-status", UpdateTxStatusHandler)
+		tx.GET("", read, GetJobTxHashesHandler)
+		tx.POST("/request-reward", admin, RequestRewardHandler)
+		tx.POST("/send-status", operate, SendTxStatusHandler)
+		tx.POST("/update-status", operate, UpdateTxStatusHandler)
 	}
 
 	tele := v1.Group("/telemetry")
 	{
-		tele.GET("/free", GetFreeResourcesHandler)
+		tele.GET("/free", read, GetFreeResourcesHandler)
+	}
+
+	logging := v1.Group("/logging")
+	{
+		logging.GET("/levels", read, ListLogLevelsHandler)
+		logging.PUT("/levels/:package", operate, SetLogLevelHandler)
+		logging.DELETE("/levels/:package", operate, ResetLogLevelHandler)
+	}
+
+	tasks := v1.Group("/tasks")
+	{
+		tasks.GET("", read, ListTasksHandler)
+		tasks.GET("/:id", read, ShowTaskHandler)
+		tasks.POST("/:id/run", operate, RunTaskHandler)
+		tasks.POST("/:id/cancel", operate, CancelTaskHandler)
+		tasks.POST("/:id/disable", operate, DisableTaskHandler)
+		tasks.POST("/:id/enable", operate, EnableTaskHandler)
 	}
 
 	if _, debugMode := os.LookupEnv("NUNET_DEBUG"); debugMode {
 		dht := v1.Group("/dht")
 		{
-			dht.GET("/update", ManualDHTUpdateHandler)
+			dht.GET("/update", operate, ManualDHTUpdateHandler)
 		}
 		kadDHT := v1.Group("/kad-dht")
 		{
-			kadDHT.GET("", DumpKademliaDHTHandler)
+			kadDHT.GET("", read, DumpKademliaDHTHandler)
 		}
-		v1.GET("/ping", PingPeerHandler)
-		v1.GET("/oldping", OldPingPeerHandler)
-		v1.GET("/cleanup", CleanupPeerHandler)
+		v1.GET("/ping", read, PingPeerHandler)
+		v1.GET("/oldping", read, OldPingPeerHandler)
+		v1.GET("/cleanup", operate, CleanupPeerHandler)
 	}
 
 	p2p := v1.Group("/peers")
 	{
-		p2p.GET("", ListPeersHandler)
-		p2p.GET("/dht", ListDHTPeersHandler)
-		p2p.GET("/dht/dump", DumpDHTHandler)
-		p2p.GET("/kad-dht", ListKadDHTPeersHandler)
-		p2p.GET("/self", SelfPeerInfoHandler)
-		p2p.GET("/depreq", DefaultDepReqPeerHandler)
-		p2p.GET("/chat", ListChatHandler)
-		p2p.GET("/chat/start", StartChatHandler)
-		p2p.GET("/chat/join", JoinChatHandler)
-		p2p.GET("/chat/clear", ClearChatHandler)
-		p2p.GET("/file", ListFileTransferRequestsHandler)
-		p2p.GET("/file/send", SendFileTransferHandler)
-		p2p.GET("/file/accept", AcceptFileTransferHandler)
-		p2p.GET("/file/clear", ClearFileTransferRequestsHandler)
+		p2p.GET("", read, ListPeersHandler)
+		p2p.GET("/dht", read, ListDHTPeersHandler)
+		p2p.GET("/dht/dump", read, DumpDHTHandler)
+		p2p.GET("/kad-dht", read, ListKadDHTPeersHandler)
+		p2p.GET("/self", read, SelfPeerInfoHandler)
+		p2p.GET("/stat", read, NetStatHandler)
+		p2p.GET("/usage", read, NetUsageHandler)
+		p2p.GET("/ping", read, PingHandler)
+		p2p.GET("/depreq", operate, DefaultDepReqPeerHandler)
+		p2p.GET("/chat", read, ListChatHandler)
+		p2p.GET("/chat/start", operate, StartChatHandler)
+		p2p.GET("/chat/join", operate, JoinChatHandler)
+		p2p.GET("/chat/clear", operate, ClearChatHandler)
+		p2p.GET("/file", read, ListFileTransferRequestsHandler)
+		p2p.GET("/file/send", operate, SendFileTransferHandler)
+		p2p.GET("/file/accept", operate, AcceptFileTransferHandler)
+		p2p.GET("/file/clear", operate, ClearFileTransferRequestsHandler)
+	}
+
+	// resource-oriented API with uniform errors, see the api/v2 package
+	apiv2.Register(router.Group("/api/v2"), func(scope auth.Scope) gin.HandlerFunc {
+		return authorizeWith(scope, apiv2.AbortAuth)
+	})
+
+	// scraped by Prometheus, served here unless it has its own listen address
+	metricsConfig := config.GetConfig().Telemetry.Metrics
+	if metricsConfig.Enabled && metricsConfig.ListenAddress == "" {
+		router.GET("/metrics", read, gin.WrapH(metrics.Handler()))
 	}
 
 	return router
@@ -97,16 +148,25 @@ func SetupRouter() *gin.Engine {
 
 func getCustomCorsConfig() cors.Config {
 	config := DefaultConfig()
-	// FIXME: This is a security concern.
-	config.AllowOrigins = []string{"http://localhost:9991", "http://localhost:9992"}
+	config.AllowOrigins = configuredOrigins()
 	return config
 }
 
+// configuredOrigins returns the origins of rest.cors.allow_origins, cors.New
+// panics without any
+func configuredOrigins() []string {
+	origins := config.GetConfig().Rest.CORS.AllowOrigins
+	if len(origins) == 0 {
+		return []string{"http://localhost:9991"}
+	}
+	return origins
+}
+
 // DefaultConfig returns a generic default configuration mapped to localhost.
 func DefaultConfig() cors.Config {
 	return cors.Config{
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Access-Control-Allow-Origin", "Origin", "Content-Length", "Content-Type"},
+		AllowHeaders:     []string{"Access-Control-Allow-Origin", "Origin", "Content-Length", "Content-Type", "Authorization"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	}
@@ -90,6 +90,22 @@ func SetupMockRouter() *gin.Engine {
 	{
 		tele.GET("/free", m.GetFreeResourcesHandler)
 	}
+	// the logging handlers only change the levels of the loggers
+	logging := v1.Group("/logging")
+	{
+		logging.GET("/levels", ListLogLevelsHandler)
+		logging.PUT("/levels/:package", SetLogLevelHandler)
+		logging.DELETE("/levels/:package", ResetLogLevelHandler)
+	}
+	tasks := v1.Group("/tasks")
+	{
+		tasks.GET("", m.ListTasksHandler)
+		tasks.GET("/:id", m.ShowTaskHandler)
+		tasks.POST("/:id/run", m.RunTaskHandler)
+		tasks.POST("/:id/cancel", m.CancelTaskHandler)
+		tasks.POST("/:id/disable", m.DisableTaskHandler)
+		tasks.POST("/:id/enable", m.EnableTaskHandler)
+	}
 	if debug == true {
 		dht := v1.Group("/dht")
 		{
@@ -110,6 +126,9 @@ func SetupMockRouter() *gin.Engine {
 		p2p.GET("/dht", m.ListDHTPeersHandler)
 		p2p.GET("/kad-dht", m.ListKadDHTPeersHandler)
 		p2p.GET("/self", m.SelfPeerInfoHandler)
+		p2p.GET("/stat", m.NetStatHandler)
+		p2p.GET("/usage", m.NetUsageHandler)
+		p2p.GET("/ping", m.PingHandler)
 		p2p.GET("/chat", m.ListChatHandler)
 		p2p.GET("/depreq", m.DefaultDepReqPeerHandler)
 		p2p.GET("/chat/start", m.StartChatHandler)
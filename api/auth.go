@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"gitlab.com/nunet/device-management-service/internal/auth"
+)
+
+var (
+	// authenticator verifies the tokens of the requests, authentication is
+	// disabled while it is nil
+	authenticator *auth.Authenticator
+
+	errMissingCredentials = errors.New("missing credentials: set the Authorization header to Bearer <token>")
+)
+
+// scopeKey is the key of the scope of the client in the gin context
+const scopeKey = "auth.scope"
+
+// unixSocketKey marks the contexts of the connections accepted on the Unix socket
+type unixSocketKey struct{}
+
+// SetAuthenticator enables the authentication of the requests
+func SetAuthenticator(a *auth.Authenticator) {
+	authenticator = a
+}
+
+// ConnContext is the http.Server ConnContext of the API servers, it marks the
+// connections of the Unix socket whose clients are trusted as admins: the
+// permissions of the socket file restrict who can connect
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if _, ok := conn.(*net.UnixConn); ok {
+		return context.WithValue(ctx, unixSocketKey{}, true)
+	}
+	return ctx
+}
+
+// authorize aborts the requests whose client isn't authenticated or whose
+// scope doesn't allow the required one
+func authorize(required auth.Scope) gin.HandlerFunc {
+	return authorizeWith(required, func(c *gin.Context, status int, err error) {
+		c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+	})
+}
+
+// authorizeWith is authorize reporting its failures, 401 or 403, with fail
+func authorizeWith(required auth.Scope, fail func(c *gin.Context, status int, err error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authenticator == nil {
+			c.Next()
+			return
+		}
+
+		scope, client, err := requestClient(c)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="nunet-dms"`)
+			fail(c, 401, err)
+			return
+		}
+		if !scope.Allows(required) {
+			fail(c, 403, fmt.Errorf("the %s scope is required", required))
+			return
+		}
+		c.Set(scopeKey, scope)
+		c.Set(auth.ClientKey, client)
+		c.Next()
+	}
+}
+
+// requestClient authenticates the client with, in order, the Unix socket it
+// connected to, its bearer token, the access_token query parameter of an event
+// stream or its verified TLS certificate. It returns the scope of the client
+// and its description, e.g. the name of its token.
+func requestClient(c *gin.Context) (auth.Scope, string, error) {
+	if trusted, _ := c.Request.Context().Value(unixSocketKey{}).(bool); trusted {
+		return auth.ScopeAdmin, "unix socket", nil
+	}
+
+	if header := c.GetHeader("Authorization"); header != "" {
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			return "", "", errors.New("unsupported authorization scheme, use Bearer")
+		}
+		record, err := authenticator.Verify(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			return "", "", err
+		}
+		return auth.Scope(record.Scope), "token " + record.Name, nil
+	}
+
+	// browsers can't set the headers of EventSource and WebSocket requests
+	if token := c.Query("access_token"); token != "" && isStreamRequest(c.Request) {
+		record, err := authenticator.Verify(c.Request.Context(), token)
+		if err != nil {
+			return "", "", err
+		}
+		return auth.Scope(record.Scope), "token " + record.Name, nil
+	}
+
+	if c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0 {
+		cert := c.Request.TLS.VerifiedChains[0][0]
+		return auth.CertificateScope(cert), "certificate " + cert.Subject.CommonName, nil
+	}
+
+	return "", "", errMissingCredentials
+}
+
+// isStreamRequest reports whether r requests an event stream, over SSE or WebSocket
+func isStreamRequest(r *http.Request) bool {
+	return websocket.IsWebSocketUpgrade(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// ListTokensHandler  godoc
+//
+//	@Summary		List API tokens
+//	@Description	Lists the API tokens issued, without the tokens themselves
+//	@Tags			auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{array}		models.APIToken
+//	@Failure		500	{object}	object	"authentication is disabled"
+//	@Router			/auth/tokens [get]
+func ListTokensHandler(c *gin.Context) {
+	if authenticator == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": "authentication is disabled"})
+		return
+	}
+	tokens, err := authenticator.Tokens(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, tokens)
+}
+
+// CreateTokenHandler  godoc
+//
+//	@Summary		Issue an API token
+//	@Description	Issues an API token with a scope: read, operator or admin. The token is only returned by this request.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		object	true	"name, scope and optional expires_in duration, e.g. 720h"
+//	@Success		201		{object}	object	"token and its record"
+//	@Failure		400		{object}	object	"invalid request body, scope or duration"
+//	@Failure		500		{object}	object	"authentication is disabled"
+//	@Router			/auth/tokens [post]
+func CreateTokenHandler(c *gin.Context) {
+	if authenticator == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": "authentication is disabled"})
+		return
+	}
+
+	var body struct {
+		Name      string `json:"name" binding:"required"`
+		Scope     string `json:"scope" binding:"required"`
+		ExpiresIn string `json:"expires_in"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid request body: name and scope are required"})
+		return
+	}
+	scope, err := auth.ParseScope(body.Scope)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	var ttl time.Duration
+	if body.ExpiresIn != "" {
+		if ttl, err = time.ParseDuration(body.ExpiresIn); err != nil || ttl <= 0 {
+			c.AbortWithStatusJSON(400, gin.H{"error": fmt.Sprintf("invalid expires_in %q", body.ExpiresIn)})
+			return
+		}
+	}
+
+	token, record, err := authenticator.Issue(c.Request.Context(), body.Name, scope, ttl)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, gin.H{"token": token, "record": record})
+}
+
+// RevokeTokenHandler  godoc
+//
+//	@Summary		Revoke an API token
+//	@Description	Revokes an API token, the requests using it are rejected from now on
+//	@Tags			auth
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int		true	"token ID"
+//	@Success		200	{object}	object	"token revoked"
+//	@Failure		400	{object}	object	"invalid token ID"
+//	@Failure		404	{object}	object	"token not found"
+//	@Failure		500	{object}	object	"authentication is disabled"
+//	@Router			/auth/tokens/{id} [delete]
+func RevokeTokenHandler(c *gin.Context) {
+	if authenticator == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": "authentication is disabled"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid token ID"})
+		return
+	}
+	if err := authenticator.Revoke(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, auth.ErrTokenNotFound) {
+			c.AbortWithStatusJSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("token %d revoked", id)})
+}
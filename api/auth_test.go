@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	apiv2 "gitlab.com/nunet/device-management-service/api/v2"
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// setupAuthRouter enables the authentication with an in-memory token store
+// and returns a router with a route per scope
+func setupAuthRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.APIToken{}))
+	SetAuthenticator(auth.NewAuthenticator(repositories_gorm.NewAPITokenRepository(db)))
+	t.Cleanup(func() { SetAuthenticator(nil) })
+
+	ok := func(c *gin.Context) { c.JSON(200, gin.H{"message": "ok"}) }
+	router := gin.New()
+	router.GET("/read", authorize(auth.ScopeRead), ok)
+	router.POST("/operate", authorize(auth.ScopeOperator), ok)
+	tokens := router.Group("/api/v1/auth", authorize(auth.ScopeAdmin))
+	tokens.GET("/tokens", ListTokensHandler)
+	tokens.POST("/tokens", CreateTokenHandler)
+	tokens.DELETE("/tokens/:id", RevokeTokenHandler)
+	return router
+}
+
+func issueToken(t *testing.T, scope auth.Scope) (string, models.APIToken) {
+	t.Helper()
+	token, record, err := authenticator.Issue(context.Background(), "test", scope, 0)
+	require.NoError(t, err)
+	return token, record
+}
+
+func TestAuthorize(t *testing.T) {
+	router := setupAuthRouter(t)
+	readToken, _ := issueToken(t, auth.ScopeRead)
+	operatorToken, _ := issueToken(t, auth.ScopeOperator)
+
+	tests := []struct {
+		description   string
+		method        string
+		path          string
+		authorization string
+		expectedCode  int
+	}{
+		{description: "no credentials", method: "GET", path: "/read", expectedCode: 401},
+		{description: "unknown token", method: "GET", path: "/read", authorization: "Bearer nunet_unknown", expectedCode: 401},
+		{description: "basic auth", method: "GET", path: "/read", authorization: "Basic dXNlcjpwYXNz", expectedCode: 401},
+		{description: "read token", method: "GET", path: "/read", authorization: "Bearer " + readToken, expectedCode: 200},
+		{description: "read token on operator route", method: "POST", path: "/operate", authorization: "Bearer " + readToken, expectedCode: 403},
+		{description: "operator token", method: "POST", path: "/operate", authorization: "Bearer " + operatorToken, expectedCode: 200},
+		{description: "operator token on admin route", method: "GET", path: "/api/v1/auth/tokens", authorization: "Bearer " + operatorToken, expectedCode: 403},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedCode, w.Code, tc.description)
+	}
+}
+
+func TestAuthorizeStreamAccessToken(t *testing.T) {
+	router := setupAuthRouter(t)
+	readToken, _ := issueToken(t, auth.ScopeRead)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/read?access_token="+readToken, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code, "EventSource can't set the Authorization header")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/read?access_token="+readToken, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code, "the query token is only accepted for event streams")
+}
+
+func TestAuthorizeV2Envelope(t *testing.T) {
+	router := setupAuthRouter(t)
+	readToken, _ := issueToken(t, auth.ScopeRead)
+	router.GET("/v2/read", authorizeWith(auth.ScopeRead, apiv2.AbortAuth), func(c *gin.Context) { c.Status(204) })
+	router.POST("/v2/operate", authorizeWith(auth.ScopeOperator, apiv2.AbortAuth), func(c *gin.Context) { c.Status(204) })
+
+	tests := []struct {
+		method        string
+		path          string
+		authorization string
+		expectedCode  int
+		expectedError string
+	}{
+		{method: "GET", path: "/v2/read", expectedCode: 401, expectedError: apiv2.CodeUnauthenticated},
+		{method: "POST", path: "/v2/operate", authorization: "Bearer " + readToken, expectedCode: 403, expectedError: apiv2.CodePermissionDenied},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, nil)
+		if tc.authorization != "" {
+			req.Header.Set("Authorization", tc.authorization)
+		}
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedCode, w.Code)
+
+		var envelope apiv2.ErrorEnvelope
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+		assert.Equal(t, tc.expectedError, envelope.Error.Code)
+	}
+}
+
+func TestAuthorizeUnixSocketAndCertificate(t *testing.T) {
+	router := setupAuthRouter(t)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/auth/tokens", nil)
+	req = req.WithContext(context.WithValue(req.Context(), unixSocketKey{}, true))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code, "the clients of the Unix socket are admins")
+
+	cert := &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"operator"}}}
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/operate", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code, "the scope of a verified certificate comes from its OU")
+}
+
+func TestAuthorizeDisabled(t *testing.T) {
+	SetAuthenticator(nil)
+	router := gin.New()
+	router.POST("/operate", authorize(auth.ScopeAdmin), func(c *gin.Context) { c.Status(204) })
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/operate", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code)
+}
+
+func TestTokenHandlers(t *testing.T) {
+	router := setupAuthRouter(t)
+	adminToken, adminRecord := issueToken(t, auth.ScopeAdmin)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/v1/auth/tokens", `{"name": "dashboard", "scope": "read", "expires_in": "720h"}`)
+	require.Equal(t, 201, w.Code)
+	var created struct {
+		Token  string          `json:"token"`
+		Record models.APIToken `json:"record"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, strings.HasPrefix(created.Token, "nunet_"))
+	assert.Equal(t, "read", created.Record.Scope)
+	assert.NotNil(t, created.Record.ExpiresAt)
+
+	assert.Equal(t, 400, do("POST", "/api/v1/auth/tokens", `{"name": "x", "scope": "root"}`).Code)
+	assert.Equal(t, 400, do("POST", "/api/v1/auth/tokens", `{"name": "x", "scope": "read", "expires_in": "soon"}`).Code)
+	assert.Equal(t, 400, do("POST", "/api/v1/auth/tokens", `{"scope": "read"}`).Code)
+
+	w = do("GET", "/api/v1/auth/tokens", "")
+	require.Equal(t, 200, w.Code)
+	var tokens []models.APIToken
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.Len(t, tokens, 2)
+	assert.NotContains(t, w.Body.String(), `"hash"`)
+
+	assert.Equal(t, 200, do("DELETE", "/api/v1/auth/tokens/"+fmt.Sprint(created.Record.ID), "").Code)
+	assert.Equal(t, 404, do("DELETE", "/api/v1/auth/tokens/"+fmt.Sprint(created.Record.ID), "").Code)
+	assert.Equal(t, 400, do("DELETE", "/api/v1/auth/tokens/first", "").Code)
+
+	// revoking its own token locks the client out
+	assert.Equal(t, 200, do("DELETE", "/api/v1/auth/tokens/"+fmt.Sprint(adminRecord.ID), "").Code)
+	assert.Equal(t, 401, do("GET", "/api/v1/auth/tokens", "").Code)
+}
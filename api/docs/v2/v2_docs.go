@@ -0,0 +1,1731 @@
+// Code generated by swaggo/swag. DO NOT EDIT.
+
+package v2
+
+import "github.com/swaggo/swag"
+
+const docTemplatev2 = `{
+    "schemes": {{ marshal .Schemes }},
+    "swagger": "2.0",
+    "info": {
+        "description": "{{escape .Description}}",
+        "title": "{{.Title}}",
+        "termsOfService": "https://nunet.io/tos",
+        "contact": {
+            "name": "Support",
+            "url": "https://devexchange.nunet.io/",
+            "email": "support@nunet.io"
+        },
+        "license": {
+            "name": "Apache 2.0",
+            "url": "http://www.apache.org/licenses/LICENSE-2.0.html"
+        },
+        "version": "{{.Version}}"
+    },
+    "host": "{{.Host}}",
+    "basePath": "{{.BasePath}}",
+    "paths": {
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams the events of the DMS as Server-Sent Events, or as WebSocket text messages when the request is a WebSocket upgrade. Each event is a JSON object with an id, a type such as execution.started, a time and its data.\nThe topics are event types, e.g. peer.connected, or their category, e.g. peer: execution, peer, resources, onboarding and heartbeat. A client resumes the stream after the last event it received with the Last-Event-ID header, which EventSource sends when it reconnects, or the last_event_id query parameter. When the events following it are no longer retained or were dropped, a stream.reset event is sent first. The log lines of the executions are streamed by /executions/{id}/logs.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "comma-separated topics, all by default",
+                        "name": "topics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "last_event_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Record"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/executions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the running and recently finished executions of the DMS, or of the node of the node query parameter, sorted by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "executions"
+                ],
+                "summary": "List executions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "running, finished or failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "executor, e.g. docker",
+                        "name": "executor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "job of the executions",
+                        "name": "job_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "peer ID of the node running the executions, this node by default",
+                        "name": "node",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, up to 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Page-models_ExecutionInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts an execution of a job spec with the executor of its engine, e.g. docker, and returns it once started. The image of the engine may be pulled first.\nWith the node query parameter, the job runs on that peer, which must allow the peer ID of this node in job.allowed_peers. With node=auto, it runs on the first peer accepting it among those found by capability search.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "executions"
+                ],
+                "summary": "Run a job",
+                "parameters": [
+                    {
+                        "description": "job spec",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.JobSpec"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "peer ID of the node running the job, or auto, this node by default",
+                        "name": "node",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExecutionInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "500": {
+                        "description": "internal",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/executions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shows a running or recently finished execution with its result",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "executions"
+                ],
+                "summary": "Show an execution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "execution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "peer ID of the node running the execution, this node by default",
+                        "name": "node",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExecutionInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancels a running execution",
+                "tags": [
+                    "executions"
+                ],
+                "summary": "Cancel an execution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "execution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "peer ID of the node running the execution, this node by default",
+                        "name": "node",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict, the execution is not running",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/executions/{id}/logs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Streams the output of an execution as plain text, stdout and stderr merged. With follow, the output of a running execution is streamed until it finishes. The output of a finished execution is the one recorded in its result.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "executions"
+                ],
+                "summary": "Stream the logs of an execution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "execution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "follow the output until the execution finishes",
+                        "name": "follow",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "peer ID of the node running the execution, this node by default",
+                        "name": "node",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "output of the execution",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/executions/{id}/shell": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Runs an interactive command in a running execution, e.g. with docker exec, and attaches it to a WebSocket. The input and the output of the command are binary messages. The client sends {\"type\": \"resize\", \"rows\": 24, \"cols\": 80} text messages when its terminal is resized and the DMS sends {\"type\": \"exit\", \"exit_code\": 0}, or {\"type\": \"error\", \"error\": \"...\"}, once the command exits, then closes the connection.\nWith the node query parameter, the shell is opened on that peer, which must allow the peer ID of this node in shell.allowed_peers. Every session is recorded by an audit event on the node running the execution.",
+                "tags": [
+                    "executions"
+                ],
+                "summary": "Open a shell into an execution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "execution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "command and its arguments, repeated, the shell of the execution by default",
+                        "name": "command",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "allocate a pseudo-terminal, true by default",
+                        "name": "tty",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "initial rows of the terminal",
+                        "name": "rows",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "initial columns of the terminal",
+                        "name": "cols",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "peer ID of the node running the execution, this node by default",
+                        "name": "node",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict, the execution is not running",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the jobs the DMS ran as a compute provider, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "List jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "job status, e.g. running",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "transaction type, e.g. done",
+                        "name": "transaction_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, up to 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Page-v2_Job"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shows a job the DMS ran as a compute provider",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Show a job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/peers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the peers known to the node, connected or not, sorted by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "List peers",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only the connected or the disconnected peers",
+                        "name": "connected",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the peers connected through a relay or not",
+                        "name": "relayed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, up to 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Page-models_PeerStat"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/peers/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shows a peer known to the node",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Show a peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "peer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PeerStat"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/peers/{id}/pings": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Pings a peer and returns the round trip time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "peers"
+                ],
+                "summary": "Ping a peer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "peer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "timeout such as 2s, defaults to 5s",
+                        "name": "timeout",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Ping"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable: the peer can't be reached",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the background tasks of the DMS, sorted by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "List tasks",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only the enabled or the disabled tasks",
+                        "name": "enabled",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the running or the idle tasks",
+                        "name": "running",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, up to 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Page-models_TaskStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/tasks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shows a background task with the history of its latest executions",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Show a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enables or disables a background task, runs it now or cancels its running execution. The enabled state is kept across restarts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tasks"
+                ],
+                "summary": "Update a task",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "task ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "changes of the task",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v2.TaskUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TaskStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "409": {
+                        "description": "conflict: the task is already running or isn't running",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/volumes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the storage volumes of the DMS, sorted by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "volumes"
+                ],
+                "summary": "List volumes",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only the locked or the writable volumes",
+                        "name": "read_only",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "only the private or the shareable volumes",
+                        "name": "private",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 50 by default, up to 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Page-v2_Volume"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an empty storage volume for the data of a source: s3, ipfs or job",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "volumes"
+                ],
+                "summary": "Create a volume",
+                "parameters": [
+                    {
+                        "description": "source and optional private flag",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Volume"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_argument",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        },
+        "/volumes/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Shows a storage volume with its size",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "volumes"
+                ],
+                "summary": "Show a volume",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "volume ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.Volume"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a storage volume",
+                "tags": [
+                    "volumes"
+                ],
+                "summary": "Delete a volume",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "volume ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "not_found",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    },
+                    "503": {
+                        "description": "unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ErrorEnvelope"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "events.Record": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "id": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/events.Type"
+                }
+            }
+        },
+        "events.Type": {
+            "type": "string",
+            "enum": [
+                "execution.started",
+                "execution.finished",
+                "execution.log",
+                "peer.connected",
+                "peer.disconnected",
+                "resources.changed",
+                "onboarding.changed",
+                "heartbeat.missed",
+                "heartbeat.recovered"
+            ],
+            "x-enum-varnames": [
+                "ExecutionStarted",
+                "ExecutionFinished",
+                "ExecutionLog",
+                "PeerConnected",
+                "PeerDisconnected",
+                "ResourcesChanged",
+                "OnboardingChanged",
+                "HeartbeatMissed",
+                "HeartbeatRecovered"
+            ]
+        },
+        "gorm.DeletedAt": {
+            "type": "object",
+            "properties": {
+                "time": {
+                    "type": "string"
+                },
+                "valid": {
+                    "description": "Valid is true if Time is not NULL",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.ExecutionInfo": {
+            "type": "object",
+            "properties": {
+                "executor": {
+                    "description": "e.g. docker",
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "job_id": {
+                    "type": "string"
+                },
+                "node": {
+                    "description": "peer ID of the node running the execution, empty for this node",
+                    "type": "string"
+                },
+                "result": {
+                    "$ref": "#/definitions/models.ExecutionResult"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "running, finished or failed",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExecutionResources": {
+            "type": "object",
+            "properties": {
+                "cpu": {
+                    "description": "CPU units",
+                    "type": "number"
+                },
+                "disk": {
+                    "description": "Disk in bytes",
+                    "type": "integer"
+                },
+                "gpus": {
+                    "description": "GPU configurations",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GPU"
+                    }
+                },
+                "memory": {
+                    "description": "Memory in bytes",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ExecutionResult": {
+            "type": "object",
+            "properties": {
+                "error_msg": {
+                    "description": "Error message if the execution failed",
+                    "type": "string"
+                },
+                "exit_code": {
+                    "description": "Exit code of the execution",
+                    "type": "integer"
+                },
+                "stderr": {
+                    "description": "STDERR of the execution",
+                    "type": "string"
+                },
+                "stdout": {
+                    "description": "STDOUT of the execution",
+                    "type": "string"
+                }
+            }
+        },
+        "models.GPU": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "description": "Self-reported index of the device in the system",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Model name of the GPU e.g. Tesla T4",
+                    "type": "string"
+                },
+                "pciaddress": {
+                    "description": "PCI address of the device, in the format AAAA:BB:CC.C\nUsed to discover the correct device rendering cards",
+                    "type": "string"
+                },
+                "vendor": {
+                    "description": "Maker of the GPU, e.g. NVidia, AMD, Intel",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GPUVendor"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.GPUVendor": {
+            "type": "string",
+            "enum": [
+                "NVIDIA",
+                "AMD/ATI",
+                "Intel"
+            ],
+            "x-enum-varnames": [
+                "GPUVendorNvidia",
+                "GPUVendorAMDATI",
+                "GPUVendorIntel"
+            ]
+        },
+        "models.JobSpec": {
+            "type": "object",
+            "properties": {
+                "engine": {
+                    "description": "engine spec, its type selects the executor, e.g. docker",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SpecConfig"
+                        }
+                    ]
+                },
+                "inputs": {
+                    "description": "volumes mounted in the execution",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StorageVolume"
+                    }
+                },
+                "outputs": {
+                    "description": "volumes receiving the results",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StorageVolume"
+                    }
+                },
+                "resources": {
+                    "description": "resources of the execution",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ExecutionResources"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.PeerStat": {
+            "type": "object",
+            "properties": {
+                "addrs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "connected": {
+                    "type": "boolean"
+                },
+                "direction": {
+                    "description": "inbound or outbound while connected",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latency": {
+                    "description": "moving average of the round trip time, 0 when unknown",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                },
+                "relayed": {
+                    "description": "connected through a relay",
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.SpecConfig": {
+            "type": "object",
+            "properties": {
+                "params": {
+                    "description": "Params of the spec",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "type": {
+                    "description": "Type of the spec (e.g. docker, firecracker, storage, etc.)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.StorageVolume": {
+            "type": "object",
+            "properties": {
+                "readonly": {
+                    "description": "ReadOnly flag to mount the volume as read-only",
+                    "type": "boolean"
+                },
+                "source": {
+                    "description": "Source path of the volume on the host",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "Target path of the volume in the execution",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type of the volume (e.g. bind)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.TaskExecution": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "ended_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "task_name": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TaskStatus": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaskExecution"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "running": {
+                    "type": "boolean"
+                },
+                "triggers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "time.Duration": {
+            "type": "integer",
+            "enum": [
+                1,
+                1000,
+                1000000,
+                1000000000,
+                1,
+                1000,
+                1000000,
+                1000000000
+            ],
+            "x-enum-varnames": [
+                "Nanosecond",
+                "Microsecond",
+                "Millisecond",
+                "Second",
+                "Nanosecond",
+                "Microsecond",
+                "Millisecond",
+                "Second"
+            ]
+        },
+        "v2.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "task 3 not found"
+                }
+            }
+        },
+        "v2.ErrorEnvelope": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/v2.Error"
+                }
+            }
+        },
+        "v2.Job": {
+            "type": "object",
+            "properties": {
+                "compute_provider_addr": {
+                    "type": "string"
+                },
+                "container_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duration_minutes": {
+                    "type": "integer"
+                },
+                "estimated_minutes": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_id": {
+                    "type": "string"
+                },
+                "log_url": {
+                    "type": "string"
+                },
+                "service_name": {
+                    "type": "string"
+                },
+                "service_provider_addr": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "running, finished without errors or finished with errors",
+                    "type": "string"
+                },
+                "transaction_type": {
+                    "description": "running, done, withdraw, refund or distribute",
+                    "type": "string"
+                },
+                "tx_hash": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.Page-models_ExecutionInfo": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExecutionInfo"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.Page-models_PeerStat": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PeerStat"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.Page-models_TaskStatus": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TaskStatus"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.Page-v2_Job": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.Job"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.Page-v2_Volume": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.Volume"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.Ping": {
+            "type": "object",
+            "properties": {
+                "peer_id": {
+                    "type": "string"
+                },
+                "rtt": {
+                    "description": "round trip time in nanoseconds",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/time.Duration"
+                        }
+                    ]
+                }
+            }
+        },
+        "v2.TaskUpdate": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "description": "whether the triggers run the task",
+                    "type": "boolean"
+                },
+                "running": {
+                    "description": "true runs the task now, false cancels its running execution",
+                    "type": "boolean"
+                }
+            }
+        },
+        "v2.Volume": {
+            "type": "object",
+            "properties": {
+                "cid": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "encrypted": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "name of the directory of the volume",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "private": {
+                    "type": "boolean"
+                },
+                "read_only": {
+                    "type": "boolean"
+                },
+                "size": {
+                    "description": "in bytes, only shown for a single volume",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    }
+}`
+
+// SwaggerInfov2 holds exported Swagger Info so clients can modify it
+var SwaggerInfov2 = &swag.Spec{
+	Version:          "2.0",
+	Host:             "localhost:9999",
+	BasePath:         "/api/v2",
+	Schemes:          []string{"http", "https"},
+	Title:            "Device Management Service API v2",
+	Description:      "Resource-oriented API of the DMS. Failed requests return an error envelope {\"error\": {\"code\": \"...\", \"message\": \"...\"}} and collections are paginated with the limit and cursor query parameters.",
+	InfoInstanceName: "v2",
+	SwaggerTemplate:  docTemplatev2,
+	LeftDelim:        "{{",
+	RightDelim:       "}}",
+}
+
+func init() {
+	swag.Register(SwaggerInfov2.InstanceName(), SwaggerInfov2)
+}
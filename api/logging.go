@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/nunet/device-management-service/telemetry/logger"
+)
+
+// ListLogLevelsHandler  godoc
+//
+//	@Summary		List log levels
+//	@Description	Lists the default log level followed by the level of every package logger
+//	@Tags			logging
+//	@Produce		json
+//	@Success		200	{array}	models.LogLevel
+//	@Router			/logging/levels [get]
+func ListLogLevelsHandler(c *gin.Context) {
+	c.JSON(200, logger.Levels())
+}
+
+// SetLogLevelHandler  godoc
+//
+//	@Summary		Set the log level of a package
+//	@Description	Sets the log level of a package and its subpackages until the DMS restarts, the "default" package sets the level of the packages without their own level
+//	@Tags			logging
+//	@Accept			json
+//	@Produce		json
+//	@Param			package	path		string	true	"package, e.g. network.libp2p"
+//	@Param			body	body		object	true	"level: debug, info, warn, error, dpanic, panic or fatal"
+//	@Success		200		{object}	object	"log level set"
+//	@Failure		400		{object}	object	"invalid request body or log level"
+//	@Router			/logging/levels/{package} [put]
+func SetLogLevelHandler(c *gin.Context) {
+	var body struct {
+		Level string `json:"level" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid request body: level is required"})
+		return
+	}
+
+	pkg := c.Param("package")
+	if err := logger.SetLevel(pkg, body.Level); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("log level of %s set to %s", pkg, body.Level)})
+}
+
+// ResetLogLevelHandler  godoc
+//
+//	@Summary		Reset the log level of a package
+//	@Description	Removes the log level set for a package, which then follows its parent package or the default level
+//	@Tags			logging
+//	@Produce		json
+//	@Param			package	path		string	true	"package, e.g. network.libp2p"
+//	@Success		200		{object}	object	"log level reset"
+//	@Failure		400		{object}	object	"the default level can't be reset"
+//	@Router			/logging/levels/{package} [delete]
+func ResetLogLevelHandler(c *gin.Context) {
+	pkg := c.Param("package")
+	if pkg == logger.DefaultPackage {
+		c.AbortWithStatusJSON(400, gin.H{"error": "the default level can't be reset, set it instead"})
+		return
+	}
+	logger.ResetLevel(pkg)
+	c.JSON(200, gin.H{"message": fmt.Sprintf("log level of %s reset", pkg)})
+}
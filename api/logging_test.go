@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry/logger"
+)
+
+func TestLogLevelHandlers(t *testing.T) {
+	router := SetupMockRouter()
+	logger.New("api.test")
+	t.Cleanup(func() { logger.ResetLevel("api.test") })
+
+	tests := []struct {
+		description  string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{description: "set level", method: "PUT", path: "/api/v1/logging/levels/api.test", body: `{"level": "debug"}`, expectedCode: 200},
+		{description: "invalid level", method: "PUT", path: "/api/v1/logging/levels/api.test", body: `{"level": "loud"}`, expectedCode: 400},
+		{description: "missing level", method: "PUT", path: "/api/v1/logging/levels/api.test", body: `{}`, expectedCode: 400},
+		{description: "reset default level", method: "DELETE", path: "/api/v1/logging/levels/default", expectedCode: 400},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedCode, w.Code, tc.description)
+	}
+
+	assert.Equal(t, "debug", listedLevel(t, router, "api.test"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/api/v1/logging/levels/api.test", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, listedLevel(t, router, "default"), listedLevel(t, router, "api.test"))
+}
+
+func listedLevel(t *testing.T, router *gin.Engine, pkg string) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/logging/levels", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+
+	var levels []models.LogLevel
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &levels))
+	for _, l := range levels {
+		if l.Package == pkg {
+			return l.Level
+		}
+	}
+	t.Fatalf("no level listed for package %s", pkg)
+	return ""
+}
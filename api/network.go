@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/network"
+)
+
+var (
+	// p2pNetwork is the network the handlers below operate on
+	p2pNetwork network.Network
+
+	errNetworkNotInitialized = errors.New("host node hasn't yet been initialized")
+)
+
+// SetNetwork sets the network used by the network handlers once the node is running
+func SetNetwork(net network.Network) {
+	p2pNetwork = net
+}
+
+// PingHandler  godoc
+//
+//	@Summary		Ping a peer
+//	@Description	Pings a peer and returns the round trip time
+//	@Tags			p2p
+//	@Produce		json
+//	@Param			peerID	query		string	true	"ID of the peer to ping"
+//	@Param			timeout	query		string	false	"timeout such as 2s, defaults to 5s"
+//	@Success		200		{object}	object	"ping successful with peer"
+//	@Failure		400		{object}	object	"invalid peerID or timeout"
+//	@Failure		500		{object}	object	"could not ping peer"
+//	@Router			/peers/ping [get]
+func PingHandler(c *gin.Context) {
+	if p2pNetwork == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": errNetworkNotInitialized.Error()})
+		return
+	}
+
+	id := c.Query("peerID")
+	if id == "" {
+		c.AbortWithStatusJSON(400, gin.H{"error": "peerID not provided"})
+		return
+	}
+
+	var timeout time.Duration
+	if raw := c.Query("timeout"); raw != "" {
+		var err error
+		timeout, err = time.ParseDuration(raw)
+		if err != nil {
+			c.AbortWithStatusJSON(400, gin.H{"error": fmt.Sprintf("invalid timeout: %v", err)})
+			return
+		}
+	}
+
+	address := models.SpecConfig{
+		Type:   string(models.NetP2P),
+		Params: map[string]interface{}{"peer_id": id},
+	}
+	result, err := p2pNetwork.Ping(c.Request.Context(), address, timeout)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": fmt.Sprintf("could not ping peer %s: %v", id, err)})
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("ping successful with peer %s", id), "RTT": result.RTT})
+}
+
+// NetStatHandler  godoc
+//
+//	@Summary		Return network status
+//	@Description	Gets the addresses, reachability, relay reservations, connections and bandwidth usage of the libp2p node
+//	@Tags			p2p
+//	@Produce		json
+//	@Success		200	{object}	models.NetStat
+//	@Failure		500	{object}	object	"host node hasn't yet been initialized"
+//	@Router			/peers/stat [get]
+func NetStatHandler(c *gin.Context) {
+	if p2pNetwork == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": errNetworkNotInitialized.Error()})
+		return
+	}
+	c.JSON(200, p2pNetwork.Stat())
+}
+
+// NetUsageHandler  godoc
+//
+//	@Summary		Return network resource usage
+//	@Description	Gets the streams, connections, file descriptors, memory and bandwidth used by the libp2p node against the configured limits
+//	@Tags			p2p
+//	@Produce		json
+//	@Success		200	{object}	models.NetUsage
+//	@Failure		500	{object}	object	"host node hasn't yet been initialized"
+//	@Router			/peers/usage [get]
+func NetUsageHandler(c *gin.Context) {
+	if p2pNetwork == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": errNetworkNotInitialized.Error()})
+		return
+	}
+	c.JSON(200, p2pNetwork.Usage())
+}
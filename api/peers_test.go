@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -573,3 +574,117 @@ func mockMaddrs() []multiaddr.Multiaddr {
 	}
 	return multiaddrs
 }
+
+func (h *MockHandler) PingHandler(c *gin.Context) {
+	id := c.Query("peerID")
+	if !validateMockID(id) {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid query data: peerID string is not valid peer ID"})
+		return
+	} else if id == mockHostID {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid query data: peerID string cannot be self peer ID"})
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("ping successful with peer %s", id), "RTT": 28859000})
+}
+
+func (m *MockHandler) NetStatHandler(c *gin.Context) {
+	if mockHostID == "" {
+		c.AbortWithStatusJSON(500, gin.H{"error": "host node hasn't yet been initialized"})
+		return
+	}
+	c.JSON(200, models.NetStat{
+		ID:           mockHostID,
+		ListenAddrs:  []string{"/ip4/127.0.0.1/tcp/9000"},
+		Reachability: "Public",
+		Connections:  models.ConnStat{Peers: 2, Inbound: 1, Outbound: 1},
+	})
+}
+
+func (m *MockHandler) NetUsageHandler(c *gin.Context) {
+	if mockHostID == "" {
+		c.AbortWithStatusJSON(500, gin.H{"error": "host node hasn't yet been initialized"})
+		return
+	}
+	c.JSON(200, models.NetUsage{
+		System: models.ScopeUsage{
+			StreamsInbound: models.Usage{Used: 4, Limit: 2048},
+			Memory:         models.Usage{Used: 1 << 20, Limit: 1 << 30},
+		},
+		Protocols: map[string]models.ScopeUsage{},
+		Bandwidth: models.BandwidthUsage{Total: models.BandwidthLimit{RateIn: 100, MaxIn: 1 << 20}},
+	})
+}
+
+func TestPingHandler(t *testing.T) {
+	router := SetupMockRouter()
+
+	tests := []struct {
+		description  string
+		peerID       string
+		expectedCode int
+	}{
+		{
+			description:  "valid peer ID",
+			peerID:       "Qmx0abcdefhjgiklbazbaz23",
+			expectedCode: 200,
+		},
+		{
+			description:  "self peer ID",
+			peerID:       mockHostID,
+			expectedCode: 400,
+		},
+		{
+			description:  "invalid peer ID",
+			peerID:       "foobar",
+			expectedCode: 400,
+		},
+		{
+			description:  "missing peer ID",
+			peerID:       "",
+			expectedCode: 400,
+		},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/peers/ping?peerID="+tc.peerID, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.expectedCode, w.Code, tc.description)
+		if tc.expectedCode == 200 {
+			assert.Contains(t, w.Body.String(), tc.peerID, tc.description)
+		}
+	}
+}
+
+func TestNetStatHandler(t *testing.T) {
+	router := SetupMockRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/peers/stat", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var stat models.NetStat
+	err := json.Unmarshal(w.Body.Bytes(), &stat)
+	assert.NoError(t, err)
+	assert.Equal(t, mockHostID, stat.ID)
+	assert.Equal(t, 2, stat.Connections.Peers)
+}
+
+func TestNetUsageHandler(t *testing.T) {
+	router := SetupMockRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/peers/usage", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var usage models.NetUsage
+	err := json.Unmarshal(w.Body.Bytes(), &usage)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), usage.System.StreamsInbound.Used)
+	assert.Equal(t, int64(1<<20), usage.Bandwidth.Total.MaxIn)
+}
@@ -0,0 +1,129 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+)
+
+// reflectionPrefix is the prefix of the methods of the reflection services,
+// which are public like the swagger documentation of the REST API
+const reflectionPrefix = "/grpc.reflection."
+
+var errMissingCredentials = errors.New("missing credentials: set the authorization metadata to Bearer <token>")
+
+// methodScopes are the scopes required by the methods, the same as the ones
+// of the REST routes. The methods missing from it require the admin scope.
+var methodScopes = map[string]auth.Scope{
+	dmspb.OnboardingService_GetStatus_FullMethodName:      auth.ScopeRead,
+	dmspb.OnboardingService_GetMetadata_FullMethodName:    auth.ScopeRead,
+	dmspb.OnboardingService_Onboard_FullMethodName:        auth.ScopeAdmin,
+	dmspb.OnboardingService_UpdateCapacity_FullMethodName: auth.ScopeAdmin,
+	dmspb.OnboardingService_Offboard_FullMethodName:       auth.ScopeAdmin,
+
+	dmspb.ResourceService_GetResources_FullMethodName:   auth.ScopeRead,
+	dmspb.ResourceService_WatchResources_FullMethodName: auth.ScopeRead,
+
+	dmspb.ExecutionService_ListExecutions_FullMethodName:  auth.ScopeRead,
+	dmspb.ExecutionService_GetExecution_FullMethodName:    auth.ScopeRead,
+	dmspb.ExecutionService_StreamLogs_FullMethodName:      auth.ScopeRead,
+	dmspb.ExecutionService_RunExecution_FullMethodName:    auth.ScopeOperator,
+	dmspb.ExecutionService_CancelExecution_FullMethodName: auth.ScopeOperator,
+
+	dmspb.VolumeService_ListVolumes_FullMethodName:  auth.ScopeRead,
+	dmspb.VolumeService_GetVolume_FullMethodName:    auth.ScopeRead,
+	dmspb.VolumeService_CreateVolume_FullMethodName: auth.ScopeOperator,
+	dmspb.VolumeService_DeleteVolume_FullMethodName: auth.ScopeOperator,
+
+	dmspb.PeerService_ListPeers_FullMethodName: auth.ScopeRead,
+	dmspb.PeerService_GetPeer_FullMethodName:   auth.ScopeRead,
+	dmspb.PeerService_PingPeer_FullMethodName:  auth.ScopeRead,
+}
+
+func (s *Server) unaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize fails with codes.Unauthenticated when the client of the call
+// isn't authenticated and with codes.PermissionDenied when its scope doesn't
+// allow the one the method requires
+func (s *Server) authorize(ctx context.Context, method string) error {
+	if s.opts.Authenticator == nil || strings.HasPrefix(method, reflectionPrefix) {
+		return nil
+	}
+	required, ok := methodScopes[method]
+	if !ok {
+		required = auth.ScopeAdmin
+	}
+
+	scope, err := s.callScope(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	if !scope.Allows(required) {
+		return status.Errorf(codes.PermissionDenied, "the %s scope is required", required)
+	}
+	return nil
+}
+
+// callScope authenticates the client of a call with, in order, the Unix
+// socket it connected to, its bearer token or its verified TLS certificate,
+// like the REST API does
+func (s *Server) callScope(ctx context.Context) (auth.Scope, error) {
+	p, _ := peer.FromContext(ctx)
+	// the permissions of the socket file restrict who can connect
+	if p != nil && p.Addr != nil && p.Addr.Network() == "unix" {
+		return auth.ScopeAdmin, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		token, found := strings.CutPrefix(values[0], "Bearer ")
+		if !found {
+			return "", errors.New("unsupported authorization scheme, use Bearer")
+		}
+		record, err := s.opts.Authenticator.Verify(ctx, strings.TrimSpace(token))
+		if err != nil {
+			return "", err
+		}
+		return auth.Scope(record.Scope), nil
+	}
+
+	if p != nil {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			return auth.CertificateScope(info.State.VerifiedChains[0][0]), nil
+		}
+	}
+
+	return "", errMissingCredentials
+}
@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// newAuthenticator returns an authenticator with an in-memory token store
+func newAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.APIToken{}))
+	return auth.NewAuthenticator(repositories_gorm.NewAPITokenRepository(db))
+}
+
+// withToken returns a context sending token in the authorization metadata
+func withToken(t *testing.T, a *auth.Authenticator, scope auth.Scope) context.Context {
+	t.Helper()
+	token, _, err := a.Issue(context.Background(), "test", scope, 0)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestAuthorize(t *testing.T) {
+	authenticator := newAuthenticator(t)
+	_, conn := serve(t, Options{Authenticator: authenticator, Onboarding: &mockOnboarding{}})
+	client := dmspb.NewOnboardingServiceClient(conn)
+	readCtx := withToken(t, authenticator, auth.ScopeRead)
+	adminCtx := withToken(t, authenticator, auth.ScopeAdmin)
+
+	_, err := client.GetStatus(context.Background(), &dmspb.GetStatusRequest{})
+	assertCode(t, err, codes.Unauthenticated)
+
+	unknownCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nunet_unknown")
+	_, err = client.GetStatus(unknownCtx, &dmspb.GetStatusRequest{})
+	assertCode(t, err, codes.Unauthenticated)
+
+	basicCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic dXNlcjpwYXNz")
+	_, err = client.GetStatus(basicCtx, &dmspb.GetStatusRequest{})
+	assertCode(t, err, codes.Unauthenticated)
+
+	_, err = client.GetStatus(readCtx, &dmspb.GetStatusRequest{})
+	assert.NoError(t, err)
+
+	_, err = client.Offboard(readCtx, &dmspb.OffboardRequest{})
+	assertCode(t, err, codes.PermissionDenied)
+	_, err = client.Offboard(adminCtx, &dmspb.OffboardRequest{})
+	assert.NoError(t, err)
+
+	// the streams are authorized too
+	stream, err := dmspb.NewExecutionServiceClient(conn).StreamLogs(context.Background(), &dmspb.StreamLogsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assertCode(t, err, codes.Unauthenticated)
+
+	// like the swagger documentation, reflection is public
+	reflection, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, reflection.Send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	}))
+	_, err = reflection.Recv()
+	assert.NoError(t, err)
+}
+
+func TestAuthorizeUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dms-grpc.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	server := NewServer(Options{Authenticator: newAuthenticator(t), Onboarding: &mockOnboarding{}})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	_, err = dmspb.NewOnboardingServiceClient(conn).Offboard(context.Background(), &dmspb.OffboardRequest{})
+	assert.NoError(t, err, "the clients of the Unix socket are admins")
+}
+
+func TestMethodScopes(t *testing.T) {
+	for _, desc := range []grpc.ServiceDesc{
+		dmspb.OnboardingService_ServiceDesc,
+		dmspb.ResourceService_ServiceDesc,
+		dmspb.ExecutionService_ServiceDesc,
+		dmspb.VolumeService_ServiceDesc,
+		dmspb.PeerService_ServiceDesc,
+	} {
+		var methods []string
+		for _, m := range desc.Methods {
+			methods = append(methods, m.MethodName)
+		}
+		for _, s := range desc.Streams {
+			methods = append(methods, s.StreamName)
+		}
+		for _, m := range methods {
+			assert.Contains(t, methodScopes, "/"+desc.ServiceName+"/"+m, "the scope of every method is explicit")
+		}
+	}
+}
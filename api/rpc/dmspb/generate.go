@@ -0,0 +1,5 @@
+// Package dmspb holds the messages and the services of the gRPC control API,
+// generated from dms.proto with protoc-gen-go and protoc-gen-go-grpc
+package dmspb
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative dms.proto
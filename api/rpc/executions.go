@@ -0,0 +1,319 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+type executionService struct {
+	dmspb.UnimplementedExecutionServiceServer
+	s *Server
+}
+
+func (e *executionService) ready() error {
+	if e.s.opts.Executions == nil {
+		return status.Error(codes.Unavailable, "the execution registry hasn't yet been initialized")
+	}
+	return nil
+}
+
+func (e *executionService) ListExecutions(_ context.Context, req *dmspb.ListExecutionsRequest) (*dmspb.ListExecutionsResponse, error) {
+	if err := e.ready(); err != nil {
+		return nil, err
+	}
+	switch req.GetStatus() {
+	case "", models.ExecutionStatusRunning, models.ExecutionStatusFinished, models.ExecutionStatusFailed:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid status %q: use running, finished or failed", req.GetStatus())
+	}
+
+	resp := &dmspb.ListExecutionsResponse{}
+	for _, info := range e.s.opts.Executions.Executions() {
+		if (req.GetStatus() != "" && info.Status != req.GetStatus()) ||
+			(req.GetExecutor() != "" && info.Executor != req.GetExecutor()) ||
+			(req.GetJobId() != "" && info.JobID != req.GetJobId()) {
+			continue
+		}
+		resp.Executions = append(resp.Executions, newExecution(info))
+	}
+	return resp, nil
+}
+
+func (e *executionService) GetExecution(_ context.Context, req *dmspb.GetExecutionRequest) (*dmspb.Execution, error) {
+	if err := e.ready(); err != nil {
+		return nil, err
+	}
+	info, ok := e.s.opts.Executions.Execution(req.GetId())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "execution %s not found", req.GetId())
+	}
+	return newExecution(info), nil
+}
+
+// StreamLogs sends the log lines of the execution retained by the log
+// journal, then follows it until the execution finishes. The lines of a
+// finished execution the journal no longer retains are read from its result.
+// The stream fails with codes.DataLoss when lines were dropped while following.
+func (e *executionService) StreamLogs(req *dmspb.StreamLogsRequest, stream dmspb.ExecutionService_StreamLogsServer) error {
+	if err := e.ready(); err != nil {
+		return err
+	}
+	logs := e.s.opts.Logs
+	if logs == nil {
+		return status.Error(codes.Unavailable, "the log journal hasn't yet been initialized")
+	}
+	registry := e.s.opts.Executions
+	id := req.GetExecutionId()
+	if _, ok := registry.Execution(id); !ok {
+		return status.Errorf(codes.NotFound, "execution %s not found", id)
+	}
+
+	ctx := stream.Context()
+	var after uint64
+	sent, dropped := false, false
+	for first := true; ; first = false {
+		// the execution is looked up after the changes are subscribed to so
+		// that its end isn't missed
+		executionChanged := registry.Changed()
+		info, _ := registry.Execution(id)
+		finished := info.FinishedAt != nil
+
+		records, complete, changed := logs.Since(after)
+		// the first records may follow discarded ones, later gaps are lines
+		// dropped while following
+		if !complete && !first {
+			dropped = true
+		}
+		for _, record := range records {
+			after = record.ID
+			payload, ok := record.Payload.(events.Log)
+			if !ok || payload.ExecutionID != id {
+				continue
+			}
+			line := &dmspb.LogLine{Stream: payload.Stream, Line: payload.Line, Time: timestamppb.New(record.Time)}
+			if err := stream.Send(line); err != nil {
+				return err
+			}
+			sent = true
+		}
+		if finished || !req.GetFollow() {
+			break
+		}
+
+		select {
+		case <-changed:
+		case <-executionChanged:
+		case <-logs.Done():
+			return status.Error(codes.Unavailable, "the DMS is shutting down")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if dropped {
+		return status.Errorf(codes.DataLoss, "log lines of execution %s were dropped, its full output is in its result", id)
+	}
+	if sent {
+		return nil
+	}
+	// e.g. the execution finished before the DMS started recording its logs
+	if info, ok := registry.Execution(id); ok && info.Result != nil {
+		return sendResultLogs(stream, info.Result)
+	}
+	return nil
+}
+
+// RunExecution starts an execution of the job spec like POST /api/v2/executions
+func (e *executionService) RunExecution(ctx context.Context, req *dmspb.RunExecutionRequest) (*dmspb.Execution, error) {
+	spec := newJobSpec(req.GetSpec())
+
+	var (
+		info models.ExecutionInfo
+		err  error
+	)
+	switch node := req.GetNode(); node {
+	case "":
+		jobs := e.s.opts.Jobs
+		if jobs == nil {
+			return nil, status.Error(codes.Unavailable, "the job runner hasn't yet been initialized")
+		}
+		if info, err = jobs.Run(ctx, spec); err != nil {
+			return nil, jobError(err, "", false)
+		}
+	case "auto":
+		remote, err := e.remoteJobs()
+		if err != nil {
+			return nil, err
+		}
+		if info, err = remote.RunAnywhere(ctx, spec); err != nil {
+			return nil, jobError(err, "", true)
+		}
+	default:
+		to, err := decodeNode(node)
+		if err != nil {
+			return nil, err
+		}
+		remote, err := e.remoteJobs()
+		if err != nil {
+			return nil, err
+		}
+		if info, err = remote.Run(ctx, to, spec); err != nil {
+			return nil, jobError(err, "", true)
+		}
+	}
+	return newExecution(info), nil
+}
+
+// CancelExecution cancels a running execution like DELETE /api/v2/executions/:id
+func (e *executionService) CancelExecution(ctx context.Context, req *dmspb.CancelExecutionRequest) (*dmspb.CancelExecutionResponse, error) {
+	id := req.GetId()
+	if node := req.GetNode(); node != "" {
+		to, err := decodeNode(node)
+		if err != nil {
+			return nil, err
+		}
+		remote, err := e.remoteJobs()
+		if err != nil {
+			return nil, err
+		}
+		if err := remote.Cancel(ctx, to, id); err != nil {
+			return nil, jobError(err, id, true)
+		}
+		return &dmspb.CancelExecutionResponse{}, nil
+	}
+
+	jobs := e.s.opts.Jobs
+	if jobs == nil {
+		return nil, status.Error(codes.Unavailable, "the job runner hasn't yet been initialized")
+	}
+	if err := jobs.Cancel(ctx, id); err != nil {
+		return nil, jobError(err, id, false)
+	}
+	return &dmspb.CancelExecutionResponse{}, nil
+}
+
+// remoteJobs returns the jobs of the other peers, unavailable while the node isn't running
+func (e *executionService) remoteJobs() (RemoteJobs, error) {
+	remote := e.s.getRemoteJobs()
+	if remote == nil {
+		return nil, status.Error(codes.Unavailable, "the host node hasn't yet been initialized")
+	}
+	return remote, nil
+}
+
+func decodeNode(node string) (peer.ID, error) {
+	to, err := peer.Decode(node)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "invalid node %q: %v", node, err)
+	}
+	return to, nil
+}
+
+// jobError returns the status of a job error, with the same codes as the
+// REST API. The other errors of remote peers make them unavailable.
+func jobError(err error, id string, remote bool) error {
+	switch {
+	case errors.Is(err, executor.ErrExecutionNotFound):
+		return status.Errorf(codes.NotFound, "execution %s not found", id)
+	case errors.Is(err, executor.ErrExecutionNotRunning):
+		return status.Errorf(codes.FailedPrecondition, "execution %s is not running", id)
+	case errors.Is(err, executor.ErrInvalidJobSpec), errors.Is(err, executor.ErrExecutorUnavailable):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case remote:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// newJobSpec returns the job spec of a request, the spec is validated when it runs
+func newJobSpec(req *dmspb.JobSpec) models.JobSpec {
+	var spec models.JobSpec
+	if engine := req.GetEngine(); engine != nil {
+		spec.Engine = &models.SpecConfig{Type: engine.GetType(), Params: engine.GetParams().AsMap()}
+	}
+	if resources := req.GetResources(); resources != nil {
+		spec.Resources = &models.ExecutionResources{
+			CPU:    resources.GetCpu(),
+			Memory: resources.GetMemory(),
+			Disk:   resources.GetDisk(),
+		}
+		for _, gpu := range resources.GetGpus() {
+			spec.Resources.GPUs = append(spec.Resources.GPUs, models.GPU{
+				Index:      gpu.GetIndex(),
+				Name:       gpu.GetName(),
+				Vendor:     models.GPUVendor(gpu.GetVendor()),
+				PCIAddress: gpu.GetPciAddress(),
+			})
+		}
+	}
+	spec.Inputs = newJobVolumes(req.GetInputs())
+	spec.Outputs = newJobVolumes(req.GetOutputs())
+	return spec
+}
+
+func newJobVolumes(volumes []*dmspb.JobVolume) []*models.StorageVolume {
+	var result []*models.StorageVolume
+	for _, volume := range volumes {
+		result = append(result, &models.StorageVolume{
+			Type:     volume.GetType(),
+			Source:   volume.GetSource(),
+			Target:   volume.GetTarget(),
+			ReadOnly: volume.GetReadOnly(),
+		})
+	}
+	return result
+}
+
+// sendResultLogs sends the lines of the output of a finished execution
+func sendResultLogs(stream dmspb.ExecutionService_StreamLogsServer, result *models.ExecutionResult) error {
+	for _, output := range []struct{ stream, text string }{
+		{"stdout", result.STDOUT},
+		{"stderr", result.STDERR},
+	} {
+		text := strings.TrimSuffix(output.text, "\n")
+		if text == "" {
+			continue
+		}
+		for _, line := range strings.Split(text, "\n") {
+			if err := stream.Send(&dmspb.LogLine{Stream: output.stream, Line: line}); err != nil {
+				return fmt.Errorf("send log line: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func newExecution(info models.ExecutionInfo) *dmspb.Execution {
+	execution := &dmspb.Execution{
+		Id:        info.ID,
+		JobId:     info.JobID,
+		Executor:  info.Executor,
+		Status:    info.Status,
+		StartTime: timestamppb.New(info.StartedAt),
+		Node:      info.Node,
+	}
+	if info.FinishedAt != nil {
+		execution.FinishTime = timestamppb.New(*info.FinishedAt)
+	}
+	if info.Result != nil {
+		execution.Result = &dmspb.ExecutionResult{
+			ExitCode: int32(info.Result.ExitCode),
+			Stdout:   info.Result.STDOUT,
+			Stderr:   info.Result.STDERR,
+			Error:    info.Result.ErrorMsg,
+		}
+	}
+	return execution
+}
@@ -0,0 +1,292 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// setupExecutions returns a bus with a registry and a journal following it,
+// and a log bus with the log journal following it
+func setupExecutions(t *testing.T) (*events.Bus, *events.Bus, Options) {
+	t.Helper()
+	bus, logs := events.NewBus(), events.NewBus()
+	registry := executor.NewRegistry(bus, 0)
+	t.Cleanup(registry.Close)
+	journal := events.NewJournal(bus, 64)
+	t.Cleanup(journal.Close)
+	logJournal := events.NewJournal(logs, 64)
+	t.Cleanup(logJournal.Close)
+	return bus, logs, Options{Executions: registry, Journal: journal, Logs: logJournal}
+}
+
+// waitStatus waits for the registry to know an execution with a status
+func waitStatus(t *testing.T, registry *executor.Registry, id, status string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		info, ok := registry.Execution(id)
+		return ok && info.Status == status
+	}, time.Second, 10*time.Millisecond)
+}
+
+// readLogs receives the lines of a log stream until it ends
+func readLogs(t *testing.T, stream dmspb.ExecutionService_StreamLogsClient) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := stream.Recv()
+		if err == io.EOF {
+			return lines
+		}
+		require.NoError(t, err)
+		lines = append(lines, line.GetStream()+": "+line.GetLine())
+	}
+}
+
+func TestExecutions(t *testing.T) {
+	bus, _, opts := setupExecutions(t)
+	_, conn := serve(t, opts)
+	client := dmspb.NewExecutionServiceClient(conn)
+	ctx := context.Background()
+
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "firecracker", JobID: "job-2", ExecutionID: "exec-2"})
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1",
+		Result: models.NewExecutionResult(models.ExecutionStatusCodeSuccess)})
+	waitStatus(t, opts.Executions, "exec-1", models.ExecutionStatusFinished)
+
+	list, err := client.ListExecutions(ctx, &dmspb.ListExecutionsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.GetExecutions(), 2)
+
+	list, err = client.ListExecutions(ctx, &dmspb.ListExecutionsRequest{Status: "running", Executor: "firecracker"})
+	require.NoError(t, err)
+	require.Len(t, list.GetExecutions(), 1)
+	assert.Equal(t, "exec-2", list.GetExecutions()[0].GetId())
+
+	execution, err := client.GetExecution(ctx, &dmspb.GetExecutionRequest{Id: "exec-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", execution.GetJobId())
+	assert.NotNil(t, execution.GetFinishTime())
+	assert.NotNil(t, execution.GetResult())
+
+	_, err = client.ListExecutions(ctx, &dmspb.ListExecutionsRequest{Status: "paused"})
+	assertCode(t, err, codes.InvalidArgument)
+	_, err = client.GetExecution(ctx, &dmspb.GetExecutionRequest{Id: "exec-3"})
+	assertCode(t, err, codes.NotFound)
+}
+
+func TestStreamLogs(t *testing.T) {
+	bus, logs, opts := setupExecutions(t)
+	_, conn := serve(t, opts)
+	client := dmspb.NewExecutionServiceClient(conn)
+	ctx := context.Background()
+
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	logs.Publish(events.ExecutionLog, events.Log{ExecutionID: "exec-1", Stream: "stdout", Line: "starting"})
+	logs.Publish(events.ExecutionLog, events.Log{ExecutionID: "exec-2", Stream: "stdout", Line: "other execution"})
+	waitStatus(t, opts.Executions, "exec-1", models.ExecutionStatusRunning)
+	require.Eventually(t, func() bool { return opts.Logs.LastID() == 2 }, time.Second, 10*time.Millisecond)
+
+	// without follow, only the retained lines are sent
+	stream, err := client.StreamLogs(ctx, &dmspb.StreamLogsRequest{ExecutionId: "exec-1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stdout: starting"}, readLogs(t, stream))
+
+	stream, err = client.StreamLogs(ctx, &dmspb.StreamLogsRequest{ExecutionId: "exec-1", Follow: true})
+	require.NoError(t, err)
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "starting", first.GetLine())
+	assert.NotNil(t, first.GetTime())
+
+	logs.Publish(events.ExecutionLog, events.Log{ExecutionID: "exec-1", Stream: "stderr", Line: "warning"})
+	require.Eventually(t, func() bool { return opts.Logs.LastID() == 3 }, time.Second, 10*time.Millisecond)
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1",
+		Result: models.NewExecutionResult(models.ExecutionStatusCodeSuccess)})
+	assert.Equal(t, []string{"stderr: warning"}, readLogs(t, stream), "the stream ends with the execution")
+}
+
+func TestStreamLogsEndsWithoutLines(t *testing.T) {
+	bus, _, opts := setupExecutions(t)
+	_, conn := serve(t, opts)
+
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", ExecutionID: "exec-1"})
+	waitStatus(t, opts.Executions, "exec-1", models.ExecutionStatusRunning)
+
+	// the stream fails with DeadlineExceeded if it doesn't end with the execution
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := dmspb.NewExecutionServiceClient(conn).StreamLogs(ctx,
+		&dmspb.StreamLogsRequest{ExecutionId: "exec-1", Follow: true})
+	require.NoError(t, err)
+
+	// the end of the execution ends the stream even when no line follows it
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", ExecutionID: "exec-1"})
+	assert.Empty(t, readLogs(t, stream))
+}
+
+func TestStreamLogsResult(t *testing.T) {
+	bus, _, opts := setupExecutions(t)
+	_, conn := serve(t, opts)
+
+	// the execution finished before the journal recorded its logs
+	result := models.NewExecutionResult(models.ExecutionStatusCodeSuccess)
+	result.STDOUT = "line 1\nline 2\n"
+	result.STDERR = "oops"
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", ExecutionID: "exec-1"})
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", ExecutionID: "exec-1", Result: result})
+	waitStatus(t, opts.Executions, "exec-1", models.ExecutionStatusFinished)
+
+	stream, err := dmspb.NewExecutionServiceClient(conn).StreamLogs(context.Background(),
+		&dmspb.StreamLogsRequest{ExecutionId: "exec-1", Follow: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stdout: line 1", "stdout: line 2", "stderr: oops"}, readLogs(t, stream))
+
+	stream, err = dmspb.NewExecutionServiceClient(conn).StreamLogs(context.Background(),
+		&dmspb.StreamLogsRequest{ExecutionId: "exec-2"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assertCode(t, err, codes.NotFound)
+}
+
+// jobExecutor starts the executions of the job tests and finishes them when
+// they are cancelled
+type jobExecutor struct {
+	bus *events.Bus
+}
+
+func (e *jobExecutor) IsInstalled(context.Context) bool {
+	return true
+}
+
+func (e *jobExecutor) Start(_ context.Context, request *models.ExecutionRequest) error {
+	e.bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: request.JobID, ExecutionID: request.ExecutionID})
+	return nil
+}
+
+func (e *jobExecutor) Run(context.Context, *models.ExecutionRequest) (*models.ExecutionResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *jobExecutor) Wait(context.Context, string) (<-chan *models.ExecutionResult, <-chan error) {
+	return nil, nil
+}
+
+func (e *jobExecutor) Cancel(_ context.Context, executionID string) error {
+	e.bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", ExecutionID: executionID,
+		Result: &models.ExecutionResult{ExitCode: 137}})
+	return nil
+}
+
+func (e *jobExecutor) GetLogStream(context.Context, models.LogStreamRequest) (io.ReadCloser, error) {
+	return nil, executor.ErrLogStreamUnsupported
+}
+
+func (e *jobExecutor) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestRunExecution(t *testing.T) {
+	bus, _, opts := setupExecutions(t)
+	params, err := structpb.NewStruct(map[string]interface{}{"image": "alpine"})
+	require.NoError(t, err)
+	spec := &dmspb.JobSpec{Engine: &dmspb.EngineSpec{Type: models.ExecutorTypeDocker, Params: params}}
+
+	// without job runner
+	_, conn := serve(t, opts)
+	_, err = dmspb.NewExecutionServiceClient(conn).RunExecution(context.Background(), &dmspb.RunExecutionRequest{Spec: spec})
+	assertCode(t, err, codes.Unavailable)
+
+	opts.Jobs = executor.NewJobs(opts.Executions, map[string]executor.Executor{"docker": &jobExecutor{bus: bus}}, t.TempDir())
+	_, conn = serve(t, opts)
+	client := dmspb.NewExecutionServiceClient(conn)
+	ctx := context.Background()
+
+	execution, err := client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: spec})
+	require.NoError(t, err)
+	assert.Equal(t, models.ExecutionStatusRunning, execution.GetStatus())
+	assert.Equal(t, "docker", execution.GetExecutor())
+
+	waitStatus(t, opts.Executions, execution.GetId(), models.ExecutionStatusRunning)
+	_, err = client.CancelExecution(ctx, &dmspb.CancelExecutionRequest{Id: execution.GetId()})
+	require.NoError(t, err)
+	waitStatus(t, opts.Executions, execution.GetId(), models.ExecutionStatusFailed)
+	_, err = client.CancelExecution(ctx, &dmspb.CancelExecutionRequest{Id: execution.GetId()})
+	assertCode(t, err, codes.FailedPrecondition)
+	_, err = client.CancelExecution(ctx, &dmspb.CancelExecutionRequest{Id: "exec-2"})
+	assertCode(t, err, codes.NotFound)
+
+	_, err = client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: &dmspb.JobSpec{}})
+	assertCode(t, err, codes.InvalidArgument)
+	_, err = client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: &dmspb.JobSpec{Engine: &dmspb.EngineSpec{Type: "wasm"}}})
+	assertCode(t, err, codes.InvalidArgument)
+}
+
+// remoteJobsStub runs the jobs of a single peer
+type remoteJobsStub struct {
+	node     peer.ID
+	anywhere bool
+}
+
+func (r *remoteJobsStub) Run(_ context.Context, to peer.ID, _ models.JobSpec) (models.ExecutionInfo, error) {
+	if to != r.node {
+		return models.ExecutionInfo{}, errors.New("peer unreachable")
+	}
+	return models.ExecutionInfo{ID: "exec-1", Node: to.String(), Status: models.ExecutionStatusRunning}, nil
+}
+
+func (r *remoteJobsStub) RunAnywhere(ctx context.Context, spec models.JobSpec) (models.ExecutionInfo, error) {
+	r.anywhere = true
+	return r.Run(ctx, r.node, spec)
+}
+
+func (r *remoteJobsStub) Cancel(context.Context, peer.ID, string) error {
+	return executor.ErrExecutionNotRunning
+}
+
+func TestRunRemoteExecution(t *testing.T) {
+	const node = "12D3KooWJbA4hd6TnGW9AzLeVBjSFJcAz4bRQNrdfpdEJPvHTZyK"
+	id, err := peer.Decode(node)
+	require.NoError(t, err)
+	_, _, opts := setupExecutions(t)
+	server, conn := serve(t, opts)
+	client := dmspb.NewExecutionServiceClient(conn)
+	ctx := context.Background()
+	spec := &dmspb.JobSpec{Engine: &dmspb.EngineSpec{Type: models.ExecutorTypeDocker}}
+
+	_, err = client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: spec, Node: node})
+	assertCode(t, err, codes.Unavailable)
+
+	remote := &remoteJobsStub{node: id}
+	server.SetRemoteJobs(remote)
+
+	execution, err := client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: spec, Node: node})
+	require.NoError(t, err)
+	assert.Equal(t, "exec-1", execution.GetId())
+	assert.Equal(t, node, execution.GetNode())
+	_, err = client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: spec, Node: "auto"})
+	require.NoError(t, err)
+	assert.True(t, remote.anywhere)
+
+	_, err = client.CancelExecution(ctx, &dmspb.CancelExecutionRequest{Id: "exec-1", Node: node})
+	assertCode(t, err, codes.FailedPrecondition)
+	_, err = client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: spec, Node: "not-a-peer"})
+	assertCode(t, err, codes.InvalidArgument)
+	_, err = client.RunExecution(ctx, &dmspb.RunExecutionRequest{Spec: spec,
+		Node: "12D3KooWQYhTNQdmr3ArTeUHRYzFg94BKyTkoWBDWez9kSCVe2Xo"})
+	assertCode(t, err, codes.Unavailable)
+}
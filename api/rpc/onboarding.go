@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+type onboardingService struct {
+	dmspb.UnimplementedOnboardingServiceServer
+	s *Server
+}
+
+func (o *onboardingService) onboarding() (Onboarding, error) {
+	if o.s.opts.Onboarding == nil {
+		return nil, status.Error(codes.Unavailable, "the onboarding hasn't yet been initialized")
+	}
+	return o.s.opts.Onboarding, nil
+}
+
+func (o *onboardingService) GetStatus(_ context.Context, _ *dmspb.GetStatusRequest) (*dmspb.OnboardingStatus, error) {
+	onboarding, err := o.onboarding()
+	if err != nil {
+		return nil, err
+	}
+	st, err := onboarding.Status()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to check the onboarding status: %v", err)
+	}
+	return &dmspb.OnboardingStatus{
+		Onboarded:    st.Onboarded,
+		MachineUuid:  st.MachineUUID,
+		MetadataPath: st.MetadataPath,
+		DatabasePath: st.DatabasePath,
+	}, nil
+}
+
+func (o *onboardingService) GetMetadata(_ context.Context, _ *dmspb.GetMetadataRequest) (*dmspb.Metadata, error) {
+	onboarding, err := o.onboarding()
+	if err != nil {
+		return nil, err
+	}
+	if st, err := onboarding.Status(); err == nil && !st.Onboarded {
+		return nil, status.Error(codes.FailedPrecondition, "the machine is not onboarded")
+	}
+	metadata, err := onboarding.Metadata()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to read the metadata: %v", err)
+	}
+	return newMetadata(metadata), nil
+}
+
+func (o *onboardingService) Onboard(ctx context.Context, req *dmspb.OnboardRequest) (*dmspb.Metadata, error) {
+	onboarding, err := o.onboarding()
+	if err != nil {
+		return nil, err
+	}
+	if req.GetCapacity() == nil {
+		return nil, status.Error(codes.InvalidArgument, "the capacity is required")
+	}
+	capacity := newCapacity(req.GetCapacity())
+	capacity.Channel = req.GetChannel()
+	capacity.PaymentAddress = req.GetPaymentAddress()
+	capacity.Cardano = req.GetCardano()
+	capacity.ServerMode = req.GetServerMode()
+	capacity.IsAvailable = req.GetAvailable()
+
+	metadata, err := onboarding.Onboard(ctx, capacity)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "unable to onboard: %v", err)
+	}
+	return newMetadata(metadata), nil
+}
+
+func (o *onboardingService) UpdateCapacity(ctx context.Context, req *dmspb.UpdateCapacityRequest) (*dmspb.Metadata, error) {
+	onboarding, err := o.onboarding()
+	if err != nil {
+		return nil, err
+	}
+	if req.GetCapacity() == nil {
+		return nil, status.Error(codes.InvalidArgument, "the capacity is required")
+	}
+	metadata, err := onboarding.UpdateCapacity(ctx, newCapacity(req.GetCapacity()))
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "unable to update the capacity: %v", err)
+	}
+	return newMetadata(metadata), nil
+}
+
+func (o *onboardingService) Offboard(ctx context.Context, req *dmspb.OffboardRequest) (*dmspb.OffboardResponse, error) {
+	onboarding, err := o.onboarding()
+	if err != nil {
+		return nil, err
+	}
+	if err := onboarding.Offboard(ctx, req.GetForce()); err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "unable to offboard: %v", err)
+	}
+	return &dmspb.OffboardResponse{}, nil
+}
+
+func newCapacity(c *dmspb.Capacity) models.CapacityForNunet {
+	return models.CapacityForNunet{
+		Memory:            c.GetMemory(),
+		CPU:               c.GetCpu(),
+		NTXPricePerMinute: c.GetNtxPricePerMinute(),
+	}
+}
+
+func newMetadata(m *models.Metadata) *dmspb.Metadata {
+	metadata := &dmspb.Metadata{
+		Name:              m.Name,
+		MemoryMax:         m.Resource.MemoryMax,
+		TotalCores:        m.Resource.TotalCore,
+		CpuMax:            m.Resource.CPUMax,
+		ReservedCpu:       m.Reserved.CPU,
+		ReservedMemory:    m.Reserved.Memory,
+		Network:           m.Network,
+		PublicKey:         m.PublicKey,
+		NodeId:            m.NodeID,
+		AllowCardano:      m.AllowCardano,
+		NtxPricePerMinute: m.NTXPricePerMinute,
+	}
+	if m.UpdateTimestamp != 0 {
+		metadata.UpdateTime = &timestamppb.Timestamp{Seconds: m.UpdateTimestamp}
+	}
+	return metadata
+}
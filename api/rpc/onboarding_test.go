@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// mockOnboarding onboards a machine in memory
+type mockOnboarding struct {
+	onboarded bool
+	capacity  models.CapacityForNunet
+}
+
+func (o *mockOnboarding) Status() (*models.OnboardingStatus, error) {
+	return &models.OnboardingStatus{Onboarded: o.onboarded, MachineUUID: "machine-1"}, nil
+}
+
+func (o *mockOnboarding) Metadata() (*models.Metadata, error) {
+	m := &models.Metadata{Name: "machine-1", UpdateTimestamp: 1633036800, NTXPricePerMinute: o.capacity.NTXPricePerMinute}
+	m.Reserved.CPU = o.capacity.CPU
+	m.Reserved.Memory = o.capacity.Memory
+	return m, nil
+}
+
+func (o *mockOnboarding) Onboard(_ context.Context, capacity models.CapacityForNunet) (*models.Metadata, error) {
+	if o.onboarded {
+		return nil, errors.New("machine is already onboarded")
+	}
+	o.onboarded, o.capacity = true, capacity
+	return o.Metadata()
+}
+
+func (o *mockOnboarding) UpdateCapacity(_ context.Context, capacity models.CapacityForNunet) (*models.Metadata, error) {
+	if !o.onboarded {
+		return nil, errors.New("machine is not onboarded")
+	}
+	o.capacity.CPU, o.capacity.Memory, o.capacity.NTXPricePerMinute = capacity.CPU, capacity.Memory, capacity.NTXPricePerMinute
+	return o.Metadata()
+}
+
+func (o *mockOnboarding) Offboard(_ context.Context, _ bool) error {
+	o.onboarded = false
+	return nil
+}
+
+func TestOnboarding(t *testing.T) {
+	onboarding := &mockOnboarding{}
+	_, conn := serve(t, Options{Onboarding: onboarding})
+	client := dmspb.NewOnboardingServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := client.GetMetadata(ctx, &dmspb.GetMetadataRequest{})
+	assertCode(t, err, codes.FailedPrecondition)
+	_, err = client.UpdateCapacity(ctx, &dmspb.UpdateCapacityRequest{Capacity: &dmspb.Capacity{Cpu: 1000}})
+	assertCode(t, err, codes.FailedPrecondition)
+	_, err = client.Onboard(ctx, &dmspb.OnboardRequest{Channel: "nunet-test"})
+	assertCode(t, err, codes.InvalidArgument)
+
+	metadata, err := client.Onboard(ctx, &dmspb.OnboardRequest{
+		Capacity:  &dmspb.Capacity{Memory: 2000, Cpu: 3000},
+		Channel:   "nunet-test",
+		Available: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), metadata.GetReservedCpu())
+	assert.Equal(t, int64(1633036800), metadata.GetUpdateTime().GetSeconds())
+	assert.Equal(t, "nunet-test", onboarding.capacity.Channel)
+	assert.True(t, onboarding.capacity.IsAvailable)
+
+	st, err := client.GetStatus(ctx, &dmspb.GetStatusRequest{})
+	require.NoError(t, err)
+	assert.True(t, st.GetOnboarded())
+	assert.Equal(t, "machine-1", st.GetMachineUuid())
+
+	metadata, err = client.UpdateCapacity(ctx, &dmspb.UpdateCapacityRequest{Capacity: &dmspb.Capacity{Memory: 1000, Cpu: 1500}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), metadata.GetReservedMemory())
+
+	_, err = client.Offboard(ctx, &dmspb.OffboardRequest{})
+	require.NoError(t, err)
+	assert.False(t, onboarding.onboarded)
+}
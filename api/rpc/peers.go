@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/network"
+)
+
+type peerService struct {
+	dmspb.UnimplementedPeerServiceServer
+	s *Server
+}
+
+// network returns the network of the node while it is running
+func (p *peerService) network() (network.Network, error) {
+	net := p.s.getNetwork()
+	if net == nil {
+		return nil, status.Error(codes.Unavailable, "the host node hasn't yet been initialized")
+	}
+	return net, nil
+}
+
+func (p *peerService) ListPeers(_ context.Context, req *dmspb.ListPeersRequest) (*dmspb.ListPeersResponse, error) {
+	net, err := p.network()
+	if err != nil {
+		return nil, err
+	}
+	stats := net.Peers()
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+
+	resp := &dmspb.ListPeersResponse{}
+	for _, stat := range stats {
+		if req.GetConnectedOnly() && !stat.Connected {
+			continue
+		}
+		resp.Peers = append(resp.Peers, newPeer(stat))
+	}
+	return resp, nil
+}
+
+func (p *peerService) GetPeer(_ context.Context, req *dmspb.GetPeerRequest) (*dmspb.Peer, error) {
+	net, err := p.network()
+	if err != nil {
+		return nil, err
+	}
+	for _, stat := range net.Peers() {
+		if stat.ID == req.GetId() {
+			return newPeer(stat), nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "peer %s not found", req.GetId())
+}
+
+func (p *peerService) PingPeer(ctx context.Context, req *dmspb.PingPeerRequest) (*dmspb.PingPeerResponse, error) {
+	net, err := p.network()
+	if err != nil {
+		return nil, err
+	}
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "the peer ID is required")
+	}
+	timeout := req.GetTimeout().AsDuration()
+	if timeout < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid timeout %s", timeout)
+	}
+
+	address := models.SpecConfig{
+		Type:   string(models.NetP2P),
+		Params: map[string]interface{}{"peer_id": req.GetId()},
+	}
+	result, err := net.Ping(ctx, address, timeout)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "could not ping peer %s: %v", req.GetId(), err)
+	}
+	return &dmspb.PingPeerResponse{Rtt: durationpb.New(result.RTT)}, nil
+}
+
+func newPeer(stat models.PeerStat) *dmspb.Peer {
+	peer := &dmspb.Peer{
+		Id:        stat.ID,
+		Addrs:     stat.Addrs,
+		Connected: stat.Connected,
+		Direction: stat.Direction,
+		Relayed:   stat.Relayed,
+	}
+	if stat.Latency > 0 {
+		peer.Latency = durationpb.New(stat.Latency)
+	}
+	return peer
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/network"
+)
+
+// mockNetwork implements the methods of network.Network used by the peer service
+type mockNetwork struct {
+	network.Network
+	peers []models.PeerStat
+}
+
+func (n *mockNetwork) Peers() []models.PeerStat {
+	return n.peers
+}
+
+func (n *mockNetwork) Ping(_ context.Context, address models.SpecConfig, _ time.Duration) (models.PingResult, error) {
+	if address.Params["peer_id"] != "peer-a" {
+		return models.PingResult{}, errors.New("no route to peer")
+	}
+	return models.PingResult{RTT: 3 * time.Millisecond, Success: true}, nil
+}
+
+func TestPeers(t *testing.T) {
+	server, conn := serve(t, Options{})
+	client := dmspb.NewPeerServiceClient(conn)
+	ctx := context.Background()
+
+	_, err := client.ListPeers(ctx, &dmspb.ListPeersRequest{})
+	assertCode(t, err, codes.Unavailable)
+
+	server.SetNetwork(&mockNetwork{peers: []models.PeerStat{
+		{ID: "peer-b", Connected: true, Relayed: true, Latency: time.Millisecond},
+		{ID: "peer-a", Connected: true},
+		{ID: "peer-c"},
+	}})
+
+	list, err := client.ListPeers(ctx, &dmspb.ListPeersRequest{ConnectedOnly: true})
+	require.NoError(t, err)
+	require.Len(t, list.GetPeers(), 2)
+	assert.Equal(t, "peer-a", list.GetPeers()[0].GetId())
+	assert.Equal(t, time.Millisecond, list.GetPeers()[1].GetLatency().AsDuration())
+
+	peer, err := client.GetPeer(ctx, &dmspb.GetPeerRequest{Id: "peer-c"})
+	require.NoError(t, err)
+	assert.False(t, peer.GetConnected())
+	_, err = client.GetPeer(ctx, &dmspb.GetPeerRequest{Id: "peer-d"})
+	assertCode(t, err, codes.NotFound)
+
+	ping, err := client.PingPeer(ctx, &dmspb.PingPeerRequest{Id: "peer-a", Timeout: durationpb.New(time.Second)})
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Millisecond, ping.GetRtt().AsDuration())
+	_, err = client.PingPeer(ctx, &dmspb.PingPeerRequest{Id: "peer-b"})
+	assertCode(t, err, codes.Unavailable)
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/internal/events"
+)
+
+type resourceService struct {
+	dmspb.UnimplementedResourceServiceServer
+	s *Server
+}
+
+func (r *resourceService) GetResources(ctx context.Context, _ *dmspb.GetResourcesRequest) (*dmspb.Resources, error) {
+	if r.s.opts.Resources == nil {
+		return nil, status.Error(codes.Unavailable, "the resources haven't yet been initialized")
+	}
+	return r.resources(ctx)
+}
+
+func (r *resourceService) resources(ctx context.Context) (*dmspb.Resources, error) {
+	resources := &dmspb.Resources{}
+	if p := r.s.opts.Resources.Provisioned(); p != nil {
+		resources.Provisioned = &dmspb.ProvisionedResources{Cpu: p.CPU, Memory: p.Memory, Cores: p.NumCores}
+	}
+	free, err := r.s.opts.Resources.Free(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to compute the free resources: %v", err)
+	}
+	if free != nil {
+		resources.Free = &dmspb.FreeResources{
+			Cpu:               int64(free.TotCpuHz),
+			Memory:            int64(free.Ram),
+			Vcpu:              int64(free.Vcpu),
+			Disk:              free.Disk,
+			NtxPricePerMinute: free.NTXPricePerMinute,
+		}
+	}
+	return resources, nil
+}
+
+func (r *resourceService) WatchResources(_ *dmspb.WatchResourcesRequest, stream dmspb.ResourceService_WatchResourcesServer) error {
+	journal := r.s.opts.Journal
+	if r.s.opts.Resources == nil || journal == nil {
+		return status.Error(codes.Unavailable, "the resources haven't yet been initialized")
+	}
+
+	ctx := stream.Context()
+	after := journal.LastID()
+	resources, err := r.resources(ctx)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(resources); err != nil {
+		return err
+	}
+
+	for {
+		records, complete, changed := journal.Since(after)
+		// the resources are sent again when changes may have been missed
+		updated := !complete
+		for _, record := range records {
+			after = record.ID
+			if record.Type == events.ResourcesChanged || record.Type == events.OnboardingChanged {
+				updated = true
+			}
+		}
+		if updated {
+			if resources, err = r.resources(ctx); err != nil {
+				return err
+			}
+			if err := stream.Send(resources); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-journal.Done():
+			return status.Error(codes.Unavailable, "the DMS is shutting down")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
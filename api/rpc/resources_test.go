@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// mockResources reports fixed resources, free once onboarded
+type mockResources struct {
+	free *models.FreeResources
+}
+
+func (r *mockResources) Provisioned() *models.Provisioned {
+	return &models.Provisioned{CPU: 8000, Memory: 16000, NumCores: 4}
+}
+
+func (r *mockResources) Free(_ context.Context) (*models.FreeResources, error) {
+	return r.free, nil
+}
+
+func TestResources(t *testing.T) {
+	bus := events.NewBus()
+	journal := events.NewJournal(bus, 16)
+	t.Cleanup(journal.Close)
+	resources := &mockResources{}
+	_, conn := serve(t, Options{Resources: resources, Journal: journal})
+	client := dmspb.NewResourceServiceClient(conn)
+
+	got, err := client.GetResources(context.Background(), &dmspb.GetResourcesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), got.GetProvisioned().GetCores())
+	assert.Nil(t, got.GetFree(), "the free resources are unset before onboarding")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.WatchResources(ctx, &dmspb.WatchResourcesRequest{})
+	require.NoError(t, err)
+	got, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Nil(t, got.GetFree())
+
+	resources.free = &models.FreeResources{TotCpuHz: 2000, Ram: 1000, Vcpu: 1}
+	bus.Publish(events.ResourcesChanged, events.Resources{Free: *resources.free})
+	got, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000), got.GetFree().GetCpu())
+	assert.Equal(t, int64(1000), got.GetFree().GetMemory())
+}
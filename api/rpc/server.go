@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/network"
+	"gitlab.com/nunet/device-management-service/storage"
+)
+
+// Onboarding onboards the machine, it is implemented by the DMS with the
+// dms/onboarding package the REST API uses
+type Onboarding interface {
+	Status() (*models.OnboardingStatus, error)
+	Metadata() (*models.Metadata, error)
+	Onboard(ctx context.Context, capacity models.CapacityForNunet) (*models.Metadata, error)
+	UpdateCapacity(ctx context.Context, capacity models.CapacityForNunet) (*models.Metadata, error)
+	Offboard(ctx context.Context, force bool) error
+}
+
+// Resources reports the resources of the machine, it is implemented by the
+// DMS with the dms/resources package
+type Resources interface {
+	Provisioned() *models.Provisioned
+	// Free returns nil without error while the machine isn't onboarded
+	Free(ctx context.Context) (*models.FreeResources, error)
+}
+
+// RemoteJobs runs the jobs on other peers, it is implemented by the job
+// service of network/libp2p the REST API uses
+type RemoteJobs interface {
+	Run(ctx context.Context, to peer.ID, spec models.JobSpec) (models.ExecutionInfo, error)
+	RunAnywhere(ctx context.Context, spec models.JobSpec) (models.ExecutionInfo, error)
+	Cancel(ctx context.Context, to peer.ID, id string) error
+}
+
+// Options are the components of the DMS the services serve, the methods
+// of a service whose component is nil fail with codes.Unavailable
+type Options struct {
+	Authenticator *auth.Authenticator // authentication is disabled while nil
+	Onboarding    Onboarding
+	Resources     Resources
+	Executions    *executor.Registry
+	Jobs          *executor.Jobs  // runs the jobs submitted to this node, nil when it doesn't run jobs
+	Journal       *events.Journal // streams the resource changes
+	Logs          *events.Journal // streams the log lines of the executions, follows events.Logs
+	Volumes       storage.VolumeController
+}
+
+// Server is the gRPC server of the control API
+type Server struct {
+	*grpc.Server
+	opts Options
+
+	mu         sync.RWMutex
+	network    network.Network
+	remoteJobs RemoteJobs
+}
+
+// NewServer returns a server of the control API with its services, the
+// authentication interceptors and the reflection service
+func NewServer(opts Options, serverOpts ...grpc.ServerOption) *Server {
+	s := &Server{opts: opts}
+	serverOpts = append(serverOpts,
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	)
+	s.Server = grpc.NewServer(serverOpts...)
+
+	dmspb.RegisterOnboardingServiceServer(s.Server, &onboardingService{s: s})
+	dmspb.RegisterResourceServiceServer(s.Server, &resourceService{s: s})
+	dmspb.RegisterExecutionServiceServer(s.Server, &executionService{s: s})
+	dmspb.RegisterVolumeServiceServer(s.Server, &volumeService{s: s})
+	dmspb.RegisterPeerServiceServer(s.Server, &peerService{s: s})
+	// lets grpcurl and the other clients discover the services
+	reflection.Register(s.Server)
+	return s
+}
+
+// Shutdown stops the server gracefully, the calls in flight finish first
+// unless ctx is done before, the server is then stopped
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		return ctx.Err()
+	}
+}
+
+// SetNetwork sets the network of the peer service once the node is running
+func (s *Server) SetNetwork(net network.Network) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.network = net
+}
+
+func (s *Server) getNetwork() network.Network {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.network
+}
+
+// SetRemoteJobs sets the jobs of the other peers once the node is running
+func (s *Server) SetRemoteJobs(j RemoteJobs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remoteJobs = j
+}
+
+func (s *Server) getRemoteJobs() RemoteJobs {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.remoteJobs
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+)
+
+// serve serves a server of opts on an in-memory listener and returns a
+// client connection to it
+func serve(t *testing.T, opts Options) (*Server, *grpc.ClientConn) {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(opts)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return server, conn
+}
+
+// assertCode checks the status code of a failed call
+func assertCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	require.Error(t, err)
+	assert.Equal(t, code, status.Code(err), err.Error())
+}
+
+func TestUnavailable(t *testing.T) {
+	_, conn := serve(t, Options{})
+	ctx := context.Background()
+
+	_, err := dmspb.NewOnboardingServiceClient(conn).GetStatus(ctx, &dmspb.GetStatusRequest{})
+	assertCode(t, err, codes.Unavailable)
+	_, err = dmspb.NewResourceServiceClient(conn).GetResources(ctx, &dmspb.GetResourcesRequest{})
+	assertCode(t, err, codes.Unavailable)
+	_, err = dmspb.NewExecutionServiceClient(conn).ListExecutions(ctx, &dmspb.ListExecutionsRequest{})
+	assertCode(t, err, codes.Unavailable)
+	_, err = dmspb.NewVolumeServiceClient(conn).ListVolumes(ctx, &dmspb.ListVolumesRequest{})
+	assertCode(t, err, codes.Unavailable)
+	_, err = dmspb.NewPeerServiceClient(conn).ListPeers(ctx, &dmspb.ListPeersRequest{})
+	assertCode(t, err, codes.Unavailable)
+}
+
+func TestReflection(t *testing.T) {
+	_, conn := serve(t, Options{})
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+
+	var services []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		services = append(services, s.GetName())
+	}
+	for _, name := range []string{"OnboardingService", "ResourceService", "ExecutionService", "VolumeService", "PeerService"} {
+		assert.Contains(t, services, "nunet.dms.v1."+name)
+	}
+}
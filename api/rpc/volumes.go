@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/storage"
+	"gitlab.com/nunet/device-management-service/storage/basic_controller"
+)
+
+type volumeService struct {
+	dmspb.UnimplementedVolumeServiceServer
+	s *Server
+}
+
+func (v *volumeService) ready() error {
+	if v.s.opts.Volumes == nil {
+		return status.Error(codes.Unavailable, "the volume controller hasn't yet been initialized")
+	}
+	return nil
+}
+
+// list returns the volumes of the controller sorted by ID
+func (v *volumeService) list() ([]*dmspb.Volume, error) {
+	if err := v.ready(); err != nil {
+		return nil, err
+	}
+	stored, err := v.s.opts.Volumes.ListVolumes()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to list the volumes: %v", err)
+	}
+	volumes := make([]*dmspb.Volume, 0, len(stored))
+	for _, volume := range stored {
+		volumes = append(volumes, newVolume(volume))
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Id < volumes[j].Id })
+	return volumes, nil
+}
+
+// find returns the volume of an ID
+func (v *volumeService) find(id string) (*dmspb.Volume, error) {
+	volumes, err := v.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range volumes {
+		if volume.Id == id {
+			return volume, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "volume %s not found", id)
+}
+
+func (v *volumeService) ListVolumes(_ context.Context, _ *dmspb.ListVolumesRequest) (*dmspb.ListVolumesResponse, error) {
+	volumes, err := v.list()
+	if err != nil {
+		return nil, err
+	}
+	return &dmspb.ListVolumesResponse{Volumes: volumes}, nil
+}
+
+func (v *volumeService) GetVolume(_ context.Context, req *dmspb.GetVolumeRequest) (*dmspb.Volume, error) {
+	volume, err := v.find(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if size, err := v.s.opts.Volumes.GetSize(volume.Path, storage.IDTypePath); err == nil {
+		volume.Size = size
+	}
+	return volume, nil
+}
+
+func (v *volumeService) CreateVolume(_ context.Context, req *dmspb.CreateVolumeRequest) (*dmspb.Volume, error) {
+	if err := v.ready(); err != nil {
+		return nil, err
+	}
+	source := storage.VolumeSource(req.GetSource())
+	switch source {
+	case storage.VolumeSourceS3, storage.VolumeSourceIPFS, storage.VolumeSourceJob:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid source %q: use s3, ipfs or job", req.GetSource())
+	}
+	var opts []storage.CreateVolOpt
+	if req.GetPrivate() {
+		opts = append(opts, basic_controller.WithPrivate[storage.CreateVolOpt]())
+	}
+
+	volume, err := v.s.opts.Volumes.CreateVolume(source, opts...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to create the volume: %v", err)
+	}
+	return newVolume(volume), nil
+}
+
+func (v *volumeService) DeleteVolume(_ context.Context, req *dmspb.DeleteVolumeRequest) (*dmspb.DeleteVolumeResponse, error) {
+	volume, err := v.find(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := v.s.opts.Volumes.DeleteVolume(volume.Path, storage.IDTypePath); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to delete volume %s: %v", volume.Id, err)
+	}
+	return &dmspb.DeleteVolumeResponse{}, nil
+}
+
+func newVolume(v storage.StorageVolume) *dmspb.Volume {
+	return &dmspb.Volume{
+		Id:         filepath.Base(v.Path),
+		Cid:        v.CID,
+		Path:       v.Path,
+		ReadOnly:   v.ReadOnly,
+		Private:    v.Private,
+		Encrypted:  v.EncryptionType != models.EncryptionTypeNull,
+		CreateTime: timestamppb.New(v.CreatedAt),
+		UpdateTime: timestamppb.New(v.UpdatedAt),
+	}
+}
@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"gitlab.com/nunet/device-management-service/api/rpc/dmspb"
+	"gitlab.com/nunet/device-management-service/storage/basic_controller"
+)
+
+func TestVolumes(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	fs := afero.NewMemMapFs()
+	vc, err := basic_controller.NewDefaultVolumeController(db, "/volumes/", fs)
+	require.NoError(t, err)
+	_, conn := serve(t, Options{Volumes: vc})
+	client := dmspb.NewVolumeServiceClient(conn)
+	ctx := context.Background()
+
+	created, err := client.CreateVolume(ctx, &dmspb.CreateVolumeRequest{Source: "ipfs", Private: true})
+	require.NoError(t, err)
+	assert.True(t, created.GetPrivate())
+	require.NotEmpty(t, created.GetId())
+	_, err = client.CreateVolume(ctx, &dmspb.CreateVolumeRequest{Source: "s3"})
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, created.GetPath()+"/data", []byte("12345"), 0o644))
+
+	list, err := client.ListVolumes(ctx, &dmspb.ListVolumesRequest{})
+	require.NoError(t, err)
+	assert.Len(t, list.GetVolumes(), 2)
+
+	volume, err := client.GetVolume(ctx, &dmspb.GetVolumeRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), volume.GetSize())
+
+	_, err = client.DeleteVolume(ctx, &dmspb.DeleteVolumeRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	_, err = client.GetVolume(ctx, &dmspb.GetVolumeRequest{Id: created.GetId()})
+	assertCode(t, err, codes.NotFound)
+	_, err = client.CreateVolume(ctx, &dmspb.CreateVolumeRequest{Source: "ftp"})
+	assertCode(t, err, codes.InvalidArgument)
+}
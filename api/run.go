@@ -1,10 +1,15 @@
 package api
 
 import (
+	"context"
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"gitlab.com/nunet/device-management-service/libp2p"
 	"gitlab.com/nunet/device-management-service/libp2p/machines"
 	"gitlab.com/nunet/device-management-service/models"
+	nlibp2p "gitlab.com/nunet/device-management-service/network/libp2p"
+	"gitlab.com/nunet/device-management-service/telemetry"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -15,10 +20,12 @@ type checkpoint struct {
 	LastModified  int64  `json:"last_modified"`
 }
 
+var errNoMatchingPeer = errors.New("no peers found with matched specs")
+
 // RequestServiceHandler  godoc
 //
 //	@Summary		RequestServiceHandler receives parameters from the SP client and returns blockchain realted data to client.
-//	@Description	RequestServiceHandler receives the parameters from the SP client, searches the DHT for non-busy, available devices with appropriate metadata. Then informs parameters related to blockchain to client to run a service on NuNet.
+//	@Description	RequestServiceHandler receives the parameters from the SP client, searches the DHT for available devices advertising the capabilities of the request unless a node_id is given. Then informs parameters related to blockchain to client to run a service on NuNet.
 //	@Tags			run
 //	@Produce		json
 //	@Param			deployment_request	body		models.DeploymentRequest	true	"Deployment Request"
@@ -46,6 +53,16 @@ func RequestServiceHandler(c *gin.Context) {
 		c.AbortWithStatusJSON(400, gin.H{"error": "invalid payload data"})
 		return
 	}
+	if depReq.Params.RemoteNodeID == "" {
+		data, err := selectPeer(reqCtx, depReq)
+		if err != nil {
+			c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		depReq.Params.RemoteNodeID = data.PeerID
+	}
+	// the compute provider continues the trace of the request
+	depReq.TraceInfo = telemetry.TraceInfo(reqCtx)
 	resp, err := machines.RequestService(reqCtx, depReq)
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
@@ -54,6 +71,34 @@ func RequestServiceHandler(c *gin.Context) {
 	c.JSON(200, resp)
 }
 
+// deploymentQuery builds the capability query of a deployment request, its
+// service is run by the docker executor
+func deploymentQuery(depReq models.DeploymentRequest) nlibp2p.CapabilityQuery {
+	query := nlibp2p.QueryFromJobSpec(models.JobSpec{
+		Engine:    &models.SpecConfig{Type: models.ExecutorTypeDocker},
+		Resources: &models.ExecutionResources{Memory: uint64(depReq.Constraints.RAM) << 20},
+	})
+	query.MinCPU = depReq.Constraints.CPU
+	query.MinVRAM = uint64(depReq.Constraints.Vram)
+	query.Limit = 1
+	return query
+}
+
+// selectPeer returns the first peer found by a capability search for the deployment request
+func selectPeer(ctx context.Context, depReq models.DeploymentRequest) (models.PeerData, error) {
+	finder, ok := p2pNetwork.(nlibp2p.PeerFinder)
+	if !ok {
+		return models.PeerData{}, errNetworkNotInitialized
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for data := range finder.FindPeers(ctx, deploymentQuery(depReq)) {
+		return data, nil
+	}
+	return models.PeerData{}, errNoMatchingPeer
+}
+
 // DeploymentRequestHandler  godoc
 //
 //	@Summary		Websocket endpoint responsible for sending deployment request and receiving deployment response.
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -103,3 +104,21 @@ func TestListCheckpointHandler(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &checks)
 	assert.NoError(t, err)
 }
+
+func TestDeploymentQuery(t *testing.T) {
+	depReq := models.DeploymentRequest{}
+	depReq.Constraints.CPU = 4000
+	depReq.Constraints.RAM = 2048
+	depReq.Constraints.Vram = 8192
+
+	query := deploymentQuery(depReq)
+	assert.Equal(t, []string{models.ExecutorTypeDocker}, query.ExecutorTypes)
+	assert.Equal(t, 4000, query.MinCPU)
+	assert.Equal(t, 2048, query.MinRAM)
+	assert.Equal(t, uint64(8192), query.MinVRAM)
+	assert.Equal(t, 1, query.Limit)
+
+	SetNetwork(nil)
+	_, err := selectPeer(context.Background(), depReq)
+	assert.ErrorIs(t, err, errNetworkNotInitialized)
+}
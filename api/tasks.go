@@ -0,0 +1,188 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+)
+
+var (
+	// scheduler is the background task scheduler the handlers below operate on
+	scheduler *bt.Scheduler
+
+	errSchedulerNotInitialized = errors.New("task scheduler hasn't yet been initialized")
+)
+
+// SetScheduler sets the scheduler used by the task handlers
+func SetScheduler(s *bt.Scheduler) {
+	scheduler = s
+}
+
+// ListTasksHandler  godoc
+//
+//	@Summary		List background tasks
+//	@Description	Lists the background tasks of the DMS with their triggers and state
+//	@Tags			tasks
+//	@Produce		json
+//	@Success		200	{array}		models.TaskStatus
+//	@Failure		500	{object}	object	"task scheduler hasn't yet been initialized"
+//	@Router			/tasks [get]
+func ListTasksHandler(c *gin.Context) {
+	if scheduler == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": errSchedulerNotInitialized.Error()})
+		return
+	}
+	c.JSON(200, scheduler.Tasks())
+}
+
+// ShowTaskHandler  godoc
+//
+//	@Summary		Show a background task
+//	@Description	Shows a background task with the history of its latest executions
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int	true	"task ID"
+//	@Success		200	{object}	models.TaskStatus
+//	@Failure		400	{object}	object	"invalid task ID"
+//	@Failure		404	{object}	object	"task not found"
+//	@Failure		500	{object}	object	"task scheduler hasn't yet been initialized"
+//	@Router			/tasks/{id} [get]
+func ShowTaskHandler(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	status, err := scheduler.TaskStatus(id)
+	if err != nil {
+		abortTaskError(c, err)
+		return
+	}
+	c.JSON(200, status)
+}
+
+// RunTaskHandler  godoc
+//
+//	@Summary		Run a background task
+//	@Description	Runs a background task immediately, regardless of its triggers
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int		true	"task ID"
+//	@Success		200	{object}	object	"task started"
+//	@Failure		400	{object}	object	"invalid task ID or task already running"
+//	@Failure		404	{object}	object	"task not found"
+//	@Failure		500	{object}	object	"task scheduler hasn't yet been initialized"
+//	@Router			/tasks/{id}/run [post]
+func RunTaskHandler(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	if err := scheduler.RunTask(id); err != nil {
+		abortTaskError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("task %d started", id)})
+}
+
+// CancelTaskHandler  godoc
+//
+//	@Summary		Cancel a background task
+//	@Description	Cancels the running execution of a background task
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int		true	"task ID"
+//	@Success		200	{object}	object	"task cancelled"
+//	@Failure		400	{object}	object	"invalid task ID or task not running"
+//	@Failure		404	{object}	object	"task not found"
+//	@Failure		500	{object}	object	"task scheduler hasn't yet been initialized"
+//	@Router			/tasks/{id}/cancel [post]
+func CancelTaskHandler(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	if err := scheduler.CancelTask(id); err != nil {
+		abortTaskError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("task %d cancelled", id)})
+}
+
+// DisableTaskHandler  godoc
+//
+//	@Summary		Disable a background task
+//	@Description	Disables a background task so that its triggers no longer run it, the state is kept across restarts
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int		true	"task ID"
+//	@Success		200	{object}	object	"task disabled"
+//	@Failure		400	{object}	object	"invalid task ID"
+//	@Failure		404	{object}	object	"task not found"
+//	@Failure		500	{object}	object	"could not disable task"
+//	@Router			/tasks/{id}/disable [post]
+func DisableTaskHandler(c *gin.Context) {
+	setTaskEnabled(c, false)
+}
+
+// EnableTaskHandler  godoc
+//
+//	@Summary		Enable a background task
+//	@Description	Enables a background task disabled earlier
+//	@Tags			tasks
+//	@Produce		json
+//	@Param			id	path		int		true	"task ID"
+//	@Success		200	{object}	object	"task enabled"
+//	@Failure		400	{object}	object	"invalid task ID"
+//	@Failure		404	{object}	object	"task not found"
+//	@Failure		500	{object}	object	"could not enable task"
+//	@Router			/tasks/{id}/enable [post]
+func EnableTaskHandler(c *gin.Context) {
+	setTaskEnabled(c, true)
+}
+
+func setTaskEnabled(c *gin.Context, enabled bool) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	if err := scheduler.SetTaskEnabled(id, enabled); err != nil {
+		abortTaskError(c, err)
+		return
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("task %d %s", id, state)})
+}
+
+// taskID parses the task ID of the request, aborting it when the ID is
+// invalid or the scheduler is not running
+func taskID(c *gin.Context) (int, bool) {
+	if scheduler == nil {
+		c.AbortWithStatusJSON(500, gin.H{"error": errSchedulerNotInitialized.Error()})
+		return 0, false
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid task ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+func abortTaskError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, bt.ErrTaskNotFound):
+		c.AbortWithStatusJSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, bt.ErrTaskNotRunning), errors.Is(err, bt.ErrTaskRunning):
+		c.AbortWithStatusJSON(400, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func mockTasks() []models.TaskStatus {
+	return []models.TaskStatus{
+		{ID: 0, Name: "Peer Discovery", Triggers: []string{"every 15m0s"}, Enabled: true},
+		{ID: 1, Name: "Peer Cleanup", Triggers: []string{"every 5m0s"}, Enabled: true, Running: true,
+			History: []models.TaskExecution{{TaskName: "Peer Cleanup", Status: "SUCCESS"}}},
+	}
+}
+
+func mockTask(c *gin.Context) (models.TaskStatus, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"error": "invalid task ID"})
+		return models.TaskStatus{}, false
+	}
+	for _, task := range mockTasks() {
+		if task.ID == id {
+			return task, true
+		}
+	}
+	c.AbortWithStatusJSON(404, gin.H{"error": "task not found"})
+	return models.TaskStatus{}, false
+}
+
+func (m *MockHandler) ListTasksHandler(c *gin.Context) {
+	tasks := mockTasks()
+	for i := range tasks {
+		tasks[i].History = nil
+	}
+	c.JSON(200, tasks)
+}
+
+func (m *MockHandler) ShowTaskHandler(c *gin.Context) {
+	if task, ok := mockTask(c); ok {
+		c.JSON(200, task)
+	}
+}
+
+func (m *MockHandler) RunTaskHandler(c *gin.Context) {
+	task, ok := mockTask(c)
+	if !ok {
+		return
+	}
+	if task.Running {
+		c.AbortWithStatusJSON(400, gin.H{"error": "task is already running"})
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("task %d started", task.ID)})
+}
+
+func (m *MockHandler) CancelTaskHandler(c *gin.Context) {
+	task, ok := mockTask(c)
+	if !ok {
+		return
+	}
+	if !task.Running {
+		c.AbortWithStatusJSON(400, gin.H{"error": "task is not running"})
+		return
+	}
+	c.JSON(200, gin.H{"message": fmt.Sprintf("task %d cancelled", task.ID)})
+}
+
+func (m *MockHandler) DisableTaskHandler(c *gin.Context) {
+	if task, ok := mockTask(c); ok {
+		c.JSON(200, gin.H{"message": fmt.Sprintf("task %d disabled", task.ID)})
+	}
+}
+
+func (m *MockHandler) EnableTaskHandler(c *gin.Context) {
+	if task, ok := mockTask(c); ok {
+		c.JSON(200, gin.H{"message": fmt.Sprintf("task %d enabled", task.ID)})
+	}
+}
+
+func TestListTasksHandler(t *testing.T) {
+	router := SetupMockRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/tasks", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	var tasks []models.TaskStatus
+	err := json.Unmarshal(w.Body.Bytes(), &tasks)
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Empty(t, tasks[1].History)
+}
+
+func TestShowTaskHandler(t *testing.T) {
+	router := SetupMockRouter()
+
+	tests := []struct {
+		description  string
+		id           string
+		expectedCode int
+	}{
+		{description: "existing task", id: "1", expectedCode: 200},
+		{description: "unknown task", id: "42", expectedCode: 404},
+		{description: "invalid task ID", id: "foo", expectedCode: 400},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/tasks/"+tc.id, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedCode, w.Code, tc.description)
+	}
+}
+
+func TestTaskActionHandlers(t *testing.T) {
+	router := SetupMockRouter()
+
+	tests := []struct {
+		description  string
+		path         string
+		expectedCode int
+	}{
+		{description: "run idle task", path: "/api/v1/tasks/0/run", expectedCode: 200},
+		{description: "run running task", path: "/api/v1/tasks/1/run", expectedCode: 400},
+		{description: "cancel running task", path: "/api/v1/tasks/1/cancel", expectedCode: 200},
+		{description: "cancel idle task", path: "/api/v1/tasks/0/cancel", expectedCode: 400},
+		{description: "disable task", path: "/api/v1/tasks/0/disable", expectedCode: 200},
+		{description: "enable unknown task", path: "/api/v1/tasks/42/enable", expectedCode: 404},
+	}
+
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", tc.path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tc.expectedCode, w.Code, tc.description)
+	}
+}
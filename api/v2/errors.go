@@ -0,0 +1,60 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes of the error envelope, stable identifiers the clients can
+// switch on instead of the messages
+const (
+	CodeInvalidArgument  = "invalid_argument"
+	CodeUnauthenticated  = "unauthenticated"
+	CodePermissionDenied = "permission_denied"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeUnavailable      = "unavailable"
+	CodeInternal         = "internal"
+)
+
+// statusCodes maps the error codes to their HTTP status
+var statusCodes = map[string]int{
+	CodeInvalidArgument:  http.StatusBadRequest,
+	CodeUnauthenticated:  http.StatusUnauthorized,
+	CodePermissionDenied: http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeConflict:         http.StatusConflict,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+	CodeInternal:         http.StatusInternalServerError,
+}
+
+// ErrorEnvelope is the body of every failed request
+type ErrorEnvelope struct {
+	Error Error `json:"error"`
+}
+
+// Error describes why a request failed
+type Error struct {
+	Code    string `json:"code" example:"not_found"`
+	Message string `json:"message" example:"task 3 not found"`
+}
+
+// abort aborts the request with the error envelope of code
+func abort(c *gin.Context, code, message string) {
+	status, ok := statusCodes[code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	c.AbortWithStatusJSON(status, ErrorEnvelope{Error: Error{Code: code, Message: message}})
+}
+
+// AbortAuth reports the authentication and authorization failures of the v2
+// routes with the error envelope
+func AbortAuth(c *gin.Context, status int, err error) {
+	code := CodeUnauthenticated
+	if status == http.StatusForbidden {
+		code = CodePermissionDenied
+	}
+	abort(c, code, err.Error())
+}
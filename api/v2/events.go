@@ -0,0 +1,212 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/events"
+)
+
+// StreamReset is sent in place of the events a client resuming the stream
+// missed, it should then reload the state it follows
+const StreamReset events.Type = "stream.reset"
+
+// keepAliveInterval is the interval of the keep-alive messages of the idle
+// streams, short enough for the proxies not to close them
+const keepAliveInterval = 15 * time.Second
+
+// journal records the events streamed to the clients
+var journal *events.Journal
+
+// SetEventJournal sets the journal of the event stream
+func SetEventJournal(j *events.Journal) {
+	journal = j
+}
+
+// streamWriter writes the messages of an event stream to a client
+type streamWriter interface {
+	write(r events.Record) error
+	keepAlive() error
+}
+
+// StreamEventsHandler  godoc
+//
+//	@Summary		Stream events
+//	@Description	Streams the events of the DMS as Server-Sent Events, or as WebSocket text messages when the request is a WebSocket upgrade. Each event is a JSON object with an id, a type such as execution.started, a time and its data.
+//	@Description	The topics are event types, e.g. peer.connected, or their category, e.g. peer: execution, peer, resources, onboarding and heartbeat. A client resumes the stream after the last event it received with the Last-Event-ID header, which EventSource sends when it reconnects, or the last_event_id query parameter. When the events following it are no longer retained or were dropped, a stream.reset event is sent first. The log lines of the executions are streamed by /executions/{id}/logs.
+//	@Tags			events
+//	@Produce		text/event-stream
+//	@Security		BearerAuth
+//	@Param			topics			query		string	false	"comma-separated topics, all by default"
+//	@Param			last_event_id	query		int		false	"ID of the last event received"
+//	@Param			Last-Event-ID	header		int		false	"ID of the last event received"
+//	@Success		200				{object}	events.Record
+//	@Failure		400				{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503				{object}	ErrorEnvelope	"unavailable"
+//	@Router			/events [get]
+func StreamEventsHandler(c *gin.Context) {
+	if journal == nil {
+		abort(c, CodeUnavailable, "the event journal hasn't yet been initialized")
+		return
+	}
+	// new clients only receive the events following their request
+	after := journal.LastID()
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			abort(c, CodeInvalidArgument, fmt.Sprintf("invalid last event ID %q", raw))
+			return
+		}
+		after = id
+	}
+	var topics []string
+	for _, topic := range strings.Split(c.Query("topics"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		streamWebSocket(c, after, topics)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disables the buffering of nginx
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+	if err := stream(c.Request.Context(), &sseWriter{w: c.Writer}, after, topics); err != nil {
+		zlog.Sugar().Debugf("event stream closed: %v", err)
+	}
+}
+
+// stream writes the events following the event of ID after to w until ctx is
+// done, the journal closes or a write fails
+func stream(ctx context.Context, w streamWriter, after uint64, topics []string) error {
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		records, complete, changed := journal.Since(after)
+		if !complete {
+			if err := w.write(events.Record{Type: StreamReset, Time: time.Now()}); err != nil {
+				return err
+			}
+			// resumes from the retained records, or from the start when none is
+			after = 0
+		}
+		for _, r := range records {
+			after = r.ID
+			if len(topics) > 0 && !events.MatchesTopics(r.Type, topics...) {
+				continue
+			}
+			if err := w.write(r); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			if err := w.keepAlive(); err != nil {
+				return err
+			}
+		case <-journal.Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// sseWriter writes the events as Server-Sent Events
+type sseWriter struct {
+	w gin.ResponseWriter
+}
+
+func (s *sseWriter) write(r events.Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	// the reset has no ID so that the client resumes from the last event
+	if r.ID != 0 {
+		if _, err := fmt.Fprintf(s.w, "id: %d\n", r.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", r.Type, data); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+func (s *sseWriter) keepAlive() error {
+	if _, err := fmt.Fprint(s.w, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+// wsWriter writes the events as WebSocket text messages
+type wsWriter struct {
+	conn *websocket.Conn
+}
+
+func (s *wsWriter) write(r events.Record) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(keepAliveInterval))
+	return s.conn.WriteJSON(r)
+}
+
+func (s *wsWriter) keepAlive() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(keepAliveInterval))
+}
+
+// streamWebSocket upgrades the request and streams the events until the
+// client closes the connection
+func streamWebSocket(c *gin.Context, after uint64, topics []string) {
+	conn, err := internal.UpgradeConnection.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		zlog.Sugar().Debugf("unable to upgrade the event stream: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// the client only sends control messages, reading them notices the close
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := stream(ctx, &wsWriter{conn: conn}, after, topics); err != nil {
+		zlog.Sugar().Debugf("event stream closed: %v", err)
+		return
+	}
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseGoingAway, "the DMS is shutting down"),
+		time.Now().Add(time.Second))
+}
@@ -0,0 +1,141 @@
+package v2
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+)
+
+// setupJournal sets a journal of a new bus and serves the v2 routes
+func setupJournal(t *testing.T, size int) (*events.Bus, *httptest.Server) {
+	t.Helper()
+	bus := events.NewBus()
+	j := events.NewJournal(bus, size)
+	SetEventJournal(j)
+	server := httptest.NewServer(setupRouter())
+	t.Cleanup(func() {
+		j.Close()
+		server.Close()
+		SetEventJournal(nil)
+	})
+	return bus, server
+}
+
+// publish publishes the events and waits for the journal to record them
+func publish(t *testing.T, bus *events.Bus, types ...events.Type) {
+	t.Helper()
+	last := journal.LastID()
+	for _, typ := range types {
+		bus.Publish(typ, events.Peer{PeerID: "Qm1"})
+	}
+	require.Eventually(t, func() bool {
+		return journal.LastID() == last+uint64(len(types))
+	}, time.Second, 10*time.Millisecond)
+}
+
+// sseEvent is a message of a Server-Sent Events stream
+type sseEvent struct {
+	id     string
+	record events.Record
+}
+
+func readSSE(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && e.record.Type != "":
+			return e
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.record))
+		}
+	}
+}
+
+func openSSE(t *testing.T, url string, lastEventID string) *bufio.Reader {
+	t.Helper()
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return bufio.NewReader(resp.Body)
+}
+
+func TestStreamEventsSSE(t *testing.T) {
+	bus, server := setupJournal(t, 0)
+	publish(t, bus, events.PeerConnected)
+
+	stream := openSSE(t, server.URL+"/api/v2/events?topics=peer.disconnected,onboarding", "")
+	publish(t, bus, events.PeerConnected, events.PeerDisconnected, events.OnboardingChanged)
+
+	e := readSSE(t, stream)
+	assert.Equal(t, "3", e.id, "the events from before the request and of other topics are skipped")
+	assert.Equal(t, events.PeerDisconnected, e.record.Type)
+	assert.Equal(t, map[string]interface{}{"peer_id": "Qm1"}, e.record.Payload)
+	e = readSSE(t, stream)
+	assert.Equal(t, events.OnboardingChanged, e.record.Type)
+
+	// resumes after the event 2
+	stream = openSSE(t, server.URL+"/api/v2/events", "2")
+	assert.Equal(t, "3", readSSE(t, stream).id)
+	assert.Equal(t, "4", readSSE(t, stream).id)
+}
+
+func TestStreamEventsReset(t *testing.T) {
+	bus, server := setupJournal(t, 2)
+	publish(t, bus, events.PeerConnected, events.PeerDisconnected, events.PeerConnected)
+
+	stream := openSSE(t, server.URL+"/api/v2/events?last_event_id=0", "")
+	e := readSSE(t, stream)
+	assert.Equal(t, StreamReset, e.record.Type, "the event 1 is no longer retained")
+	assert.Empty(t, e.id)
+	assert.Equal(t, "2", readSSE(t, stream).id)
+
+	assertError(t, request(t, "GET", "/events?last_event_id=abc", nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+}
+
+func TestStreamEventsWebSocket(t *testing.T) {
+	bus, server := setupJournal(t, 0)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v2/events?topics=execution"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	publish(t, bus, events.PeerConnected)
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", ExecutionID: "exec-1"})
+
+	var record events.Record
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	require.NoError(t, conn.ReadJSON(&record))
+	assert.Equal(t, uint64(2), record.ID)
+	assert.Equal(t, events.ExecutionStarted, record.Type)
+	assert.Equal(t, "exec-1", record.Payload.(map[string]interface{})["execution_id"])
+
+	// the stream ends when the DMS shuts down
+	journal.Close()
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+}
@@ -0,0 +1,385 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// RemoteJobs runs the jobs on other peers and reports on their executions, it
+// is implemented by the job service of network/libp2p
+type RemoteJobs interface {
+	Run(ctx context.Context, to peer.ID, spec models.JobSpec) (models.ExecutionInfo, error)
+	RunAnywhere(ctx context.Context, spec models.JobSpec) (models.ExecutionInfo, error)
+	Execution(ctx context.Context, to peer.ID, id string) (models.ExecutionInfo, error)
+	Executions(ctx context.Context, to peer.ID) ([]models.ExecutionInfo, error)
+	Cancel(ctx context.Context, to peer.ID, id string) error
+	Logs(ctx context.Context, to peer.ID, id string, follow bool, w io.Writer) error
+}
+
+var (
+	// executions follows the executions of the DMS
+	executions *executor.Registry
+	// runner runs the jobs submitted to this node
+	runner *executor.Jobs
+	// remoteJobs runs the jobs on the other peers
+	remoteJobs RemoteJobs
+)
+
+// SetExecutionRegistry sets the registry of the execution handlers
+func SetExecutionRegistry(r *executor.Registry) {
+	executions = r
+}
+
+// SetJobRunner sets the runner of the jobs submitted to this node
+func SetJobRunner(j *executor.Jobs) {
+	runner = j
+}
+
+// SetRemoteJobs sets the jobs of the other peers once the node is running
+func SetRemoteJobs(j RemoteJobs) {
+	remoteJobs = j
+}
+
+// ListExecutionsHandler  godoc
+//
+//	@Summary		List executions
+//	@Description	Lists the running and recently finished executions of the DMS, or of the node of the node query parameter, sorted by ID
+//	@Tags			executions
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			status		query		string	false	"running, finished or failed"
+//	@Param			executor	query		string	false	"executor, e.g. docker"
+//	@Param			job_id		query		string	false	"job of the executions"
+//	@Param			node		query		string	false	"peer ID of the node running the executions, this node by default"
+//	@Param			limit		query		int		false	"page size, 50 by default, up to 500"
+//	@Param			cursor		query		string	false	"next_cursor of the previous page"
+//	@Success		200			{object}	Page[models.ExecutionInfo]
+//	@Failure		400			{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503			{object}	ErrorEnvelope	"unavailable"
+//	@Router			/executions [get]
+func ListExecutionsHandler(c *gin.Context) {
+	req, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	var predicates []func(models.ExecutionInfo) bool
+	if status := c.Query("status"); status != "" {
+		switch status {
+		case models.ExecutionStatusRunning, models.ExecutionStatusFinished, models.ExecutionStatusFailed:
+		default:
+			abort(c, CodeInvalidArgument, fmt.Sprintf("invalid status %q: use running, finished or failed", status))
+			return
+		}
+		predicates = append(predicates, func(e models.ExecutionInfo) bool { return e.Status == status })
+	}
+	if name := c.Query("executor"); name != "" {
+		predicates = append(predicates, func(e models.ExecutionInfo) bool { return e.Executor == name })
+	}
+	if jobID := c.Query("job_id"); jobID != "" {
+		predicates = append(predicates, func(e models.ExecutionInfo) bool { return e.JobID == jobID })
+	}
+
+	list, ok := listExecutions(c)
+	if !ok {
+		return
+	}
+	matched := filter(list, predicates...)
+	c.JSON(200, paginate(matched, func(e models.ExecutionInfo) string { return e.ID }, req))
+}
+
+// listExecutions returns the executions of the registry or of the node of
+// the request, aborting the request on failure
+func listExecutions(c *gin.Context) ([]models.ExecutionInfo, bool) {
+	to, ok := nodeQuery(c)
+	if !ok {
+		return nil, false
+	}
+	if to != "" {
+		if !remoteJobsReady(c) {
+			return nil, false
+		}
+		list, err := remoteJobs.Executions(c.Request.Context(), to)
+		if err != nil {
+			abortJob(c, err, true)
+			return nil, false
+		}
+		return list, true
+	}
+
+	if executions == nil {
+		abort(c, CodeUnavailable, "the execution registry hasn't yet been initialized")
+		return nil, false
+	}
+	return executions.Executions(), true
+}
+
+// GetExecutionHandler  godoc
+//
+//	@Summary		Show an execution
+//	@Description	Shows a running or recently finished execution with its result
+//	@Tags			executions
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"execution ID"
+//	@Param			node	query		string	false	"peer ID of the node running the execution, this node by default"
+//	@Success		200		{object}	models.ExecutionInfo
+//	@Failure		400		{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		404		{object}	ErrorEnvelope	"not_found"
+//	@Failure		503		{object}	ErrorEnvelope	"unavailable"
+//	@Router			/executions/{id} [get]
+func GetExecutionHandler(c *gin.Context) {
+	to, ok := nodeQuery(c)
+	if !ok {
+		return
+	}
+	if to != "" {
+		if !remoteJobsReady(c) {
+			return
+		}
+		info, err := remoteJobs.Execution(c.Request.Context(), to, c.Param("id"))
+		if err != nil {
+			abortJob(c, err, true)
+			return
+		}
+		c.JSON(200, info)
+		return
+	}
+
+	if executions == nil {
+		abort(c, CodeUnavailable, "the execution registry hasn't yet been initialized")
+		return
+	}
+	info, ok := executions.Execution(c.Param("id"))
+	if !ok {
+		abort(c, CodeNotFound, fmt.Sprintf("execution %s not found", c.Param("id")))
+		return
+	}
+	c.JSON(200, info)
+}
+
+// RunJobHandler  godoc
+//
+//	@Summary		Run a job
+//	@Description	Starts an execution of a job spec with the executor of its engine, e.g. docker, and returns it once started. The image of the engine may be pulled first.
+//	@Description	With the node query parameter, the job runs on that peer, which must allow the peer ID of this node in job.allowed_peers. With node=auto, it runs on the first peer accepting it among those found by capability search.
+//	@Tags			executions
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		models.JobSpec	true	"job spec"
+//	@Param			node	query		string			false	"peer ID of the node running the job, or auto, this node by default"
+//	@Success		201		{object}	models.ExecutionInfo
+//	@Failure		400		{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		500		{object}	ErrorEnvelope	"internal"
+//	@Failure		503		{object}	ErrorEnvelope	"unavailable"
+//	@Router			/executions [post]
+func RunJobHandler(c *gin.Context) {
+	var spec models.JobSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		abort(c, CodeInvalidArgument, fmt.Sprintf("invalid job spec: %v", err))
+		return
+	}
+
+	var (
+		info models.ExecutionInfo
+		err  error
+	)
+	ctx := c.Request.Context()
+	switch node := c.Query("node"); node {
+	case "":
+		if !jobsReady(c) {
+			return
+		}
+		if info, err = runner.Run(ctx, spec); err != nil {
+			abortJob(c, err, false)
+			return
+		}
+	case "auto":
+		if !remoteJobsReady(c) {
+			return
+		}
+		if info, err = remoteJobs.RunAnywhere(ctx, spec); err != nil {
+			abortJob(c, err, true)
+			return
+		}
+	default:
+		to, ok := nodeQuery(c)
+		if !ok || !remoteJobsReady(c) {
+			return
+		}
+		if info, err = remoteJobs.Run(ctx, to, spec); err != nil {
+			abortJob(c, err, true)
+			return
+		}
+	}
+	c.JSON(201, info)
+}
+
+// CancelExecutionHandler  godoc
+//
+//	@Summary		Cancel an execution
+//	@Description	Cancels a running execution
+//	@Tags			executions
+//	@Security		BearerAuth
+//	@Param			id		path	string	true	"execution ID"
+//	@Param			node	query	string	false	"peer ID of the node running the execution, this node by default"
+//	@Success		204
+//	@Failure		400	{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		404	{object}	ErrorEnvelope	"not_found"
+//	@Failure		409	{object}	ErrorEnvelope	"conflict, the execution is not running"
+//	@Failure		503	{object}	ErrorEnvelope	"unavailable"
+//	@Router			/executions/{id} [delete]
+func CancelExecutionHandler(c *gin.Context) {
+	to, ok := nodeQuery(c)
+	if !ok {
+		return
+	}
+	if to != "" {
+		if !remoteJobsReady(c) {
+			return
+		}
+		if err := remoteJobs.Cancel(c.Request.Context(), to, c.Param("id")); err != nil {
+			abortJob(c, err, true)
+			return
+		}
+		c.Status(204)
+		return
+	}
+
+	if !jobsReady(c) {
+		return
+	}
+	if err := runner.Cancel(c.Request.Context(), c.Param("id")); err != nil {
+		abortJob(c, err, false)
+		return
+	}
+	c.Status(204)
+}
+
+// ExecutionLogsHandler  godoc
+//
+//	@Summary		Stream the logs of an execution
+//	@Description	Streams the output of an execution as plain text, stdout and stderr merged. With follow, the output of a running execution is streamed until it finishes. The output of a finished execution is the one recorded in its result.
+//	@Tags			executions
+//	@Produce		plain
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"execution ID"
+//	@Param			follow	query		bool	false	"follow the output until the execution finishes"
+//	@Param			node	query		string	false	"peer ID of the node running the execution, this node by default"
+//	@Success		200		{string}	string	"output of the execution"
+//	@Failure		400		{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		404		{object}	ErrorEnvelope	"not_found"
+//	@Failure		503		{object}	ErrorEnvelope	"unavailable"
+//	@Router			/executions/{id}/logs [get]
+func ExecutionLogsHandler(c *gin.Context) {
+	follow, ok := boolQuery(c, "follow")
+	if !ok {
+		return
+	}
+	to, ok := nodeQuery(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	ctx := c.Request.Context()
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+
+	if to != "" {
+		if !remoteJobsReady(c) {
+			return
+		}
+		err := remoteJobs.Logs(ctx, to, id, follow != nil && *follow, flushWriter{c.Writer})
+		if err != nil && !c.Writer.Written() {
+			abortJob(c, err, true)
+		} else if err != nil {
+			zlog.Sugar().Debugf("the logs of execution %s ended: %v", id, err)
+		}
+		return
+	}
+
+	if !jobsReady(c) {
+		return
+	}
+	logs, err := runner.Logs(ctx, id, follow != nil && *follow)
+	if err != nil {
+		abortJob(c, err, false)
+		return
+	}
+	defer logs.Close()
+	c.Status(200)
+	if _, err := io.Copy(flushWriter{c.Writer}, logs); err != nil {
+		zlog.Sugar().Debugf("the logs of execution %s ended: %v", id, err)
+	}
+}
+
+// flushWriter sends every write of the logs to the client right away
+type flushWriter struct {
+	gin.ResponseWriter
+}
+
+func (w flushWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.Flush()
+	return n, err
+}
+
+// nodeQuery parses the node query parameter, the peer ID of the node serving
+// the request in place of this one. It is empty without the parameter.
+func nodeQuery(c *gin.Context) (peer.ID, bool) {
+	node := c.Query("node")
+	if node == "" {
+		return "", true
+	}
+	to, err := peer.Decode(node)
+	if err != nil {
+		abort(c, CodeInvalidArgument, fmt.Sprintf("invalid node %q: %v", node, err))
+		return "", false
+	}
+	return to, true
+}
+
+// jobsReady aborts the request until the job runner is set
+func jobsReady(c *gin.Context) bool {
+	if runner == nil {
+		abort(c, CodeUnavailable, "the job runner hasn't yet been initialized")
+		return false
+	}
+	return true
+}
+
+// remoteJobsReady aborts the request while the node isn't running
+func remoteJobsReady(c *gin.Context) bool {
+	if remoteJobs == nil {
+		abort(c, CodeUnavailable, "the host node hasn't yet been initialized")
+		return false
+	}
+	return true
+}
+
+// abortJob aborts a job request with the code of err. The other errors of
+// remote peers make them unavailable.
+func abortJob(c *gin.Context, err error, remote bool) {
+	switch {
+	case errors.Is(err, executor.ErrExecutionNotFound):
+		abort(c, CodeNotFound, fmt.Sprintf("execution %s not found", c.Param("id")))
+	case errors.Is(err, executor.ErrExecutionNotRunning):
+		abort(c, CodeConflict, fmt.Sprintf("execution %s is not running", c.Param("id")))
+	case errors.Is(err, executor.ErrInvalidJobSpec),
+		errors.Is(err, executor.ErrExecutorUnavailable),
+		errors.Is(err, executor.ErrLogStreamUnsupported):
+		abort(c, CodeInvalidArgument, err.Error())
+	case remote:
+		abort(c, CodeUnavailable, err.Error())
+	default:
+		abort(c, CodeInternal, err.Error())
+	}
+}
@@ -0,0 +1,205 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func TestExecutions(t *testing.T) {
+	bus := events.NewBus()
+	registry := executor.NewRegistry(bus, 0)
+	t.Cleanup(registry.Close)
+	SetExecutionRegistry(registry)
+	t.Cleanup(func() { SetExecutionRegistry(nil) })
+
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "firecracker", JobID: "job-2", ExecutionID: "exec-2"})
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1",
+		Result: models.NewExecutionResult(models.ExecutionStatusCodeSuccess)})
+	require.Eventually(t, func() bool {
+		info, ok := registry.Execution("exec-1")
+		return ok && info.Status == models.ExecutionStatusFinished
+	}, time.Second, 10*time.Millisecond)
+
+	var page Page[models.ExecutionInfo]
+	w := request(t, "GET", "/executions", nil, &page)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "exec-1", page.Items[0].ID)
+
+	page = Page[models.ExecutionInfo]{}
+	request(t, "GET", "/executions?status=running&executor=firecracker", nil, &page)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "exec-2", page.Items[0].ID)
+
+	var info models.ExecutionInfo
+	w = request(t, "GET", "/executions/exec-1", nil, &info)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "job-1", info.JobID)
+	require.NotNil(t, info.Result)
+
+	assertError(t, request(t, "GET", "/executions?status=paused", nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "GET", "/executions/exec-3", nil, nil), http.StatusNotFound, CodeNotFound)
+}
+
+// jobExecutor runs the executions of the job tests until they are cancelled,
+// their output is "hello"
+type jobExecutor struct {
+	bus *events.Bus
+}
+
+func (e *jobExecutor) IsInstalled(context.Context) bool {
+	return true
+}
+
+func (e *jobExecutor) Start(_ context.Context, request *models.ExecutionRequest) error {
+	e.bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: request.JobID, ExecutionID: request.ExecutionID})
+	return nil
+}
+
+func (e *jobExecutor) Run(context.Context, *models.ExecutionRequest) (*models.ExecutionResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *jobExecutor) Wait(context.Context, string) (<-chan *models.ExecutionResult, <-chan error) {
+	return nil, nil
+}
+
+func (e *jobExecutor) Cancel(_ context.Context, executionID string) error {
+	e.bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", ExecutionID: executionID,
+		Result: &models.ExecutionResult{STDOUT: "hello\n", ExitCode: 137}})
+	return nil
+}
+
+func (e *jobExecutor) GetLogStream(context.Context, models.LogStreamRequest) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("hello\n")), nil
+}
+
+func (e *jobExecutor) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestRunJob(t *testing.T) {
+	spec := models.JobSpec{Engine: models.NewSpecConfig(models.ExecutorTypeDocker).WithParam("image", "alpine")}
+	assertError(t, request(t, "POST", "/executions", spec, nil), http.StatusServiceUnavailable, CodeUnavailable)
+
+	bus := events.NewBus()
+	registry := executor.NewRegistry(bus, 0)
+	t.Cleanup(registry.Close)
+	SetExecutionRegistry(registry)
+	SetJobRunner(executor.NewJobs(registry, map[string]executor.Executor{"docker": &jobExecutor{bus: bus}}, t.TempDir()))
+	t.Cleanup(func() { SetExecutionRegistry(nil); SetJobRunner(nil) })
+
+	var info models.ExecutionInfo
+	w := request(t, "POST", "/executions", spec, &info)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, models.ExecutionStatusRunning, info.Status)
+	assert.Equal(t, "docker", info.Executor)
+
+	w = request(t, "GET", "/executions/"+info.ID+"/logs?follow=true", nil, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello\n", w.Body.String())
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+	require.Eventually(t, func() bool { _, ok := registry.Execution(info.ID); return ok }, time.Second, 10*time.Millisecond)
+	w = request(t, "DELETE", "/executions/"+info.ID, nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	require.Eventually(t, func() bool {
+		execution, _ := registry.Execution(info.ID)
+		return execution.Status == models.ExecutionStatusFailed
+	}, time.Second, 10*time.Millisecond)
+	assertError(t, request(t, "DELETE", "/executions/"+info.ID, nil, nil), http.StatusConflict, CodeConflict)
+
+	assertError(t, request(t, "POST", "/executions", models.JobSpec{}, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "POST", "/executions", models.JobSpec{Engine: models.NewSpecConfig("wasm")}, nil),
+		http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "DELETE", "/executions/exec-2", nil, nil), http.StatusNotFound, CodeNotFound)
+	assertError(t, request(t, "GET", "/executions/exec-2/logs", nil, nil), http.StatusNotFound, CodeNotFound)
+	assertError(t, request(t, "GET", "/executions/"+info.ID+"/logs?follow=maybe", nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+}
+
+// remoteJobsStub runs the jobs of a single peer
+type remoteJobsStub struct {
+	node     peer.ID
+	anywhere bool
+}
+
+func (r *remoteJobsStub) Run(_ context.Context, to peer.ID, _ models.JobSpec) (models.ExecutionInfo, error) {
+	if to != r.node {
+		return models.ExecutionInfo{}, errors.New("peer unreachable")
+	}
+	return models.ExecutionInfo{ID: "exec-1", Node: to.String(), Status: models.ExecutionStatusRunning}, nil
+}
+
+func (r *remoteJobsStub) RunAnywhere(ctx context.Context, spec models.JobSpec) (models.ExecutionInfo, error) {
+	r.anywhere = true
+	return r.Run(ctx, r.node, spec)
+}
+
+func (r *remoteJobsStub) Execution(_ context.Context, to peer.ID, id string) (models.ExecutionInfo, error) {
+	if id != "exec-1" {
+		return models.ExecutionInfo{}, executor.ErrExecutionNotFound
+	}
+	return models.ExecutionInfo{ID: id, Node: to.String(), Status: models.ExecutionStatusRunning}, nil
+}
+
+func (r *remoteJobsStub) Executions(ctx context.Context, to peer.ID) ([]models.ExecutionInfo, error) {
+	info, _ := r.Execution(ctx, to, "exec-1")
+	return []models.ExecutionInfo{info}, nil
+}
+
+func (r *remoteJobsStub) Cancel(context.Context, peer.ID, string) error {
+	return executor.ErrExecutionNotRunning
+}
+
+func (r *remoteJobsStub) Logs(_ context.Context, _ peer.ID, _ string, _ bool, w io.Writer) error {
+	_, err := io.WriteString(w, "remote\n")
+	return err
+}
+
+func TestRemoteJobs(t *testing.T) {
+	const node = "12D3KooWJbA4hd6TnGW9AzLeVBjSFJcAz4bRQNrdfpdEJPvHTZyK"
+	id, err := peer.Decode(node)
+	require.NoError(t, err)
+	spec := models.JobSpec{Engine: models.NewSpecConfig(models.ExecutorTypeDocker)}
+	assertError(t, request(t, "POST", "/executions?node="+node, spec, nil), http.StatusServiceUnavailable, CodeUnavailable)
+
+	remote := &remoteJobsStub{node: id}
+	SetRemoteJobs(remote)
+	t.Cleanup(func() { SetRemoteJobs(nil) })
+
+	var info models.ExecutionInfo
+	w := request(t, "POST", "/executions?node="+node, spec, &info)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, node, info.Node)
+	w = request(t, "POST", "/executions?node=auto", spec, &info)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.True(t, remote.anywhere)
+
+	w = request(t, "GET", "/executions/exec-1?node="+node, nil, &info)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, node, info.Node)
+	var page Page[models.ExecutionInfo]
+	request(t, "GET", "/executions?status=running&node="+node, nil, &page)
+	require.Len(t, page.Items, 1)
+	w = request(t, "GET", "/executions/exec-1/logs?node="+node, nil, nil)
+	assert.Equal(t, "remote\n", w.Body.String())
+
+	assertError(t, request(t, "GET", "/executions/exec-2?node="+node, nil, nil), http.StatusNotFound, CodeNotFound)
+	assertError(t, request(t, "DELETE", "/executions/exec-1?node="+node, nil, nil), http.StatusConflict, CodeConflict)
+	assertError(t, request(t, "POST", "/executions?node=not-a-peer", spec, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "POST", "/executions?node=12D3KooWA", spec, nil), http.StatusBadRequest, CodeInvalidArgument)
+}
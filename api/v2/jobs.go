@@ -0,0 +1,155 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// jobs stores the jobs the DMS ran as a compute provider
+var jobs repositories.ServicesRepository
+
+// SetJobRepository sets the repository of the job handlers
+func SetJobRepository(r repositories.ServicesRepository) {
+	jobs = r
+}
+
+// Job is a job the DMS ran as a compute provider
+type Job struct {
+	ID                  uint      `json:"id"`
+	Status              string    `json:"status"` // running, finished without errors or finished with errors
+	ServiceName         string    `json:"service_name"`
+	ImageID             string    `json:"image_id"`
+	ContainerID         string    `json:"container_id"`
+	DurationMinutes     int64     `json:"duration_minutes"`
+	EstimatedMinutes    int64     `json:"estimated_minutes"`
+	TransactionType     string    `json:"transaction_type"` // running, done, withdraw, refund or distribute
+	TxHash              string    `json:"tx_hash"`
+	ServiceProviderAddr string    `json:"service_provider_addr"`
+	ComputeProviderAddr string    `json:"compute_provider_addr"`
+	LogURL              string    `json:"log_url,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func newJob(s models.Services) Job {
+	return Job{
+		ID:                  s.ID,
+		Status:              s.JobStatus,
+		ServiceName:         s.ServiceName,
+		ImageID:             s.ImageID,
+		ContainerID:         s.ContainerID,
+		DurationMinutes:     s.JobDuration,
+		EstimatedMinutes:    s.EstimatedJobDuration,
+		TransactionType:     s.TransactionType,
+		TxHash:              s.TxHash,
+		ServiceProviderAddr: s.ServiceProviderAddr,
+		ComputeProviderAddr: s.ComputeProviderAddr,
+		LogURL:              s.LogURL,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+// ListJobsHandler  godoc
+//
+//	@Summary		List jobs
+//	@Description	Lists the jobs the DMS ran as a compute provider, oldest first
+//	@Tags			jobs
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			status				query		string	false	"job status, e.g. running"
+//	@Param			transaction_type	query		string	false	"transaction type, e.g. done"
+//	@Param			limit				query		int		false	"page size, 50 by default, up to 500"
+//	@Param			cursor				query		string	false	"next_cursor of the previous page"
+//	@Success		200					{object}	Page[Job]
+//	@Failure		400					{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503					{object}	ErrorEnvelope	"unavailable"
+//	@Router			/jobs [get]
+func ListJobsHandler(c *gin.Context) {
+	if jobs == nil {
+		abort(c, CodeUnavailable, "the job store hasn't yet been initialized")
+		return
+	}
+	req, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	query := jobs.GetQuery()
+	if req.after != "" {
+		after, err := strconv.ParseUint(req.after, 10, 0)
+		if err != nil {
+			abort(c, CodeInvalidArgument, "invalid cursor")
+			return
+		}
+		query.Conditions = append(query.Conditions, repositories.GT("ID", after))
+	}
+	if status := c.Query("status"); status != "" {
+		query.Conditions = append(query.Conditions, repositories.EQ("JobStatus", status))
+	}
+	if txType := c.Query("transaction_type"); txType != "" {
+		query.Conditions = append(query.Conditions, repositories.EQ("TransactionType", txType))
+	}
+	query.SortBy = "ID"
+	// one more to know whether there is a next page
+	query.Limit = req.limit + 1
+
+	services, err := jobs.FindAll(c.Request.Context(), query)
+	if err != nil {
+		abort(c, CodeInternal, fmt.Sprintf("unable to list the jobs: %v", err))
+		return
+	}
+
+	page := Page[Job]{Items: []Job{}}
+	for i, s := range services {
+		if i == req.limit {
+			page.NextCursor = encodeCursor(strconv.FormatUint(uint64(services[i-1].ID), 10))
+			break
+		}
+		page.Items = append(page.Items, newJob(s))
+	}
+	c.JSON(200, page)
+}
+
+// GetJobHandler  godoc
+//
+//	@Summary		Show a job
+//	@Description	Shows a job the DMS ran as a compute provider
+//	@Tags			jobs
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int	true	"job ID"
+//	@Success		200	{object}	Job
+//	@Failure		400	{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		404	{object}	ErrorEnvelope	"not_found"
+//	@Failure		503	{object}	ErrorEnvelope	"unavailable"
+//	@Router			/jobs/{id} [get]
+func GetJobHandler(c *gin.Context) {
+	if jobs == nil {
+		abort(c, CodeUnavailable, "the job store hasn't yet been initialized")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		abort(c, CodeInvalidArgument, "invalid job ID")
+		return
+	}
+
+	s, err := jobs.Get(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.NotFoundError) {
+			abort(c, CodeNotFound, fmt.Sprintf("job %d not found", id))
+			return
+		}
+		abort(c, CodeInternal, fmt.Sprintf("unable to get job %d: %v", id, err))
+		return
+	}
+	c.JSON(200, newJob(s))
+}
@@ -0,0 +1,60 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// openDB opens an in-memory database migrated for models
+func openDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(models...))
+	return db
+}
+
+func TestJobs(t *testing.T) {
+	repo := repositories_gorm.NewServicesRepository(openDB(t, &models.Services{}))
+	for _, status := range []string{"running", "finished without errors", "running"} {
+		_, err := repo.Create(context.Background(), models.Services{JobStatus: status, ServiceName: "svc"})
+		require.NoError(t, err)
+	}
+	SetJobRepository(repo)
+	t.Cleanup(func() { SetJobRepository(nil) })
+
+	var page Page[Job]
+	w := request(t, "GET", "/jobs?limit=2", nil, &page)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, uint(1), page.Items[0].ID)
+	require.NotEmpty(t, page.NextCursor)
+
+	next := Page[Job]{}
+	request(t, "GET", "/jobs?limit=2&cursor="+page.NextCursor, nil, &next)
+	require.Len(t, next.Items, 1)
+	assert.Equal(t, uint(3), next.Items[0].ID)
+	assert.Empty(t, next.NextCursor)
+
+	page = Page[Job]{}
+	request(t, "GET", "/jobs?status=running", nil, &page)
+	assert.Len(t, page.Items, 2)
+
+	var job Job
+	w = request(t, "GET", "/jobs/2", nil, &job)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "finished without errors", job.Status)
+
+	assertError(t, request(t, "GET", "/jobs?cursor="+encodeCursor("abc"), nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "GET", "/jobs/abc", nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "GET", "/jobs/9", nil, nil), http.StatusNotFound, CodeNotFound)
+}
@@ -0,0 +1,113 @@
+package v2
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Page is a page of a listed collection. The next page is requested with
+// NextCursor as the cursor query parameter, it is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageRequest is the page requested with the limit and cursor query parameters
+type pageRequest struct {
+	limit int
+	after string // key of the last item of the previous page, empty for the first page
+}
+
+// parsePage parses the page requested, aborting the request when invalid
+func parsePage(c *gin.Context) (pageRequest, bool) {
+	req := pageRequest{limit: defaultPageSize}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			abort(c, CodeInvalidArgument, fmt.Sprintf("limit must be a number between 1 and %d", maxPageSize))
+			return req, false
+		}
+		req.limit = limit
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		after, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil || len(after) == 0 {
+			abort(c, CodeInvalidArgument, "invalid cursor")
+			return req, false
+		}
+		req.after = string(after)
+	}
+	return req, true
+}
+
+// encodeCursor returns the opaque cursor of the page following the item of key
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// numericKey returns a key of id sorting like the number
+func numericKey(id uint64) string {
+	return fmt.Sprintf("%020d", id)
+}
+
+// paginate sorts the items by key and returns the requested page
+func paginate[T any](items []T, key func(T) string, req pageRequest) Page[T] {
+	sort.SliceStable(items, func(i, j int) bool { return key(items[i]) < key(items[j]) })
+
+	start := 0
+	if req.after != "" {
+		start = sort.Search(len(items), func(i int) bool { return key(items[i]) > req.after })
+	}
+	end := start + req.limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := Page[T]{Items: append([]T{}, items[start:end]...)}
+	if end < len(items) {
+		page.NextCursor = encodeCursor(key(items[end-1]))
+	}
+	return page
+}
+
+// filter returns the items matched by all the predicates
+func filter[T any](items []T, predicates ...func(T) bool) []T {
+	matched := items[:0:0]
+	for _, item := range items {
+		ok := true
+		for _, p := range predicates {
+			if !p(item) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// boolQuery parses an optional boolean query parameter, aborting the request
+// when invalid
+func boolQuery(c *gin.Context, name string) (value *bool, ok bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		abort(c, CodeInvalidArgument, fmt.Sprintf("%s must be true or false", name))
+		return nil, false
+	}
+	return &b, true
+}
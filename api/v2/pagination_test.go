@@ -0,0 +1,71 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []int{5, 3, 9, 1, 7}
+	key := func(i int) string { return numericKey(uint64(i)) }
+
+	page := paginate(items, key, pageRequest{limit: 2})
+	assert.Equal(t, []int{1, 3}, page.Items)
+	require.NotEmpty(t, page.NextCursor)
+
+	var seen []int
+	req := pageRequest{limit: 2}
+	for {
+		page := paginate(items, key, req)
+		seen = append(seen, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		c := pageContext("cursor=" + page.NextCursor)
+		var ok bool
+		req, ok = parsePage(c)
+		require.True(t, ok)
+		req.limit = 2
+	}
+	assert.Equal(t, []int{1, 3, 5, 7, 9}, seen)
+
+	page = paginate([]int{}, key, pageRequest{limit: 2})
+	assert.NotNil(t, page.Items, "an empty page lists no items rather than null")
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestParsePage(t *testing.T) {
+	req, ok := parsePage(pageContext(""))
+	require.True(t, ok)
+	assert.Equal(t, pageRequest{limit: defaultPageSize}, req)
+
+	req, ok = parsePage(pageContext("limit=10&cursor=" + encodeCursor("abc")))
+	require.True(t, ok)
+	assert.Equal(t, pageRequest{limit: 10, after: "abc"}, req)
+
+	for _, query := range []string{"limit=0", "limit=x", "limit=" + strconv.Itoa(maxPageSize+1), "cursor=!!"} {
+		_, ok := parsePage(pageContext(query))
+		assert.False(t, ok, query)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	even := func(i int) bool { return i%2 == 0 }
+	small := func(i int) bool { return i < 5 }
+
+	assert.Equal(t, []int{2, 4}, filter([]int{1, 2, 3, 4, 6}, even, small))
+	assert.Equal(t, []int{1, 2}, filter([]int{1, 2}))
+}
+
+func pageContext(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	return c
+}
@@ -0,0 +1,141 @@
+package v2
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/network"
+)
+
+// p2pNetwork is the network of the peer handlers
+var p2pNetwork network.Network
+
+// SetNetwork sets the network of the peer handlers once the node is running
+func SetNetwork(net network.Network) {
+	p2pNetwork = net
+}
+
+// Ping is the result of a ping of a peer
+type Ping struct {
+	PeerID string        `json:"peer_id"`
+	RTT    time.Duration `json:"rtt"` // round trip time in nanoseconds
+}
+
+// networkReady aborts the request while the node isn't running
+func networkReady(c *gin.Context) bool {
+	if p2pNetwork == nil {
+		abort(c, CodeUnavailable, "the host node hasn't yet been initialized")
+		return false
+	}
+	return true
+}
+
+// ListPeersHandler  godoc
+//
+//	@Summary		List peers
+//	@Description	Lists the peers known to the node, connected or not, sorted by ID
+//	@Tags			peers
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			connected	query		bool	false	"only the connected or the disconnected peers"
+//	@Param			relayed		query		bool	false	"only the peers connected through a relay or not"
+//	@Param			limit		query		int		false	"page size, 50 by default, up to 500"
+//	@Param			cursor		query		string	false	"next_cursor of the previous page"
+//	@Success		200			{object}	Page[models.PeerStat]
+//	@Failure		400			{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503			{object}	ErrorEnvelope	"unavailable"
+//	@Router			/peers [get]
+func ListPeersHandler(c *gin.Context) {
+	if !networkReady(c) {
+		return
+	}
+	req, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	connected, ok := boolQuery(c, "connected")
+	if !ok {
+		return
+	}
+	relayed, ok := boolQuery(c, "relayed")
+	if !ok {
+		return
+	}
+
+	var predicates []func(models.PeerStat) bool
+	if connected != nil {
+		predicates = append(predicates, func(p models.PeerStat) bool { return p.Connected == *connected })
+	}
+	if relayed != nil {
+		predicates = append(predicates, func(p models.PeerStat) bool { return p.Relayed == *relayed })
+	}
+	peers := filter(p2pNetwork.Peers(), predicates...)
+	c.JSON(200, paginate(peers, func(p models.PeerStat) string { return p.ID }, req))
+}
+
+// GetPeerHandler  godoc
+//
+//	@Summary		Show a peer
+//	@Description	Shows a peer known to the node
+//	@Tags			peers
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"peer ID"
+//	@Success		200	{object}	models.PeerStat
+//	@Failure		404	{object}	ErrorEnvelope	"not_found"
+//	@Failure		503	{object}	ErrorEnvelope	"unavailable"
+//	@Router			/peers/{id} [get]
+func GetPeerHandler(c *gin.Context) {
+	if !networkReady(c) {
+		return
+	}
+	for _, p := range p2pNetwork.Peers() {
+		if p.ID == c.Param("id") {
+			c.JSON(200, p)
+			return
+		}
+	}
+	abort(c, CodeNotFound, fmt.Sprintf("peer %s not found", c.Param("id")))
+}
+
+// PingPeerHandler  godoc
+//
+//	@Summary		Ping a peer
+//	@Description	Pings a peer and returns the round trip time
+//	@Tags			peers
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"peer ID"
+//	@Param			timeout	query		string	false	"timeout such as 2s, defaults to 5s"
+//	@Success		201		{object}	Ping
+//	@Failure		400		{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503		{object}	ErrorEnvelope	"unavailable: the peer can't be reached"
+//	@Router			/peers/{id}/pings [post]
+func PingPeerHandler(c *gin.Context) {
+	if !networkReady(c) {
+		return
+	}
+	var timeout time.Duration
+	if raw := c.Query("timeout"); raw != "" {
+		var err error
+		if timeout, err = time.ParseDuration(raw); err != nil || timeout <= 0 {
+			abort(c, CodeInvalidArgument, fmt.Sprintf("invalid timeout %q", raw))
+			return
+		}
+	}
+
+	id := c.Param("id")
+	address := models.SpecConfig{
+		Type:   string(models.NetP2P),
+		Params: map[string]interface{}{"peer_id": id},
+	}
+	result, err := p2pNetwork.Ping(c.Request.Context(), address, timeout)
+	if err != nil {
+		abort(c, CodeUnavailable, fmt.Sprintf("could not ping peer %s: %v", id, err))
+		return
+	}
+	c.JSON(201, Ping{PeerID: id, RTT: result.RTT})
+}
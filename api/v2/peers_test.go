@@ -0,0 +1,68 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/network"
+)
+
+// mockNetwork implements the methods of network.Network used by the peer handlers
+type mockNetwork struct {
+	network.Network
+	peers []models.PeerStat
+}
+
+func (n *mockNetwork) Peers() []models.PeerStat {
+	return n.peers
+}
+
+func (n *mockNetwork) Ping(_ context.Context, address models.SpecConfig, _ time.Duration) (models.PingResult, error) {
+	if address.Params["peer_id"] != "peer-a" {
+		return models.PingResult{}, errors.New("no route to peer")
+	}
+	return models.PingResult{RTT: 3 * time.Millisecond, Success: true}, nil
+}
+
+func TestPeers(t *testing.T) {
+	SetNetwork(&mockNetwork{peers: []models.PeerStat{
+		{ID: "peer-b", Connected: true, Relayed: true},
+		{ID: "peer-a", Connected: true},
+		{ID: "peer-c"},
+	}})
+	t.Cleanup(func() { SetNetwork(nil) })
+
+	var page Page[models.PeerStat]
+	w := request(t, "GET", "/peers?limit=2", nil, &page)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "peer-a", page.Items[0].ID)
+	assert.NotEmpty(t, page.NextCursor)
+
+	page = Page[models.PeerStat]{}
+	request(t, "GET", "/peers?connected=true&relayed=false", nil, &page)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "peer-a", page.Items[0].ID)
+
+	var peer models.PeerStat
+	w = request(t, "GET", "/peers/peer-c", nil, &peer)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, peer.Connected)
+
+	var ping Ping
+	w = request(t, "POST", "/peers/peer-a/pings?timeout=1s", nil, &ping)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 3*time.Millisecond, ping.RTT)
+
+	assertError(t, request(t, "GET", "/peers?connected=maybe", nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "GET", "/peers/peer-d", nil, nil), http.StatusNotFound, CodeNotFound)
+	assertError(t, request(t, "POST", "/peers/peer-a/pings?timeout=-1s", nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "POST", "/peers/peer-b/pings", nil, nil), http.StatusServiceUnavailable, CodeUnavailable)
+}
@@ -0,0 +1,156 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// RemoteShells opens the shells into the executions of other peers, it is
+// implemented by the shell service of network/libp2p
+type RemoteShells interface {
+	Open(ctx context.Context, to peer.ID, executionID string, request models.ShellRequest) (executor.ShellSession, error)
+}
+
+var (
+	// shells opens the shells into the local executions
+	shells *executor.Shells
+	// remoteShells opens the shells into the executions of the other peers
+	remoteShells RemoteShells
+)
+
+// SetShells sets the shells into the local executions
+func SetShells(s *executor.Shells) {
+	shells = s
+}
+
+// SetRemoteShells sets the shells into the executions of the other peers once the node is running
+func SetRemoteShells(s RemoteShells) {
+	remoteShells = s
+}
+
+// ExecShellHandler  godoc
+//
+//	@Summary		Open a shell into an execution
+//	@Description	Runs an interactive command in a running execution, e.g. with docker exec, and attaches it to a WebSocket. The input and the output of the command are binary messages. The client sends {"type": "resize", "rows": 24, "cols": 80} text messages when its terminal is resized and the DMS sends {"type": "exit", "exit_code": 0}, or {"type": "error", "error": "..."}, once the command exits, then closes the connection.
+//	@Description	With the node query parameter, the shell is opened on that peer, which must allow the peer ID of this node in shell.allowed_peers. Every session is recorded by an audit event on the node running the execution.
+//	@Tags			executions
+//	@Security		BearerAuth
+//	@Param			id		path	string		true	"execution ID"
+//	@Param			command	query	[]string	false	"command and its arguments, repeated, the shell of the execution by default"	collectionFormat(multi)
+//	@Param			tty		query	bool		false	"allocate a pseudo-terminal, true by default"
+//	@Param			rows	query	int			false	"initial rows of the terminal"
+//	@Param			cols	query	int			false	"initial columns of the terminal"
+//	@Param			node	query	string		false	"peer ID of the node running the execution, this node by default"
+//	@Success		101
+//	@Failure		400	{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		404	{object}	ErrorEnvelope	"not_found"
+//	@Failure		409	{object}	ErrorEnvelope	"conflict, the execution is not running"
+//	@Failure		503	{object}	ErrorEnvelope	"unavailable"
+//	@Router			/executions/{id}/shell [get]
+func ExecShellHandler(c *gin.Context) {
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		abort(c, CodeInvalidArgument, "the shell is served over WebSocket")
+		return
+	}
+	request, ok := parseShellRequest(c)
+	if !ok {
+		return
+	}
+
+	session, ok := openShell(c, request)
+	if !ok {
+		return
+	}
+
+	conn, err := internal.UpgradeConnection.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		zlog.Sugar().Debugf("unable to upgrade the shell: %v", err)
+		session.Close()
+		return
+	}
+	internal.BridgeShell(c.Request.Context(), conn, session)
+}
+
+// openShell opens the session of the request into the local execution or,
+// with the node query parameter, into the execution of a remote peer
+func openShell(c *gin.Context, request models.ShellRequest) (executor.ShellSession, bool) {
+	executionID := c.Param("id")
+
+	to, ok := nodeQuery(c)
+	if !ok {
+		return nil, false
+	}
+	if to != "" {
+		if remoteShells == nil {
+			abort(c, CodeUnavailable, "the host node hasn't yet been initialized")
+			return nil, false
+		}
+		session, err := remoteShells.Open(c.Request.Context(), to, executionID, request)
+		if err != nil {
+			abort(c, CodeUnavailable, err.Error())
+			return nil, false
+		}
+		return session, true
+	}
+
+	if shells == nil {
+		abort(c, CodeUnavailable, "the shells haven't yet been initialized")
+		return nil, false
+	}
+	client := c.GetString(auth.ClientKey)
+	if client == "" {
+		client = "unauthenticated"
+	}
+	session, err := shells.Open(c.Request.Context(), client, executionID, request)
+	switch {
+	case errors.Is(err, executor.ErrExecutionNotFound):
+		abort(c, CodeNotFound, fmt.Sprintf("execution %s not found", executionID))
+	case errors.Is(err, executor.ErrExecutionNotRunning):
+		abort(c, CodeConflict, fmt.Sprintf("execution %s is not running", executionID))
+	case errors.Is(err, executor.ErrShellUnsupported):
+		abort(c, CodeInvalidArgument, err.Error())
+	case err != nil:
+		abort(c, CodeInternal, err.Error())
+	default:
+		return session, true
+	}
+	return nil, false
+}
+
+// parseShellRequest parses the query parameters of a shell request
+func parseShellRequest(c *gin.Context) (models.ShellRequest, bool) {
+	request := models.ShellRequest{Command: c.QueryArray("command"), TTY: true}
+	if raw := c.Query("tty"); raw != "" {
+		tty, err := strconv.ParseBool(raw)
+		if err != nil {
+			abort(c, CodeInvalidArgument, fmt.Sprintf("invalid tty %q", raw))
+			return request, false
+		}
+		request.TTY = tty
+	}
+	for name, size := range map[string]*uint16{"rows": &request.Size.Rows, "cols": &request.Size.Cols} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(raw, 10, 16)
+		if err != nil {
+			abort(c, CodeInvalidArgument, fmt.Sprintf("invalid %s %q", name, raw))
+			return request, false
+		}
+		*size = uint16(value)
+	}
+	return request, true
+}
@@ -0,0 +1,99 @@
+package v2
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// echoShell runs sessions echoing their input, they exit with the number
+// of rows of the terminal
+type echoShell struct {
+	requests chan models.ShellRequest
+}
+
+func (s *echoShell) Exec(_ context.Context, _ string, request models.ShellRequest) (executor.ShellSession, error) {
+	s.requests <- request
+	r, w := io.Pipe()
+	return &echoSession{PipeReader: r, w: w, rows: int(request.Size.Rows)}, nil
+}
+
+type echoSession struct {
+	*io.PipeReader
+	w    *io.PipeWriter
+	rows int
+}
+
+func (s *echoSession) Write(p []byte) (int, error) {
+	if string(p) == "exit" {
+		return len(p), s.w.Close()
+	}
+	return s.w.Write(p)
+}
+
+func (s *echoSession) Close() error {
+	return s.w.Close()
+}
+
+func (s *echoSession) Resize(context.Context, models.TerminalSize) error {
+	return nil
+}
+
+func (s *echoSession) Wait(context.Context) (int, error) {
+	return s.rows, nil
+}
+
+func TestExecShell(t *testing.T) {
+	bus := events.NewBus()
+	registry := executor.NewRegistry(bus, 0)
+	shell := &echoShell{requests: make(chan models.ShellRequest, 1)}
+	SetShells(executor.NewShells(registry, map[string]executor.Shell{"docker": shell}))
+	server := httptest.NewServer(setupRouter())
+	t.Cleanup(func() {
+		server.Close()
+		registry.Close()
+		SetShells(nil)
+	})
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	require.Eventually(t, func() bool { return len(registry.Executions()) == 1 }, time.Second, 10*time.Millisecond)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v2/executions/"
+	conn, _, err := websocket.DefaultDialer.Dial(url+"exec-1/shell?command=sh&command=-i&rows=24&cols=80", nil)
+	require.NoError(t, err)
+	opened := <-shell.requests
+	assert.Equal(t, []string{"sh", "-i"}, opened.Command)
+	assert.True(t, opened.TTY)
+	assert.Equal(t, models.TerminalSize{Rows: 24, Cols: 80}, opened.Size)
+
+	code, err := internal.AttachShell(conn, strings.NewReader("exit"), io.Discard, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 24, code)
+
+	for target, status := range map[string]int{
+		"exec-2/shell":                 http.StatusNotFound,
+		"exec-1/shell?rows=-1":         http.StatusBadRequest,
+		"exec-1/shell?tty=maybe":       http.StatusBadRequest,
+		"exec-1/shell?node=not-a-peer": http.StatusBadRequest,
+		"exec-1/shell?node=12D3KooWEgUjXjxGnZL7DwExVnEz5pcL5U3jxKpB3o6XJgXrMuXz": http.StatusServiceUnavailable,
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+target, nil)
+		require.Error(t, err, target)
+		assert.Equal(t, status, resp.StatusCode, target)
+	}
+
+	w := request(t, "GET", "/executions/exec-1/shell", nil, nil)
+	assertError(t, w, http.StatusBadRequest, CodeInvalidArgument)
+}
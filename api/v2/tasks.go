@@ -0,0 +1,176 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// scheduler is the background task scheduler of the task handlers
+var scheduler *bt.Scheduler
+
+// SetScheduler sets the scheduler of the task handlers
+func SetScheduler(s *bt.Scheduler) {
+	scheduler = s
+}
+
+// TaskUpdate changes the state of a task, the fields left out are unchanged
+type TaskUpdate struct {
+	Enabled *bool `json:"enabled,omitempty"` // whether the triggers run the task
+	Running *bool `json:"running,omitempty"` // true runs the task now, false cancels its running execution
+}
+
+// taskID parses the task ID of the request, aborting it when the ID is
+// invalid or the scheduler is not running
+func taskID(c *gin.Context) (int, bool) {
+	if scheduler == nil {
+		abort(c, CodeUnavailable, "the task scheduler hasn't yet been initialized")
+		return 0, false
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abort(c, CodeInvalidArgument, "invalid task ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func abortTaskError(c *gin.Context, id int, err error) {
+	switch {
+	case errors.Is(err, bt.ErrTaskNotFound):
+		abort(c, CodeNotFound, fmt.Sprintf("task %d not found", id))
+	case errors.Is(err, bt.ErrTaskNotRunning), errors.Is(err, bt.ErrTaskRunning):
+		abort(c, CodeConflict, fmt.Sprintf("task %d: %v", id, err))
+	default:
+		abort(c, CodeInternal, fmt.Sprintf("task %d: %v", id, err))
+	}
+}
+
+// ListTasksHandler  godoc
+//
+//	@Summary		List tasks
+//	@Description	Lists the background tasks of the DMS, sorted by ID
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			enabled	query		bool	false	"only the enabled or the disabled tasks"
+//	@Param			running	query		bool	false	"only the running or the idle tasks"
+//	@Param			limit	query		int		false	"page size, 50 by default, up to 500"
+//	@Param			cursor	query		string	false	"next_cursor of the previous page"
+//	@Success		200		{object}	Page[models.TaskStatus]
+//	@Failure		400		{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503		{object}	ErrorEnvelope	"unavailable"
+//	@Router			/tasks [get]
+func ListTasksHandler(c *gin.Context) {
+	if scheduler == nil {
+		abort(c, CodeUnavailable, "the task scheduler hasn't yet been initialized")
+		return
+	}
+	req, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	enabled, ok := boolQuery(c, "enabled")
+	if !ok {
+		return
+	}
+	running, ok := boolQuery(c, "running")
+	if !ok {
+		return
+	}
+
+	var predicates []func(models.TaskStatus) bool
+	if enabled != nil {
+		predicates = append(predicates, func(t models.TaskStatus) bool { return t.Enabled == *enabled })
+	}
+	if running != nil {
+		predicates = append(predicates, func(t models.TaskStatus) bool { return t.Running == *running })
+	}
+	tasks := filter(scheduler.Tasks(), predicates...)
+	c.JSON(200, paginate(tasks, func(t models.TaskStatus) string { return numericKey(uint64(t.ID)) }, req))
+}
+
+// GetTaskHandler  godoc
+//
+//	@Summary		Show a task
+//	@Description	Shows a background task with the history of its latest executions
+//	@Tags			tasks
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int	true	"task ID"
+//	@Success		200	{object}	models.TaskStatus
+//	@Failure		400	{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		404	{object}	ErrorEnvelope	"not_found"
+//	@Failure		503	{object}	ErrorEnvelope	"unavailable"
+//	@Router			/tasks/{id} [get]
+func GetTaskHandler(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	status, err := scheduler.TaskStatus(id)
+	if err != nil {
+		abortTaskError(c, id, err)
+		return
+	}
+	c.JSON(200, status)
+}
+
+// UpdateTaskHandler  godoc
+//
+//	@Summary		Update a task
+//	@Description	Enables or disables a background task, runs it now or cancels its running execution. The enabled state is kept across restarts.
+//	@Tags			tasks
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"task ID"
+//	@Param			body	body		TaskUpdate	true	"changes of the task"
+//	@Success		200		{object}	models.TaskStatus
+//	@Failure		400		{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		404		{object}	ErrorEnvelope	"not_found"
+//	@Failure		409		{object}	ErrorEnvelope	"conflict: the task is already running or isn't running"
+//	@Failure		503		{object}	ErrorEnvelope	"unavailable"
+//	@Router			/tasks/{id} [patch]
+func UpdateTaskHandler(c *gin.Context) {
+	id, ok := taskID(c)
+	if !ok {
+		return
+	}
+	var update TaskUpdate
+	if err := c.ShouldBindJSON(&update); err != nil || (update.Enabled == nil && update.Running == nil) {
+		abort(c, CodeInvalidArgument, "invalid request body: set enabled or running")
+		return
+	}
+
+	if update.Enabled != nil {
+		if err := scheduler.SetTaskEnabled(id, *update.Enabled); err != nil {
+			abortTaskError(c, id, err)
+			return
+		}
+	}
+	if update.Running != nil {
+		var err error
+		if *update.Running {
+			err = scheduler.RunTask(id)
+		} else {
+			err = scheduler.CancelTask(id)
+		}
+		if err != nil {
+			abortTaskError(c, id, err)
+			return
+		}
+	}
+
+	status, err := scheduler.TaskStatus(id)
+	if err != nil {
+		abortTaskError(c, id, err)
+		return
+	}
+	c.JSON(200, status)
+}
@@ -0,0 +1,62 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func TestTasks(t *testing.T) {
+	s := bt.NewScheduler(1)
+	t.Cleanup(s.Stop)
+	block := func(ctx context.Context, _ interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s.AddTask(&bt.Task{Name: "Peer Discovery", Function: block})
+	cleanup := s.AddTask(&bt.Task{Name: "Peer Cleanup", Function: block})
+	require.NoError(t, s.SetTaskEnabled(cleanup.ID, false))
+	SetScheduler(s)
+	t.Cleanup(func() { SetScheduler(nil) })
+
+	var page Page[models.TaskStatus]
+	w := request(t, "GET", "/tasks", nil, &page)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, page.Items, 2)
+	assert.Empty(t, page.NextCursor)
+
+	page = Page[models.TaskStatus]{}
+	request(t, "GET", "/tasks?enabled=false", nil, &page)
+	require.Len(t, page.Items, 1)
+	id := page.Items[0].ID
+
+	enabled := true
+	var status models.TaskStatus
+	w = request(t, "PATCH", "/tasks/"+strconv.Itoa(id), TaskUpdate{Enabled: &enabled}, &status)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, status.Enabled)
+
+	running := true
+	request(t, "PATCH", "/tasks/"+strconv.Itoa(id), TaskUpdate{Running: &running}, nil)
+	require.Eventually(t, func() bool {
+		status, err := s.TaskStatus(id)
+		return err == nil && status.Running
+	}, time.Second, 10*time.Millisecond)
+	assertError(t, request(t, "PATCH", "/tasks/"+strconv.Itoa(id), TaskUpdate{Running: &running}, nil), http.StatusConflict, CodeConflict)
+
+	running = false
+	w = request(t, "PATCH", "/tasks/"+strconv.Itoa(id), TaskUpdate{Running: &running}, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assertError(t, request(t, "PATCH", "/tasks/"+strconv.Itoa(id), TaskUpdate{}, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "GET", "/tasks/abc", nil, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "GET", "/tasks/99", nil, nil), http.StatusNotFound, CodeNotFound)
+}
@@ -0,0 +1,94 @@
+// Package v2 implements the v2 of the DMS REST API, served under /api/v2: the
+// jobs, executions, volumes, peers and tasks of the DMS as resources, listed
+// in cursor-paginated pages and failing with a uniform error envelope, the
+// stream of its events, the jobs run on it or on other peers and the shells
+// into its executions.
+//
+//	@title						Device Management Service API v2
+//	@version					2.0
+//	@description				Resource-oriented API of the DMS. Failed requests return an error envelope {"error": {"code": "...", "message": "..."}} and collections are paginated with the limit and cursor query parameters.
+//	@termsOfService				https://nunet.io/tos
+//
+//	@contact.name				Support
+//	@contact.url				https://devexchange.nunet.io/
+//	@contact.email				support@nunet.io
+//
+//	@license.name				Apache 2.0
+//	@license.url				http://www.apache.org/licenses/LICENSE-2.0.html
+//
+//	@host						localhost:9999
+//	@BasePath					/api/v2
+//	@schemes					http https
+//
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+package v2
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag"
+
+	docs "gitlab.com/nunet/device-management-service/api/docs/v2"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+)
+
+// Register registers the v2 routes on group. authorize returns the middleware
+// requiring a scope, it must report its failures with AbortAuth.
+func Register(group *gin.RouterGroup, authorize func(auth.Scope) gin.HandlerFunc) {
+	read := authorize(auth.ScopeRead)
+	operate := authorize(auth.ScopeOperator)
+	admin := authorize(auth.ScopeAdmin)
+
+	group.GET("/swagger.json", DocHandler)
+
+	jobs := group.Group("/jobs")
+	{
+		jobs.GET("", read, ListJobsHandler)
+		jobs.GET("/:id", read, GetJobHandler)
+	}
+
+	executions := group.Group("/executions")
+	{
+		executions.GET("", read, ListExecutionsHandler)
+		executions.POST("", operate, RunJobHandler)
+		executions.GET("/:id", read, GetExecutionHandler)
+		executions.DELETE("/:id", operate, CancelExecutionHandler)
+		executions.GET("/:id/logs", read, ExecutionLogsHandler)
+		executions.GET("/:id/shell", admin, ExecShellHandler)
+	}
+
+	volumes := group.Group("/volumes")
+	{
+		volumes.GET("", read, ListVolumesHandler)
+		volumes.POST("", operate, CreateVolumeHandler)
+		volumes.GET("/:id", read, GetVolumeHandler)
+		volumes.DELETE("/:id", operate, DeleteVolumeHandler)
+	}
+
+	peers := group.Group("/peers")
+	{
+		peers.GET("", read, ListPeersHandler)
+		peers.GET("/:id", read, GetPeerHandler)
+		peers.POST("/:id/pings", read, PingPeerHandler)
+	}
+
+	group.GET("/events", read, StreamEventsHandler)
+
+	tasks := group.Group("/tasks")
+	{
+		tasks.GET("", read, ListTasksHandler)
+		tasks.GET("/:id", read, GetTaskHandler)
+		tasks.PATCH("/:id", operate, UpdateTaskHandler)
+	}
+}
+
+// DocHandler serves the swagger document of the v2 API, generated in api/docs/v2
+func DocHandler(c *gin.Context) {
+	doc, err := swag.ReadDoc(docs.SwaggerInfov2.InstanceName())
+	if err != nil {
+		abort(c, CodeInternal, err.Error())
+		return
+	}
+	c.Data(200, "application/json; charset=utf-8", []byte(doc))
+}
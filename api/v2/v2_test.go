@@ -0,0 +1,87 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/auth"
+)
+
+// setupRouter registers the v2 routes without authentication
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Register(router.Group("/api/v2"), func(auth.Scope) gin.HandlerFunc {
+		return func(c *gin.Context) { c.Next() }
+	})
+	return router
+}
+
+// request serves a request to the v2 routes and decodes the response into out
+func request(t *testing.T, method, target string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, "/api/v2"+target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	setupRouter().ServeHTTP(w, req)
+	if out != nil && w.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w
+}
+
+// assertError checks the status and the error envelope of a failed request
+func assertError(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	assert.Equal(t, status, w.Code, w.Body.String())
+	var envelope ErrorEnvelope
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope), w.Body.String())
+	assert.Equal(t, code, envelope.Error.Code)
+	assert.NotEmpty(t, envelope.Error.Message)
+}
+
+func TestAbortAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for status, code := range map[int]string{
+		http.StatusUnauthorized: CodeUnauthenticated,
+		http.StatusForbidden:    CodePermissionDenied,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		AbortAuth(c, status, assert.AnError)
+		assertError(t, w, status, code)
+	}
+}
+
+func TestUninitialized(t *testing.T) {
+	for _, target := range []string{"/jobs", "/executions", "/volumes", "/peers", "/tasks", "/events"} {
+		w := request(t, "GET", target, nil, nil)
+		assertError(t, w, http.StatusServiceUnavailable, CodeUnavailable)
+	}
+}
+
+func TestDocHandler(t *testing.T) {
+	var doc struct {
+		BasePath string                     `json:"basePath"`
+		Paths    map[string]json.RawMessage `json:"paths"`
+	}
+	w := request(t, "GET", "/swagger.json", nil, &doc)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/api/v2", doc.BasePath)
+	assert.Contains(t, doc.Paths, "/executions/{id}")
+}
@@ -0,0 +1,217 @@
+package v2
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/storage"
+	"gitlab.com/nunet/device-management-service/storage/basic_controller"
+)
+
+// volumes manages the storage volumes of the DMS
+var volumes storage.VolumeController
+
+// SetVolumeController sets the volume controller of the volume handlers
+func SetVolumeController(vc storage.VolumeController) {
+	volumes = vc
+}
+
+// Volume is a storage volume of the DMS
+type Volume struct {
+	ID        string    `json:"id"` // name of the directory of the volume
+	CID       string    `json:"cid,omitempty"`
+	Path      string    `json:"path"`
+	ReadOnly  bool      `json:"read_only"`
+	Private   bool      `json:"private"`
+	Encrypted bool      `json:"encrypted"`
+	Size      *int64    `json:"size,omitempty"` // in bytes, only shown for a single volume
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newVolume(v storage.StorageVolume) Volume {
+	return Volume{
+		ID:        filepath.Base(v.Path),
+		CID:       v.CID,
+		Path:      v.Path,
+		ReadOnly:  v.ReadOnly,
+		Private:   v.Private,
+		Encrypted: v.EncryptionType != models.EncryptionTypeNull,
+		CreatedAt: v.CreatedAt,
+		UpdatedAt: v.UpdatedAt,
+	}
+}
+
+// listVolumes returns the volumes of the controller, aborting the request on failure
+func listVolumes(c *gin.Context) ([]Volume, bool) {
+	if volumes == nil {
+		abort(c, CodeUnavailable, "the volume controller hasn't yet been initialized")
+		return nil, false
+	}
+	stored, err := volumes.ListVolumes()
+	if err != nil {
+		abort(c, CodeInternal, fmt.Sprintf("unable to list the volumes: %v", err))
+		return nil, false
+	}
+	list := make([]Volume, 0, len(stored))
+	for _, v := range stored {
+		list = append(list, newVolume(v))
+	}
+	return list, true
+}
+
+// findVolume returns the volume of the id path parameter, aborting the
+// request when it doesn't exist
+func findVolume(c *gin.Context) (Volume, bool) {
+	list, ok := listVolumes(c)
+	if !ok {
+		return Volume{}, false
+	}
+	for _, v := range list {
+		if v.ID == c.Param("id") {
+			return v, true
+		}
+	}
+	abort(c, CodeNotFound, fmt.Sprintf("volume %s not found", c.Param("id")))
+	return Volume{}, false
+}
+
+// ListVolumesHandler  godoc
+//
+//	@Summary		List volumes
+//	@Description	Lists the storage volumes of the DMS, sorted by ID
+//	@Tags			volumes
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			read_only	query		bool	false	"only the locked or the writable volumes"
+//	@Param			private		query		bool	false	"only the private or the shareable volumes"
+//	@Param			limit		query		int		false	"page size, 50 by default, up to 500"
+//	@Param			cursor		query		string	false	"next_cursor of the previous page"
+//	@Success		200			{object}	Page[Volume]
+//	@Failure		400			{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503			{object}	ErrorEnvelope	"unavailable"
+//	@Router			/volumes [get]
+func ListVolumesHandler(c *gin.Context) {
+	req, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	readOnly, ok := boolQuery(c, "read_only")
+	if !ok {
+		return
+	}
+	private, ok := boolQuery(c, "private")
+	if !ok {
+		return
+	}
+	list, ok := listVolumes(c)
+	if !ok {
+		return
+	}
+
+	var predicates []func(Volume) bool
+	if readOnly != nil {
+		predicates = append(predicates, func(v Volume) bool { return v.ReadOnly == *readOnly })
+	}
+	if private != nil {
+		predicates = append(predicates, func(v Volume) bool { return v.Private == *private })
+	}
+	c.JSON(200, paginate(filter(list, predicates...), func(v Volume) string { return v.ID }, req))
+}
+
+// GetVolumeHandler  godoc
+//
+//	@Summary		Show a volume
+//	@Description	Shows a storage volume with its size
+//	@Tags			volumes
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"volume ID"
+//	@Success		200	{object}	Volume
+//	@Failure		404	{object}	ErrorEnvelope	"not_found"
+//	@Failure		503	{object}	ErrorEnvelope	"unavailable"
+//	@Router			/volumes/{id} [get]
+func GetVolumeHandler(c *gin.Context) {
+	v, ok := findVolume(c)
+	if !ok {
+		return
+	}
+	if size, err := volumes.GetSize(v.Path, storage.IDTypePath); err == nil {
+		v.Size = &size
+	}
+	c.JSON(200, v)
+}
+
+// CreateVolumeHandler  godoc
+//
+//	@Summary		Create a volume
+//	@Description	Creates an empty storage volume for the data of a source: s3, ipfs or job
+//	@Tags			volumes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		object	true	"source and optional private flag"
+//	@Success		201		{object}	Volume
+//	@Failure		400		{object}	ErrorEnvelope	"invalid_argument"
+//	@Failure		503		{object}	ErrorEnvelope	"unavailable"
+//	@Router			/volumes [post]
+func CreateVolumeHandler(c *gin.Context) {
+	if volumes == nil {
+		abort(c, CodeUnavailable, "the volume controller hasn't yet been initialized")
+		return
+	}
+	var body struct {
+		Source  string `json:"source" binding:"required"`
+		Private bool   `json:"private"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		abort(c, CodeInvalidArgument, "invalid request body: source is required")
+		return
+	}
+
+	source := storage.VolumeSource(body.Source)
+	switch source {
+	case storage.VolumeSourceS3, storage.VolumeSourceIPFS, storage.VolumeSourceJob:
+	default:
+		abort(c, CodeInvalidArgument, fmt.Sprintf("invalid source %q: use s3, ipfs or job", body.Source))
+		return
+	}
+	var opts []storage.CreateVolOpt
+	if body.Private {
+		opts = append(opts, basic_controller.WithPrivate[storage.CreateVolOpt]())
+	}
+
+	v, err := volumes.CreateVolume(source, opts...)
+	if err != nil {
+		abort(c, CodeInternal, fmt.Sprintf("unable to create the volume: %v", err))
+		return
+	}
+	c.JSON(201, newVolume(v))
+}
+
+// DeleteVolumeHandler  godoc
+//
+//	@Summary		Delete a volume
+//	@Description	Deletes a storage volume
+//	@Tags			volumes
+//	@Security		BearerAuth
+//	@Param			id	path	string	true	"volume ID"
+//	@Success		204
+//	@Failure		404	{object}	ErrorEnvelope	"not_found"
+//	@Failure		503	{object}	ErrorEnvelope	"unavailable"
+//	@Router			/volumes/{id} [delete]
+func DeleteVolumeHandler(c *gin.Context) {
+	v, ok := findVolume(c)
+	if !ok {
+		return
+	}
+	if err := volumes.DeleteVolume(v.Path, storage.IDTypePath); err != nil {
+		abort(c, CodeInternal, fmt.Sprintf("unable to delete volume %s: %v", v.ID, err))
+		return
+	}
+	c.Status(204)
+}
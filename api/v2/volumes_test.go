@@ -0,0 +1,52 @@
+package v2
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/storage/basic_controller"
+)
+
+func TestVolumes(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	vc, err := basic_controller.NewDefaultVolumeController(openDB(t), "/volumes/", fs)
+	require.NoError(t, err)
+	SetVolumeController(vc)
+	t.Cleanup(func() { SetVolumeController(nil) })
+
+	var created Volume
+	w := request(t, "POST", "/volumes", map[string]interface{}{"source": "ipfs", "private": true}, &created)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.True(t, created.Private)
+	require.NotEmpty(t, created.ID)
+	request(t, "POST", "/volumes", map[string]interface{}{"source": "s3"}, nil)
+	require.NoError(t, afero.WriteFile(fs, created.Path+"/data", []byte("12345"), 0o644))
+
+	var page Page[Volume]
+	w = request(t, "GET", "/volumes", nil, &page)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, page.Items, 2)
+
+	page = Page[Volume]{}
+	request(t, "GET", "/volumes?private=true", nil, &page)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, created.ID, page.Items[0].ID)
+	assert.Nil(t, page.Items[0].Size)
+
+	var volume Volume
+	w = request(t, "GET", "/volumes/"+created.ID, nil, &volume)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, volume.Size)
+	assert.Equal(t, int64(5), *volume.Size)
+
+	w = request(t, "DELETE", "/volumes/"+created.ID, nil, nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	assertError(t, request(t, "GET", "/volumes/"+created.ID, nil, nil), http.StatusNotFound, CodeNotFound)
+	assertError(t, request(t, "POST", "/volumes", map[string]interface{}{"source": "ftp"}, nil), http.StatusBadRequest, CodeInvalidArgument)
+	assertError(t, request(t, "POST", "/volumes", map[string]interface{}{}, nil), http.StatusBadRequest, CodeInvalidArgument)
+}
@@ -1,11 +1,14 @@
 package api
 
 import (
+	"context"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
 	"gitlab.com/nunet/device-management-service/executor/firecracker"
+	"gitlab.com/nunet/device-management-service/internal"
 	"gitlab.com/nunet/device-management-service/models"
 )
 
@@ -79,6 +82,7 @@ func StartCustomHandler(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	stopWithDMS(fc, fer.ExecutionID)
 
 	c.JSON(200, gin.H{"message": "VM started successfully"})
 }
@@ -138,5 +142,20 @@ func StartDefaultHandler(c *gin.Context) {
 		c.AbortWithStatusJSON(500, gin.H{"error": err.Error()})
 		return
 	}
+	stopWithDMS(fc, fer.ExecutionID)
 	c.JSON(200, gin.H{"message": "VM started successfully"})
 }
+
+// stopWithDMS stops the VM of an execution when the DMS shuts down, the
+// shutdown hook is removed once the VM ends
+func stopWithDMS(fc *firecracker.Executor, executionID string) {
+	unregister := internal.Shutdown.Register("firecracker executor", fc.Shutdown)
+	go func() {
+		defer unregister()
+		resultCh, errCh := fc.Wait(context.Background(), executionID)
+		select {
+		case <-resultCh:
+		case <-errCh:
+		}
+	}()
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var authCmd = NewAuthCmd(networkService)
+
+func NewAuthCmd(net backend.NetworkManager) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "API token operations",
+		Long: `Issue, list and revoke the tokens of the DMS REST API
+
+The CLI authenticates with the admin token written by the DMS to its
+credentials file, or with the token of the NUNET_API_TOKEN environment variable.`,
+		PersistentPreRunE: isDMSRunning(net),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(authListCmd)
+	cmd.AddCommand(authCreateCmd)
+	cmd.AddCommand(authRevokeCmd)
+	return cmd
+}
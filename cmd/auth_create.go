@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var (
+	authCreateCmd  = NewAuthCreateCmd(utilsService)
+	flagTokenScope string
+	flagExpiresIn  string
+)
+
+func NewAuthCreateCmd(utilsService backend.Utility) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Issue an API token",
+		Long: `Issue an API token with a scope:
+  read      read the state of the DMS
+  operator  also run and control jobs, tasks and peers
+  admin     also onboard, manage wallets, payments and tokens
+
+The token is only printed once, keep it safe.`,
+		Example: "  nunet auth create dashboard --scope read --expires 720h",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scope, _ := cmd.Flags().GetString("scope")
+			expiresIn, _ := cmd.Flags().GetString("expires")
+
+			reqBody, err := json.Marshal(map[string]string{"name": args[0], "scope": scope, "expires_in": expiresIn})
+			if err != nil {
+				return fmt.Errorf("error encoding request: %w", err)
+			}
+
+			body, err := utilsService.ResponseBody(nil, "POST", "/api/v1/auth/tokens", "", reqBody)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			token, err := jsonparser.GetString(body, "token")
+			if err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), token)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&flagTokenScope, "scope", "s", "read", "scope of the token: read, operator or admin")
+	cmd.Flags().StringVarP(&flagExpiresIn, "expires", "e", "", "lifetime of the token, e.g. 720h, it never expires by default")
+	return cmd
+}
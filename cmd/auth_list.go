@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var authListCmd = NewAuthListCmd(utilsService)
+
+func NewAuthListCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List API tokens",
+		Long:  `List the API tokens issued with their scope and expiry, the tokens themselves are never shown again`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v1/auth/tokens", "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			var tokens []models.APIToken
+			if err := json.Unmarshal(body, &tokens); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(tokens) == 0 {
+				fmt.Fprintln(out, "No API tokens")
+				return nil
+			}
+			for _, token := range tokens {
+				fmt.Fprintf(out, "%d\t%s\t%s\t%s\n", token.ID, token.Name, token.Scope, tokenExpiry(token))
+			}
+			return nil
+		},
+	}
+}
+
+// tokenExpiry describes when a token expires
+func tokenExpiry(token models.APIToken) string {
+	if token.ExpiresAt == nil {
+		return "never expires"
+	}
+	return "expires " + token.ExpiresAt.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var authRevokeCmd = NewAuthRevokeCmd(utilsService)
+
+func NewAuthRevokeCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "revoke <token-id>",
+		Short: "Revoke an API token",
+		Long:  `Revoke an API token, the requests using it are rejected from now on`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := strconv.ParseUint(args[0], 10, 0); err != nil {
+				return fmt.Errorf("invalid token ID: %s", args[0])
+			}
+
+			body, err := utilsService.ResponseBody(nil, "DELETE", "/api/v1/auth/tokens/"+args[0], "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			msg, err := jsonparser.GetString(body, "message")
+			if err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+}
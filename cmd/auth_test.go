@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AuthListCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	listResponse := []byte(`[
+    {"ID": 1, "name": "cli", "scope": "admin"},
+    {"ID": 2, "name": "dashboard", "scope": "read", "expires_at": "2024-06-01T00:00:00Z"}
+    ]`)
+	mockUtils.SetResponseFor("GET", "/api/v1/auth/tokens", listResponse)
+
+	buf := new(bytes.Buffer)
+	cmd := NewAuthListCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+
+	err := cmd.Execute()
+	assert.NoError(err)
+
+	expected := "1\tcli\tadmin\tnever expires\n" +
+		"2\tdashboard\tread\texpires 2024-06-01T00:00:00Z\n"
+	assert.Equal(expected, buf.String())
+}
+
+func Test_AuthCreateCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("POST", "/api/v1/auth/tokens",
+		[]byte(`{"token": "nunet_c2VjcmV0", "record": {"ID": 3, "name": "dashboard", "scope": "read"}}`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewAuthCreateCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"dashboard", "--scope", "read"})
+	assert.NoError(cmd.Execute())
+	assert.Equal("nunet_c2VjcmV0\n", buf.String())
+}
+
+func Test_AuthRevokeCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("DELETE", "/api/v1/auth/tokens/2", []byte(`{"message": "token 2 revoked"}`))
+	mockUtils.SetResponseFor("DELETE", "/api/v1/auth/tokens/9", []byte(`{"error": "token not found"}`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewAuthRevokeCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"2"})
+	assert.NoError(cmd.Execute())
+	assert.Equal("token 2 revoked\n", buf.String())
+
+	for _, id := range []string{"9", "two"} {
+		cmd = NewAuthRevokeCmd(mockUtils)
+		cmd.SetOut(new(bytes.Buffer))
+		cmd.SetErr(new(bytes.Buffer))
+		cmd.SetArgs([]string{id})
+		assert.Error(cmd.Execute(), id)
+	}
+}
@@ -9,15 +9,22 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"gitlab.com/nunet/device-management-service/utils"
 )
 
 type WebSocket struct {
 	Conn *websocket.Conn
 }
 
+// Initialize connects to a websocket of DMS's own API, authenticated like the
+// other requests of the CLI
 func (ws *WebSocket) Initialize(url string) error {
-	var err error
-	ws.Conn, _, err = websocket.DefaultDialer.Dial(url, nil)
+	dialer, err := utils.InternalWebSocketDialer()
+	if err != nil {
+		return err
+	}
+
+	ws.Conn, _, err = dialer.Dial(url, utils.AuthorizationHeader())
 	return err
 }
 
@@ -373,3 +373,20 @@ func Test_InfoCmdDMSNotRunning(t *testing.T) {
 	err = cmd.Execute()
 	assert.ErrorContains(err, "looks like DMS is not running...")
 }
+
+func Test_ListenDMSPortUnixSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	socket := config.GetConfig().Rest.UnixSocket
+	if socket == "" {
+		t.Skip("the API isn't served on a Unix socket")
+	}
+
+	open, err := listenDMSPort(&MockConnection{conns: []gonet.ConnectionStat{{Laddr: gonet.Addr{IP: socket}}}})
+	assert.NoError(err)
+	assert.True(open, "the DMS listens on its Unix socket")
+
+	open, err = listenDMSPort(&MockConnection{conns: []gonet.ConnectionStat{{Laddr: gonet.Addr{IP: "/run/other.sock"}}}})
+	assert.NoError(err)
+	assert.False(open)
+}
@@ -36,7 +36,8 @@ func init() {
 	gpuCmd.AddCommand(gpuStatusCmd)
 	gpuCmd.AddCommand(gpuOnboardCmd)
 	offboardCmd.Flags().BoolVarP(&flagForce, "force", "f", false, "force offboarding")
-	shellCmd.Flags().StringVar(&flagNode, "node-id", "", "set nodeID value")
+	shellCmd.Flags().StringVar(&flagNode, "node", "", "peer ID of the node running the execution")
+	shellCmd.Flags().BoolVar(&flagNoTTY, "no-tty", false, "don't allocate a pseudo-terminal, e.g. to pipe the output")
 
 	// initialize top level commands
 	rootCmd.AddCommand(gpuCmd)
@@ -44,8 +45,12 @@ func init() {
 	rootCmd.AddCommand(onboardMLCmd)
 	rootCmd.AddCommand(chatCmd)
 	rootCmd.AddCommand(shellCmd)
+	rootCmd.AddCommand(jobCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(peerCmd)
+	rootCmd.AddCommand(tasksCmd)
+	rootCmd.AddCommand(loggingCmd)
+	rootCmd.AddCommand(authCmd)
 	rootCmd.AddCommand(onboardCmd)
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(deviceCmd)
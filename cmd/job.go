@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/utils"
+)
+
+var jobCmd = NewJobCmd(networkService)
+
+func NewJobCmd(net backend.NetworkManager) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "job",
+		Short: "Run and follow jobs",
+		Long: `Run jobs on this node or on other peers and follow their executions.
+
+A job spec is a YAML or JSON file with the engine of the job, its resources, inputs and outputs:
+
+  engine:
+    type: docker
+    params:
+      image: alpine
+      cmd: ["echo", "hello"]
+  resources:
+    cpu: 1
+    memory: 536870912
+  outputs:
+    - type: bind
+      source: /data
+      target: /out`,
+		PersistentPreRunE: isDMSRunning(net),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(jobRunCmd)
+	cmd.AddCommand(jobStatusCmd)
+	cmd.AddCommand(jobLogsCmd)
+	cmd.AddCommand(jobCancelCmd)
+	cmd.AddCommand(jobListCmd)
+	return cmd
+}
+
+// readJobSpec reads a YAML or JSON job spec from a file, or from in for "-"
+func readJobSpec(path string, in io.Reader) (models.JobSpec, error) {
+	var (
+		spec models.JobSpec
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(in)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return spec, fmt.Errorf("could not read job spec: %w", err)
+	}
+
+	// YAML is a superset of JSON
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return spec, fmt.Errorf("invalid job spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return spec, fmt.Errorf("invalid job spec: %w", err)
+	}
+	return spec, nil
+}
+
+// jobQuery returns the query of the execution endpoints for the node running them
+func jobQuery(node string) string {
+	if node == "" {
+		return ""
+	}
+	return url.Values{"node": {node}}.Encode()
+}
+
+// apiError returns the message of the error envelope of an /api/v2 response, if any
+func apiError(body []byte) error {
+	if errMsg, err := jsonparser.GetString(body, "error", "message"); err == nil {
+		return fmt.Errorf("error: %s", errMsg)
+	}
+	return nil
+}
+
+// checkOutputFormat validates the -o flag of the job commands
+func checkOutputFormat(format string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid output format %q, expected table or json", format)
+	}
+	return nil
+}
+
+// printExecution prints an execution as a table row or as JSON
+func printExecution(out io.Writer, format string, info models.ExecutionInfo) error {
+	if format == "json" {
+		return printJSON(out, info)
+	}
+	return printExecutions(out, format, []models.ExecutionInfo{info})
+}
+
+// printExecutions prints executions as a table or as JSON
+func printExecutions(out io.Writer, format string, executions []models.ExecutionInfo) error {
+	if format == "json" {
+		if executions == nil {
+			executions = []models.ExecutionInfo{}
+		}
+		return printJSON(out, executions)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EXECUTION ID\tJOB ID\tEXECUTOR\tSTATUS\tSTARTED\tNODE")
+	for _, e := range executions {
+		node := e.Node
+		if node == "" {
+			node = "local"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.JobID, e.Executor, executionStatus(e), e.StartedAt.Format(time.RFC3339), node)
+	}
+	return w.Flush()
+}
+
+func printJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// executionStatus describes the status of an execution with its exit code once finished
+func executionStatus(e models.ExecutionInfo) string {
+	if e.Result == nil || e.FinishedAt == nil {
+		return e.Status
+	}
+	return fmt.Sprintf("%s (exit %d)", e.Status, e.Result.ExitCode)
+}
+
+// streamJobLogs copies the output of an execution to out as it is received
+func streamJobLogs(out io.Writer, id, node string, follow bool) error {
+	query := url.Values{"follow": {fmt.Sprint(follow)}}
+	if node != "" {
+		query.Set("node", node)
+	}
+
+	resp, err := utils.MakeInternalRequest(nil, "GET", "/api/v2/executions/"+url.PathEscape(id)+"/logs", query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if err := apiError(body); err != nil {
+			return err
+		}
+		return fmt.Errorf("error: %s", strings.TrimSpace(resp.Status))
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("error reading logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var jobCancelCmd = NewJobCancelCmd(utilsService)
+
+func NewJobCancelCmd(utilsService backend.Utility) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cancel <execution-id>",
+		Short: "Cancel a running execution",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			node, _ := cmd.Flags().GetString("node")
+
+			body, err := utilsService.ResponseBody(nil, "DELETE", "/api/v2/executions/"+url.PathEscape(args[0]), jobQuery(node), nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+			if err := apiError(body); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "execution %s cancelled\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&flagJobNode, "node", "n", "", "peer ID of the node running the execution, this node by default")
+	return cmd
+}
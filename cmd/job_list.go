@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var (
+	jobListCmd    = NewJobListCmd(utilsService)
+	flagJobStatus string
+)
+
+func NewJobListCmd(utilsService backend.Utility) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List executions",
+		Long:  `List the running and recently finished executions of this node or of another peer`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			node, _ := cmd.Flags().GetString("node")
+			status, _ := cmd.Flags().GetString("status")
+			format, _ := cmd.Flags().GetString("output")
+			if err := checkOutputFormat(format); err != nil {
+				return err
+			}
+
+			query := url.Values{}
+			if node != "" {
+				query.Set("node", node)
+			}
+			if status != "" {
+				query.Set("status", status)
+			}
+
+			// follow the pages until the last one
+			var executions []models.ExecutionInfo
+			for {
+				body, err := utilsService.ResponseBody(nil, "GET", "/api/v2/executions", query.Encode(), nil)
+				if err != nil {
+					return fmt.Errorf("error making request: %w", err)
+				}
+				if err := apiError(body); err != nil {
+					return err
+				}
+
+				var page struct {
+					Items      []models.ExecutionInfo `json:"items"`
+					NextCursor string                 `json:"next_cursor"`
+				}
+				if err := json.Unmarshal(body, &page); err != nil {
+					return fmt.Errorf("error parsing response: %w", err)
+				}
+				executions = append(executions, page.Items...)
+				if page.NextCursor == "" {
+					break
+				}
+				query.Set("cursor", page.NextCursor)
+			}
+
+			if len(executions) == 0 && format == "table" {
+				fmt.Fprintln(cmd.OutOrStdout(), "No executions")
+				return nil
+			}
+			return printExecutions(cmd.OutOrStdout(), format, executions)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flagJobNode, "node", "n", "", "peer ID of the node running the executions, this node by default")
+	cmd.Flags().StringVarP(&flagJobStatus, "status", "s", "", "only list the executions with this status: running, finished or failed")
+	cmd.Flags().StringVarP(&flagJobOutput, "output", "o", "table", "output format: table or json")
+	return cmd
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var jobLogsCmd = NewJobLogsCmd()
+
+func NewJobLogsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs <execution-id>",
+		Short: "Print the output of an execution",
+		Long: `Print the output of an execution, stdout and stderr merged.
+
+With --follow, the output of a running execution is streamed until it finishes. The output of a finished execution is the one recorded in its result.`,
+		Example: "  nunet job logs -f 3f0e6b2c",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			node, _ := cmd.Flags().GetString("node")
+			follow, _ := cmd.Flags().GetBool("follow")
+			return streamJobLogs(cmd.OutOrStdout(), args[0], node, follow)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flagJobNode, "node", "n", "", "peer ID of the node running the execution, this node by default")
+	cmd.Flags().BoolVarP(&flagJobFollow, "follow", "f", false, "stream the output until the execution finishes")
+	return cmd
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var (
+	jobRunCmd     = NewJobRunCmd(utilsService)
+	flagJobNode   string
+	flagJobAuto   bool
+	flagJobFollow bool
+	flagJobOutput string
+)
+
+func NewJobRunCmd(utilsService backend.Utility) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run <spec-file>",
+		Short: "Run a job",
+		Long: `Run a job from a YAML or JSON job spec, read from stdin with "-".
+
+The job runs on this node with the executor of its engine by default. With --node, it runs on another peer, which must allow the peer ID of this node in job.allowed_peers. With --auto, it searches the peers advertising the capabilities the job needs (executor type, GPU, resources) and runs on the first one accepting it.`,
+		Example: "  nunet job run job.yaml --follow\n  nunet job run --node 12D3KooW... job.yaml\n  cat job.json | nunet job run --auto -",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			node, _ := cmd.Flags().GetString("node")
+			auto, _ := cmd.Flags().GetBool("auto")
+			follow, _ := cmd.Flags().GetBool("follow")
+			format, _ := cmd.Flags().GetString("output")
+			if err := checkOutputFormat(format); err != nil {
+				return err
+			}
+			if auto {
+				if node != "" {
+					return fmt.Errorf("--node and --auto are mutually exclusive")
+				}
+				node = "auto"
+			}
+
+			spec, err := readJobSpec(args[0], cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			reqBody, err := json.Marshal(spec)
+			if err != nil {
+				return fmt.Errorf("error encoding request: %w", err)
+			}
+
+			body, err := utilsService.ResponseBody(nil, "POST", "/api/v2/executions", jobQuery(node), reqBody)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+			if err := apiError(body); err != nil {
+				return err
+			}
+
+			var info models.ExecutionInfo
+			if err := json.Unmarshal(body, &info); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+			if !follow {
+				return printExecution(cmd.OutOrStdout(), format, info)
+			}
+			// the status is printed on stderr to keep the output of the job apart
+			if err := printExecution(cmd.ErrOrStderr(), format, info); err != nil {
+				return err
+			}
+			return streamJobLogs(cmd.OutOrStdout(), info.ID, info.Node, true)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flagJobNode, "node", "n", "", "peer ID of the node running the job, this node by default")
+	cmd.Flags().BoolVarP(&flagJobAuto, "auto", "a", false, "run the job on the first peer found by capability search that accepts it")
+	cmd.Flags().BoolVarP(&flagJobFollow, "follow", "f", false, "stream the output of the job until it finishes")
+	cmd.Flags().StringVarP(&flagJobOutput, "output", "o", "table", "output format: table or json")
+	return cmd
+}
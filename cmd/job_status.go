@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var jobStatusCmd = NewJobStatusCmd(utilsService)
+
+func NewJobStatusCmd(utilsService backend.Utility) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status <execution-id>",
+		Short: "Show the status of an execution",
+		Long:  `Show the status of a running or recently finished execution, with its exit code once finished`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			node, _ := cmd.Flags().GetString("node")
+			format, _ := cmd.Flags().GetString("output")
+			if err := checkOutputFormat(format); err != nil {
+				return err
+			}
+
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v2/executions/"+url.PathEscape(args[0]), jobQuery(node), nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+			if err := apiError(body); err != nil {
+				return err
+			}
+
+			var info models.ExecutionInfo
+			if err := json.Unmarshal(body, &info); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+			return printExecution(cmd.OutOrStdout(), format, info)
+		},
+	}
+
+	cmd.Flags().StringVarP(&flagJobNode, "node", "n", "", "peer ID of the node running the execution, this node by default")
+	cmd.Flags().StringVarP(&flagJobOutput, "output", "o", "table", "output format: table or json")
+	return cmd
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+const runningExecution = `{"id": "exec-1", "job_id": "job-1", "executor": "docker", "status": "running", "started_at": "2024-05-01T10:00:00Z"}`
+
+func Test_ReadJobSpec(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "job.yaml")
+	yamlSpec := `engine:
+  type: docker
+  params:
+    image: alpine
+    cmd: ["echo", "hello"]
+resources:
+  cpu: 1
+  memory: 536870912
+outputs:
+  - type: bind
+    source: /data
+    target: /out
+`
+	require.NoError(t, os.WriteFile(path, []byte(yamlSpec), 0o600))
+	spec, err := readJobSpec(path, nil)
+	require.NoError(t, err)
+	assert.Equal(models.ExecutorTypeDocker, spec.Engine.Type)
+	assert.Equal("alpine", spec.Engine.Params["image"])
+	assert.Equal(uint64(512<<20), spec.Resources.Memory)
+	require.Len(t, spec.Outputs, 1)
+	assert.Equal("/out", spec.Outputs[0].Target)
+
+	spec, err = readJobSpec("-", strings.NewReader(`{"engine": {"type": "firecracker"}}`))
+	require.NoError(t, err)
+	assert.Equal(models.ExecutorTypeFirecracker, spec.Engine.Type)
+
+	_, err = readJobSpec("-", strings.NewReader(`resources: {memory: 1}`))
+	assert.ErrorContains(err, "invalid job spec")
+	_, err = readJobSpec(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	assert.Error(err)
+}
+
+func Test_JobRunCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("POST", "/api/v2/executions", []byte(runningExecution))
+
+	buf := new(bytes.Buffer)
+	cmd := NewJobRunCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetIn(strings.NewReader(`{"engine": {"type": "docker", "params": {"image": "alpine"}}}`))
+	cmd.SetArgs([]string{"-"})
+	assert.NoError(cmd.Execute())
+
+	expected := "EXECUTION ID  JOB ID  EXECUTOR  STATUS   STARTED               NODE\n" +
+		"exec-1        job-1   docker    running  2024-05-01T10:00:00Z  local\n"
+	assert.Equal(expected, buf.String())
+
+	cmd = NewJobRunCmd(mockUtils)
+	cmd.SetIn(strings.NewReader(`{"engine": {"type": "docker"}}`))
+	cmd.SetArgs([]string{"--node", "12D3KooW", "--auto", "-"})
+	assert.ErrorContains(cmd.Execute(), "mutually exclusive")
+
+	mockUtils.SetResponseFor("POST", "/api/v2/executions", []byte(`{"error": {"code": "invalid_argument", "message": "no executor available for the engine: wasm"}}`))
+	cmd = NewJobRunCmd(mockUtils)
+	cmd.SetIn(strings.NewReader(`{"engine": {"type": "wasm"}}`))
+	cmd.SetArgs([]string{"-"})
+	assert.EqualError(cmd.Execute(), "error: no executor available for the engine: wasm")
+}
+
+func Test_JobStatusCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("GET", "/api/v2/executions/exec-1", []byte(`{
+    "id": "exec-1", "job_id": "job-1", "executor": "docker", "status": "failed",
+    "started_at": "2024-05-01T10:00:00Z", "finished_at": "2024-05-01T10:01:00Z",
+    "result": {"exit_code": 137}, "node": "12D3KooWPeer"
+    }`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewJobStatusCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"exec-1", "-o", "json"})
+	assert.NoError(cmd.Execute())
+
+	var info models.ExecutionInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &info))
+	assert.Equal("exec-1", info.ID)
+	assert.Equal("12D3KooWPeer", info.Node)
+	assert.Equal(137, info.Result.ExitCode)
+
+	buf.Reset()
+	cmd = NewJobStatusCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"exec-1"})
+	assert.NoError(cmd.Execute())
+	assert.Contains(buf.String(), "failed (exit 137)")
+
+	cmd = NewJobStatusCmd(mockUtils)
+	cmd.SetArgs([]string{"exec-1", "-o", "yaml"})
+	assert.ErrorContains(cmd.Execute(), "invalid output format")
+}
+
+func Test_JobCancelCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("DELETE", "/api/v2/executions/exec-1", []byte{})
+	mockUtils.SetResponseFor("DELETE", "/api/v2/executions/exec-2", []byte(`{"error": {"code": "conflict", "message": "execution exec-2 is not running"}}`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewJobCancelCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"exec-1"})
+	assert.NoError(cmd.Execute())
+	assert.Equal("execution exec-1 cancelled\n", buf.String())
+
+	cmd = NewJobCancelCmd(mockUtils)
+	cmd.SetArgs([]string{"exec-2"})
+	assert.EqualError(cmd.Execute(), "error: execution exec-2 is not running")
+}
+
+func Test_JobListCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("GET", "/api/v2/executions", []byte(`{"items": [`+runningExecution+`]}`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewJobListCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"-o", "json"})
+	assert.NoError(cmd.Execute())
+
+	var executions []models.ExecutionInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &executions))
+	require.Len(t, executions, 1)
+	assert.Equal("exec-1", executions[0].ID)
+
+	mockUtils.SetResponseFor("GET", "/api/v2/executions", []byte(`{"items": []}`))
+	buf.Reset()
+	cmd = NewJobListCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{})
+	assert.NoError(cmd.Execute())
+	assert.Equal("No executions\n", buf.String())
+}
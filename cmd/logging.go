@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var loggingCmd = NewLoggingCmd(networkService)
+
+func NewLoggingCmd(net backend.NetworkManager) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "logging",
+		Short:             "Log level operations",
+		Long:              `Inspect and change the log levels of the DMS packages at runtime`,
+		PersistentPreRunE: isDMSRunning(net),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(loggingLevelsCmd)
+	cmd.AddCommand(loggingSetCmd)
+	cmd.AddCommand(loggingResetCmd)
+	return cmd
+}
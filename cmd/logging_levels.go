@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var loggingLevelsCmd = NewLoggingLevelsCmd(utilsService)
+
+func NewLoggingLevelsCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "levels",
+		Short: "List log levels",
+		Long:  `List the default log level followed by the level of every package logger`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v1/logging/levels", "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			var levels []models.LogLevel
+			if err := json.Unmarshal(body, &levels); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+
+			for _, level := range levels {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", level.Package, level.Level)
+			}
+			return nil
+		},
+	}
+}
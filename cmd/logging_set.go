@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var (
+	loggingSetCmd   = NewLoggingSetCmd(utilsService)
+	loggingResetCmd = NewLoggingResetCmd(utilsService)
+)
+
+func NewLoggingSetCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <package> <level>",
+		Short: "Set the log level of a package",
+		Long: `Set the log level of a package and its subpackages until the DMS restarts.
+The "default" package sets the level of the packages without their own level.
+Levels: debug, info, warn, error, dpanic, panic or fatal`,
+		Example: "  nunet logging set network.libp2p debug",
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reqBody, err := json.Marshal(map[string]string{"level": args[1]})
+			if err != nil {
+				return fmt.Errorf("error encoding request: %w", err)
+			}
+			return loggingLevelRequest(cmd, utilsService, "PUT", args[0], reqBody)
+		},
+	}
+}
+
+func NewLoggingResetCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reset <package>",
+		Short: "Reset the log level of a package",
+		Long:  `Remove the log level set for a package, which then follows its parent package or the default level`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return loggingLevelRequest(cmd, utilsService, "DELETE", args[0], nil)
+		},
+	}
+}
+
+// loggingLevelRequest sends a request on the level of the package and prints the response message
+func loggingLevelRequest(cmd *cobra.Command, utilsService backend.Utility, method, pkg string, reqBody []byte) error {
+	body, err := utilsService.ResponseBody(nil, method, "/api/v1/logging/levels/"+pkg, "", reqBody)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+
+	if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+		return fmt.Errorf("error: %s", errMsg)
+	}
+
+	msg, err := jsonparser.GetString(body, "message")
+	if err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), msg)
+	return nil
+}
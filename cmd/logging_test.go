@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoggingLevelsCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	levelsResponse := []byte(`[
+    {"package": "default", "level": "info"},
+    {"package": "network.libp2p", "level": "debug"}
+    ]`)
+	mockUtils.SetResponseFor("GET", "/api/v1/logging/levels", levelsResponse)
+
+	buf := new(bytes.Buffer)
+	cmd := NewLoggingLevelsCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+
+	err := cmd.Execute()
+	assert.NoError(err)
+	assert.Equal("default\tinfo\nnetwork.libp2p\tdebug\n", buf.String())
+}
+
+func Test_LoggingSetCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("PUT", "/api/v1/logging/levels/network.libp2p",
+		[]byte(`{"message": "log level of network.libp2p set to debug"}`))
+	mockUtils.SetResponseFor("PUT", "/api/v1/logging/levels/dms", []byte(`{"error": "invalid log level \"loud\""}`))
+	mockUtils.SetResponseFor("DELETE", "/api/v1/logging/levels/network.libp2p",
+		[]byte(`{"message": "log level of network.libp2p reset"}`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewLoggingSetCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"network.libp2p", "debug"})
+	assert.NoError(cmd.Execute())
+	assert.Equal("log level of network.libp2p set to debug\n", buf.String())
+
+	cmd = NewLoggingSetCmd(mockUtils)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"dms", "loud"})
+	assert.EqualError(cmd.Execute(), `error: invalid log level "loud"`)
+
+	buf.Reset()
+	cmd = NewLoggingResetCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"network.libp2p"})
+	assert.NoError(cmd.Execute())
+	assert.Equal("log level of network.libp2p reset\n", buf.String())
+}
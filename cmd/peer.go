@@ -21,5 +21,8 @@ func NewPeerCmd(net backend.NetworkManager) *cobra.Command {
 	cmd.AddCommand(peerListCmd)
 	cmd.AddCommand(peerSelfCmd)
 	cmd.AddCommand(peerDefaultCmd)
+	cmd.AddCommand(peerPingCmd)
+	cmd.AddCommand(peerStatCmd)
+	cmd.AddCommand(peerUsageCmd)
 	return cmd
 }
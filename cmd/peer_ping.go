@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var peerPingCmd = NewPeerPingCmd(utilsService)
+
+func NewPeerPingCmd(utilsService backend.Utility) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ping <PEER_ID>",
+		Short: "Ping a peer and display the round trip time",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := checkOnboarded(utilsService)
+			if err != nil {
+				return err
+			}
+
+			query := "peerID=" + args[0]
+			timeout, _ := cmd.Flags().GetDuration("timeout")
+			if timeout > 0 {
+				query += "&timeout=" + timeout.String()
+			}
+
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v1/peers/ping", query, nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			rtt, err := jsonparser.GetInt(body, "RTT")
+			if err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Ping %s: RTT %s\n", args[0], time.Duration(rtt))
+			return nil
+		},
+	}
+
+	cmd.Flags().Duration("timeout", 0, "ping timeout, defaults to 5s")
+	return cmd
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PeerPingCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	err := setupMockDB()
+	assert.NoError(err)
+
+	err = setMockMetadata()
+	assert.NoError(err)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("GET", "/api/v1/peers/ping", []byte(`{"message": "ping successful with peer abc", "RTT": 1500000}`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewPeerPingCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"abc"})
+
+	err = cmd.Execute()
+	assert.NoError(err)
+	assert.Equal("Ping abc: RTT 1.5ms\n", buf.String())
+
+	mockUtils.SetResponseFor("GET", "/api/v1/peers/ping", []byte(`{"error": "could not ping peer abc"}`))
+	cmd = NewPeerPingCmd(mockUtils)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"abc"})
+
+	err = cmd.Execute()
+	assert.ErrorContains(err, "could not ping peer abc")
+}
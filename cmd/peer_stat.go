@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var peerStatCmd = NewPeerStatCmd(utilsService)
+
+func NewPeerStatCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stat",
+		Short: "Display network status of the node",
+		Long:  `Display addresses, reachability, relay reservations, connections and bandwidth usage of the node`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := checkOnboarded(utilsService)
+			if err != nil {
+				return err
+			}
+
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v1/peers/stat", "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			var stat models.NetStat
+			if err := json.Unmarshal(body, &stat); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintln(out, "Host ID:", stat.ID)
+			fmt.Fprintln(out, "Listen addresses:", strings.Join(stat.ListenAddrs, ", "))
+			fmt.Fprintln(out, "Observed addresses:", strings.Join(stat.ObservedAddrs, ", "))
+			if stat.NATDeviceType != "" {
+				fmt.Fprintf(out, "Reachability: %s (%s)\n", stat.Reachability, stat.NATDeviceType)
+			} else {
+				fmt.Fprintln(out, "Reachability:", stat.Reachability)
+			}
+			fmt.Fprintln(out, "Relay reservations:", strings.Join(stat.RelayReservations, ", "))
+			fmt.Fprintf(out, "Connections: %d peers, %d inbound, %d outbound, %d relayed\n",
+				stat.Connections.Peers, stat.Connections.Inbound, stat.Connections.Outbound, stat.Connections.Relayed)
+			fmt.Fprintf(out, "Bandwidth: %d bytes in (%.0f B/s), %d bytes out (%.0f B/s)\n",
+				stat.Bandwidth.TotalIn, stat.Bandwidth.RateIn, stat.Bandwidth.TotalOut, stat.Bandwidth.RateOut)
+
+			return nil
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PeerStatCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	err := setupMockDB()
+	assert.NoError(err)
+
+	err = setMockMetadata()
+	assert.NoError(err)
+
+	mockUtils := &MockUtilsService{}
+	statResponse := []byte(`{
+    "id": "abcdef12345",
+    "listen_addrs": ["/ip4/0.0.0.0/tcp/9000"],
+    "observed_addrs": ["/ip4/1.2.3.4/tcp/9000"],
+    "reachability": "Private",
+    "nat_device_type": "Cone",
+    "relay_reservations": ["relay1"],
+    "connections": {"peers": 3, "inbound": 1, "outbound": 2, "relayed": 0},
+    "bandwidth": {"total_in": 2048, "total_out": 1024, "rate_in": 10, "rate_out": 5}
+    }`)
+	mockUtils.SetResponseFor("GET", "/api/v1/peers/stat", statResponse)
+
+	buf := new(bytes.Buffer)
+	cmd := NewPeerStatCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+
+	err = cmd.Execute()
+	assert.NoError(err)
+
+	expected := "Host ID: abcdef12345\n" +
+		"Listen addresses: /ip4/0.0.0.0/tcp/9000\n" +
+		"Observed addresses: /ip4/1.2.3.4/tcp/9000\n" +
+		"Reachability: Private (Cone)\n" +
+		"Relay reservations: relay1\n" +
+		"Connections: 3 peers, 1 inbound, 2 outbound, 0 relayed\n" +
+		"Bandwidth: 2048 bytes in (10 B/s), 1024 bytes out (5 B/s)\n"
+	assert.Equal(expected, buf.String())
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var peerUsageCmd = NewPeerUsageCmd(utilsService)
+
+func NewPeerUsageCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "usage",
+		Short: "Display network resource usage of the node",
+		Long:  `Display streams, connections, file descriptors, memory and bandwidth used by the node against the configured limits`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := checkOnboarded(utilsService)
+			if err != nil {
+				return err
+			}
+
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v1/peers/usage", "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			var usage models.NetUsage
+			if err := json.Unmarshal(body, &usage); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			printScopeUsage(out, "System", usage.System)
+			printScopeUsage(out, "Transient", usage.Transient)
+
+			protocols := make([]string, 0, len(usage.Protocols))
+			for proto := range usage.Protocols {
+				protocols = append(protocols, proto)
+			}
+			sort.Strings(protocols)
+			for _, proto := range protocols {
+				fmt.Fprintf(out, "Protocol %s: streams %s in, %s out\n", proto,
+					formatUsage(usage.Protocols[proto].StreamsInbound), formatUsage(usage.Protocols[proto].StreamsOutbound))
+			}
+
+			fmt.Fprintln(out, "Bandwidth:", formatBandwidth(usage.Bandwidth.Total))
+			protocols = protocols[:0]
+			for proto := range usage.Bandwidth.Protocols {
+				protocols = append(protocols, proto)
+			}
+			sort.Strings(protocols)
+			for _, proto := range protocols {
+				fmt.Fprintf(out, "Bandwidth %s: %s\n", proto, formatBandwidth(usage.Bandwidth.Protocols[proto]))
+			}
+
+			return nil
+		},
+	}
+}
+
+func printScopeUsage(out io.Writer, name string, scope models.ScopeUsage) {
+	fmt.Fprintf(out, "%s: streams %s in, %s out; connections %s in, %s out; fds %s; memory %s bytes\n", name,
+		formatUsage(scope.StreamsInbound), formatUsage(scope.StreamsOutbound),
+		formatUsage(scope.ConnsInbound), formatUsage(scope.ConnsOutbound),
+		formatUsage(scope.FD), formatUsage(scope.Memory))
+}
+
+// formatUsage prints the usage as used/limit
+func formatUsage(u models.Usage) string {
+	if u.Limit == 0 {
+		return fmt.Sprintf("%d/unlimited", u.Used)
+	}
+	return fmt.Sprintf("%d/%d", u.Used, u.Limit)
+}
+
+// formatBandwidth prints the current rates against the caps in B/s
+func formatBandwidth(b models.BandwidthLimit) string {
+	limit := func(max int64) string {
+		if max == 0 {
+			return "unlimited"
+		}
+		return fmt.Sprintf("%d", max)
+	}
+	return fmt.Sprintf("%.0f/%s B/s in, %.0f/%s B/s out", b.RateIn, limit(b.MaxIn), b.RateOut, limit(b.MaxOut))
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PeerUsageCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	err := setupMockDB()
+	assert.NoError(err)
+
+	err = setMockMetadata()
+	assert.NoError(err)
+
+	mockUtils := &MockUtilsService{}
+	usageResponse := []byte(`{
+    "system": {
+        "streams_inbound": {"used": 4, "limit": 2048},
+        "streams_outbound": {"used": 3, "limit": 2048},
+        "conns_inbound": {"used": 1, "limit": 128},
+        "conns_outbound": {"used": 2, "limit": 128},
+        "fd": {"used": 3, "limit": 256},
+        "memory": {"used": 1024, "limit": 0}
+    },
+    "transient": {},
+    "protocols": {"/nunet/vpn/1.0.0": {"streams_inbound": {"used": 1, "limit": 16}, "streams_outbound": {"used": 0, "limit": 0}}},
+    "bandwidth": {
+        "total": {"rate_in": 100, "rate_out": 50, "max_in": 1048576, "max_out": 0},
+        "protocols": {"/nunet/vpn/1.0.0": {"rate_in": 10, "rate_out": 5, "max_in": 0, "max_out": 4096}}
+    }
+    }`)
+	mockUtils.SetResponseFor("GET", "/api/v1/peers/usage", usageResponse)
+
+	buf := new(bytes.Buffer)
+	cmd := NewPeerUsageCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+
+	err = cmd.Execute()
+	assert.NoError(err)
+
+	expected := "System: streams 4/2048 in, 3/2048 out; connections 1/128 in, 2/128 out; fds 3/256; memory 1024/unlimited bytes\n" +
+		"Transient: streams 0/unlimited in, 0/unlimited out; connections 0/unlimited in, 0/unlimited out; fds 0/unlimited; memory 0/unlimited bytes\n" +
+		"Protocol /nunet/vpn/1.0.0: streams 1/16 in, 0/unlimited out\n" +
+		"Bandwidth: 100/1048576 B/s in, 50/unlimited B/s out\n" +
+		"Bandwidth /nunet/vpn/1.0.0: 10/unlimited B/s in, 5/4096 B/s out\n"
+	assert.Equal(expected, buf.String())
+}
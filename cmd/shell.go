@@ -1,13 +1,119 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 
-var flagNode string
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/utils"
+)
+
+var (
+	flagNode  string
+	flagNoTTY bool
+)
 
 var shellCmd = &cobra.Command{
-	Use:   "shell",
-	Short: "Send commands to DMS instance",
-	Long:  "",
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:   "shell <execution-id> [-- command [args...]]",
+	Short: "Open a shell into a running execution",
+	Long: `Open an interactive shell into a running execution, e.g. a docker container or a firecracker VM.
+
+The command runs the shell of the execution by default. With --node, the execution runs on another peer, which must allow the peer ID of this node in shell.allowed_peers. Every session is audited by the node running the execution.`,
+	Example: "nunet shell 3f0e6b2c\n  nunet shell --node 12D3KooW... 3f0e6b2c -- ls -l /data",
+	Args:    cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fd, isTerminal := term.GetFdInfo(cmd.InOrStdin())
+		tty := isTerminal && !flagNoTTY
+
+		query := url.Values{"command": args[1:], "tty": {strconv.FormatBool(tty)}}
+		if flagNode != "" {
+			query.Set("node", flagNode)
+		}
+		if tty {
+			if size, err := term.GetWinsize(fd); err == nil {
+				query.Set("rows", strconv.Itoa(int(size.Height)))
+				query.Set("cols", strconv.Itoa(int(size.Width)))
+			}
+		}
+
+		shellURL, err := utils.InternalAPIURL("ws", "/api/v2/executions/"+url.PathEscape(args[0])+"/shell", query.Encode())
+		if err != nil {
+			return fmt.Errorf("could not compose WebSocket URL: %w", err)
+		}
+		dialer, err := utils.InternalWebSocketDialer()
+		if err != nil {
+			return fmt.Errorf("could not connect to DMS: %w", err)
+		}
+		conn, resp, err := dialer.Dial(shellURL, utils.AuthorizationHeader())
+		if err != nil {
+			return fmt.Errorf("could not open shell: %w", shellError(resp, err))
+		}
+
+		var resizes chan models.TerminalSize
+		restore := func() {}
+		if tty {
+			state, err := term.SetRawTerminal(fd)
+			if err != nil {
+				conn.Close()
+				return fmt.Errorf("could not set terminal to raw mode: %w", err)
+			}
+			restore = func() { _ = term.RestoreTerminal(fd, state) }
+
+			resizes = make(chan models.TerminalSize, 1)
+			winch := make(chan os.Signal, 1)
+			signal.Notify(winch, syscall.SIGWINCH)
+			defer signal.Stop(winch)
+			go func() {
+				for range winch {
+					if size, err := term.GetWinsize(fd); err == nil {
+						select {
+						case resizes <- models.TerminalSize{Rows: size.Height, Cols: size.Width}:
+						default:
+						}
+					}
+				}
+			}()
+		}
+
+		code, err := internal.AttachShell(conn, cmd.InOrStdin(), cmd.OutOrStdout(), resizes)
+		restore()
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			// nunet exits with the exit code of the command, like ssh
+			os.Exit(code)
+		}
+		return nil
 	},
 }
+
+// shellError reads the message of the error envelope of a refused shell
+func shellError(resp *http.Response, err error) error {
+	if resp == nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	var envelope struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.Error.Message == "" {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return fmt.Errorf("%s", envelope.Error.Message)
+}
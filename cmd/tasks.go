@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var tasksCmd = NewTasksCmd(networkService)
+
+func NewTasksCmd(net backend.NetworkManager) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "tasks",
+		Short:             "Background task operations",
+		Long:              `Inspect and control the background tasks run by the DMS scheduler`,
+		PersistentPreRunE: isDMSRunning(net),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(tasksListCmd)
+	cmd.AddCommand(tasksShowCmd)
+	cmd.AddCommand(tasksRunCmd)
+	cmd.AddCommand(tasksCancelCmd)
+	cmd.AddCommand(tasksDisableCmd)
+	cmd.AddCommand(tasksEnableCmd)
+	return cmd
+}
+
+// newTaskActionCmd returns a command posting an action on the task given as argument
+func newTaskActionCmd(utilsService backend.Utility, action, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action + " <task-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid task ID: %s", args[0])
+			}
+
+			endpoint := fmt.Sprintf("/api/v1/tasks/%s/%s", args[0], action)
+			body, err := utilsService.ResponseBody(nil, "POST", endpoint, "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			msg, err := jsonparser.GetString(body, "message")
+			if err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), msg)
+			return nil
+		},
+	}
+}
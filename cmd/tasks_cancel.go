@@ -0,0 +1,12 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var tasksCancelCmd = NewTasksCancelCmd(utilsService)
+
+func NewTasksCancelCmd(utilsService backend.Utility) *cobra.Command {
+	return newTaskActionCmd(utilsService, "cancel", "Cancel the running execution of a background task")
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var (
+	tasksDisableCmd = NewTasksDisableCmd(utilsService)
+	tasksEnableCmd  = NewTasksEnableCmd(utilsService)
+)
+
+func NewTasksDisableCmd(utilsService backend.Utility) *cobra.Command {
+	return newTaskActionCmd(utilsService, "disable", "Disable a background task, the state is kept across restarts")
+}
+
+func NewTasksEnableCmd(utilsService backend.Utility) *cobra.Command {
+	return newTaskActionCmd(utilsService, "enable", "Enable a disabled background task")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var tasksListCmd = NewTasksListCmd(utilsService)
+
+func NewTasksListCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List background tasks",
+		Long:  `List the background tasks of the DMS with their triggers and state`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v1/tasks", "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			var tasks []models.TaskStatus
+			if err := json.Unmarshal(body, &tasks); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if len(tasks) == 0 {
+				fmt.Fprintln(out, "No background tasks")
+				return nil
+			}
+			for _, task := range tasks {
+				fmt.Fprintf(out, "%d\t%s\t%s\t%s\n", task.ID, task.Name, taskState(task), strings.Join(task.Triggers, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+// taskState describes whether a task is running, enabled or disabled
+func taskState(task models.TaskStatus) string {
+	switch {
+	case task.Running:
+		return "running"
+	case task.Enabled:
+		return "enabled"
+	default:
+		return "disabled"
+	}
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+)
+
+var tasksRunCmd = NewTasksRunCmd(utilsService)
+
+func NewTasksRunCmd(utilsService backend.Utility) *cobra.Command {
+	return newTaskActionCmd(utilsService, "run", "Run a background task now, regardless of its triggers")
+}
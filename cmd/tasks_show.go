@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/spf13/cobra"
+	"gitlab.com/nunet/device-management-service/cmd/backend"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+var tasksShowCmd = NewTasksShowCmd(utilsService)
+
+func NewTasksShowCmd(utilsService backend.Utility) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <task-id>",
+		Short: "Show a background task",
+		Long:  `Show a background task with the history of its latest executions`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid task ID: %s", args[0])
+			}
+
+			body, err := utilsService.ResponseBody(nil, "GET", "/api/v1/tasks/"+args[0], "", nil)
+			if err != nil {
+				return fmt.Errorf("error making request: %w", err)
+			}
+
+			if errMsg, err := jsonparser.GetString(body, "error"); err == nil {
+				return fmt.Errorf("error: %s", errMsg)
+			}
+
+			var task models.TaskStatus
+			if err := json.Unmarshal(body, &task); err != nil {
+				return fmt.Errorf("error parsing response: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "ID: %d\n", task.ID)
+			fmt.Fprintf(out, "Name: %s\n", task.Name)
+			fmt.Fprintf(out, "Description: %s\n", task.Description)
+			fmt.Fprintf(out, "State: %s\n", taskState(task))
+			fmt.Fprintf(out, "Priority: %d\n", task.Priority)
+			fmt.Fprintf(out, "Triggers: %s\n", strings.Join(task.Triggers, ", "))
+
+			fmt.Fprintln(out, "History:")
+			if len(task.History) == 0 {
+				fmt.Fprintln(out, "  no executions")
+			}
+			for _, execution := range task.History {
+				line := fmt.Sprintf("  %s\t%s\t%s", execution.StartedAt.Format(time.RFC3339),
+					execution.EndedAt.Sub(execution.StartedAt).Round(time.Millisecond), execution.Status)
+				if execution.Error != "" {
+					line += "\t" + execution.Error
+				}
+				fmt.Fprintln(out, line)
+			}
+			return nil
+		},
+	}
+}
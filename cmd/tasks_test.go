@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TasksListCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	listResponse := []byte(`[
+    {"id": 0, "name": "Peer Discovery", "triggers": ["every 15m0s"], "enabled": true, "running": false},
+    {"id": 1, "name": "Peer Cleanup", "triggers": ["every 5m0s"], "enabled": true, "running": true},
+    {"id": 2, "name": "Re-advertise", "triggers": ["every 6h0m0s"], "enabled": false, "running": false}
+    ]`)
+	mockUtils.SetResponseFor("GET", "/api/v1/tasks", listResponse)
+
+	buf := new(bytes.Buffer)
+	cmd := NewTasksListCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+
+	err := cmd.Execute()
+	assert.NoError(err)
+
+	expected := "0\tPeer Discovery\tenabled\tevery 15m0s\n" +
+		"1\tPeer Cleanup\trunning\tevery 5m0s\n" +
+		"2\tRe-advertise\tdisabled\tevery 6h0m0s\n"
+	assert.Equal(expected, buf.String())
+}
+
+func Test_TasksShowCmd(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	showResponse := []byte(`{
+    "id": 1, "name": "Peer Cleanup", "description": "Periodic task to remove offline peers every 5 minutes",
+    "triggers": ["every 5m0s"], "enabled": true, "running": false, "priority": 0,
+    "history": [{"task_name": "Peer Cleanup", "started_at": "2024-05-01T10:00:00Z", "ended_at": "2024-05-01T10:00:01.5Z", "status": "FAILED", "error": "boom"}]
+    }`)
+	mockUtils.SetResponseFor("GET", "/api/v1/tasks/1", showResponse)
+
+	buf := new(bytes.Buffer)
+	cmd := NewTasksShowCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetErr(buf)
+	cmd.SetArgs([]string{"1"})
+
+	err := cmd.Execute()
+	assert.NoError(err)
+
+	expected := "ID: 1\n" +
+		"Name: Peer Cleanup\n" +
+		"Description: Periodic task to remove offline peers every 5 minutes\n" +
+		"State: enabled\n" +
+		"Priority: 0\n" +
+		"Triggers: every 5m0s\n" +
+		"History:\n" +
+		"  2024-05-01T10:00:00Z\t1.5s\tFAILED\tboom\n"
+	assert.Equal(expected, buf.String())
+}
+
+func Test_TasksActionCmds(t *testing.T) {
+	assert := assert.New(t)
+
+	mockUtils := &MockUtilsService{}
+	mockUtils.SetResponseFor("POST", "/api/v1/tasks/0/run", []byte(`{"message": "task 0 started"}`))
+	mockUtils.SetResponseFor("POST", "/api/v1/tasks/0/disable", []byte(`{"message": "task 0 disabled"}`))
+	mockUtils.SetResponseFor("POST", "/api/v1/tasks/0/cancel", []byte(`{"error": "task is not running"}`))
+
+	buf := new(bytes.Buffer)
+	cmd := NewTasksRunCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"0"})
+	assert.NoError(cmd.Execute())
+	assert.Equal("task 0 started\n", buf.String())
+
+	buf.Reset()
+	cmd = NewTasksDisableCmd(mockUtils)
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"0"})
+	assert.NoError(cmd.Execute())
+	assert.Equal("task 0 disabled\n", buf.String())
+
+	cmd = NewTasksCancelCmd(mockUtils)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"0"})
+	assert.EqualError(cmd.Execute(), "error: task is not running")
+
+	cmd = NewTasksRunCmd(mockUtils)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+	cmd.SetArgs([]string{"foo"})
+	assert.EqualError(cmd.Execute(), "invalid task ID: foo")
+}
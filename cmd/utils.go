@@ -16,13 +16,16 @@ import (
 
 	"gitlab.com/nunet/device-management-service/cmd/backend"
 	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/endpoint"
 	"gitlab.com/nunet/device-management-service/libp2p"
 	"gitlab.com/nunet/device-management-service/models"
 	"gitlab.com/nunet/device-management-service/utils"
 )
 
+// listenDMSPort tells whether the DMS listens on the TCP port or on the Unix
+// socket of the API endpoint the CLI connects to, see the internal/endpoint package
 func listenDMSPort(net backend.NetworkManager) (bool, error) {
-	port := config.GetConfig().Rest.Port
+	e := endpoint.FromConfig(config.GetConfig())
 
 	conns, err := net.GetConnections("all")
 	if err != nil {
@@ -30,7 +33,11 @@ func listenDMSPort(net backend.NetworkManager) (bool, error) {
 	}
 
 	for _, conn := range conns {
-		if conn.Status == "LISTEN" && uint32(port) == conn.Laddr.Port {
+		if conn.Status == "LISTEN" && uint32(e.Port) == conn.Laddr.Port {
+			return true, nil
+		}
+		// the local address of a Unix socket is its path
+		if e.UnixSocket != "" && conn.Laddr.IP == e.UnixSocket {
 			return true, nil
 		}
 	}
@@ -34,6 +34,10 @@ func ConnectDatabase() {
 	database.AutoMigrate(&models.MachineUUID{})
 	database.AutoMigrate(&models.Connection{})
 	database.AutoMigrate(&models.LogBinAuth{})
+	database.AutoMigrate(&models.TaskDefinition{})
+	database.AutoMigrate(&models.TaskExecution{})
+	database.AutoMigrate(&models.TelemetryEvent{})
+	database.AutoMigrate(&models.APIToken{})
 
 	DB = database
 	if err := DB.Use(otelgorm.NewPlugin()); err != nil {
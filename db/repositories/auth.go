@@ -0,0 +1,10 @@
+package repositories
+
+import (
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// APITokenRepository represents a repository for CRUD operations on APIToken entities.
+type APITokenRepository interface {
+	GenericRepository[models.APIToken]
+}
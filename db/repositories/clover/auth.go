@@ -0,0 +1,21 @@
+package repositories_clover
+
+import (
+	clover "github.com/ostafen/clover/v2"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// APITokenRepositoryClover is a Clover implementation of the APITokenRepository interface.
+type APITokenRepositoryClover struct {
+	repositories.GenericRepository[models.APIToken]
+}
+
+// NewAPITokenRepository creates a new instance of APITokenRepositoryClover.
+// It initializes and returns a Clover-based repository for APIToken entities.
+func NewAPITokenRepository(db *clover.DB) repositories.APITokenRepository {
+	return &APITokenRepositoryClover{
+		NewGenericRepository[models.APIToken](db),
+	}
+}
@@ -33,6 +33,10 @@ func setup() (*clover.DB, string) {
 	db.CreateCollection("deployment_request_flat")
 	db.CreateCollection("request_tracker")
 	db.CreateCollection("virtual_machine")
+	db.CreateCollection("task_definition")
+	db.CreateCollection("task_execution")
+	db.CreateCollection("telemetry_event")
+	db.CreateCollection("api_token")
 
 	return db, path
 }
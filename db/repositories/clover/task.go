@@ -0,0 +1,34 @@
+package repositories_clover
+
+import (
+	clover "github.com/ostafen/clover/v2"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TaskDefinitionRepositoryClover is a Clover implementation of the TaskDefinitionRepository interface.
+type TaskDefinitionRepositoryClover struct {
+	repositories.GenericRepository[models.TaskDefinition]
+}
+
+// NewTaskDefinitionRepository creates a new instance of TaskDefinitionRepositoryClover.
+// It initializes and returns a Clover-based repository for TaskDefinition entities.
+func NewTaskDefinitionRepository(db *clover.DB) repositories.TaskDefinitionRepository {
+	return &TaskDefinitionRepositoryClover{
+		NewGenericRepository[models.TaskDefinition](db),
+	}
+}
+
+// TaskExecutionRepositoryClover is a Clover implementation of the TaskExecutionRepository interface.
+type TaskExecutionRepositoryClover struct {
+	repositories.GenericRepository[models.TaskExecution]
+}
+
+// NewTaskExecutionRepository creates a new instance of TaskExecutionRepositoryClover.
+// It initializes and returns a Clover-based repository for TaskExecution entities.
+func NewTaskExecutionRepository(db *clover.DB) repositories.TaskExecutionRepository {
+	return &TaskExecutionRepositoryClover{
+		NewGenericRepository[models.TaskExecution](db),
+	}
+}
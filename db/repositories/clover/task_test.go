@@ -0,0 +1,76 @@
+package repositories_clover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TestTaskDefinitionRepository is a test suite for the TaskDefinitionRepository.
+// It includes test cases that cover the basic CRUD operations and custom repository functions if there are any.
+// This test suite ensures that the repository functions for the TaskDefinition model behave as expected.
+func TestTaskDefinitionRepository(t *testing.T) {
+	// Setup database connection for testing
+	db, path := setup()
+	defer teardown(db, path)
+
+	// Initialize the repository
+	taskDefinitionRepo := NewTaskDefinitionRepository(db)
+
+	// Test Create method
+	createdTaskDefinition, err := taskDefinitionRepo.Create(
+		context.Background(),
+		models.TaskDefinition{Name: "discovery", State: models.TaskEnabled},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "discovery", createdTaskDefinition.Name)
+
+	// Test Find method
+	query := taskDefinitionRepo.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("Name", "discovery"))
+	foundTaskDefinition, err := taskDefinitionRepo.Find(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Equal(t, models.TaskEnabled, foundTaskDefinition.State)
+}
+
+// TestTaskExecutionRepository is a test suite for the TaskExecutionRepository.
+// It includes test cases that cover the basic CRUD operations and custom repository functions if there are any.
+// This test suite ensures that the repository functions for the TaskExecution model behave as expected.
+func TestTaskExecutionRepository(t *testing.T) {
+	// Setup database connection for testing
+	db, path := setup()
+	defer teardown(db, path)
+
+	// Initialize the repository
+	taskExecutionRepo := NewTaskExecutionRepository(db)
+
+	// Test Create method
+	start := time.Now().UTC()
+	for i := 0; i < 3; i++ {
+		_, err := taskExecutionRepo.Create(
+			context.Background(),
+			models.TaskExecution{
+				TaskName:  "discovery",
+				StartedAt: start.Add(time.Duration(i) * time.Second),
+				Status:    "SUCCESS",
+			},
+		)
+		assert.NoError(t, err)
+	}
+
+	// Test FindAll method sorted by the latest execution
+	query := taskExecutionRepo.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("TaskName", "discovery"))
+	query.SortBy = "-StartedAt"
+	executions, err := taskExecutionRepo.FindAll(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Len(t, executions, 3)
+	if len(executions) == 3 {
+		assert.True(t, executions[0].StartedAt.After(executions[2].StartedAt))
+	}
+}
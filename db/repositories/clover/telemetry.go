@@ -0,0 +1,21 @@
+package repositories_clover
+
+import (
+	clover "github.com/ostafen/clover/v2"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TelemetryEventRepositoryClover is a Clover implementation of the TelemetryEventRepository interface.
+type TelemetryEventRepositoryClover struct {
+	repositories.GenericRepository[models.TelemetryEvent]
+}
+
+// NewTelemetryEventRepository creates a new instance of TelemetryEventRepositoryClover.
+// It initializes and returns a Clover-based repository for TelemetryEvent entities.
+func NewTelemetryEventRepository(db *clover.DB) repositories.TelemetryEventRepository {
+	return &TelemetryEventRepositoryClover{
+		NewGenericRepository[models.TelemetryEvent](db),
+	}
+}
@@ -0,0 +1,44 @@
+package repositories_clover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TestTelemetryEventRepository is a test suite for the TelemetryEventRepository.
+// It includes test cases that cover the basic CRUD operations and custom repository functions if there are any.
+// This test suite ensures that the repository functions for the TelemetryEvent model behave as expected.
+func TestTelemetryEventRepository(t *testing.T) {
+	// Setup database connection for testing
+	db, path := setup()
+	defer teardown(db, path)
+
+	// Initialize the repository
+	telemetryEventRepo := NewTelemetryEventRepository(db)
+
+	// Test Create method
+	createdTelemetryEvent, err := telemetryEventRepo.Create(
+		context.Background(),
+		models.TelemetryEvent{
+			Name:     "execution.finished",
+			Category: "ACCOUNTING",
+			Level:    "INFO",
+			Time:     time.Now().UTC(),
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "execution.finished", createdTelemetryEvent.Name)
+
+	// Test Find method
+	query := telemetryEventRepo.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("Category", "ACCOUNTING"))
+	foundTelemetryEvent, err := telemetryEventRepo.Find(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Equal(t, "INFO", foundTelemetryEvent.Level)
+}
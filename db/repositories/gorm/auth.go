@@ -0,0 +1,21 @@
+package repositories_gorm
+
+import (
+	"gorm.io/gorm"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// APITokenRepositoryGORM is a GORM implementation of the APITokenRepository interface.
+type APITokenRepositoryGORM struct {
+	repositories.GenericRepository[models.APIToken]
+}
+
+// NewAPITokenRepository creates a new instance of APITokenRepositoryGORM.
+// It initializes and returns a GORM-based repository for APIToken entities.
+func NewAPITokenRepository(db *gorm.DB) repositories.APITokenRepository {
+	return &APITokenRepositoryGORM{
+		NewGenericRepository[models.APIToken](db),
+	}
+}
@@ -0,0 +1,45 @@
+package repositories_gorm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TestAPITokenRepository is a test suite for the APITokenRepository.
+// It includes test cases that cover the basic CRUD operations and custom repository functions if there are any.
+// This test suite ensures that the repository functions for the APIToken model behave as expected.
+func TestAPITokenRepository(t *testing.T) {
+	// Setup database connection for testing
+	setup()
+	defer teardown()
+
+	// Initialize the repository
+	apiTokenRepo := NewAPITokenRepository(db)
+
+	// Test Create method
+	createdAPIToken, err := apiTokenRepo.Create(
+		context.Background(),
+		models.APIToken{Name: "cli", Scope: "admin", Hash: "2c26b46b68ffc68ff99b453c1d304134"},
+	)
+	assert.NoError(t, err)
+	assert.NotZero(t, createdAPIToken.ID)
+
+	// Test Find method
+	query := apiTokenRepo.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("Hash", "2c26b46b68ffc68ff99b453c1d304134"))
+	foundAPIToken, err := apiTokenRepo.Find(context.Background(), query)
+	assert.NoError(t, err)
+	assert.Equal(t, "cli", foundAPIToken.Name)
+
+	// Test Delete method
+	err = apiTokenRepo.Delete(context.Background(), createdAPIToken.ID)
+	assert.NoError(t, err)
+
+	_, err = apiTokenRepo.Find(context.Background(), query)
+	assert.ErrorIs(t, err, repositories.NotFoundError)
+}
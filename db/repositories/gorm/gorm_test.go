@@ -34,6 +34,10 @@ func setup() {
 		&models.DeploymentRequestFlat{},
 		&models.RequestTracker{},
 		&models.VirtualMachine{},
+		&models.TaskDefinition{},
+		&models.TaskExecution{},
+		&models.TelemetryEvent{},
+		&models.APIToken{},
 	)
 }
 
@@ -0,0 +1,34 @@
+package repositories_gorm
+
+import (
+	"gorm.io/gorm"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TaskDefinitionRepositoryGORM is a GORM implementation of the TaskDefinitionRepository interface.
+type TaskDefinitionRepositoryGORM struct {
+	repositories.GenericRepository[models.TaskDefinition]
+}
+
+// NewTaskDefinitionRepository creates a new instance of TaskDefinitionRepositoryGORM.
+// It initializes and returns a GORM-based repository for TaskDefinition entities.
+func NewTaskDefinitionRepository(db *gorm.DB) repositories.TaskDefinitionRepository {
+	return &TaskDefinitionRepositoryGORM{
+		NewGenericRepository[models.TaskDefinition](db),
+	}
+}
+
+// TaskExecutionRepositoryGORM is a GORM implementation of the TaskExecutionRepository interface.
+type TaskExecutionRepositoryGORM struct {
+	repositories.GenericRepository[models.TaskExecution]
+}
+
+// NewTaskExecutionRepository creates a new instance of TaskExecutionRepositoryGORM.
+// It initializes and returns a GORM-based repository for TaskExecution entities.
+func NewTaskExecutionRepository(db *gorm.DB) repositories.TaskExecutionRepository {
+	return &TaskExecutionRepositoryGORM{
+		NewGenericRepository[models.TaskExecution](db),
+	}
+}
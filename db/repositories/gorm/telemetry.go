@@ -0,0 +1,21 @@
+package repositories_gorm
+
+import (
+	"gorm.io/gorm"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TelemetryEventRepositoryGORM is a GORM implementation of the TelemetryEventRepository interface.
+type TelemetryEventRepositoryGORM struct {
+	repositories.GenericRepository[models.TelemetryEvent]
+}
+
+// NewTelemetryEventRepository creates a new instance of TelemetryEventRepositoryGORM.
+// It initializes and returns a GORM-based repository for TelemetryEvent entities.
+func NewTelemetryEventRepository(db *gorm.DB) repositories.TelemetryEventRepository {
+	return &TelemetryEventRepositoryGORM{
+		NewGenericRepository[models.TelemetryEvent](db),
+	}
+}
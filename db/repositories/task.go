@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TaskDefinitionRepository represents a repository for CRUD operations on TaskDefinition entities.
+type TaskDefinitionRepository interface {
+	GenericRepository[models.TaskDefinition]
+}
+
+// TaskExecutionRepository represents a repository for CRUD operations on TaskExecution entities.
+type TaskExecutionRepository interface {
+	GenericRepository[models.TaskExecution]
+}
@@ -0,0 +1,10 @@
+package repositories
+
+import (
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// TelemetryEventRepository represents a repository for CRUD operations on TelemetryEvent entities.
+type TelemetryEventRepository interface {
+	GenericRepository[models.TelemetryEvent]
+}
@@ -2,31 +2,151 @@ package dms
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
 	"gitlab.com/nunet/device-management-service/api"
+	"gitlab.com/nunet/device-management-service/api/rpc"
+	apiv2 "gitlab.com/nunet/device-management-service/api/v2"
 	"gitlab.com/nunet/device-management-service/db"
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/dms/resources"
+	"gitlab.com/nunet/device-management-service/executor"
 	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
 	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/endpoint"
+	"gitlab.com/nunet/device-management-service/internal/events"
 	"gitlab.com/nunet/device-management-service/internal/messaging"
 	"gitlab.com/nunet/device-management-service/libp2p"
 	"gitlab.com/nunet/device-management-service/models"
+	nlibp2p "gitlab.com/nunet/device-management-service/network/libp2p"
+	"gitlab.com/nunet/device-management-service/storage"
+	"gitlab.com/nunet/device-management-service/storage/basic_controller"
+	"gitlab.com/nunet/device-management-service/telemetry"
+	"gitlab.com/nunet/device-management-service/telemetry/logger"
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
+	"gitlab.com/nunet/device-management-service/telemetry/tracing"
 	"gitlab.com/nunet/device-management-service/utils"
 
 	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/spf13/afero"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// maxRunningTasks is the number of background tasks the scheduler runs at once
+const maxRunningTasks = 4
+
 func Run() {
 	ctx := context.Background()
 	config.LoadConfig()
+	// the package loggers are created before the configuration is loaded
+	if err := logger.Configure(config.GetConfig().Telemetry.Logging, config.GetConfig().General.DataDir); err != nil {
+		zlog.Sugar().Errorf("invalid logging configuration: %v", err)
+	}
 
 	db.ConnectDatabase()
+	// hooks run in reverse order, registering it first closes the database last
+	internal.Shutdown.Register("database", func(_ context.Context) error {
+		sqlDB, err := db.DB.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+
+	var authenticator *auth.Authenticator
+	if config.GetConfig().Rest.Auth.Enabled {
+		authenticator = setupAuth(ctx)
+	}
+
+	cfg := config.GetConfig()
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Telemetry.Tracing, nodeAttributes()...)
+	if err != nil {
+		zlog.Sugar().Errorf("failed to set up tracing: %v", err)
+	} else {
+		internal.Shutdown.Register("tracing", shutdownTracing)
+	}
+
+	err = telemetry.RegisterCollectors(
+		cfg.Telemetry,
+		cfg.General.DataDir,
+		repositories_gorm.NewTelemetryEventRepository(db.DB),
+	)
+	if err != nil {
+		zlog.Sugar().Errorf("failed to register telemetry collectors: %v", err)
+	}
+	internal.Shutdown.Register("telemetry collectors", func(_ context.Context) error {
+		return telemetry.Close()
+	})
+
+	watcher := metrics.Watch(events.Default)
+	internal.Shutdown.Register("metrics", func(_ context.Context) error {
+		watcher.Close()
+		return nil
+	})
+	if cfg.Telemetry.Metrics.Enabled && cfg.Telemetry.Metrics.ListenAddress != "" {
+		startMetricsServer(cfg.Telemetry.Metrics.ListenAddress)
+	}
 
-	go startServer()
+	scheduler := bt.NewPersistentScheduler(
+		maxRunningTasks,
+		repositories_gorm.NewTaskDefinitionRepository(db.DB),
+		repositories_gorm.NewTaskExecutionRepository(db.DB),
+	)
+	scheduler.AddTask(&bt.Task{
+		Name:        "Free Resources",
+		Description: "Recomputes the free resources when an execution finishes",
+		Function: func(_ context.Context, _ interface{}) error {
+			return resources.CalcFreeResAndUpdateDB()
+		},
+		Triggers: []bt.Trigger{&bt.EventTrigger{
+			Bus:      events.Default,
+			Filters:  []events.Filter{events.Types(events.ExecutionFinished)},
+			Coalesce: true,
+		}},
+	})
+	scheduler.Start()
+	internal.Shutdown.Register("task scheduler", func(_ context.Context) error {
+		scheduler.Stop()
+		return nil
+	})
+	api.SetScheduler(scheduler)
+	registry, volumes := setupAPIv2(cfg, scheduler)
+	shells := setupShells(ctx, cfg, registry)
+	jobs := setupJobs(ctx, cfg, registry)
+
+	startServer()
+	journal := events.NewJournal(events.Default, events.DefaultJournalSize)
+	apiv2.SetEventJournal(journal)
+	logJournal := events.NewJournal(events.Logs, events.DefaultJournalSize)
+	var grpcServers []*rpc.Server
+	if cfg.GRPC.Enabled {
+		grpcServers = startGRPCServer(cfg, rpc.Options{
+			Authenticator: authenticator,
+			Onboarding:    rpcOnboarding{},
+			Resources:     rpcResources{},
+			Executions:    registry,
+			Jobs:          jobs,
+			Journal:       journal,
+			Logs:          logJournal,
+			Volumes:       volumes,
+		})
+	}
+	// registered after the API servers so that their event streams end first on shutdown
+	internal.Shutdown.Register("event journal", func(_ context.Context) error {
+		journal.Close()
+		logJournal.Close()
+		return nil
+	})
 
 	go messaging.DeploymentWorker()
 
@@ -50,21 +170,60 @@ func Run() {
 			zlog.Sugar().Fatalf("unable to unmarshal private key: %v", err)
 		}
 
+		// the node is attached to the host started below, refuse to start
+		// rather than run without the options it can't apply to that host
+		if err := nlibp2p.CheckAttach(p2pParams.ServerMode); err != nil {
+			zlog.Sugar().Fatalf("unable to start the network node: %v", err)
+		}
 		libp2p.RunNode(priv, p2pParams.ServerMode, p2pParams.Available)
 		if libp2p.GetP2P().Host != nil {
 			SanityCheck(db.DB)
+			// the streams of the node are capped and metered
+			shellHost := libp2p.GetP2P().Host
+			if node := setupNetwork(cfg, metadata, priv, p2pParams.ServerMode, scheduler, grpcServers); node != nil {
+				advertiseNode(node, metadata, p2pParams.Available, jobs, scheduler)
+				serveRemoteJobs(cfg, node, jobs, grpcServers)
+				shellHost = node.Host
+			}
+			serveRemoteShells(cfg, shellHost, shells)
 		}
 	}
 
-	// wait for SIGINT or SIGTERM
-	sig := <-internal.ShutdownChan
-	fmt.Printf("Shutting down after receiving %v...\n", sig)
+	// wait for SIGINT or SIGTERM and shut the components down
+	if err := internal.Shutdown.WaitForSignal(internal.ShutdownChan, internal.DefaultShutdownTimeout); err != nil {
+		zlog.Sugar().Errorf("unclean shutdown: %v", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
 
-	// add actual cleanup code here
-	fmt.Println("Cleaning up before shutting down")
+// setupAPIv2 gives the /api/v2 handlers the components they serve and
+// returns the ones the gRPC API shares, the volume controller is nil when it
+// can't be set up
+func setupAPIv2(cfg *config.Config, scheduler *bt.Scheduler) (*executor.Registry, storage.VolumeController) {
+	apiv2.SetScheduler(scheduler)
+	apiv2.SetJobRepository(repositories_gorm.NewServicesRepository(db.DB))
 
-	// exit
-	os.Exit(0)
+	registry := executor.NewRegistry(events.Default, executor.DefaultRetainedExecutions)
+	internal.Shutdown.Register("execution registry", func(_ context.Context) error {
+		registry.Close()
+		return nil
+	})
+	apiv2.SetExecutionRegistry(registry)
+
+	volumesDir := filepath.Join(cfg.General.DataDir, "volumes")
+	if err := os.MkdirAll(volumesDir, 0o755); err != nil {
+		zlog.Sugar().Errorf("unable to create the volumes directory: %v", err)
+		return registry, nil
+	}
+	// the controller expects a trailing slash
+	volumes, err := basic_controller.NewDefaultVolumeController(db.DB, volumesDir+"/", afero.NewOsFs())
+	if err != nil {
+		zlog.Sugar().Errorf("unable to set up the volume controller: %v", err)
+		return registry, nil
+	}
+	apiv2.SetVolumeController(volumes)
+	return registry, volumes
 }
 
 func GetP2PParams() (libp2pInfo models.Libp2pInfo) {
@@ -75,6 +234,18 @@ func GetP2PParams() (libp2pInfo models.Libp2pInfo) {
 	return
 }
 
+// nodeAttributes identifies the node in the resource of its spans, the peer
+// ID is only known once onboarded
+func nodeAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{tracing.MachineUUIDKey.String(utils.GetMachineUUID())}
+	if priv, err := crypto.UnmarshalPrivateKey(GetP2PParams().PrivateKey); err == nil {
+		if id, err := peer.IDFromPrivateKey(priv); err == nil {
+			attrs = append(attrs, tracing.PeerIDKey.String(id.String()))
+		}
+	}
+	return attrs
+}
+
 func ValidateOnboarding(metadata *models.Metadata) {
 	// Check 1: Check if payment address is valid
 	err := utils.ValidateAddress(metadata.PublicKey)
@@ -85,12 +256,59 @@ func ValidateOnboarding(metadata *models.Metadata) {
 	}
 }
 
+// startMetricsServer serves /metrics on its own address, e.g. to keep it
+// reachable by Prometheus only
+func startMetricsServer(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	server := &http.Server{Addr: address, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zlog.Sugar().Errorf("metrics server failed: %v", err)
+		}
+	}()
+	internal.Shutdown.Register("metrics server", server.Shutdown)
+}
+
+// startServer serves the API on the TCP address and on the Unix socket of
+// the endpoint of the configuration until the DMS shuts down
 func startServer() {
 	router := api.SetupRouter()
-	// router.Use(otelgin.Middleware(tracing.MachineName))
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	router.Run(fmt.Sprintf(":%d", config.GetConfig().Rest.Port))
+	e := endpoint.FromConfig(config.GetConfig())
+	if e.UnixSocket != "" {
+		serveUnixSocket(router, e.UnixSocket)
+	}
+
+	listener, err := net.Listen("tcp", e.ListenAddress())
+	if err != nil {
+		zlog.Sugar().Fatalf("unable to listen on %s: %v", e.ListenAddress(), err)
+	}
+	server := newAPIServer(router)
+	if e.TLS {
+		if e.SelfSigned {
+			if err := endpoint.EnsureSelfSigned(e); err != nil {
+				zlog.Sugar().Fatalf("unable to generate the certificate of the API: %v", err)
+			}
+		}
+		if server.TLSConfig, err = serverTLSConfig(config.GetConfig().Rest.TLS); err != nil {
+			zlog.Sugar().Fatalf("invalid TLS configuration of the API: %v", err)
+		}
+	}
 
+	zlog.Sugar().Infof("serving the API on %s (TLS: %t)", e.ListenAddress(), e.TLS)
+	go func() {
+		if e.TLS {
+			err = server.ServeTLS(listener, e.CertFile, e.KeyFile)
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zlog.Sugar().Errorf("API server failed: %v", err)
+		}
+	}()
+	internal.Shutdown.Register("API server", server.Shutdown)
 }
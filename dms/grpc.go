@@ -0,0 +1,114 @@
+package dms
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"gitlab.com/nunet/device-management-service/api/rpc"
+	"gitlab.com/nunet/device-management-service/dms/onboarding"
+	"gitlab.com/nunet/device-management-service/dms/resources"
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/endpoint"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/utils"
+)
+
+// rpcOnboarding serves the onboarding of the REST API over gRPC
+type rpcOnboarding struct{}
+
+func (rpcOnboarding) Status() (*models.OnboardingStatus, error) {
+	return onboarding.Status()
+}
+
+func (rpcOnboarding) Metadata() (*models.Metadata, error) {
+	return onboarding.GetMetadata()
+}
+
+func (rpcOnboarding) Onboard(ctx context.Context, capacity models.CapacityForNunet) (*models.Metadata, error) {
+	return onboarding.Onboard(ctx, capacity)
+}
+
+func (rpcOnboarding) UpdateCapacity(ctx context.Context, capacity models.CapacityForNunet) (*models.Metadata, error) {
+	return onboarding.ResourceConfig(ctx, capacity)
+}
+
+func (rpcOnboarding) Offboard(ctx context.Context, force bool) error {
+	return onboarding.Offboard(ctx, force)
+}
+
+// rpcResources serves the resources of the REST API over gRPC
+type rpcResources struct{}
+
+func (rpcResources) Provisioned() *models.Provisioned {
+	return resources.GetTotalProvisioned()
+}
+
+func (rpcResources) Free(ctx context.Context) (*models.FreeResources, error) {
+	// the free resources are only computed once onboarded
+	if onboarded, err := utils.IsOnboarded(); err != nil || !onboarded {
+		return nil, nil
+	}
+	return resources.GetFreeResource(ctx)
+}
+
+// startGRPCServer serves the gRPC control API on the TCP address and on the
+// Unix socket of the grpc section of the configuration until the DMS shuts
+// down. The TCP listener uses the TLS settings of the REST API. It returns
+// the servers started.
+func startGRPCServer(cfg *config.Config, opts rpc.Options) []*rpc.Server {
+	var servers []*rpc.Server
+	if cfg.GRPC.UnixSocket != "" {
+		if listener, err := listenUnixSocket(cfg.GRPC.UnixSocket); err != nil {
+			zlog.Sugar().Warnf("not serving the gRPC API on %s: %v", cfg.GRPC.UnixSocket, err)
+		} else {
+			zlog.Sugar().Infof("serving the gRPC API on %s", cfg.GRPC.UnixSocket)
+			server := rpc.NewServer(opts)
+			serveGRPC(server, listener, "gRPC server on "+cfg.GRPC.UnixSocket)
+			servers = append(servers, server)
+		}
+	}
+	if cfg.GRPC.Port == 0 {
+		return servers
+	}
+
+	address := net.JoinHostPort(cfg.GRPC.Address, strconv.Itoa(cfg.GRPC.Port))
+	var serverOpts []grpc.ServerOption
+	// the certificate is generated by the REST API server when self-signed
+	if e := endpoint.FromConfig(cfg); e.TLS {
+		tlsConfig, err := serverTLSConfig(cfg.Rest.TLS)
+		if err != nil {
+			zlog.Sugar().Fatalf("invalid TLS configuration of the gRPC API: %v", err)
+		}
+		cert, err := tls.LoadX509KeyPair(e.CertFile, e.KeyFile)
+		if err != nil {
+			zlog.Sugar().Fatalf("unable to load the certificate of the gRPC API: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		zlog.Sugar().Fatalf("unable to listen on %s: %v", address, err)
+	}
+	zlog.Sugar().Infof("serving the gRPC API on %s (TLS: %t)", address, len(serverOpts) > 0)
+	server := rpc.NewServer(opts, serverOpts...)
+	serveGRPC(server, listener, "gRPC server")
+	return append(servers, server)
+}
+
+func serveGRPC(server *rpc.Server, listener net.Listener, name string) {
+	go func() {
+		// closing the listener removes the socket file
+		if err := server.Serve(listener); err != nil {
+			zlog.Sugar().Errorf("%s failed: %v", name, err)
+		}
+	}()
+	internal.Shutdown.Register(name, server.Shutdown)
+}
@@ -0,0 +1,69 @@
+package dms
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"gitlab.com/nunet/device-management-service/api/rpc"
+	apiv2 "gitlab.com/nunet/device-management-service/api/v2"
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/executor/docker"
+	"gitlab.com/nunet/device-management-service/executor/firecracker"
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/models"
+	nlibp2p "gitlab.com/nunet/device-management-service/network/libp2p"
+)
+
+// setupJobs gives the API the jobs run with the executors installed on the
+// machine, nil when none is installed
+func setupJobs(ctx context.Context, cfg *config.Config, registry *executor.Registry) *executor.Jobs {
+	executors := make(map[string]executor.Executor)
+	if e, err := docker.NewExecutor(ctx, "jobs"); err == nil && e.IsInstalled(ctx) {
+		executors[models.ExecutorTypeDocker] = e
+	}
+	if e, err := firecracker.NewExecutor(ctx, "jobs"); err == nil && e.IsInstalled(ctx) {
+		executors[models.ExecutorTypeFirecracker] = e
+	}
+	if len(executors) == 0 {
+		zlog.Sugar().Warn("no executor installed, this node doesn't run jobs")
+		return nil
+	}
+
+	for engine, e := range executors {
+		internal.Shutdown.Register(engine+" executor of the jobs", e.Shutdown)
+	}
+	jobs := executor.NewJobs(registry, executors, filepath.Join(cfg.General.DataDir, "jobs"))
+	apiv2.SetJobRunner(jobs)
+	return jobs
+}
+
+// serveRemoteJobs runs the jobs of the peers of job.allowed_peers and lets
+// the APIs run jobs on the other peers, found by their capabilities
+func serveRemoteJobs(cfg *config.Config, node *nlibp2p.Libp2p, jobs *executor.Jobs, grpcServers []*rpc.Server) {
+	allowed := make([]peer.ID, 0, len(cfg.Job.AllowedPeers))
+	for _, id := range cfg.Job.AllowedPeers {
+		p, err := peer.Decode(id)
+		if err != nil {
+			zlog.Sugar().Warnf("ignoring invalid peer %q of job.allowed_peers: %v", id, err)
+			continue
+		}
+		allowed = append(allowed, p)
+	}
+
+	var runner nlibp2p.JobRunner
+	if jobs != nil {
+		runner = jobs
+	}
+	service, err := nlibp2p.NewJobService(node.RPC, runner, node, allowed)
+	if err != nil {
+		zlog.Sugar().Errorf("unable to serve the jobs of the peers: %v", err)
+		return
+	}
+	apiv2.SetRemoteJobs(service)
+	for _, s := range grpcServers {
+		s.SetRemoteJobs(service)
+	}
+}
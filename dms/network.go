@@ -0,0 +1,107 @@
+package dms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"gitlab.com/nunet/device-management-service/api"
+	"gitlab.com/nunet/device-management-service/api/rpc"
+	apiv2 "gitlab.com/nunet/device-management-service/api/v2"
+	"gitlab.com/nunet/device-management-service/dms/resources"
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/internal"
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/libp2p"
+	"gitlab.com/nunet/device-management-service/models"
+	nlibp2p "gitlab.com/nunet/device-management-service/network/libp2p"
+)
+
+// setupNetwork starts the network node on the host started by the onboarding
+// and gives it to the API servers, nil when it can't be set up. The node
+// shuts the host down gracefully with the DMS.
+func setupNetwork(cfg *config.Config, metadata *models.Metadata, priv crypto.PrivKey, server bool,
+	scheduler *bt.Scheduler, grpcServers []*rpc.Server) *nlibp2p.Libp2p {
+	node := &nlibp2p.Libp2p{}
+	err := node.Attach(libp2p.GetP2P().Host, libp2p.GetP2P().DHT, nlibp2p.Libp2pConfig{
+		PrivateKey:     priv,
+		ListenAddr:     cfg.P2P.ListenAddress,
+		BootstrapPeers: nlibp2p.NuNetBootstrapPeers,
+		Rendezvous:     metadata.Network,
+		Server:         server,
+		Scheduler:      scheduler,
+	})
+	if err != nil {
+		zlog.Sugar().Errorf("unable to set up the network node: %v", err)
+		internal.Shutdown.Register("libp2p host", func(_ context.Context) error {
+			return libp2p.GetP2P().Host.Close()
+		})
+		return nil
+	}
+	internal.Shutdown.Register("libp2p node", node.Shutdown)
+	// the legacy node has already bootstrapped the host, starting the node
+	// runs its discovery, its heartbeats and their checks
+	if err := node.Start(context.Background()); err != nil {
+		zlog.Sugar().Errorf("unable to start the network node: %v", err)
+	}
+
+	api.SetNetwork(node)
+	apiv2.SetNetwork(node)
+	for _, s := range grpcServers {
+		s.SetNetwork(node)
+	}
+	return node
+}
+
+// advertiseNode advertises the peer data of this node, with the engines the
+// jobs run with, and advertises it again whenever its free resources change
+func advertiseNode(node *nlibp2p.Libp2p, metadata *models.Metadata, available bool, jobs *executor.Jobs, scheduler *bt.Scheduler) {
+	var engines []string
+	if jobs != nil {
+		engines = jobs.Engines()
+	}
+	advertise := func(_ context.Context, _ interface{}) error {
+		free, err := resources.GetFreeResources()
+		if err != nil {
+			return fmt.Errorf("unable to read the free resources: %w", err)
+		}
+		data, err := json.Marshal(models.PeerData{
+			PeerID:             node.Host.ID().String(),
+			IsAvailable:        available,
+			HasGpu:             len(metadata.GpuInfo) > 0,
+			AllowCardano:       metadata.AllowCardano,
+			GpuInfo:            metadata.GpuInfo,
+			ExecutorTypes:      engines,
+			AvailableResources: free,
+			Timestamp:          time.Now().Unix(),
+		})
+		if err != nil {
+			return err
+		}
+		return node.Advertise(nlibp2p.AdTypePeerInfo, data)
+	}
+	if err := advertise(context.Background(), nil); err != nil {
+		zlog.Sugar().Warnf("unable to advertise the peer data: %v", err)
+	}
+
+	task := scheduler.AddTask(&bt.Task{
+		Name:        "Advertise Peer Data",
+		Description: "Advertises the peer data of this node when its free resources change",
+		Function:    advertise,
+		Triggers: []bt.Trigger{&bt.EventTrigger{
+			Bus:      events.Default,
+			Filters:  []events.Filter{events.Types(events.ResourcesChanged)},
+			Coalesce: true,
+		}},
+	})
+	// registered after the node so that the task is removed before it stops
+	internal.Shutdown.Register("peer data advertisement", func(_ context.Context) error {
+		scheduler.RemoveTask(task.ID)
+		return nil
+	})
+}
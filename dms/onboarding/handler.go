@@ -12,6 +12,7 @@ import (
 	"gitlab.com/nunet/device-management-service/db"
 	"gitlab.com/nunet/device-management-service/dms/resources"
 	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/events"
 	"gitlab.com/nunet/device-management-service/libp2p"
 	"gitlab.com/nunet/device-management-service/models"
 	"gitlab.com/nunet/device-management-service/utils"
@@ -204,6 +205,8 @@ func Onboard(ctx context.Context, capacity models.CapacityForNunet) (*models.Met
 	if err != nil {
 		zlog.Sugar().Errorf("unable to register with logbin: %v", err)
 	}
+
+	events.Publish(events.OnboardingChanged, events.Onboarding{Onboarded: true})
 	return &metadata, nil
 }
 
@@ -292,6 +295,7 @@ func Offboard(ctx context.Context, force bool) error {
 		return fmt.Errorf("unable to delete available resources on database: %w", err)
 	}
 
+	events.Publish(events.OnboardingChanged, events.Onboarding{Onboarded: false})
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/shirou/gopsutil/cpu"
 	"gitlab.com/nunet/device-management-service/db"
+	"gitlab.com/nunet/device-management-service/internal/events"
 	"gitlab.com/nunet/device-management-service/models"
 	"gorm.io/gorm"
 )
@@ -27,6 +28,12 @@ func CalcFreeResAndUpdateDB() error {
 		return err
 	}
 
+	availableRes, err := GetAvailableResources(db.DB)
+	if err != nil {
+		return err
+	}
+
+	events.Publish(events.ResourcesChanged, events.Resources{Free: freeRes, Available: availableRes})
 	return nil
 }
 
@@ -28,6 +28,7 @@ func Check_gpu() ([]models.Gpu, error) {
 				}
 				for _, i := range info {
 					gpu.Name = i.GPUName
+					gpu.Vendor = models.GPUVendorNvidia
 					gpu.FreeVram = i.FreeMemory
 					gpu.TotVram = i.TotalMemory
 					gpu_info = append(gpu_info, gpu)
@@ -43,6 +44,7 @@ func Check_gpu() ([]models.Gpu, error) {
 				}
 				for _, i := range info {
 					gpu.Name = i.GPUName
+					gpu.Vendor = models.GPUVendorAMDATI
 					gpu.FreeVram = i.FreeMemory
 					gpu.TotVram = i.TotalMemory
 					gpu_info = append(gpu_info, gpu)
@@ -0,0 +1,100 @@
+package dms
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/api"
+	"gitlab.com/nunet/device-management-service/db"
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/auth"
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// setupAuth enables the authentication of the API and makes sure the CLI
+// has an admin token in its credentials file
+func setupAuth(ctx context.Context) *auth.Authenticator {
+	authenticator := auth.NewAuthenticator(repositories_gorm.NewAPITokenRepository(db.DB))
+	if err := auth.Bootstrap(ctx, authenticator, auth.CredentialsPath(config.GetConfig())); err != nil {
+		zlog.Sugar().Fatalf("unable to set up the API credentials: %v", err)
+	}
+	api.SetAuthenticator(authenticator)
+	return authenticator
+}
+
+// serverTLSConfig returns the TLS configuration of the API server, which
+// verifies the client certificates issued by the client CA if one is set
+func serverTLSConfig(cfg config.RestTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", cfg.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	// the clients without a certificate authenticate with a token
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	return tlsConfig, nil
+}
+
+// newAPIServer returns an HTTP server of the API
+func newAPIServer(handler http.Handler) *http.Server {
+	return &http.Server{Handler: handler, ConnContext: api.ConnContext, ReadHeaderTimeout: 10 * time.Second}
+}
+
+// listenUnixSocket listens on a Unix socket only the owner and the group of
+// the DMS can connect to, replacing a stale socket file
+func listenUnixSocket(path string) (net.Listener, error) {
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("remove stale socket: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("restrict the permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// serveUnixSocket serves the API on a Unix socket, only the owner and the
+// group of the DMS can connect to it. The DMS keeps running without the
+// socket when it can't listen on it, e.g. when its directory doesn't exist.
+func serveUnixSocket(handler http.Handler, path string) {
+	listener, err := listenUnixSocket(path)
+	if err != nil {
+		zlog.Sugar().Warnf("not serving the API on %s: %v", path, err)
+		return
+	}
+
+	server := newAPIServer(handler)
+	zlog.Sugar().Infof("serving the API on %s", path)
+	go func() {
+		// closing the listener removes the socket file
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			zlog.Sugar().Errorf("API server on %s failed: %v", path, err)
+		}
+	}()
+	internal.Shutdown.Register("API server on "+path, server.Shutdown)
+}
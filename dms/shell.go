@@ -0,0 +1,64 @@
+package dms
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	apiv2 "gitlab.com/nunet/device-management-service/api/v2"
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/executor/docker"
+	"gitlab.com/nunet/device-management-service/executor/firecracker"
+	"gitlab.com/nunet/device-management-service/internal"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/models"
+	nlibp2p "gitlab.com/nunet/device-management-service/network/libp2p"
+)
+
+// setupShells gives the API the shells into the executions run by the
+// executors installed on the machine, nil when the shells are disabled
+func setupShells(ctx context.Context, cfg *config.Config, registry *executor.Registry) *executor.Shells {
+	if !cfg.Shell.Enabled {
+		return nil
+	}
+
+	executors := make(map[string]executor.Shell)
+	// the executions are found by their labels or sockets, whichever
+	// executor instance started them
+	if e, err := docker.NewExecutor(ctx, "shell"); err == nil && e.IsInstalled(ctx) {
+		executors[models.ExecutorTypeDocker] = e
+	}
+	if e, err := firecracker.NewExecutor(ctx, "shell"); err == nil && e.IsInstalled(ctx) {
+		executors[models.ExecutorTypeFirecracker] = e
+	}
+
+	shells := executor.NewShells(registry, executors)
+	apiv2.SetShells(shells)
+	return shells
+}
+
+// serveRemoteShells serves the shells into the local executions to the peers
+// of shell.allowed_peers and lets the API open shells on the other peers
+func serveRemoteShells(cfg *config.Config, h host.Host, shells *executor.Shells) {
+	allowed := make([]peer.ID, 0, len(cfg.Shell.AllowedPeers))
+	for _, id := range cfg.Shell.AllowedPeers {
+		p, err := peer.Decode(id)
+		if err != nil {
+			zlog.Sugar().Warnf("ignoring invalid peer %q of shell.allowed_peers: %v", id, err)
+			continue
+		}
+		allowed = append(allowed, p)
+	}
+
+	var opener nlibp2p.ShellOpener
+	if shells != nil {
+		opener = shells
+	}
+	service := nlibp2p.NewShellService(h, opener, allowed)
+	apiv2.SetRemoteShells(service)
+	internal.Shutdown.Register("shell service", func(_ context.Context) error {
+		service.Close()
+		return nil
+	})
+}
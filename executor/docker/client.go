@@ -21,6 +21,8 @@ import (
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
+
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
 )
 
 // Client wraps the Docker client to provide high-level operations on Docker containers and networks.
@@ -137,6 +139,16 @@ func (c *Client) StopContainer(
 	return c.client.ContainerStop(ctx, containerID, &timeout)
 }
 
+// CheckpointContainer checkpoints a running Docker container and stops it. It
+// requires the experimental checkpoint support (CRIU) of the Docker daemon.
+func (c *Client) CheckpointContainer(ctx context.Context, containerID, checkpointID string) error {
+	return c.client.CheckpointCreate(
+		ctx,
+		containerID,
+		types.CheckpointCreateOptions{CheckpointID: checkpointID, Exit: true},
+	)
+}
+
 // RemoveContainer removes a Docker container, optionally forcing removal and removing associated volumes.
 func (c *Client) RemoveContainer(ctx context.Context, containerID string) error {
 	return c.client.ContainerRemove(
@@ -267,8 +279,55 @@ func (c *Client) FindContainer(ctx context.Context, label string, value string)
 	return "", fmt.Errorf("unable to find container for %s=%s", label, value)
 }
 
+// FindContainerByLabelSuffix searches for a container whose label value ends with suffix, returning its ID if found.
+func (c *Client) FindContainerByLabelSuffix(ctx context.Context, label string, suffix string) (string, error) {
+	containers, err := c.client.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	for _, container := range containers {
+		if strings.HasSuffix(container.Labels[label], suffix) {
+			return container.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("unable to find running container for %s=*%s", label, suffix)
+}
+
+// ExecContainer runs a command in a running container and attaches to its standard streams.
+// It returns the ID of the exec instance and the connection to its streams, which the caller must close.
+func (c *Client) ExecContainer(
+	ctx context.Context,
+	containerID string,
+	config types.ExecConfig,
+) (string, types.HijackedResponse, error) {
+	created, err := c.client.ContainerExecCreate(ctx, containerID, config)
+	if err != nil {
+		return "", types.HijackedResponse{}, errors.Wrap(err, "failed to create exec instance")
+	}
+
+	attached, err := c.client.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{Tty: config.Tty})
+	if err != nil {
+		return "", types.HijackedResponse{}, errors.Wrap(err, "failed to attach to exec instance")
+	}
+	return created.ID, attached, nil
+}
+
+// ResizeExec resizes the TTY of an exec instance.
+func (c *Client) ResizeExec(ctx context.Context, execID string, rows, cols uint) error {
+	return c.client.ContainerExecResize(ctx, execID, types.ResizeOptions{Height: rows, Width: cols})
+}
+
+// InspectExec returns the state of an exec instance, e.g. its exit code once it is not running anymore.
+func (c *Client) InspectExec(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	return c.client.ContainerExecInspect(ctx, execID)
+}
+
 // PullImage pulls a Docker image from a registry.
-func (c *Client) PullImage(ctx context.Context, imageName string) (string, error) {
+func (c *Client) PullImage(ctx context.Context, imageName string) (digest string, err error) {
+	defer func(start time.Time) { metrics.ObserveImagePull("docker", start, err) }(time.Now())
+
 	out, err := c.client.ImagePull(ctx, imageName, types.ImagePullOptions{})
 	if err != nil {
 		zlog.Sugar().Errorf("unable to pull image: %v", err)
@@ -279,7 +338,6 @@ func (c *Client) PullImage(ctx context.Context, imageName string) (string, error
 	d := json.NewDecoder(io.TeeReader(out, os.Stdout))
 
 	var message jsonmessage.JSONMessage
-	var digest string
 	for {
 		if err := d.Decode(&message); err != nil {
 			if err == io.EOF {
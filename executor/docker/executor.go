@@ -12,8 +12,13 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
 
+	"gitlab.com/nunet/device-management-service/executor"
 	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
 	"gitlab.com/nunet/device-management-service/utils"
 )
 
@@ -34,6 +39,11 @@ type Executor struct {
 	client   *Client                                  // Docker client for container management.
 }
 
+var (
+	_ executor.Executor = (*Executor)(nil)
+	_ executor.Shell    = (*Executor)(nil)
+)
+
 // NewExecutor initializes a new Executor instance with a Docker client.
 func NewExecutor(_ context.Context, id string) (*Executor, error) {
 	dockerClient, err := NewDockerClient()
@@ -54,6 +64,16 @@ func (e *Executor) IsInstalled(ctx context.Context) bool {
 
 // Start begins the execution of a request by starting a Docker container.
 func (e *Executor) Start(ctx context.Context, request *models.ExecutionRequest) error {
+	// the start joins the trace of the request, which may come from another DMS
+	ctx, span := tracer.Start(telemetry.ContextWithTraceInfo(ctx, request.TraceInfo), "executor start",
+		trace.WithAttributes(
+			attribute.String("executor", "docker"),
+			attribute.String("job.id", request.JobID),
+			attribute.String("execution.id", request.ExecutionID),
+		),
+	)
+	defer span.End()
+
 	zlog.Sugar().
 		Infof("Starting execution for job %s, execution %s", request.JobID, request.ExecutionID)
 
@@ -245,6 +265,20 @@ func (e *Executor) Cleanup(ctx context.Context) error {
 	return nil
 }
 
+// Shutdown checkpoints the running containers so that their executions can be
+// restored once the DMS is restarted. Containers that can't be checkpointed are
+// stopped.
+func (e *Executor) Shutdown(ctx context.Context) error {
+	var errs error
+	e.handlers.Iter(func(executionID string, handler *executionHandler) bool {
+		if err := handler.checkpoint(ctx); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to stop execution (%s): %w", executionID, err))
+		}
+		return true
+	})
+	return errs
+}
+
 // newDockerExecutionContainer is an internal method called by Start to set up a new Docker container
 // for the job execution. It configures the container based on the provided ExecutionRequest.
 // This includes decoding engine specifications, setting up environment variables, mounts and resource
@@ -440,6 +474,11 @@ func (e *Executor) containerLabels(jobID string, executionID string) map[string]
 	}
 }
 
+// checkpointID returns the name of the checkpoint created for the execution on shutdown.
+func checkpointID(executionID string) string {
+	return fmt.Sprintf("nunet-%s", executionID)
+}
+
 // labelJobValue returns the value for the job label.
 func labelJobValue(executorID string, jobID string) string {
 	return fmt.Sprintf("%s_%s", executorID, jobID)
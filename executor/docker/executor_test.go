@@ -2,11 +2,14 @@ package docker_test
 
 import (
 	"context"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"gitlab.com/nunet/device-management-service/executor"
 	"gitlab.com/nunet/device-management-service/executor/docker"
 	"gitlab.com/nunet/device-management-service/models"
 )
@@ -81,3 +84,35 @@ func (s *ExecutorTestSuite) TestWaitJob() {
 		require.NoError(s.T(), err)
 	}
 }
+
+// Test Exec tests the Exec method of the Docker executor.
+func (s *ExecutorTestSuite) TestExec() {
+	request := s.newJobRequest()
+	request.EngineSpec = docker.NewDockerEngineBuilder(defaultImage).WithCmd("sleep", "30").Build()
+	require.NoError(s.T(), s.executor.Start(context.Background(), request))
+
+	var session executor.ShellSession
+	require.Eventually(s.T(), func() bool {
+		var err error
+		session, err = s.executor.Exec(context.Background(), request.ExecutionID, models.ShellRequest{
+			Command: []string{"sh", "-c", "cat; exit 3"},
+		})
+		return err == nil
+	}, 30*time.Second, 500*time.Millisecond)
+	defer session.Close()
+
+	_, err := session.Write([]byte("hello\n"))
+	require.NoError(s.T(), err)
+	output := make([]byte, 6)
+	_, err = io.ReadFull(session, output)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "hello\n", string(output))
+
+	require.NoError(s.T(), session.Close())
+	code, err := session.Wait(context.Background())
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 3, code)
+
+	_, err = s.executor.Exec(context.Background(), "unknown_execution", models.ShellRequest{})
+	require.Error(s.T(), err)
+}
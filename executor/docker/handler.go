@@ -7,14 +7,26 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
 	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
 )
 
 const DestroyTimeout = time.Second * 10
 
+// maxLogLineSize is the size of the longest log line published, the rest of
+// the logs of an execution writing a longer line isn't published
+const maxLogLineSize = 64 * 1024
+
+// logsDrainTimeout bounds the wait for the last log lines once the container stopped
+const logsDrainTimeout = 5 * time.Second
+
 // executionHandler manages the lifecycle and execution of a Docker container for a specific job.
 type executionHandler struct {
 	// provided by the executor
@@ -30,10 +42,16 @@ type executionHandler struct {
 	// synchronization
 	activeCh chan bool    // Blocks until the container starts running.
 	waitCh   chan bool    // BLocks until execution completes or fails.
+	logsCh   chan bool    // Closed once the logs of the running container are published.
 	running  *atomic.Bool // Indicates if the container is currently running.
 
+	// checkpointed is set when the container was checkpointed on shutdown, the
+	// container is then kept so that the execution can be restored.
+	checkpointed atomic.Bool
+
 	// result of the execution
-	result *models.ExecutionResult
+	result    *models.ExecutionResult
+	startedAt time.Time // Time the execution started, accounted when it finishes.
 }
 
 // active checks if the execution handler's container is running.
@@ -45,10 +63,23 @@ func (h *executionHandler) active() bool {
 func (h *executionHandler) run(ctx context.Context) {
 	h.running.Store(true)
 	defer func() {
-		if err := h.destroy(DestroyTimeout); err != nil {
+		if h.checkpointed.Load() {
+			zlog.Sugar().Infof("keeping checkpointed container %s", h.containerID)
+		} else if err := h.destroy(DestroyTimeout); err != nil {
 			zlog.Sugar().Warnf("failed to destroy container: %v\n", err)
 		}
 		h.running.Store(false)
+		// the lines are published before the end of the execution so that
+		// the log streams following it get them all
+		if h.logsCh != nil {
+			select {
+			case <-h.logsCh:
+			case <-time.After(logsDrainTimeout):
+			}
+		}
+		events.Publish(events.ExecutionFinished, h.event())
+		// the execution context may be cancelled, the accounting event is observed regardless
+		h.account(context.Background(), events.ExecutionFinished)
 		close(h.waitCh)
 	}()
 
@@ -58,6 +89,14 @@ func (h *executionHandler) run(ctx context.Context) {
 	}
 
 	close(h.activeCh) // Indicate that the container has started.
+	h.startedAt = time.Now()
+	events.Publish(events.ExecutionStarted, h.event())
+	h.account(ctx, events.ExecutionStarted)
+	h.logsCh = make(chan bool)
+	go func() {
+		defer close(h.logsCh)
+		h.followLogs(ctx)
+	}()
 
 	var containerError error
 	var containerExitStatusCode int64
@@ -130,6 +169,24 @@ func (h *executionHandler) kill(ctx context.Context) error {
 	return h.client.StopContainer(ctx, h.containerID, DestroyTimeout)
 }
 
+// checkpoint checkpoints the container so that it can be restored after a
+// restart and stops it. The container is stopped instead when checkpointing fails.
+func (h *executionHandler) checkpoint(ctx context.Context) error {
+	if !h.active() {
+		return nil
+	}
+
+	h.checkpointed.Store(true)
+	err := h.client.CheckpointContainer(ctx, h.containerID, checkpointID(h.executionID))
+	if err == nil {
+		return nil
+	}
+	h.checkpointed.Store(false)
+
+	zlog.Sugar().Warnf("failed to checkpoint container %s, stopping it: %v", h.containerID, err)
+	return h.kill(ctx)
+}
+
 // destroy cleans up the container and its associated resources.
 func (h *executionHandler) destroy(timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -166,5 +223,105 @@ func (h *executionHandler) outputStream(
 	case <-h.activeCh: // Ensure the container is active before attempting to stream logs.
 	}
 	// Gets the underlying reader, and provides data since the value of the `since` timestamp.
-	return h.client.GetOutputStream(ctx, h.containerID, since, request.Follow)
+	logs, err := h.client.GetOutputStream(ctx, h.containerID, since, request.Follow)
+	if err != nil {
+		return nil, err
+	}
+
+	// the logs of containers without a TTY multiplex stdout and stderr
+	output, writer := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(writer, writer, logs)
+		writer.CloseWithError(err)
+	}()
+	return &outputStream{PipeReader: output, logs: logs}, nil
+}
+
+// outputStream is the demultiplexed output of a container, closing it stops
+// following the logs
+type outputStream struct {
+	*io.PipeReader
+	logs io.ReadCloser
+}
+
+func (s *outputStream) Close() error {
+	s.PipeReader.Close()
+	return s.logs.Close()
+}
+
+// followLogs publishes the lines written by the container until it stops
+func (h *executionHandler) followLogs(ctx context.Context) {
+	logs, err := h.client.GetOutputStream(ctx, h.containerID, "1", true)
+	if err != nil {
+		// e.g. the container already exited, its logs are in the result
+		zlog.Sugar().Debugf("unable to follow the logs of container %s: %v", h.containerID, err)
+		return
+	}
+	defer logs.Close()
+	h.publishLogs(logs)
+}
+
+// publishLogs publishes the lines of the multiplexed stdout and stderr of the
+// container as ExecutionLog events on the Logs bus
+func (h *executionHandler) publishLogs(logs io.Reader) {
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(stdoutWriter, stderrWriter, logs)
+		stdoutWriter.CloseWithError(err)
+		stderrWriter.CloseWithError(err)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go h.publishLines(&wg, stdout, "stdout")
+	go h.publishLines(&wg, stderr, "stderr")
+	wg.Wait()
+}
+
+func (h *executionHandler) publishLines(wg *sync.WaitGroup, r io.Reader, stream string) {
+	defer wg.Done()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLogLineSize)
+	for scanner.Scan() {
+		events.Logs.Publish(events.ExecutionLog, events.Log{
+			Executor:    "docker",
+			JobID:       h.jobID,
+			ExecutionID: h.executionID,
+			Stream:      stream,
+			Line:        scanner.Text(),
+		})
+	}
+	// a line too long stops the scanner, the rest is drained so that the
+	// other stream isn't blocked
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// event returns the payload of the lifecycle events of the execution.
+func (h *executionHandler) event() events.Execution {
+	return events.Execution{
+		Executor:    "docker",
+		JobID:       h.jobID,
+		ExecutionID: h.executionID,
+		Result:      h.result,
+	}
+}
+
+// account observes an accounting event of the execution lifecycle, with the
+// time the execution ran for and its exit code once it finished.
+func (h *executionHandler) account(ctx context.Context, name events.Type) {
+	fields := telemetry.Fields{
+		"executor":     "docker",
+		"job_id":       h.jobID,
+		"execution_id": h.executionID,
+	}
+	if name == events.ExecutionFinished {
+		if !h.startedAt.IsZero() {
+			fields["duration_seconds"] = time.Since(h.startedAt).Seconds()
+		}
+		if h.result != nil {
+			fields["exit_code"] = h.result.ExitCode
+		}
+	}
+	telemetry.Observe(ctx, telemetry.Accounting, telemetry.InfoLevel, string(name), fields)
 }
@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+)
+
+func TestPublishLogs(t *testing.T) {
+	sub := events.Logs.Subscribe(8, events.Types(events.ExecutionLog), func(e events.Event) bool {
+		return e.Payload.(events.Log).ExecutionID == "exec-logs"
+	})
+	defer sub.Close()
+
+	var logs bytes.Buffer
+	_, err := stdcopy.NewStdWriter(&logs, stdcopy.Stdout).Write([]byte("hello\nworld\n"))
+	require.NoError(t, err)
+	_, err = stdcopy.NewStdWriter(&logs, stdcopy.Stderr).Write([]byte("oops"))
+	require.NoError(t, err)
+
+	h := &executionHandler{jobID: "job-logs", executionID: "exec-logs"}
+	h.publishLogs(&logs)
+
+	lines := map[string][]string{}
+	for len(sub.C) > 0 {
+		e := <-sub.C
+		log := e.Payload.(events.Log)
+		assert.Equal(t, "job-logs", log.JobID)
+		lines[log.Stream] = append(lines[log.Stream], log.Line)
+	}
+	assert.Equal(t, []string{"hello", "world"}, lines["stdout"])
+	assert.Equal(t, []string{"oops"}, lines["stderr"])
+
+	done := make(chan struct{})
+	go func() {
+		h.publishLogs(bytes.NewReader([]byte("not multiplexed")))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishLogs blocked on invalid logs")
+	}
+}
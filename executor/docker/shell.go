@@ -0,0 +1,133 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+const (
+	defaultShell = "/bin/sh"
+
+	execInspectTickTime = 100 * time.Millisecond
+)
+
+// Exec runs a command in the container of a running execution and returns
+// the session attached to its standard streams. The command defaults to the
+// shell of the container.
+func (e *Executor) Exec(
+	ctx context.Context,
+	executionID string,
+	request models.ShellRequest,
+) (executor.ShellSession, error) {
+	containerID, err := e.findExecutionContainer(ctx, executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	config := types.ExecConfig{
+		Tty:          request.TTY,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          request.Command,
+	}
+	if len(config.Cmd) == 0 {
+		config.Cmd = []string{defaultShell}
+	}
+	if request.TTY {
+		config.Env = []string{"TERM=xterm"}
+	}
+
+	execID, conn, err := e.client.ExecContainer(ctx, containerID, config)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &execSession{client: e.client, execID: execID, conn: conn}
+	if request.TTY {
+		session.output = conn.Reader
+		if request.Size.Rows > 0 && request.Size.Cols > 0 {
+			if err := session.Resize(ctx, request.Size); err != nil {
+				zlog.Sugar().Warnf("failed to resize the TTY of execution %s: %v", executionID, err)
+			}
+		}
+	} else {
+		// without a TTY the output is multiplexed, stdout and stderr are merged
+		r, w := io.Pipe()
+		go func() {
+			_, err := stdcopy.StdCopy(w, w, conn.Reader)
+			w.CloseWithError(err)
+		}()
+		session.output = r
+	}
+	return session, nil
+}
+
+// findExecutionContainer returns the container of a running execution. An
+// execution started before the DMS restarted has no handler, its container is
+// then found by its label.
+func (e *Executor) findExecutionContainer(ctx context.Context, executionID string) (string, error) {
+	if handler, ok := e.handlers.Get(executionID); ok {
+		if !handler.active() {
+			return "", fmt.Errorf("execution (%s) is not running", executionID)
+		}
+		return handler.containerID, nil
+	}
+	return e.client.FindContainerByLabelSuffix(ctx, labelExecutionID, "_"+executionID)
+}
+
+// execSession is a command running in a container, see executor.ShellSession.
+type execSession struct {
+	client *Client
+	execID string
+	conn   types.HijackedResponse
+	output io.Reader
+}
+
+func (s *execSession) Read(p []byte) (int, error) {
+	return s.output.Read(p)
+}
+
+func (s *execSession) Write(p []byte) (int, error) {
+	return s.conn.Conn.Write(p)
+}
+
+// Close detaches from the command and closes its input, a shell then exits.
+func (s *execSession) Close() error {
+	s.conn.Close()
+	return nil
+}
+
+func (s *execSession) Resize(ctx context.Context, size models.TerminalSize) error {
+	return s.client.ResizeExec(ctx, s.execID, uint(size.Rows), uint(size.Cols))
+}
+
+// Wait polls the exec instance until the command exits.
+func (s *execSession) Wait(ctx context.Context) (int, error) {
+	ticker := time.NewTicker(execInspectTickTime)
+	defer ticker.Stop()
+
+	for {
+		inspect, err := s.client.InspectExec(ctx, s.execID)
+		if err != nil {
+			return 0, err
+		}
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
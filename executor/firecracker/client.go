@@ -61,8 +61,11 @@ func (c *Client) DestroyVM(
 	m *firecracker.Machine,
 	timeout time.Duration,
 ) error {
-	// Remove the socket file.
+	// Remove the socket files.
 	defer os.Remove(m.Cfg.SocketPath)
+	for _, vsock := range m.Cfg.VsockDevices {
+		defer os.Remove(vsock.Path)
+	}
 
 	// Get the PID of the Firecracker process and shut down the VM.
 	// If the process is still running after the timeout, kill it.
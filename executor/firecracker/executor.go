@@ -3,6 +3,7 @@ package firecracker
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 	"sync/atomic"
@@ -10,9 +11,13 @@ import (
 
 	"github.com/firecracker-microvm/firecracker-go-sdk"
 	fcModels "github.com/firecracker-microvm/firecracker-go-sdk/client/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
 
+	"gitlab.com/nunet/device-management-service/executor"
 	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
 	"gitlab.com/nunet/device-management-service/utils"
 )
 
@@ -31,6 +36,11 @@ type Executor struct {
 	client   *Client                                  // Firecracker client for VM management.
 }
 
+var (
+	_ executor.Executor = (*Executor)(nil)
+	_ executor.Shell    = (*Executor)(nil)
+)
+
 // NewExecutor initializes a new executor for Firecracker VMs.
 func NewExecutor(
 	_ context.Context,
@@ -55,6 +65,16 @@ func (e *Executor) IsInstalled(ctx context.Context) bool {
 
 // start begins the execution of a request by starting a new Firecracker VM.
 func (e *Executor) Start(ctx context.Context, request *models.ExecutionRequest) error {
+	// the start joins the trace of the request, which may come from another DMS
+	ctx, span := tracer.Start(telemetry.ContextWithTraceInfo(ctx, request.TraceInfo), "executor start",
+		trace.WithAttributes(
+			attribute.String("executor", "firecracker"),
+			attribute.String("job.id", request.JobID),
+			attribute.String("execution.id", request.ExecutionID),
+		),
+	)
+	defer span.End()
+
 	zlog.Sugar().
 		Infof("Starting execution for job %s, execution %s", request.JobID, request.ExecutionID)
 
@@ -162,6 +182,12 @@ func (e *Executor) Cancel(ctx context.Context, executionID string) error {
 	return handler.kill(ctx)
 }
 
+// GetLogStream isn't supported, the output of a VM is only available in the
+// result of its execution.
+func (e *Executor) GetLogStream(_ context.Context, _ models.LogStreamRequest) (io.ReadCloser, error) {
+	return nil, executor.ErrLogStreamUnsupported
+}
+
 // Run initiates and waits for the completion of an execution in one call.
 // This method serves as a higher-level convenience function that
 // internally calls Start and Wait methods.
@@ -211,6 +237,21 @@ func (e *Executor) Cleanup(ctx context.Context) error {
 	return errs
 }
 
+// Shutdown stops the running VMs. Their sockets are kept and are removed by Cleanup.
+func (e *Executor) Shutdown(ctx context.Context) error {
+	var errs error
+	e.handlers.Iter(func(executionID string, handler *executionHandler) bool {
+		if !handler.active() {
+			return true
+		}
+		if err := handler.kill(ctx); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to stop execution (%s): %w", executionID, err))
+		}
+		return true
+	})
+	return errs
+}
+
 // newFirecrackerExecutionVM is an internal method called by Start to set up a new Firecracker VM
 // for the job execution. It configures the VM based on the provided ExecutionRequest.
 // This includes decoding engine specifications, setting up mounts and resource constraints.
@@ -235,6 +276,12 @@ func (e *Executor) newFirecrackerExecutionVM(
 			VcpuCount:  firecracker.Int64(int64(params.Resources.CPU)),
 			MemSizeMib: firecracker.Int64(int64(params.Resources.Memory)),
 		},
+		// the shell agent of the guest is reached through the vsock, see Exec
+		VsockDevices: []firecracker.VsockDevice{{
+			ID:   "shell",
+			Path: e.generateVsockPath(params.JobID, params.ExecutionID),
+			CID:  guestCID,
+		}},
 	}
 
 	mounts, err := makeVMMounts(
@@ -306,3 +353,8 @@ func (e *Executor) FindRunningVM(
 func (e *Executor) generateSocketPath(jobID string, executionID string) string {
 	return fmt.Sprintf("%s/%s_%s_%s.sock", socketDir, e.ID, jobID, executionID)
 }
+
+// generateVsockPath generates the path of the host side of the vsock of the VM based on the job identifiers.
+func (e *Executor) generateVsockPath(jobID string, executionID string) string {
+	return fmt.Sprintf("%s/%s_%s_%s.vsock", socketDir, e.ID, jobID, executionID)
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/firecracker-microvm/firecracker-go-sdk"
 
+	"gitlab.com/nunet/device-management-service/internal/events"
 	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
 )
 
 // executionHandler is a struct that holds the necessary information to manage the execution of a firecracker VM.
@@ -31,7 +33,8 @@ type executionHandler struct {
 	running  *atomic.Bool // Indicates if the container is currently running.
 
 	// result of the execution
-	result *models.ExecutionResult
+	result    *models.ExecutionResult
+	startedAt time.Time // Time the execution started, accounted when it finishes.
 }
 
 // active returns true if the firecracker VM is running.
@@ -49,6 +52,9 @@ func (h *executionHandler) run(ctx context.Context) {
 			zlog.Sugar().Warnf("failed to destroy container: %v\n", err)
 		}
 		h.running.Store(false)
+		events.Publish(events.ExecutionFinished, h.event())
+		// the execution context may be cancelled, the accounting event is observed regardless
+		h.account(context.Background(), events.ExecutionFinished)
 		close(h.waitCh)
 	}()
 
@@ -60,6 +66,9 @@ func (h *executionHandler) run(ctx context.Context) {
 	}
 
 	close(h.activeCh) // Indicate that the VM has started.
+	h.startedAt = time.Now()
+	events.Publish(events.ExecutionStarted, h.event())
+	h.account(ctx, events.ExecutionStarted)
 
 	err := h.machine.Wait(ctx)
 	if err != nil {
@@ -88,3 +97,32 @@ func (h *executionHandler) destroy(timeout time.Duration) error {
 
 	return h.client.DestroyVM(ctx, h.machine, timeout)
 }
+
+// event returns the payload of the lifecycle events of the execution.
+func (h *executionHandler) event() events.Execution {
+	return events.Execution{
+		Executor:    "firecracker",
+		JobID:       h.JobID,
+		ExecutionID: h.executionID,
+		Result:      h.result,
+	}
+}
+
+// account observes an accounting event of the execution lifecycle, with the
+// time the execution ran for and its exit code once it finished.
+func (h *executionHandler) account(ctx context.Context, name events.Type) {
+	fields := telemetry.Fields{
+		"executor":     "firecracker",
+		"job_id":       h.JobID,
+		"execution_id": h.executionID,
+	}
+	if name == events.ExecutionFinished {
+		if !h.startedAt.IsZero() {
+			fields["duration_seconds"] = time.Since(h.startedAt).Seconds()
+		}
+		if h.result != nil {
+			fields["exit_code"] = h.result.ExitCode
+		}
+	}
+	telemetry.Observe(ctx, telemetry.Accounting, telemetry.InfoLevel, string(name), fields)
+}
@@ -1,10 +1,17 @@
 package firecracker
 
 import (
+	"go.opentelemetry.io/otel"
+
 	"gitlab.com/nunet/device-management-service/telemetry/logger"
 )
 
-var zlog *logger.Logger
+var (
+	zlog *logger.Logger
+
+	// tracer starts the spans of the executions, it follows the global tracer provider
+	tracer = otel.Tracer("gitlab.com/nunet/device-management-service/executor/firecracker")
+)
 
 func init() {
 	zlog = logger.New("executor.firecracker")
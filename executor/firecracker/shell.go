@@ -0,0 +1,230 @@
+package firecracker
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+const (
+	// guestCID is the context identifier of the guest side of the vsock.
+	guestCID = 3
+	// shellAgentPort is the vsock port the shell agent of the guest listens on.
+	shellAgentPort = 52
+
+	vsockDialTimeout = 5 * time.Second
+	maxFrameSize     = 1 << 20
+)
+
+// The frames exchanged with the shell agent of the guest: a type byte, the
+// length of the payload as a big-endian uint32 and the payload.
+const (
+	frameExec   byte = iota + 1 // host to guest, JSON models.ShellRequest, sent first
+	frameInput                  // host to guest, input of the command, empty once closed
+	frameOutput                 // guest to host, output of the command
+	frameResize                 // host to guest, JSON models.TerminalSize
+	frameExit                   // guest to host, JSON shellExit, sent last
+)
+
+// shellExit is the payload of the exit frame.
+type shellExit struct {
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Exec runs a command in the VM of a running execution through the shell
+// agent listening on the vsock of the guest, and returns the session attached
+// to it. The command defaults to the shell of the guest.
+func (e *Executor) Exec(
+	ctx context.Context,
+	executionID string,
+	request models.ShellRequest,
+) (executor.ShellSession, error) {
+	path, err := e.findExecutionVsock(executionID)
+	if err != nil {
+		return nil, err
+	}
+	return dialShellAgent(ctx, path, request)
+}
+
+// findExecutionVsock returns the vsock path of the VM of a running execution.
+// An execution started before the DMS restarted has no handler, its vsock is
+// then found by its name.
+func (e *Executor) findExecutionVsock(executionID string) (string, error) {
+	if handler, ok := e.handlers.Get(executionID); ok {
+		if !handler.active() {
+			return "", fmt.Errorf("execution (%s) is not running", executionID)
+		}
+		return e.generateVsockPath(handler.JobID, executionID), nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(socketDir, "*_"+executionID+".vsock"))
+	if err != nil || len(matches) == 0 {
+		return "", fmt.Errorf("execution (%s) not found", executionID)
+	}
+	return matches[0], nil
+}
+
+// dialShellAgent connects to the shell agent through the host side of the
+// vsock and sends it the request.
+func dialShellAgent(ctx context.Context, path string, request models.ShellRequest) (*vsockSession, error) {
+	d := net.Dialer{Timeout: vsockDialTimeout}
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the vsock: %w", err)
+	}
+
+	// the host initiated connections start with a handshake with firecracker,
+	// see https://github.com/firecracker-microvm/firecracker/blob/main/docs/vsock.md
+	_ = conn.SetDeadline(time.Now().Add(vsockDialTimeout))
+	reader := bufio.NewReader(conn)
+	if _, err := fmt.Fprintf(conn, "CONNECT %d\n", shellAgentPort); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to the shell agent: %w", err)
+	}
+	ack, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(ack, "OK ") {
+		conn.Close()
+		return nil, fmt.Errorf("the shell agent of the guest is not listening on port %d", shellAgentPort)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s := &vsockSession{conn: conn, exited: make(chan struct{})}
+	if err := s.writeFrame(frameExec, payload); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r, w := io.Pipe()
+	s.output = r
+	go s.readFrames(reader, w)
+	return s, nil
+}
+
+// vsockSession is a command run by the shell agent of a guest, see
+// executor.ShellSession.
+type vsockSession struct {
+	conn   net.Conn
+	output *io.PipeReader
+
+	writeMu sync.Mutex
+
+	exited chan struct{}
+	exit   shellExit
+	err    error
+}
+
+// readFrames copies the output of the command until it exits or the
+// connection is closed.
+func (s *vsockSession) readFrames(r io.Reader, output *io.PipeWriter) {
+	defer close(s.exited)
+
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			s.err = fmt.Errorf("connection to the shell agent lost: %w", err)
+			output.CloseWithError(err)
+			return
+		}
+		size := binary.BigEndian.Uint32(header[1:])
+		if size > maxFrameSize {
+			s.err = fmt.Errorf("frame of %d bytes exceeds the maximum size", size)
+			output.CloseWithError(s.err)
+			return
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			s.err = fmt.Errorf("connection to the shell agent lost: %w", err)
+			output.CloseWithError(err)
+			return
+		}
+
+		switch header[0] {
+		case frameOutput:
+			if _, err := output.Write(payload); err != nil {
+				s.err = fmt.Errorf("session closed before the command exited")
+				return
+			}
+		case frameExit:
+			if err := json.Unmarshal(payload, &s.exit); err != nil {
+				s.err = fmt.Errorf("invalid exit frame: %w", err)
+			} else if s.exit.Error != "" {
+				s.err = fmt.Errorf("shell agent: %s", s.exit.Error)
+			}
+			output.Close()
+			return
+		}
+	}
+}
+
+func (s *vsockSession) writeFrame(typ byte, payload []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	header := make([]byte, 5)
+	header[0] = typ
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := s.conn.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("failed to write to the shell agent: %w", err)
+	}
+	return nil
+}
+
+func (s *vsockSession) Read(p []byte) (int, error) {
+	return s.output.Read(p)
+}
+
+func (s *vsockSession) Write(p []byte) (int, error) {
+	for sent := 0; sent < len(p); {
+		n := len(p) - sent
+		if n > maxFrameSize {
+			n = maxFrameSize
+		}
+		if err := s.writeFrame(frameInput, p[sent:sent+n]); err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return len(p), nil
+}
+
+// Close closes the input of the command and the connection to the agent.
+func (s *vsockSession) Close() error {
+	_ = s.writeFrame(frameInput, nil)
+	s.output.Close()
+	return s.conn.Close()
+}
+
+func (s *vsockSession) Resize(_ context.Context, size models.TerminalSize) error {
+	payload, err := json.Marshal(size)
+	if err != nil {
+		return err
+	}
+	return s.writeFrame(frameResize, payload)
+}
+
+// Wait waits for the exit frame of the agent.
+func (s *vsockSession) Wait(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-s.exited:
+		return s.exit.ExitCode, s.err
+	}
+}
@@ -0,0 +1,125 @@
+package firecracker
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// fakeAgent serves the host side of a vsock with a shell agent echoing the
+// input of the command until it is closed, the command then exits with the
+// number of resizes.
+func fakeAgent(t *testing.T) (string, <-chan models.ShellRequest) {
+	path := filepath.Join(t.TempDir(), "agent.vsock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	requests := make(chan models.ShellRequest, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		if line, err := r.ReadString('\n'); err != nil || line != "CONNECT 52\n" {
+			return
+		}
+		_, _ = conn.Write([]byte("OK 1073741824\n"))
+
+		write := func(typ byte, payload []byte) {
+			header := []byte{typ, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+			_, _ = conn.Write(append(header, payload...))
+		}
+		resizes := 0
+		for {
+			header := make([]byte, 5)
+			if _, err := io.ReadFull(r, header); err != nil {
+				return
+			}
+			payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+			switch header[0] {
+			case frameExec:
+				var request models.ShellRequest
+				_ = json.Unmarshal(payload, &request)
+				requests <- request
+			case frameResize:
+				resizes++
+			case frameInput:
+				if len(payload) == 0 {
+					exit, _ := json.Marshal(shellExit{ExitCode: resizes})
+					write(frameExit, exit)
+					return
+				}
+				write(frameOutput, payload)
+			}
+		}
+	}()
+	return path, requests
+}
+
+func TestVsockSession(t *testing.T) {
+	path, requests := fakeAgent(t)
+	ctx := context.Background()
+
+	session, err := dialShellAgent(ctx, path, models.ShellRequest{Command: []string{"cat"}, TTY: true})
+	require.NoError(t, err)
+	request := <-requests
+	assert.Equal(t, []string{"cat"}, request.Command)
+	assert.True(t, request.TTY)
+
+	_, err = session.Write([]byte("hello"))
+	require.NoError(t, err)
+	output := make([]byte, 5)
+	_, err = io.ReadFull(session, output)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(output))
+
+	require.NoError(t, session.Resize(ctx, models.TerminalSize{Rows: 24, Cols: 80}))
+	require.NoError(t, session.Resize(ctx, models.TerminalSize{Rows: 50, Cols: 120}))
+	require.NoError(t, session.writeFrame(frameInput, nil))
+
+	code, err := session.Wait(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, code)
+	_, err = session.Read(output)
+	assert.ErrorIs(t, err, io.EOF)
+	require.NoError(t, session.Close())
+}
+
+func TestVsockSessionNoAgent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.vsock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			// firecracker closes the connection when nothing listens on the port
+			conn.Close()
+		}
+	}()
+
+	_, err = dialShellAgent(context.Background(), path, models.ShellRequest{})
+	assert.ErrorContains(t, err, "not listening")
+
+	e := &Executor{ID: "test"}
+	_, err = e.Exec(context.Background(), "unknown_execution", models.ShellRequest{})
+	assert.Error(t, err)
+}
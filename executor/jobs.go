@@ -0,0 +1,185 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+var (
+	// ErrInvalidJobSpec is returned when running a job spec which isn't valid
+	ErrInvalidJobSpec = errors.New("invalid job spec")
+	// ErrExecutorUnavailable is returned when running a job whose engine has no executor on the machine
+	ErrExecutorUnavailable = errors.New("no executor available for the engine")
+	// ErrLogStreamUnsupported is returned by the executors unable to stream the output of their executions
+	ErrLogStreamUnsupported = errors.New("the executor doesn't stream logs")
+)
+
+// Jobs runs the jobs submitted to the DMS, e.g. with nunet job run, with the
+// executors installed on the machine. Their executions are followed by the
+// registry like the others.
+type Jobs struct {
+	registry   *Registry
+	executors  map[string]Executor // by engine type, e.g. docker
+	resultsDir string
+
+	mu      sync.Mutex
+	started map[string]string // engine type of the executions started, by execution ID
+}
+
+// NewJobs returns the jobs run with executors, keyed by the engine type of
+// the specs, whose results are stored in a directory per job in resultsDir
+func NewJobs(registry *Registry, executors map[string]Executor, resultsDir string) *Jobs {
+	return &Jobs{
+		registry:   registry,
+		executors:  executors,
+		resultsDir: resultsDir,
+		started:    make(map[string]string),
+	}
+}
+
+// Engines returns the engine types the jobs can run with, sorted
+func (j *Jobs) Engines() []string {
+	engines := make([]string, 0, len(j.executors))
+	for engine := range j.executors {
+		engines = append(engines, engine)
+	}
+	sort.Strings(engines)
+	return engines
+}
+
+// Run starts an execution of a new job for spec and returns it once started,
+// the image of the engine may be pulled first
+func (j *Jobs) Run(ctx context.Context, spec models.JobSpec) (models.ExecutionInfo, error) {
+	if err := spec.Validate(); err != nil {
+		return models.ExecutionInfo{}, fmt.Errorf("%w: %v", ErrInvalidJobSpec, err)
+	}
+	engine := strings.ToLower(strings.TrimSpace(spec.Engine.Type))
+	e, ok := j.executors[engine]
+	if !ok {
+		return models.ExecutionInfo{}, fmt.Errorf("%w: %s", ErrExecutorUnavailable, spec.Engine.Type)
+	}
+
+	request := &models.ExecutionRequest{
+		JobID:       uuid.NewString(),
+		ExecutionID: uuid.NewString(),
+		EngineSpec:  spec.Engine,
+		Resources:   spec.Resources,
+		Inputs:      spec.Inputs,
+		Outputs:     spec.Outputs,
+		TraceInfo:   telemetry.TraceInfo(ctx),
+	}
+	if request.Resources == nil {
+		request.Resources = &models.ExecutionResources{}
+	}
+	if len(spec.Outputs) > 0 {
+		request.ResultsDir = filepath.Join(j.resultsDir, request.JobID)
+	}
+
+	// the executions outlive the requests starting them, they only keep
+	// their trace context
+	if err := e.Start(context.Background(), request); err != nil {
+		return models.ExecutionInfo{}, fmt.Errorf("failed to start the job: %w", err)
+	}
+	j.mu.Lock()
+	j.started[request.ExecutionID] = engine
+	j.mu.Unlock()
+
+	return models.ExecutionInfo{
+		ID:        request.ExecutionID,
+		JobID:     request.JobID,
+		Executor:  engine,
+		Status:    models.ExecutionStatusRunning,
+		StartedAt: time.Now(),
+	}, nil
+}
+
+// Execution returns an execution of the registry
+func (j *Jobs) Execution(id string) (models.ExecutionInfo, bool) {
+	return j.registry.Execution(id)
+}
+
+// Executions returns the executions of the registry, sorted by start time
+func (j *Jobs) Executions() []models.ExecutionInfo {
+	return j.registry.Executions()
+}
+
+// Cancel cancels a running execution
+func (j *Jobs) Cancel(ctx context.Context, executionID string) error {
+	info, known := j.registry.Execution(executionID)
+	if known && info.FinishedAt != nil {
+		return ErrExecutionNotRunning
+	}
+	e, err := j.executor(executionID)
+	if err != nil {
+		return err
+	}
+	if err := e.Cancel(ctx, executionID); err != nil {
+		return fmt.Errorf("failed to cancel execution %s: %w", executionID, err)
+	}
+	return nil
+}
+
+// Logs returns the output of an execution, stdout and stderr merged. The
+// output of a running execution is streamed by its executor and followed
+// until it finishes with follow, the output of a finished one is read from
+// its result.
+func (j *Jobs) Logs(ctx context.Context, executionID string, follow bool) (io.ReadCloser, error) {
+	if info, ok := j.registry.Execution(executionID); ok && info.FinishedAt != nil {
+		return resultOutput(info.Result), nil
+	}
+	e, err := j.executor(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := e.GetLogStream(ctx, models.LogStreamRequest{ExecutionID: executionID, Follow: follow})
+	if err != nil {
+		// e.g. the execution finished since it was looked up
+		if info, ok := j.registry.Execution(executionID); ok && info.FinishedAt != nil {
+			return resultOutput(info.Result), nil
+		}
+		return nil, err
+	}
+	return logs, nil
+}
+
+// executor returns the executor of an execution, started by the jobs or
+// known to the registry
+func (j *Jobs) executor(executionID string) (Executor, error) {
+	j.mu.Lock()
+	engine, ok := j.started[executionID]
+	j.mu.Unlock()
+	if !ok {
+		info, known := j.registry.Execution(executionID)
+		if !known {
+			return nil, ErrExecutionNotFound
+		}
+		engine = info.Executor
+	}
+
+	e, ok := j.executors[engine]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrExecutorUnavailable, engine)
+	}
+	return e, nil
+}
+
+// resultOutput returns the output recorded in the result of an execution
+func resultOutput(result *models.ExecutionResult) io.ReadCloser {
+	if result == nil {
+		return io.NopCloser(strings.NewReader(""))
+	}
+	return io.NopCloser(strings.NewReader(result.STDOUT + result.STDERR))
+}
@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// fakeExecutor publishes the events of the executions it starts, they run
+// until cancelled and their output is "hello"
+type fakeExecutor struct {
+	bus      *events.Bus
+	requests chan *models.ExecutionRequest
+}
+
+func (e *fakeExecutor) IsInstalled(context.Context) bool {
+	return true
+}
+
+func (e *fakeExecutor) Start(_ context.Context, request *models.ExecutionRequest) error {
+	e.requests <- request
+	e.bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: request.JobID, ExecutionID: request.ExecutionID})
+	return nil
+}
+
+func (e *fakeExecutor) Run(context.Context, *models.ExecutionRequest) (*models.ExecutionResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *fakeExecutor) Wait(context.Context, string) (<-chan *models.ExecutionResult, <-chan error) {
+	return nil, nil
+}
+
+func (e *fakeExecutor) Cancel(_ context.Context, executionID string) error {
+	result := &models.ExecutionResult{STDOUT: "hello\n", ExitCode: 137}
+	e.bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", ExecutionID: executionID, Result: result})
+	return nil
+}
+
+func (e *fakeExecutor) GetLogStream(context.Context, models.LogStreamRequest) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("hello\n")), nil
+}
+
+func (e *fakeExecutor) Shutdown(context.Context) error {
+	return nil
+}
+
+func TestJobs(t *testing.T) {
+	bus := events.NewBus()
+	r := NewRegistry(bus, 0)
+	t.Cleanup(r.Close)
+	docker := &fakeExecutor{bus: bus, requests: make(chan *models.ExecutionRequest, 1)}
+	jobs := NewJobs(r, map[string]Executor{"docker": docker}, "/results")
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "run")
+	defer span.End()
+	assert.Equal(t, []string{"docker"}, jobs.Engines())
+
+	spec := models.JobSpec{
+		Engine:  models.NewSpecConfig("Docker").WithParam("image", "alpine"),
+		Outputs: []*models.StorageVolume{{Type: models.StorageVolumeTypeBind, Source: "/data", Target: "/out"}},
+	}
+	info, err := jobs.Run(ctx, spec)
+	require.NoError(t, err)
+	request := <-docker.requests
+	assert.Equal(t, request.ExecutionID, info.ID)
+	assert.Equal(t, request.JobID, info.JobID)
+	assert.Equal(t, "docker", info.Executor)
+	assert.Equal(t, models.ExecutionStatusRunning, info.Status)
+	assert.NotNil(t, request.Resources, "the executors expect resources")
+	assert.Equal(t, "/results/"+info.JobID, request.ResultsDir)
+	assert.Equal(t, span.SpanContext().TraceID().String(), request.TraceInfo.TraceID, "the execution joins the trace of the request")
+
+	logs, err := jobs.Logs(ctx, info.ID, true)
+	require.NoError(t, err)
+	output, _ := io.ReadAll(logs)
+	assert.Equal(t, "hello\n", string(output))
+
+	require.Eventually(t, func() bool { _, ok := jobs.Execution(info.ID); return ok }, time.Second, 10*time.Millisecond)
+	require.NoError(t, jobs.Cancel(ctx, info.ID))
+	require.Eventually(t, func() bool {
+		execution, _ := jobs.Execution(info.ID)
+		return execution.Status == models.ExecutionStatusFailed
+	}, time.Second, 10*time.Millisecond)
+	assert.ErrorIs(t, jobs.Cancel(ctx, info.ID), ErrExecutionNotRunning)
+
+	// the output of a finished execution is the one of its result
+	logs, err = jobs.Logs(ctx, info.ID, false)
+	require.NoError(t, err)
+	output, _ = io.ReadAll(logs)
+	assert.Equal(t, "hello\n", string(output))
+
+	_, err = jobs.Run(ctx, models.JobSpec{Engine: models.NewSpecConfig("wasm")})
+	assert.ErrorIs(t, err, ErrExecutorUnavailable)
+	_, err = jobs.Run(ctx, models.JobSpec{})
+	assert.ErrorIs(t, err, ErrInvalidJobSpec)
+	_, err = jobs.Run(ctx, models.JobSpec{Engine: spec.Engine, Inputs: []*models.StorageVolume{{Source: "/data"}}})
+	assert.ErrorIs(t, err, ErrInvalidJobSpec)
+	assert.ErrorIs(t, jobs.Cancel(ctx, "exec-2"), ErrExecutionNotFound)
+	_, err = jobs.Logs(ctx, "exec-2", false)
+	assert.ErrorIs(t, err, ErrExecutionNotFound)
+}
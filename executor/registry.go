@@ -0,0 +1,145 @@
+package executor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// DefaultRetainedExecutions is the number of finished executions a Registry keeps
+const DefaultRetainedExecutions = 1000
+
+// Registry keeps the executions of the DMS, running and recently finished, by
+// following the execution events of the executors
+type Registry struct {
+	mu         sync.RWMutex
+	executions map[string]*models.ExecutionInfo
+	retain     int
+	changed    chan struct{} // closed when the next event is observed
+
+	sub  *events.Subscription
+	done chan struct{}
+}
+
+// NewRegistry returns a registry following the execution events of bus and
+// keeping up to retain finished executions, the oldest ones are forgotten first
+func NewRegistry(bus *events.Bus, retain int) *Registry {
+	if retain <= 0 {
+		retain = DefaultRetainedExecutions
+	}
+	r := &Registry{
+		executions: make(map[string]*models.ExecutionInfo),
+		retain:     retain,
+		changed:    make(chan struct{}),
+		sub:        bus.Subscribe(64, events.Types(events.ExecutionStarted, events.ExecutionFinished)),
+		done:       make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *Registry) run() {
+	defer close(r.done)
+	for e := range r.sub.C {
+		payload, ok := e.Payload.(events.Execution)
+		if !ok {
+			continue
+		}
+		r.observe(e.Type, e.Time, payload)
+	}
+}
+
+func (r *Registry) observe(typ events.Type, at time.Time, payload events.Execution) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer func() {
+		close(r.changed)
+		r.changed = make(chan struct{})
+	}()
+
+	info, ok := r.executions[payload.ExecutionID]
+	if !ok {
+		info = &models.ExecutionInfo{
+			ID:        payload.ExecutionID,
+			JobID:     payload.JobID,
+			Executor:  payload.Executor,
+			StartedAt: at,
+		}
+		r.executions[payload.ExecutionID] = info
+	}
+
+	if typ == events.ExecutionStarted {
+		info.Status = models.ExecutionStatusRunning
+		return
+	}
+
+	finishedAt := at
+	info.FinishedAt = &finishedAt
+	info.Result = payload.Result
+	info.Status = models.ExecutionStatusFinished
+	if payload.Result == nil || payload.Result.ExitCode != models.ExecutionStatusCodeSuccess {
+		info.Status = models.ExecutionStatusFailed
+	}
+	r.evict()
+}
+
+// evict forgets the oldest finished executions beyond the retained ones
+func (r *Registry) evict() {
+	var finished []*models.ExecutionInfo
+	for _, info := range r.executions {
+		if info.FinishedAt != nil {
+			finished = append(finished, info)
+		}
+	}
+	if len(finished) <= r.retain {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].FinishedAt.Before(*finished[j].FinishedAt) })
+	for _, info := range finished[:len(finished)-r.retain] {
+		delete(r.executions, info.ID)
+	}
+}
+
+// Executions returns the executions known to the registry, sorted by start time
+func (r *Registry) Executions() []models.ExecutionInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	executions := make([]models.ExecutionInfo, 0, len(r.executions))
+	for _, info := range r.executions {
+		executions = append(executions, *info)
+	}
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].StartedAt.Before(executions[j].StartedAt)
+	})
+	return executions
+}
+
+// Execution returns an execution of the registry
+func (r *Registry) Execution(id string) (models.ExecutionInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info, ok := r.executions[id]
+	if !ok {
+		return models.ExecutionInfo{}, false
+	}
+	return *info, true
+}
+
+// Changed returns a channel closed when the registry next observes an
+// execution starting or finishing
+func (r *Registry) Changed() <-chan struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.changed
+}
+
+// Close stops following the execution events
+func (r *Registry) Close() {
+	r.sub.Close()
+	<-r.done
+}
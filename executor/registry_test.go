@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func TestRegistry(t *testing.T) {
+	bus := events.NewBus()
+	r := NewRegistry(bus, 1)
+	t.Cleanup(r.Close)
+
+	execution := func(id string, result *models.ExecutionResult) events.Execution {
+		return events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: id, Result: result}
+	}
+	changed := r.Changed()
+	bus.Publish(events.ExecutionStarted, execution("exec-1", nil))
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("changed was not closed by the next event")
+	}
+	bus.Publish(events.ExecutionStarted, execution("exec-2", nil))
+	bus.Publish(events.ExecutionFinished, execution("exec-1", models.NewExecutionResult(models.ExecutionStatusCodeSuccess)))
+
+	require.Eventually(t, func() bool {
+		info, ok := r.Execution("exec-1")
+		return ok && info.Status == models.ExecutionStatusFinished
+	}, time.Second, 10*time.Millisecond)
+
+	info, ok := r.Execution("exec-2")
+	require.True(t, ok)
+	assert.Equal(t, models.ExecutionStatusRunning, info.Status)
+	assert.Equal(t, "docker", info.Executor)
+	assert.Nil(t, info.FinishedAt)
+
+	executions := r.Executions()
+	require.Len(t, executions, 2)
+	assert.Equal(t, "exec-1", executions[0].ID, "the executions are sorted by start time")
+
+	// only the latest finished execution is retained
+	bus.Publish(events.ExecutionFinished, execution("exec-2", models.NewExecutionResult(137)))
+	require.Eventually(t, func() bool {
+		_, ok := r.Execution("exec-1")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	info, ok = r.Execution("exec-2")
+	require.True(t, ok)
+	assert.Equal(t, models.ExecutionStatusFailed, info.Status)
+	assert.Equal(t, 137, info.Result.ExitCode)
+}
@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+var (
+	// ErrExecutionNotFound is returned for an execution the registry doesn't know, e.g. when opening a shell
+	ErrExecutionNotFound = errors.New("execution not found")
+	// ErrExecutionNotRunning is returned for a finished execution, e.g. when opening a shell
+	ErrExecutionNotRunning = errors.New("execution is not running")
+	// ErrShellUnsupported is returned when the executor of the execution can't run shells
+	ErrShellUnsupported = errors.New("the executor of the execution doesn't support shells")
+)
+
+// Shells opens the shell sessions into the running executions of a registry
+// with the executors running them, each session is audited
+type Shells struct {
+	registry  *Registry
+	executors map[string]Shell // by executor name, e.g. docker
+}
+
+// NewShells returns the shells into the executions of registry, executors
+// are keyed by the name of the executor in the execution events
+func NewShells(registry *Registry, executors map[string]Shell) *Shells {
+	return &Shells{registry: registry, executors: executors}
+}
+
+// Open opens a shell session into a running execution on behalf of client,
+// which identifies it in the audit events, e.g. an API token or a peer ID.
+// The opening, or the failure to, and the closing of the session are observed
+// as audit events.
+func (s *Shells) Open(ctx context.Context, client, executionID string, request models.ShellRequest) (ShellSession, error) {
+	fields := telemetry.Fields{
+		"client":       client,
+		"execution_id": executionID,
+		"command":      strings.Join(request.Command, " "),
+		"tty":          request.TTY,
+	}
+
+	session, err := s.open(ctx, executionID, request, fields)
+	if err != nil {
+		fields["error"] = err.Error()
+		telemetry.Observe(ctx, telemetry.Audit, telemetry.WarnLevel, "shell.failed", fields)
+		return nil, err
+	}
+	telemetry.Observe(ctx, telemetry.Audit, telemetry.InfoLevel, "shell.opened", fields)
+	return &auditedSession{ShellSession: session, ctx: ctx, fields: fields, openedAt: time.Now()}, nil
+}
+
+func (s *Shells) open(ctx context.Context, executionID string, request models.ShellRequest, fields telemetry.Fields) (ShellSession, error) {
+	info, ok := s.registry.Execution(executionID)
+	if !ok {
+		return nil, ErrExecutionNotFound
+	}
+	fields["job_id"] = info.JobID
+	fields["executor"] = info.Executor
+	if info.Status != models.ExecutionStatusRunning {
+		return nil, ErrExecutionNotRunning
+	}
+	shell, ok := s.executors[info.Executor]
+	if !ok {
+		return nil, ErrShellUnsupported
+	}
+	return shell.Exec(ctx, executionID, request)
+}
+
+// auditedSession observes the closing of a session with its duration and the
+// exit code of its command when it exited
+type auditedSession struct {
+	ShellSession
+	ctx      context.Context
+	fields   telemetry.Fields
+	openedAt time.Time
+
+	mu       sync.Mutex
+	exitCode *int
+	closed   bool
+}
+
+func (s *auditedSession) Wait(ctx context.Context) (int, error) {
+	code, err := s.ShellSession.Wait(ctx)
+	if err == nil {
+		s.mu.Lock()
+		s.exitCode = &code
+		s.mu.Unlock()
+	}
+	return code, err
+}
+
+func (s *auditedSession) Close() error {
+	err := s.ShellSession.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return err
+	}
+	s.closed = true
+
+	fields := telemetry.Fields{"duration_seconds": time.Since(s.openedAt).Seconds()}
+	for k, v := range s.fields {
+		fields[k] = v
+	}
+	if s.exitCode != nil {
+		fields["exit_code"] = *s.exitCode
+	}
+	telemetry.Observe(s.ctx, telemetry.Audit, telemetry.InfoLevel, "shell.closed", fields)
+	return err
+}
@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+// echoShell opens sessions echoing their input
+type echoShell struct{}
+
+func (echoShell) Exec(_ context.Context, _ string, _ models.ShellRequest) (ShellSession, error) {
+	r, w := io.Pipe()
+	return &echoSession{PipeReader: r, PipeWriter: w}, nil
+}
+
+type echoSession struct {
+	*io.PipeReader
+	*io.PipeWriter
+}
+
+func (s *echoSession) Close() error {
+	return s.PipeWriter.Close()
+}
+
+func (s *echoSession) Resize(context.Context, models.TerminalSize) error {
+	return nil
+}
+
+func (s *echoSession) Wait(context.Context) (int, error) {
+	return 3, nil
+}
+
+// auditCollector keeps the audit events
+type auditCollector struct {
+	mu     sync.Mutex
+	events []telemetry.Event
+}
+
+func (c *auditCollector) Observes(e telemetry.Event) bool {
+	return e.Category() == telemetry.Audit
+}
+
+func (c *auditCollector) Collect(_ context.Context, e telemetry.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *auditCollector) Close() error {
+	return nil
+}
+
+func (c *auditCollector) last() telemetry.Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.events[len(c.events)-1]
+}
+
+func TestShells(t *testing.T) {
+	collector := &auditCollector{}
+	telemetry.Register(collector)
+	t.Cleanup(func() { _ = telemetry.Close() })
+
+	bus := events.NewBus()
+	r := NewRegistry(bus, 0)
+	t.Cleanup(r.Close)
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "wasm", JobID: "job-1", ExecutionID: "exec-2"})
+	require.Eventually(t, func() bool { return len(r.Executions()) == 2 }, time.Second, 10*time.Millisecond)
+
+	shells := NewShells(r, map[string]Shell{"docker": echoShell{}})
+	ctx := context.Background()
+	request := models.ShellRequest{Command: []string{"cat"}}
+
+	_, err := shells.Open(ctx, "token admin", "exec-0", request)
+	assert.ErrorIs(t, err, ErrExecutionNotFound)
+	_, err = shells.Open(ctx, "token admin", "exec-2", request)
+	assert.ErrorIs(t, err, ErrShellUnsupported)
+	failed := collector.last()
+	assert.Equal(t, "shell.failed", failed.Name())
+	assert.Equal(t, "wasm", failed.Fields()["executor"])
+
+	session, err := shells.Open(ctx, "token admin", "exec-1", request)
+	require.NoError(t, err)
+	opened := collector.last()
+	assert.Equal(t, "shell.opened", opened.Name())
+	assert.Equal(t, "token admin", opened.Fields()["client"])
+	assert.Equal(t, "job-1", opened.Fields()["job_id"])
+	assert.Equal(t, "cat", opened.Fields()["command"])
+
+	go func() {
+		_, _ = session.Write([]byte("hello"))
+	}()
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(session, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+
+	code, err := session.Wait(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, code)
+	require.NoError(t, session.Close())
+	require.NoError(t, session.Close())
+
+	closed := collector.last()
+	assert.Equal(t, "shell.closed", closed.Name())
+	assert.Equal(t, 3, closed.Fields()["exit_code"])
+	assert.Contains(t, closed.Fields(), "duration_seconds")
+	assert.Len(t, collector.events, 4, "a session is closed once")
+
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	require.Eventually(t, func() bool {
+		info, _ := r.Execution("exec-1")
+		return info.FinishedAt != nil
+	}, time.Second, 10*time.Millisecond)
+	_, err = shells.Open(ctx, "token admin", "exec-1", request)
+	assert.ErrorIs(t, err, ErrExecutionNotRunning)
+}
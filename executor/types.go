@@ -34,10 +34,38 @@ type Executor interface {
 	// Returns an error if the execution does not exist or is already in a terminal state.
 	Cancel(ctx context.Context, executionID string) error
 
-	// GetLogStream provides a stream of output for an ongoing or completed execution identified by its executionID.
+	// GetLogStream provides a stream of output for an ongoing or completed execution identified by
+	// the ExecutionID of the request, stdout and stderr merged.
 	// The 'Tail' flag indicates whether to exclude hstorical data or not.
 	// The 'follow' flag indicates whether the stream should continue to send data as it is produced.
 	// Returns an io.ReadCloser to read the output stream and an error if the operation fails.
-	// Specifically, it will return an error if the execution does not exist.
-	GetLogStream(ctx context.Context, executionID string) (io.ReadCloser, error)
+	// Specifically, it will return an error if the execution does not exist, and
+	// ErrLogStreamUnsupported if the executor can't stream the output of its executions.
+	GetLogStream(ctx context.Context, request models.LogStreamRequest) (io.ReadCloser, error)
+
+	// Shutdown is called when the DMS shuts down. Implementations checkpoint or stop
+	// the ongoing executions and must return once ctx is done.
+	Shutdown(ctx context.Context) error
+}
+
+// Shell is implemented by the executors able to run interactive commands in
+// their running executions, e.g. docker exec.
+type Shell interface {
+	// Exec runs a command in the running execution identified by its executionID
+	// and returns the session attached to it. It returns an error if the execution
+	// does not exist or is not running.
+	Exec(ctx context.Context, executionID string, request models.ShellRequest) (ShellSession, error)
+}
+
+// ShellSession is an interactive command running in an execution. Reading it
+// returns the output of the command, stdout and stderr merged, and writing
+// it sends its input. Closing it detaches from the command.
+type ShellSession interface {
+	io.ReadWriteCloser
+
+	// Resize resizes the pseudo-terminal of the command, if it has one.
+	Resize(ctx context.Context, size models.TerminalSize) error
+
+	// Wait waits for the command to exit and returns its exit code.
+	Wait(ctx context.Context) (int, error)
 }
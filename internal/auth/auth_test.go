@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.APIToken{}))
+	return NewAuthenticator(repositories_gorm.NewAPITokenRepository(db))
+}
+
+func TestScope(t *testing.T) {
+	assert.True(t, ScopeAdmin.Allows(ScopeOperator))
+	assert.True(t, ScopeOperator.Allows(ScopeOperator))
+	assert.False(t, ScopeRead.Allows(ScopeOperator))
+	assert.False(t, Scope("").Allows(ScopeRead))
+
+	_, err := ParseScope("root")
+	assert.Error(t, err)
+
+	cert := &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"ops", "operator"}}}
+	assert.Equal(t, ScopeOperator, CertificateScope(cert))
+	assert.Equal(t, ScopeRead, CertificateScope(&x509.Certificate{}))
+}
+
+func TestIssueVerifyRevoke(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAuthenticator(t)
+
+	token, record, err := a.Issue(ctx, "dashboard", ScopeRead, 0)
+	require.NoError(t, err)
+	assert.Empty(t, record.Hash, "the hash isn't returned")
+
+	verified, err := a.Verify(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "dashboard", verified.Name)
+	assert.Equal(t, string(ScopeRead), verified.Scope)
+
+	_, err = a.Verify(ctx, token+"x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = a.Verify(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	tokens, err := a.Tokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Empty(t, tokens[0].Hash)
+
+	require.NoError(t, a.Revoke(ctx, record.ID))
+	_, err = a.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.ErrorIs(t, a.Revoke(ctx, record.ID), ErrTokenNotFound)
+
+	_, _, err = a.Issue(ctx, "root", Scope("root"), 0)
+	assert.Error(t, err)
+}
+
+func TestExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAuthenticator(t)
+
+	token, _, err := a.Issue(ctx, "ci", ScopeOperator, time.Nanosecond)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+
+	_, err = a.Verify(ctx, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestBootstrap(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAuthenticator(t)
+	path := filepath.Join(t.TempDir(), "credentials.json")
+
+	require.NoError(t, Bootstrap(ctx, a, path))
+	creds, err := ReadCredentials(path)
+	require.NoError(t, err)
+	record, err := a.Verify(ctx, creds.Token)
+	require.NoError(t, err)
+	assert.Equal(t, string(ScopeAdmin), record.Scope)
+
+	// a valid token is kept
+	require.NoError(t, Bootstrap(ctx, a, path))
+	kept, err := ReadCredentials(path)
+	require.NoError(t, err)
+	assert.Equal(t, creds.Token, kept.Token)
+
+	// a revoked one is replaced
+	require.NoError(t, a.Revoke(ctx, record.ID))
+	require.NoError(t, Bootstrap(ctx, a, path))
+	replaced, err := ReadCredentials(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, creds.Token, replaced.Token)
+	_, err = a.Verify(ctx, replaced.Token)
+	assert.NoError(t, err)
+}
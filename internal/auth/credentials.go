@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// TokenEnv overrides the token of the credentials file for the CLI
+const TokenEnv = "NUNET_API_TOKEN"
+
+// Credentials is the content of the credentials file read by the CLI
+type Credentials struct {
+	Token string `json:"token"`
+}
+
+// CredentialsPath returns the path of the credentials file of the configuration
+func CredentialsPath(cfg *config.Config) string {
+	if cfg.Rest.Auth.CredentialsFile != "" {
+		return cfg.Rest.Auth.CredentialsFile
+	}
+	return filepath.Join(cfg.General.MetadataPath, "credentials.json")
+}
+
+// ReadCredentials reads the credentials file
+func ReadCredentials(path string) (Credentials, error) {
+	var creds Credentials
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return creds, err
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return creds, fmt.Errorf("parse credentials file %s: %w", path, err)
+	}
+	return creds, nil
+}
+
+// WriteCredentials writes the credentials file, readable by its owner and
+// its group: the users of the CLI are members of the group of the DMS
+func WriteCredentials(path string, creds Credentials) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o640); err != nil {
+		return err
+	}
+	// WriteFile keeps the permissions of an existing file
+	return os.Chmod(path, 0o640)
+}
+
+// ClientToken returns the token the CLI authenticates with: the one of the
+// NUNET_API_TOKEN environment variable, else the one of the credentials file
+func ClientToken(cfg *config.Config) string {
+	if token := os.Getenv(TokenEnv); token != "" {
+		return token
+	}
+	creds, err := ReadCredentials(CredentialsPath(cfg))
+	if err != nil {
+		return ""
+	}
+	return creds.Token
+}
+
+// Bootstrap makes sure that the credentials file holds a valid admin token,
+// issuing one for the CLI when the file is missing or its token is invalid
+func Bootstrap(ctx context.Context, a *Authenticator, path string) error {
+	creds, err := ReadCredentials(path)
+	switch {
+	case err == nil:
+		if _, err := a.Verify(ctx, creds.Token); err == nil {
+			return nil
+		} else if !errors.Is(err, ErrInvalidToken) {
+			return err
+		}
+		zlog.Sugar().Warnf("the token of %s is invalid, issuing a new one", path)
+	case !errors.Is(err, fs.ErrNotExist):
+		zlog.Sugar().Warnf("unable to read %s, issuing a new token: %v", path, err)
+	}
+
+	token, _, err := a.Issue(ctx, "cli", ScopeAdmin, 0)
+	if err != nil {
+		return err
+	}
+	if err := WriteCredentials(path, Credentials{Token: token}); err != nil {
+		return fmt.Errorf("write credentials file: %w", err)
+	}
+	zlog.Sugar().Infof("CLI credentials written to %s", path)
+	return nil
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// Scope is the role of an API client. Every scope allows what the scopes
+// before it allow: read, then operator, then admin.
+type Scope string
+
+const (
+	// ScopeRead allows the requests reading the state of the DMS
+	ScopeRead Scope = "read"
+	// ScopeOperator allows running and controlling jobs, tasks and peers
+	ScopeOperator Scope = "operator"
+	// ScopeAdmin allows onboarding, wallets, payments and token management
+	ScopeAdmin Scope = "admin"
+)
+
+// ClientKey is the key of the description of the authenticated client in the
+// gin context of a request, e.g. "token ci", recorded by the audit events
+const ClientKey = "auth.client"
+
+var scopeRanks = map[Scope]int{ScopeRead: 1, ScopeOperator: 2, ScopeAdmin: 3}
+
+// ParseScope returns the scope of its name
+func ParseScope(name string) (Scope, error) {
+	scope := Scope(name)
+	if _, ok := scopeRanks[scope]; !ok {
+		return "", fmt.Errorf("invalid scope %q: must be read, operator or admin", name)
+	}
+	return scope, nil
+}
+
+// Allows returns whether the scope allows the requests requiring the given scope
+func (s Scope) Allows(required Scope) bool {
+	return scopeRanks[s] >= scopeRanks[required]
+}
+
+// CertificateScope returns the scope of a verified client certificate: the
+// first organizational unit naming a scope, read-only otherwise
+func CertificateScope(cert *x509.Certificate) Scope {
+	for _, unit := range cert.Subject.OrganizationalUnit {
+		if scope, err := ParseScope(unit); err == nil {
+			return scope
+		}
+	}
+	return ScopeRead
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// tokenPrefix makes the tokens recognizable, e.g. by secret scanners
+const tokenPrefix = "nunet_"
+
+var (
+	// ErrInvalidToken is returned for unknown, revoked and expired tokens
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrTokenNotFound is returned when revoking an unknown token
+	ErrTokenNotFound = errors.New("token not found")
+)
+
+// Authenticator issues the API tokens and verifies them, only their hashes
+// are stored
+type Authenticator struct {
+	tokens repositories.APITokenRepository
+}
+
+// NewAuthenticator returns an authenticator storing the tokens in the repository
+func NewAuthenticator(tokens repositories.APITokenRepository) *Authenticator {
+	return &Authenticator{tokens: tokens}
+}
+
+// Issue creates a token with the scope, it never expires if ttl is zero. The
+// token is returned along with its stored record and can't be retrieved later.
+func (a *Authenticator) Issue(ctx context.Context, name string, scope Scope, ttl time.Duration) (string, models.APIToken, error) {
+	if _, err := ParseScope(string(scope)); err != nil {
+		return "", models.APIToken{}, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", models.APIToken{}, fmt.Errorf("generate token: %w", err)
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	record := models.APIToken{Name: name, Scope: string(scope), Hash: hashToken(token)}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl).UTC()
+		record.ExpiresAt = &expiresAt
+	}
+	record, err := a.tokens.Create(ctx, record)
+	if err != nil {
+		return "", models.APIToken{}, fmt.Errorf("store token: %w", err)
+	}
+	record.Hash = ""
+	return token, record, nil
+}
+
+// Verify returns the record of a valid token
+func (a *Authenticator) Verify(ctx context.Context, token string) (models.APIToken, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return models.APIToken{}, ErrInvalidToken
+	}
+
+	query := a.tokens.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("Hash", hashToken(token)))
+	// FindAll doesn't log the unknown tokens as database errors
+	records, err := a.tokens.FindAll(ctx, query)
+	if err != nil && !errors.Is(err, repositories.NotFoundError) {
+		return models.APIToken{}, err
+	}
+	if len(records) == 0 {
+		return models.APIToken{}, ErrInvalidToken
+	}
+	record := records[0]
+	if record.ExpiresAt != nil && time.Now().After(*record.ExpiresAt) {
+		return models.APIToken{}, ErrInvalidToken
+	}
+	record.Hash = ""
+	return record, nil
+}
+
+// Tokens returns the records of the issued tokens, their hashes left empty
+func (a *Authenticator) Tokens(ctx context.Context) ([]models.APIToken, error) {
+	records, err := a.tokens.FindAll(ctx, a.tokens.GetQuery())
+	if err != nil && !errors.Is(err, repositories.NotFoundError) {
+		return nil, err
+	}
+	for i := range records {
+		records[i].Hash = ""
+	}
+	return records, nil
+}
+
+// Revoke deletes the token with the ID
+func (a *Authenticator) Revoke(ctx context.Context, id uint) error {
+	if _, err := a.tokens.Get(ctx, id); err != nil {
+		if errors.Is(err, repositories.NotFoundError) {
+			return ErrTokenNotFound
+		}
+		return err
+	}
+	return a.tokens.Delete(ctx, id)
+}
+
+// hashToken returns the SHA-256 of the token, a slow hash isn't needed for
+// random 256 bit secrets
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,355 @@
+package background_tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+// HeartbeatSink receives the heartbeats of a node or an allocation.
+type HeartbeatSink interface {
+	Send(ctx context.Context, heartbeat models.Heartbeat) error // Delivers the heartbeat.
+	String() string                                             // Describes the sink, e.g. its URL.
+}
+
+// Heartbeat describes the periodic heartbeats of a node or an allocation to
+// a sink, see Scheduler.RegisterHeartbeat.
+type Heartbeat struct {
+	Source  string                                                     // Peer ID of the node or ID of the allocation.
+	Kind    models.HeartbeatKind                                       // Kind of the source.
+	Period  time.Duration                                              // Period of the heartbeats.
+	Sink    HeartbeatSink                                              // Sink receiving the heartbeats.
+	Payload func(ctx context.Context) (models.HeartbeatPayload, error) // Reports the state of the source, optional.
+
+	sequence atomic.Uint64
+}
+
+// RegisterHeartbeat adds a task sending the heartbeats every period. The
+// heartbeats stop when the returned task is removed, e.g. when the
+// allocation ends.
+func (s *Scheduler) RegisterHeartbeat(hb *Heartbeat) *Task {
+	return s.AddTask(&Task{
+		Name:        fmt.Sprintf("Heartbeat %s to %s", hb.Source, hb.Sink),
+		Description: fmt.Sprintf("Sends the heartbeats of %s %s every %s", hb.Kind, hb.Source, hb.Period),
+		Function: func(ctx context.Context, _ interface{}) error {
+			return hb.send(ctx)
+		},
+		Triggers: []Trigger{&IntervalTrigger{Interval: hb.Period}},
+	})
+}
+
+// send sends the next heartbeat and observes it as a HEARTBEAT event.
+func (hb *Heartbeat) send(ctx context.Context) error {
+	heartbeat := models.Heartbeat{
+		Source:   hb.Source,
+		Kind:     hb.Kind,
+		Sequence: hb.sequence.Add(1),
+		Time:     time.Now(),
+		Period:   hb.Period,
+	}
+	if hb.Payload != nil {
+		payload, err := hb.Payload(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to build heartbeat payload: %w", err)
+		}
+		heartbeat.Payload = payload
+	}
+
+	err := hb.Sink.Send(ctx, heartbeat)
+
+	fields := telemetry.Fields{
+		"source":   hb.Source,
+		"kind":     string(hb.Kind),
+		"sequence": int64(heartbeat.Sequence),
+		"sink":     hb.Sink.String(),
+	}
+	level := telemetry.DebugLevel
+	if err != nil {
+		fields["error"] = err.Error()
+		level = telemetry.WarnLevel
+	}
+	telemetry.Observe(ctx, telemetry.Heartbeat, level, "heartbeat.sent", fields)
+
+	return err
+}
+
+// NewHeartbeatSink creates a file or http sink of the configuration. Sinks
+// of other types, such as libp2p, are created by the packages providing them.
+func NewHeartbeatSink(cfg config.HeartbeatSink) (HeartbeatSink, error) {
+	switch cfg.Type {
+	case "file":
+		return &FileSink{Path: cfg.Target}, nil
+	case "http":
+		return &HTTPSink{URL: cfg.Target}, nil
+	default:
+		return nil, fmt.Errorf("unsupported heartbeat sink type %q", cfg.Type)
+	}
+}
+
+// FileSink appends the heartbeats to a local file, one JSON object per line.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send appends the heartbeat to the file.
+func (s *FileSink) Send(_ context.Context, heartbeat models.Heartbeat) error {
+	data, err := json.Marshal(heartbeat)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+func (s *FileSink) String() string {
+	return "file " + s.Path
+}
+
+// HTTPSink posts the heartbeats as JSON to an HTTP endpoint.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client // Defaults to http.DefaultClient.
+}
+
+// Send posts the heartbeat, any status but 2xx is an error.
+func (s *HTTPSink) Send(ctx context.Context, heartbeat models.Heartbeat) error {
+	data, err := json.Marshal(heartbeat)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat sink %s answered %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+func (s *HTTPSink) String() string {
+	return "http " + s.URL
+}
+
+// NodeState follows the events of a bus to report the free resources and
+// the running executions of the node in its heartbeats.
+type NodeState struct {
+	sub  *events.Subscription
+	done chan struct{}
+
+	mu         sync.Mutex
+	resources  *models.FreeResources
+	executions map[string]models.HeartbeatExecution
+}
+
+// NewNodeState subscribes to the bus, the state reflects the events
+// published from now on.
+func NewNodeState(bus *events.Bus) *NodeState {
+	n := &NodeState{
+		sub: bus.Subscribe(
+			events.DefaultBufferSize,
+			events.Types(events.ResourcesChanged, events.ExecutionStarted, events.ExecutionFinished),
+		),
+		done:       make(chan struct{}),
+		executions: make(map[string]models.HeartbeatExecution),
+	}
+	go n.follow()
+	return n
+}
+
+func (n *NodeState) follow() {
+	defer close(n.done)
+	for e := range n.sub.C {
+		n.apply(e)
+	}
+}
+
+func (n *NodeState) apply(e events.Event) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch payload := e.Payload.(type) {
+	case events.Resources:
+		free := payload.Free
+		n.resources = &free
+	case events.Execution:
+		if e.Type == events.ExecutionFinished {
+			delete(n.executions, payload.ExecutionID)
+			return
+		}
+		n.executions[payload.ExecutionID] = models.HeartbeatExecution{
+			Executor:    payload.Executor,
+			JobID:       payload.JobID,
+			ExecutionID: payload.ExecutionID,
+			StartedAt:   e.Time,
+		}
+	}
+}
+
+// Payload returns the latest free resources and the running executions,
+// sorted by start time.
+func (n *NodeState) Payload(_ context.Context) (models.HeartbeatPayload, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	payload := models.HeartbeatPayload{Resources: n.resources}
+	for _, execution := range n.executions {
+		payload.Executions = append(payload.Executions, execution)
+	}
+	sort.Slice(payload.Executions, func(i, j int) bool {
+		return payload.Executions[i].StartedAt.Before(payload.Executions[j].StartedAt)
+	})
+	return payload, nil
+}
+
+// Close unsubscribes from the bus.
+func (n *NodeState) Close() {
+	n.sub.Close()
+	<-n.done
+}
+
+// HeartbeatTarget is a sink and the period of the heartbeats sent to it.
+type HeartbeatTarget struct {
+	Sink   HeartbeatSink
+	Period time.Duration
+}
+
+// AllocationHeartbeats follows the events of a bus to send the heartbeats
+// of every running execution to the targets until it finishes.
+type AllocationHeartbeats struct {
+	scheduler *Scheduler
+	targets   []HeartbeatTarget
+	sub       *events.Subscription
+	done      chan struct{}
+
+	mu    sync.Mutex
+	tasks map[string][]int // IDs of the heartbeat tasks of the running executions
+}
+
+// NewAllocationHeartbeats subscribes to the bus, the heartbeats of the
+// executions started from now on are registered on the scheduler.
+func NewAllocationHeartbeats(bus *events.Bus, scheduler *Scheduler, targets []HeartbeatTarget) *AllocationHeartbeats {
+	a := &AllocationHeartbeats{
+		scheduler: scheduler,
+		targets:   targets,
+		sub: bus.Subscribe(
+			events.DefaultBufferSize,
+			events.Types(events.ExecutionStarted, events.ExecutionFinished),
+		),
+		done:  make(chan struct{}),
+		tasks: make(map[string][]int),
+	}
+	go a.follow()
+	return a
+}
+
+func (a *AllocationHeartbeats) follow() {
+	defer close(a.done)
+	for e := range a.sub.C {
+		execution, ok := e.Payload.(events.Execution)
+		if !ok {
+			continue
+		}
+		if e.Type == events.ExecutionFinished {
+			a.remove(execution.ExecutionID)
+		} else {
+			a.register(execution, e.Time)
+		}
+	}
+}
+
+// register adds the heartbeat tasks of an execution, once per execution
+func (a *AllocationHeartbeats) register(execution events.Execution, startedAt time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.tasks[execution.ExecutionID]; ok {
+		return
+	}
+
+	payload := models.HeartbeatPayload{Executions: []models.HeartbeatExecution{{
+		Executor:    execution.Executor,
+		JobID:       execution.JobID,
+		ExecutionID: execution.ExecutionID,
+		StartedAt:   startedAt,
+	}}}
+	ids := make([]int, 0, len(a.targets))
+	for _, target := range a.targets {
+		task := a.scheduler.RegisterHeartbeat(&Heartbeat{
+			Source: execution.ExecutionID,
+			Kind:   models.AllocationHeartbeat,
+			Period: target.Period,
+			Sink:   target.Sink,
+			Payload: func(_ context.Context) (models.HeartbeatPayload, error) {
+				return payload, nil
+			},
+		})
+		ids = append(ids, task.ID)
+	}
+	a.tasks[execution.ExecutionID] = ids
+}
+
+// remove stops the heartbeats of an execution
+func (a *AllocationHeartbeats) remove(executionID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, id := range a.tasks[executionID] {
+		a.scheduler.DeleteTask(id)
+	}
+	delete(a.tasks, executionID)
+}
+
+// Close unsubscribes from the bus and stops the heartbeats of the running
+// executions.
+func (a *AllocationHeartbeats) Close() {
+	a.sub.Close()
+	<-a.done
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for executionID, ids := range a.tasks {
+		for _, id := range ids {
+			a.scheduler.DeleteTask(id)
+		}
+		delete(a.tasks, executionID)
+	}
+}
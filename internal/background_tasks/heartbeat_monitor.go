@@ -0,0 +1,135 @@
+package background_tasks
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+// DefaultMissedAfter is the number of periods without heartbeat after which
+// a source is reported missed.
+const DefaultMissedAfter = 3
+
+// heartbeatKey identifies a source, allocation IDs are only unique to the
+// peer sending their heartbeats.
+type heartbeatKey struct {
+	peer   string
+	source string
+}
+
+// HeartbeatMonitor tracks the heartbeats received from the sources sending
+// to this node. It publishes HeartbeatMissed on the bus when a source stops
+// sending them and HeartbeatRecovered when it resumes.
+type HeartbeatMonitor struct {
+	missedAfter int
+	bus         *events.Bus
+
+	mu      sync.Mutex
+	sources map[heartbeatKey]*models.HeartbeatStatus
+}
+
+// NewHeartbeatMonitor returns a monitor reporting a source missed after
+// missedAfter periods without heartbeat, DefaultMissedAfter if not positive.
+func NewHeartbeatMonitor(bus *events.Bus, missedAfter int) *HeartbeatMonitor {
+	if missedAfter <= 0 {
+		missedAfter = DefaultMissedAfter
+	}
+	return &HeartbeatMonitor{
+		missedAfter: missedAfter,
+		bus:         bus,
+		sources:     make(map[heartbeatKey]*models.HeartbeatStatus),
+	}
+}
+
+// Receive records a heartbeat received from the peer. The time it was
+// received at is used rather than the time of the heartbeat, so that the
+// detection doesn't depend on the clock of the source.
+func (m *HeartbeatMonitor) Receive(peer string, heartbeat models.Heartbeat) {
+	m.mu.Lock()
+	key := heartbeatKey{peer: peer, source: heartbeat.Source}
+	status, ok := m.sources[key]
+	if !ok {
+		status = &models.HeartbeatStatus{Peer: peer, Source: heartbeat.Source}
+		m.sources[key] = status
+	} else if heartbeat.Sequence > status.Sequence+1 {
+		// a lower sequence means the source restarted
+		status.Lost += heartbeat.Sequence - status.Sequence - 1
+	}
+
+	recovered := status.Missed
+	status.Kind = heartbeat.Kind
+	status.Period = heartbeat.Period
+	status.Sequence = heartbeat.Sequence
+	status.LastSeen = time.Now()
+	status.Payload = heartbeat.Payload
+	status.Missed = false
+	snapshot := *status
+	m.mu.Unlock()
+
+	if recovered {
+		m.publish(events.HeartbeatRecovered, snapshot, telemetry.InfoLevel)
+	}
+}
+
+// Check reports the sources whose heartbeats were missed since the last
+// check, it is meant to run periodically.
+func (m *HeartbeatMonitor) Check() {
+	now := time.Now()
+
+	m.mu.Lock()
+	var missed []models.HeartbeatStatus
+	for _, status := range m.sources {
+		if status.Missed || status.Period <= 0 {
+			continue
+		}
+		if now.Sub(status.LastSeen) > time.Duration(m.missedAfter)*status.Period {
+			status.Missed = true
+			missed = append(missed, *status)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, status := range missed {
+		m.publish(events.HeartbeatMissed, status, telemetry.WarnLevel)
+	}
+}
+
+// Forget stops tracking a source, e.g. once its allocation ended.
+func (m *HeartbeatMonitor) Forget(peer, source string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sources, heartbeatKey{peer: peer, source: source})
+}
+
+// Statuses returns the state of the tracked sources, sorted by peer and source.
+func (m *HeartbeatMonitor) Statuses() []models.HeartbeatStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]models.HeartbeatStatus, 0, len(m.sources))
+	for _, status := range m.sources {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Peer != statuses[j].Peer {
+			return statuses[i].Peer < statuses[j].Peer
+		}
+		return statuses[i].Source < statuses[j].Source
+	})
+	return statuses
+}
+
+func (m *HeartbeatMonitor) publish(typ events.Type, status models.HeartbeatStatus, level telemetry.ObservabilityLevel) {
+	m.bus.Publish(typ, events.Heartbeat{Status: status})
+	telemetry.Observe(context.Background(), telemetry.Heartbeat, level, string(typ), telemetry.Fields{
+		"peer":      status.Peer,
+		"source":    status.Source,
+		"kind":      string(status.Kind),
+		"last_seen": status.LastSeen.UTC().Format(time.RFC3339),
+	})
+}
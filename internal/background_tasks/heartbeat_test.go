@@ -0,0 +1,257 @@
+package background_tasks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// chanSink forwards the heartbeats it receives to a channel.
+type chanSink chan models.Heartbeat
+
+func (s chanSink) Send(_ context.Context, heartbeat models.Heartbeat) error {
+	s <- heartbeat
+	return nil
+}
+
+func (s chanSink) String() string {
+	return "channel"
+}
+
+func TestRegisterHeartbeat(t *testing.T) {
+	scheduler := NewScheduler(1)
+	sink := make(chanSink, 4)
+
+	task := scheduler.RegisterHeartbeat(&Heartbeat{
+		Source: "node-1",
+		Kind:   models.NodeHeartbeat,
+		Period: 10 * time.Millisecond,
+		Sink:   sink,
+		Payload: func(_ context.Context) (models.HeartbeatPayload, error) {
+			return models.HeartbeatPayload{Resources: &models.FreeResources{Ram: 1024}}, nil
+		},
+	})
+	assert.Equal(t, "Heartbeat node-1 to channel", task.Name)
+
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	for sequence := uint64(1); sequence <= 2; sequence++ {
+		select {
+		case heartbeat := <-sink:
+			assert.Equal(t, "node-1", heartbeat.Source)
+			assert.Equal(t, models.NodeHeartbeat, heartbeat.Kind)
+			assert.Equal(t, sequence, heartbeat.Sequence)
+			assert.Equal(t, 10*time.Millisecond, heartbeat.Period)
+			assert.Equal(t, 1024, heartbeat.Payload.Resources.Ram)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("heartbeat %d was not sent", sequence)
+		}
+	}
+
+	// removing the task stops the heartbeats
+	scheduler.RemoveTask(task.ID)
+	time.Sleep(1500 * time.Millisecond)
+	for len(sink) > 0 {
+		<-sink
+	}
+	time.Sleep(1500 * time.Millisecond)
+	assert.Empty(t, sink)
+}
+
+func TestHeartbeatPayloadError(t *testing.T) {
+	hb := &Heartbeat{
+		Source: "node-1",
+		Sink:   make(chanSink, 1),
+		Payload: func(_ context.Context) (models.HeartbeatPayload, error) {
+			return models.HeartbeatPayload{}, errors.New("no resources")
+		},
+	}
+	assert.ErrorContains(t, hb.send(context.Background()), "no resources")
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeats", "node.log")
+	sink, err := NewHeartbeatSink(config.HeartbeatSink{Type: "file", Target: path})
+	require.NoError(t, err)
+
+	for sequence := uint64(1); sequence <= 2; sequence++ {
+		err := sink.Send(context.Background(), models.Heartbeat{Source: "node-1", Sequence: sequence})
+		require.NoError(t, err)
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var heartbeats []models.Heartbeat
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var heartbeat models.Heartbeat
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &heartbeat))
+		heartbeats = append(heartbeats, heartbeat)
+	}
+	require.Len(t, heartbeats, 2)
+	assert.Equal(t, uint64(2), heartbeats[1].Sequence)
+}
+
+func TestHTTPSink(t *testing.T) {
+	received := make(chan models.Heartbeat, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var heartbeat models.Heartbeat
+		if err := json.NewDecoder(r.Body).Decode(&heartbeat); err != nil || heartbeat.Source == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- heartbeat
+	}))
+	defer server.Close()
+
+	sink, err := NewHeartbeatSink(config.HeartbeatSink{Type: "http", Target: server.URL})
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Send(context.Background(), models.Heartbeat{Source: "alloc-1", Kind: models.AllocationHeartbeat}))
+	assert.Equal(t, "alloc-1", (<-received).Source)
+
+	assert.Error(t, sink.Send(context.Background(), models.Heartbeat{}), "a non 2xx status is an error")
+
+	_, err = NewHeartbeatSink(config.HeartbeatSink{Type: "carrier-pigeon"})
+	assert.Error(t, err)
+}
+
+func TestNodeState(t *testing.T) {
+	bus := events.NewBus()
+	state := NewNodeState(bus)
+	defer state.Close()
+
+	bus.Publish(events.ResourcesChanged, events.Resources{Free: models.FreeResources{Vcpu: 2}})
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-2", ExecutionID: "exec-2"})
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+
+	assert.Eventually(t, func() bool {
+		payload, err := state.Payload(context.Background())
+		return err == nil && payload.Resources != nil && payload.Resources.Vcpu == 2 &&
+			len(payload.Executions) == 1 && payload.Executions[0].ExecutionID == "exec-2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAllocationHeartbeats(t *testing.T) {
+	bus := events.NewBus()
+	scheduler := NewScheduler(1)
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	sink := make(chanSink, 4)
+	allocations := NewAllocationHeartbeats(bus, scheduler, []HeartbeatTarget{{Sink: sink, Period: 10 * time.Millisecond}})
+	defer allocations.Close()
+
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	select {
+	case heartbeat := <-sink:
+		assert.Equal(t, "exec-1", heartbeat.Source)
+		assert.Equal(t, models.AllocationHeartbeat, heartbeat.Kind)
+		require.Len(t, heartbeat.Payload.Executions, 1)
+		assert.Equal(t, "job-1", heartbeat.Payload.Executions[0].JobID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the heartbeat of the execution was not sent")
+	}
+	assert.Len(t, scheduler.Tasks(), 1)
+
+	// the heartbeats stop once the execution finishes
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	assert.Eventually(t, func() bool {
+		return len(scheduler.Tasks()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAllocationHeartbeatsNotPersisted(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TaskDefinition{}, &models.TaskExecution{}))
+	definitions := repositories_gorm.NewTaskDefinitionRepository(db)
+	executions := repositories_gorm.NewTaskExecutionRepository(db)
+
+	bus := events.NewBus()
+	scheduler := NewPersistentScheduler(1, definitions, executions)
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	sink := make(chanSink, 4)
+	allocations := NewAllocationHeartbeats(bus, scheduler, []HeartbeatTarget{{Sink: sink, Period: 10 * time.Millisecond}})
+	defer allocations.Close()
+
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	select {
+	case <-sink:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the heartbeat of the execution was not sent")
+	}
+	bus.Publish(events.ExecutionFinished, events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"})
+	require.Eventually(t, func() bool {
+		return len(scheduler.Tasks()) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// the tasks of a finished execution leave nothing in the database
+	assert.Eventually(t, func() bool {
+		defs, err := definitions.FindAll(context.Background(), definitions.GetQuery())
+		if err != nil || len(defs) > 0 {
+			return false
+		}
+		records, err := executions.FindAll(context.Background(), executions.GetQuery())
+		return err == nil && len(records) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHeartbeatMonitor(t *testing.T) {
+	bus := events.NewBus()
+	sub := bus.Subscribe(events.DefaultBufferSize, events.Types(events.HeartbeatMissed, events.HeartbeatRecovered))
+	defer sub.Close()
+
+	monitor := NewHeartbeatMonitor(bus, 2)
+	heartbeat := models.Heartbeat{Source: "alloc-1", Kind: models.AllocationHeartbeat, Period: 20 * time.Millisecond}
+
+	heartbeat.Sequence = 1
+	monitor.Receive("peer-1", heartbeat)
+	monitor.Check()
+	assert.Empty(t, sub.C, "heartbeats within the period are not missed")
+
+	time.Sleep(60 * time.Millisecond)
+	monitor.Check()
+	monitor.Check()
+	e := <-sub.C
+	assert.Equal(t, events.HeartbeatMissed, e.Type)
+	assert.Equal(t, "alloc-1", e.Payload.(events.Heartbeat).Status.Source)
+	assert.Empty(t, sub.C, "a missed source is reported once")
+
+	heartbeat.Sequence = 4
+	monitor.Receive("peer-1", heartbeat)
+	e = <-sub.C
+	assert.Equal(t, events.HeartbeatRecovered, e.Type)
+
+	statuses := monitor.Statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "peer-1", statuses[0].Peer)
+	assert.Equal(t, uint64(2), statuses[0].Lost)
+	assert.False(t, statuses[0].Missed)
+
+	monitor.Forget("peer-1", "alloc-1")
+	assert.Empty(t, monitor.Statuses())
+}
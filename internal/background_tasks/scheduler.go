@@ -1,56 +1,136 @@
 package background_tasks
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sort"
 	"sync"
 	"time"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
+)
+
+// MaxExecutionHistory is the number of executions kept for each task, in
+// memory and in the database.
+const MaxExecutionHistory = 50
+
+var (
+	// ErrTaskNotFound is returned when no task has the given ID.
+	ErrTaskNotFound = errors.New("task not found")
+	// ErrTaskNotRunning is returned when cancelling a task that is not running.
+	ErrTaskNotRunning = errors.New("task is not running")
+	// ErrTaskRunning is returned when running a task that is already running.
+	ErrTaskRunning = errors.New("task is already running")
 )
 
 // Scheduler orchestrates the execution of tasks based on their triggers and priority.
 type Scheduler struct {
-	tasks           map[int]*Task // Map of tasks by their ID.
-	runningTasks    map[int]bool  // Map to keep track of running tasks.
-	ticker          *time.Ticker  // Ticker for periodic checks of task triggers.
-	stopChan        chan struct{} // Channel to signal stopping the scheduler.
-	maxRunningTasks int           // Maximum number of tasks that can run concurrently.
-	lastTaskID      int           // Counter for assigning unique IDs to tasks.
-	mu              sync.Mutex    // Mutex to protect access to task maps.
+	tasks           map[int]*Task              // Map of tasks by their ID.
+	runningTasks    map[int]bool               // Map to keep track of running tasks.
+	cancels         map[int]context.CancelFunc // Cancel functions of the running tasks.
+	ticker          *time.Ticker               // Ticker for periodic checks of task triggers.
+	stopChan        chan struct{}              // Channel to signal stopping the scheduler.
+	ctx             context.Context            // Parent context of the task executions.
+	cancel          context.CancelFunc         // Cancels all running tasks on Stop.
+	maxRunningTasks int                        // Maximum number of tasks that can run concurrently.
+	lastTaskID      int                        // Counter for assigning unique IDs to tasks.
+	mu              sync.Mutex                 // Mutex to protect access to task maps.
+
+	definitions repositories.TaskDefinitionRepository // Persisted task definitions, optional.
+	executions  repositories.TaskExecutionRepository  // Persisted task executions, optional.
 }
 
 // NewScheduler creates a new Scheduler with a specified limit on running tasks.
 func NewScheduler(maxRunningTasks int) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Scheduler{
 		tasks:           make(map[int]*Task),
 		runningTasks:    make(map[int]bool),
+		cancels:         make(map[int]context.CancelFunc),
 		ticker:          time.NewTicker(1 * time.Second),
 		stopChan:        make(chan struct{}),
+		ctx:             ctx,
+		cancel:          cancel,
 		maxRunningTasks: maxRunningTasks,
 		lastTaskID:      0,
 	}
 }
 
+// NewPersistentScheduler creates a Scheduler that persists the task definitions
+// and their execution history through the given repositories. Tasks are
+// matched to their definition by name, so a task disabled through the API
+// stays disabled after a restart.
+func NewPersistentScheduler(
+	maxRunningTasks int,
+	definitions repositories.TaskDefinitionRepository,
+	executions repositories.TaskExecutionRepository,
+) *Scheduler {
+	s := NewScheduler(maxRunningTasks)
+	s.definitions = definitions
+	s.executions = executions
+	return s
+}
+
 // AddTask adds a new task to the scheduler and initializes its state.
 func (s *Scheduler) AddTask(task *Task) *Task {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	task.ID = s.lastTaskID
+	// the task isn't shared yet, its persisted state is loaded without the
+	// lock so that the database doesn't hold up the run loop
 	task.Enabled = true
+	s.restoreTask(task)
 
 	for _, trigger := range task.Triggers {
 		trigger.Reset()
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task.ID = s.lastTaskID
 	s.tasks[task.ID] = task
 	s.lastTaskID++
 
 	return task
 }
 
-// RemoveTask removes a task from the scheduler.
+// RemoveTask removes a task from the scheduler, cancelling its execution if running.
 func (s *Scheduler) RemoveTask(taskID int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.removeTask(taskID)
+}
+
+// DeleteTask removes a task like RemoveTask and deletes its persisted
+// definition and execution history, for tasks which won't be added again
+// such as the ones of a single job.
+func (s *Scheduler) DeleteTask(taskID int) {
+	s.mu.Lock()
+	task, ok := s.tasks[taskID]
+	if ok {
+		// an execution still running is not persisted either
+		task.deleted = true
+	}
+	s.removeTask(taskID)
+	s.mu.Unlock()
+
+	if ok {
+		s.deletePersisted(task)
+	}
+}
+
+func (s *Scheduler) removeTask(taskID int) {
+	if cancel, ok := s.cancels[taskID]; ok {
+		cancel()
+	}
+	if task, ok := s.tasks[taskID]; ok {
+		for _, trigger := range task.Triggers {
+			if t, ok := trigger.(closableTrigger); ok {
+				t.Close()
+			}
+		}
+	}
 	delete(s.tasks, taskID)
 }
 
@@ -68,11 +148,8 @@ func (s *Scheduler) Start() {
 	}()
 }
 
-// runningTasksCount returns the count of running tasks.
+// runningTasksCount returns the count of running tasks. The caller must hold the lock.
 func (s *Scheduler) runningTasksCount() int {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	count := 0
 	for _, isRunning := range s.runningTasks {
 		if isRunning {
@@ -84,6 +161,9 @@ func (s *Scheduler) runningTasksCount() int {
 
 // runTasks checks and runs tasks based on their triggers and priority.
 func (s *Scheduler) runTasks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Sort tasks by priority.
 	sortedTasks := make([]*Task, 0, len(s.tasks))
 	for _, task := range s.tasks {
@@ -99,14 +179,22 @@ func (s *Scheduler) runTasks() {
 		}
 
 		if len(task.Triggers) == 0 {
-			s.RemoveTask(task.ID)
+			s.removeTask(task.ID)
 			continue
 		}
 
+		// check the slots first, IsReady consumes the events of EventTrigger
+		if s.runningTasksCount() >= s.maxRunningTasks {
+			return
+		}
+
 		for _, trigger := range task.Triggers {
-			if trigger.IsReady() && s.runningTasksCount() < s.maxRunningTasks {
-				s.runningTasks[task.ID] = true
-				go s.runTask(task.ID)
+			if trigger.IsReady() {
+				var event interface{}
+				if source, ok := trigger.(eventSource); ok {
+					event = source.Event()
+				}
+				s.startTask(task.ID, event)
 				trigger.Reset()
 				break
 			}
@@ -114,53 +202,366 @@ func (s *Scheduler) runTasks() {
 	}
 }
 
-// Stop signals the scheduler to stop running tasks.
+// startTask marks the task as running and executes it with the event that
+// triggered it, if any. The caller must hold the lock.
+func (s *Scheduler) startTask(taskID int, event interface{}) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	if event != nil {
+		ctx = context.WithValue(ctx, eventKey{}, event)
+	}
+	s.runningTasks[taskID] = true
+	s.cancels[taskID] = cancel
+	go s.runTask(ctx, taskID)
+}
+
+type eventKey struct{}
+
+// EventFromContext returns the event that triggered the execution of the
+// task, or nil when it was not triggered by an event.
+func EventFromContext(ctx context.Context) interface{} {
+	return ctx.Value(eventKey{})
+}
+
+// Stop signals the scheduler to stop running tasks and cancels the running ones.
 func (s *Scheduler) Stop() {
 	close(s.stopChan)
+	s.cancel()
+}
+
+// RunTask runs a task immediately, regardless of its triggers.
+func (s *Scheduler) RunTask(taskID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return ErrTaskNotFound
+	}
+	if s.runningTasks[taskID] {
+		return ErrTaskRunning
+	}
+	s.startTask(taskID, nil)
+	return nil
+}
+
+// CancelTask cancels the context of a running task.
+func (s *Scheduler) CancelTask(taskID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[taskID]; !ok {
+		return ErrTaskNotFound
+	}
+	cancel, ok := s.cancels[taskID]
+	if !ok {
+		return ErrTaskNotRunning
+	}
+	cancel()
+	return nil
+}
+
+// SetTaskEnabled enables or disables a task. A running execution is not
+// interrupted. The state is persisted when the scheduler has repositories.
+func (s *Scheduler) SetTaskEnabled(taskID int, enabled bool) error {
+	s.mu.Lock()
+	task, ok := s.tasks[taskID]
+	if ok {
+		task.Enabled = enabled
+	}
+	s.mu.Unlock()
+	if !ok {
+		return ErrTaskNotFound
+	}
+
+	if s.definitions == nil || task.definitionID == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	def, err := s.definitions.Get(ctx, task.definitionID)
+	if err != nil {
+		return fmt.Errorf("failed to load task definition: %w", err)
+	}
+	def.State = models.TaskEnabled
+	if !enabled {
+		def.State = models.TaskDisabled
+	}
+	if _, err := s.definitions.Update(ctx, def.ID, def); err != nil {
+		return fmt.Errorf("failed to save task definition: %w", err)
+	}
+	return nil
+}
+
+// Tasks returns the status of all the tasks, ordered by ID.
+func (s *Scheduler) Tasks() []models.TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]models.TaskStatus, 0, len(s.tasks))
+	for _, task := range s.tasks {
+		statuses = append(statuses, s.taskStatus(task, false))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].ID < statuses[j].ID
+	})
+	return statuses
+}
+
+// TaskStatus returns the status of a task with its execution history.
+func (s *Scheduler) TaskStatus(taskID int) (models.TaskStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[taskID]
+	if !ok {
+		return models.TaskStatus{}, ErrTaskNotFound
+	}
+	return s.taskStatus(task, true), nil
+}
+
+// taskStatus builds the status of a task. The caller must hold the lock.
+func (s *Scheduler) taskStatus(task *Task, withHistory bool) models.TaskStatus {
+	status := models.TaskStatus{
+		ID:          task.ID,
+		Name:        task.Name,
+		Description: task.Description,
+		Triggers:    describeTriggers(task.Triggers),
+		Enabled:     task.Enabled,
+		Running:     s.runningTasks[task.ID],
+		Priority:    task.Priority,
+	}
+	if withHistory {
+		for _, execution := range task.ExecutionHist {
+			status.History = append(status.History, models.TaskExecution{
+				TaskName:  task.Name,
+				StartedAt: execution.StartedAt,
+				EndedAt:   execution.EndedAt,
+				Status:    execution.Status,
+				Error:     execution.Error,
+			})
+		}
+	}
+	return status
 }
 
 // runTask executes a task and manages its lifecycle and retry policy.
-func (s *Scheduler) runTask(taskID int) {
+func (s *Scheduler) runTask(ctx context.Context, taskID int) {
 	defer func() {
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		s.runningTasks[taskID] = false
+		if cancel, ok := s.cancels[taskID]; ok {
+			cancel()
+			delete(s.cancels, taskID)
+		}
 	}()
 
-	task := s.tasks[taskID]
-	execution := Execution{StartedAt: time.Now()}
+	s.mu.Lock()
+	task, ok := s.tasks[taskID]
+	s.mu.Unlock()
+	if !ok {
+		// the task was removed before it got to run
+		return
+	}
+	execution := Execution{StartedAt: time.Now(), Event: EventFromContext(ctx)}
 
 	defer func() {
 		s.mu.Lock()
 		task.ExecutionHist = append(task.ExecutionHist, execution)
-		s.tasks[taskID] = task
+		if len(task.ExecutionHist) > MaxExecutionHistory {
+			task.ExecutionHist = task.ExecutionHist[len(task.ExecutionHist)-MaxExecutionHistory:]
+		}
+		// don't add back a task removed while it was running
+		if _, ok := s.tasks[taskID]; ok {
+			s.tasks[taskID] = task
+		}
+		deleted := task.deleted
 		s.mu.Unlock()
+
+		if !deleted {
+			s.saveExecution(task.Name, execution)
+		}
 	}()
 
-	for i := 0; i < task.RetryPolicy.MaxRetries+1; i++ {
-		err := runTaskWithRetry(task.Function, task.Args, task.RetryPolicy.Delay)
+	execution.Status = StatusFailed
+	for retry := 0; ; retry++ {
+		err := task.Function(ctx, task.Args)
 		if err == nil {
-			execution.Status = "SUCCESS"
-			execution.EndedAt = time.Now()
-			return
+			execution.Status = StatusSuccess
+			break
 		}
 		execution.Error = err.Error()
+		if ctx.Err() != nil {
+			execution.Status = StatusCancelled
+			break
+		}
+		if retry >= task.RetryPolicy.MaxRetries {
+			break
+		}
+
+		if !sleep(ctx, task.RetryPolicy.delay(retry)) {
+			execution.Status = StatusCancelled
+			break
+		}
 	}
 
-	execution.Status = "FAILED"
 	execution.EndedAt = time.Now()
+	metrics.ObserveTaskRun(execution.Status, execution.EndedAt.Sub(execution.StartedAt))
+}
+
+// sleep waits for the duration, it returns false when ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// restoreTask loads the persisted definition and history of a task, creating
+// the definition on its first registration. It is called before the task is
+// added, without holding the lock.
+func (s *Scheduler) restoreTask(task *Task) {
+	if s.definitions == nil {
+		return
+	}
+
+	ctx := context.Background()
+	def := models.TaskDefinition{
+		Name:        task.Name,
+		Description: task.Description,
+		Triggers:    fmt.Sprint(describeTriggers(task.Triggers)),
+		State:       models.TaskEnabled,
+		Priority:    task.Priority,
+		MaxRetries:  task.RetryPolicy.MaxRetries,
+		RetryDelay:  task.RetryPolicy.Delay,
+	}
+
+	query := s.definitions.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("Name", task.Name))
+	existing, err := s.definitions.Find(ctx, query)
+	switch {
+	case errors.Is(err, repositories.NotFoundError):
+		existing, err = s.definitions.Create(ctx, def)
+		if err != nil {
+			zlog.Sugar().Errorf("failed to save task definition %s: %v", task.Name, err)
+			return
+		}
+	case err != nil:
+		zlog.Sugar().Errorf("failed to load task definition %s: %v", task.Name, err)
+		return
+	default:
+		// keep the state changed at runtime, refresh the rest from the code
+		def.State = existing.State
+		if _, err := s.definitions.Update(ctx, existing.ID, def); err != nil {
+			zlog.Sugar().Errorf("failed to update task definition %s: %v", task.Name, err)
+		}
+	}
+
+	task.definitionID = existing.ID
+	task.Enabled = existing.State != models.TaskDisabled
+	task.ExecutionHist = s.loadExecutions(ctx, task.Name)
+
+	// let the triggers resume their schedule from the last run
+	if n := len(task.ExecutionHist); n > 0 {
+		for _, trigger := range task.Triggers {
+			if t, ok := trigger.(restorableTrigger); ok {
+				t.Restore(task.ExecutionHist[n-1].StartedAt)
+			}
+		}
+	}
 }
 
-// runTaskWithRetry attempts to execute a task with a retry policy.
-func runTaskWithRetry(
-	fn func(args interface{}) error,
-	args []interface{},
-	delay time.Duration,
-) error {
-	err := fn(args)
+// loadExecutions returns the persisted executions of a task, oldest first.
+func (s *Scheduler) loadExecutions(ctx context.Context, name string) []Execution {
+	if s.executions == nil {
+		return nil
+	}
+
+	query := s.executions.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("TaskName", name))
+	query.SortBy = "-StartedAt"
+	query.Limit = MaxExecutionHistory
+	records, err := s.executions.FindAll(ctx, query)
 	if err != nil {
-		time.Sleep(delay)
-		return err
+		zlog.Sugar().Errorf("failed to load executions of task %s: %v", name, err)
+		return nil
+	}
+
+	history := make([]Execution, len(records))
+	for i, record := range records {
+		history[len(records)-1-i] = Execution{
+			StartedAt: record.StartedAt,
+			EndedAt:   record.EndedAt,
+			Status:    record.Status,
+			Error:     record.Error,
+		}
+	}
+	return history
+}
+
+// deletePersisted deletes the definition and the executions of a task.
+func (s *Scheduler) deletePersisted(task *Task) {
+	ctx := context.Background()
+	if s.definitions != nil && task.definitionID != 0 {
+		if err := s.definitions.Delete(ctx, task.definitionID); err != nil {
+			zlog.Sugar().Errorf("failed to delete task definition %s: %v", task.Name, err)
+		}
+	}
+	if s.executions == nil {
+		return
+	}
+
+	query := s.executions.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("TaskName", task.Name))
+	records, err := s.executions.FindAll(ctx, query)
+	if err != nil {
+		zlog.Sugar().Errorf("failed to load executions of task %s: %v", task.Name, err)
+		return
+	}
+	for _, record := range records {
+		if err := s.executions.Delete(ctx, record.ID); err != nil {
+			zlog.Sugar().Errorf("failed to delete executions of task %s: %v", task.Name, err)
+			return
+		}
+	}
+}
+
+// saveExecution persists an execution and prunes the history of the task
+// beyond MaxExecutionHistory.
+func (s *Scheduler) saveExecution(name string, execution Execution) {
+	if s.executions == nil {
+		return
+	}
+
+	ctx := context.Background()
+	_, err := s.executions.Create(ctx, models.TaskExecution{
+		TaskName:  name,
+		StartedAt: execution.StartedAt,
+		EndedAt:   execution.EndedAt,
+		Status:    execution.Status,
+		Error:     execution.Error,
+	})
+	if err != nil {
+		zlog.Sugar().Errorf("failed to save execution of task %s: %v", name, err)
+		return
+	}
+
+	query := s.executions.GetQuery()
+	query.Conditions = append(query.Conditions, repositories.EQ("TaskName", name))
+	query.SortBy = "-StartedAt"
+	records, err := s.executions.FindAll(ctx, query)
+	if err != nil {
+		zlog.Sugar().Errorf("failed to load executions of task %s: %v", name, err)
+		return
+	}
+	for i := MaxExecutionHistory; i < len(records); i++ {
+		if err := s.executions.Delete(ctx, records[i].ID); err != nil {
+			zlog.Sugar().Errorf("failed to prune executions of task %s: %v", name, err)
+			return
+		}
 	}
-	return nil
 }
@@ -1,10 +1,19 @@
 package background_tasks
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
 )
 
 func TestSchedulerAddAndRemoveTask(t *testing.T) {
@@ -13,7 +22,7 @@ func TestSchedulerAddAndRemoveTask(t *testing.T) {
 	task := &Task{
 		Name:        "Test Task",
 		Description: "A task for testing",
-		Function: func(args interface{}) error {
+		Function: func(_ context.Context, args interface{}) error {
 			return nil
 		},
 		Triggers: []Trigger{&OneTimeTrigger{Delay: 1 * time.Second}},
@@ -33,7 +42,7 @@ func TestSchedulerTaskExecution(t *testing.T) {
 	task := &Task{
 		Name:        "Test Task",
 		Description: "A task for testing",
-		Function: func(_ interface{}) error {
+		Function: func(_ context.Context, _ interface{}) error {
 			triggered <- true
 			return nil
 		},
@@ -51,3 +60,197 @@ func TestSchedulerTaskExecution(t *testing.T) {
 		t.Error("Task was not executed within the expected time")
 	}
 }
+
+func TestSchedulerRemoveRunningTask(t *testing.T) {
+	scheduler := NewScheduler(1)
+
+	started := make(chan bool, 1)
+	release := make(chan bool)
+	task := scheduler.AddTask(&Task{
+		Name: "Test Task",
+		Function: func(_ context.Context, _ interface{}) error {
+			started <- true
+			<-release
+			return nil
+		},
+		Triggers: []Trigger{&OneTimeTrigger{Delay: 1 * time.Millisecond}},
+	})
+
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Task was not executed within the expected time")
+	}
+
+	scheduler.RemoveTask(task.ID)
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		scheduler.mu.Lock()
+		defer scheduler.mu.Unlock()
+		return !scheduler.runningTasks[task.ID]
+	}, time.Second, 10*time.Millisecond)
+
+	scheduler.mu.Lock()
+	defer scheduler.mu.Unlock()
+	assert.Empty(t, scheduler.tasks, "Task removed while running should not be added back")
+}
+
+func TestSchedulerCancelTask(t *testing.T) {
+	scheduler := NewScheduler(1)
+
+	started := make(chan bool, 1)
+	task := scheduler.AddTask(&Task{
+		Name: "Test Task",
+		Function: func(ctx context.Context, _ interface{}) error {
+			started <- true
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		RetryPolicy: RetryPolicy{MaxRetries: 3, Delay: time.Hour},
+		Triggers:    []Trigger{&IntervalTrigger{Interval: time.Hour}},
+	})
+
+	assert.ErrorIs(t, scheduler.CancelTask(task.ID), ErrTaskNotRunning)
+	assert.ErrorIs(t, scheduler.RunTask(42), ErrTaskNotFound)
+	require.NoError(t, scheduler.RunTask(task.ID))
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Task was not executed within the expected time")
+	}
+	assert.True(t, scheduler.Tasks()[0].Running)
+	require.NoError(t, scheduler.CancelTask(task.ID))
+
+	assert.Eventually(t, func() bool {
+		status, err := scheduler.TaskStatus(task.ID)
+		return err == nil && !status.Running && len(status.History) == 1
+	}, time.Second, 10*time.Millisecond, "the retries should be skipped once cancelled")
+
+	status, err := scheduler.TaskStatus(task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, status.History[0].Status)
+	assert.Equal(t, []string{"every 1h0m0s"}, status.Triggers)
+}
+
+func TestSchedulerHistoryCap(t *testing.T) {
+	scheduler := NewScheduler(1)
+	task := scheduler.AddTask(&Task{
+		Name:     "Test Task",
+		Function: func(_ context.Context, _ interface{}) error { return nil },
+		Triggers: []Trigger{&IntervalTrigger{Interval: time.Hour}},
+	})
+
+	for i := 0; i < MaxExecutionHistory+5; i++ {
+		scheduler.mu.Lock()
+		scheduler.runningTasks[task.ID] = true
+		scheduler.mu.Unlock()
+		scheduler.runTask(context.Background(), task.ID)
+	}
+
+	status, err := scheduler.TaskStatus(task.ID)
+	require.NoError(t, err)
+	assert.Len(t, status.History, MaxExecutionHistory)
+}
+
+func TestPersistentScheduler(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TaskDefinition{}, &models.TaskExecution{}))
+	definitions := repositories_gorm.NewTaskDefinitionRepository(db)
+	executions := repositories_gorm.NewTaskExecutionRepository(db)
+
+	newTask := func() *Task {
+		return &Task{
+			Name:     "Test Task",
+			Function: func(_ context.Context, _ interface{}) error { return errors.New("boom") },
+			Triggers: []Trigger{&IntervalTrigger{Interval: time.Hour}},
+		}
+	}
+
+	scheduler := NewPersistentScheduler(1, definitions, executions)
+	task := scheduler.AddTask(newTask())
+	assert.True(t, task.Enabled)
+	require.NoError(t, scheduler.SetTaskEnabled(task.ID, false))
+	scheduler.runTask(context.Background(), task.ID)
+
+	// a new scheduler restores the state and the history of the task
+	scheduler = NewPersistentScheduler(1, definitions, executions)
+	task = scheduler.AddTask(newTask())
+	assert.False(t, task.Enabled)
+	require.Len(t, task.ExecutionHist, 1)
+	assert.Equal(t, StatusFailed, task.ExecutionHist[0].Status)
+	assert.Equal(t, "boom", task.ExecutionHist[0].Error)
+
+	all, err := definitions.FindAll(context.Background(), definitions.GetQuery())
+	require.NoError(t, err)
+	assert.Len(t, all, 1, "the definition should be matched by name")
+}
+
+func TestDeleteTask(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TaskDefinition{}, &models.TaskExecution{}))
+	definitions := repositories_gorm.NewTaskDefinitionRepository(db)
+	executions := repositories_gorm.NewTaskExecutionRepository(db)
+
+	scheduler := NewPersistentScheduler(1, definitions, executions)
+	task := scheduler.AddTask(&Task{
+		Name:     "Test Task",
+		Function: func(_ context.Context, _ interface{}) error { return nil },
+		Triggers: []Trigger{&IntervalTrigger{Interval: time.Hour}},
+	})
+	scheduler.runTask(context.Background(), task.ID)
+
+	scheduler.DeleteTask(task.ID)
+	_, err = scheduler.TaskStatus(task.ID)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	defs, err := definitions.FindAll(context.Background(), definitions.GetQuery())
+	require.NoError(t, err)
+	assert.Empty(t, defs, "the definition should be deleted")
+	records, err := executions.FindAll(context.Background(), executions.GetQuery())
+	require.NoError(t, err)
+	assert.Empty(t, records, "the history should be deleted")
+}
+
+func TestSchedulerEventPayload(t *testing.T) {
+	bus := events.NewBus()
+	scheduler := NewScheduler(1)
+
+	received := make(chan interface{}, 1)
+	task := scheduler.AddTask(&Task{
+		Name: "Test Task",
+		Function: func(ctx context.Context, _ interface{}) error {
+			received <- EventFromContext(ctx)
+			return nil
+		},
+		Triggers: []Trigger{&EventTrigger{Bus: bus, Filters: []events.Filter{events.Types(events.ResourcesChanged)}}},
+	})
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	bus.Publish(events.ResourcesChanged, events.Resources{Free: models.FreeResources{Ram: 1024}})
+
+	select {
+	case event := <-received:
+		require.IsType(t, events.Event{}, event)
+		assert.Equal(t, 1024, event.(events.Event).Payload.(events.Resources).Free.Ram)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Task was not executed within the expected time")
+	}
+
+	assert.Eventually(t, func() bool {
+		scheduler.mu.Lock()
+		defer scheduler.mu.Unlock()
+		hist := task.ExecutionHist
+		return len(hist) == 1 && hist[0].Event != nil
+	}, time.Second, 10*time.Millisecond, "the event should be recorded on the execution")
+
+	scheduler.RemoveTask(task.ID)
+	bus.Publish(events.ResourcesChanged, events.Resources{})
+}
@@ -1,20 +1,46 @@
 package background_tasks
 
 import (
+	"context"
+	"math"
 	"time"
 )
 
+// Execution statuses.
+const (
+	StatusSuccess   = "SUCCESS"
+	StatusFailed    = "FAILED"
+	StatusCancelled = "CANCELLED"
+)
+
 // RetryPolicy defines the policy for retrying tasks on failure.
 type RetryPolicy struct {
 	MaxRetries int           // Maximum number of retries.
-	Delay      time.Duration // Delay between retries.
+	Delay      time.Duration // Delay before the first retry.
+	Backoff    float64       // Factor applied to the delay after each retry, values up to 1 keep it constant.
+	MaxDelay   time.Duration // Upper bound of the delay, no bound when zero.
+}
+
+// delay returns the delay before the given retry, starting at 0.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	delay := float64(p.Delay)
+	if p.Backoff > 1 {
+		delay *= math.Pow(p.Backoff, float64(retry))
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		return p.MaxDelay
+	}
+	if delay > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
 }
 
 // Execution records the execution details of a task.
 type Execution struct {
 	StartedAt time.Time   // Start time of the execution.
 	EndedAt   time.Time   // End time of the execution.
-	Status    string      // Status of the execution (e.g., "SUCCESS", "FAILED").
+	Status    string      // Status of the execution (e.g., "SUCCESS", "FAILED", "CANCELLED").
 	Error     string      // Error message if the execution failed.
 	Event     interface{} // Event associated with the execution.
 	Results   interface{} // Results of the execution.
@@ -22,14 +48,17 @@ type Execution struct {
 
 // Task represents a schedulable task.
 type Task struct {
-	ID            int                          // Unique identifier for the task.
-	Name          string                       // Name of the task.
-	Description   string                       // Description of the task.
-	Triggers      []Trigger                    // List of triggers for the task.
-	Function      func(args interface{}) error // Function to execute as the task.
-	Args          []interface{}                // Arguments for the task function.
-	RetryPolicy   RetryPolicy                  // Retry policy for the task.
-	Enabled       bool                         // Flag indicating if the task is enabled.
-	Priority      int                          // Priority of the task for scheduling.
-	ExecutionHist []Execution                  // History of task executions.
+	ID            int                                               // Unique identifier for the task.
+	Name          string                                            // Name of the task.
+	Description   string                                            // Description of the task.
+	Triggers      []Trigger                                         // List of triggers for the task.
+	Function      func(ctx context.Context, args interface{}) error // Function to execute as the task, it should return when ctx is cancelled.
+	Args          []interface{}                                     // Arguments for the task function.
+	RetryPolicy   RetryPolicy                                       // Retry policy for the task.
+	Enabled       bool                                              // Flag indicating if the task is enabled.
+	Priority      int                                               // Priority of the task for scheduling.
+	ExecutionHist []Execution                                       // History of task executions, capped at MaxExecutionHistory.
+
+	definitionID uint // ID of the persisted definition of the task.
+	deleted      bool // Set by DeleteTask, the executions still running are not persisted.
 }
@@ -1,6 +1,7 @@
 package background_tasks
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -11,7 +12,7 @@ func TestTaskExecution(t *testing.T) {
 	task := Task{
 		Name:        "Test Task",
 		Description: "A task for testing",
-		Function: func(args interface{}) error {
+		Function: func(_ context.Context, args interface{}) error {
 			// Simple test function that does nothing
 			return nil
 		},
@@ -21,7 +22,7 @@ func TestTaskExecution(t *testing.T) {
 		},
 	}
 
-	err := task.Function(nil)
+	err := task.Function(context.Background(), nil)
 	assert.NoError(t, err, "Task function should execute without error")
 }
 
@@ -1,9 +1,13 @@
 package background_tasks
 
 import (
+	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/robfig/cron/v3"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
 )
 
 // Trigger interface defines a method to check if a trigger condition is met.
@@ -12,7 +16,27 @@ type Trigger interface {
 	Reset()        // Resets the trigger state.
 }
 
+// eventSource is implemented by the triggers carrying the event that made
+// them ready, the event is set on the Execution of the task.
+type eventSource interface {
+	Event() interface{}
+}
+
+// closableTrigger is implemented by the triggers holding resources released
+// when the task is removed.
+type closableTrigger interface {
+	Close()
+}
+
+// restorableTrigger is implemented by the triggers that can resume their
+// schedule from the last run of the task, e.g. after a restart.
+type restorableTrigger interface {
+	Restore(lastRun time.Time)
+}
+
 // PeriodicTrigger triggers at regular intervals or based on a cron expression.
+//
+// Deprecated: use IntervalTrigger or CronTrigger.
 type PeriodicTrigger struct {
 	Interval      time.Duration // Interval for periodic triggering.
 	CronExpr      string        // Cron expression for triggering.
@@ -22,7 +46,7 @@ type PeriodicTrigger struct {
 // IsReady checks if the trigger should activate based on time or cron expression.
 func (t *PeriodicTrigger) IsReady() bool {
 	// Trigger based on interval.
-	if t.lastTriggered.Add(t.Interval).Before(time.Now()) {
+	if t.Interval > 0 && t.lastTriggered.Add(t.Interval).Before(time.Now()) {
 		return true
 	}
 
@@ -45,23 +69,192 @@ func (t *PeriodicTrigger) Reset() {
 	t.lastTriggered = time.Now()
 }
 
-// EventTrigger triggers based on an external event signaled through a channel.
+// IntervalTrigger triggers every Interval, delayed by a random jitter so that
+// the nodes of a fleet don't run the same task in lockstep. When the task is
+// busy past its run, it runs once as soon as it is free.
+type IntervalTrigger struct {
+	Interval time.Duration // Interval between runs.
+	Jitter   time.Duration // Maximum random delay added to each run.
+	CatchUp  bool          // Run once on start if the interval elapsed since the last run before a restart.
+
+	fireAt  time.Time // Time of the next run.
+	lastRun time.Time // Last run restored from the history of the task.
+}
+
+// IsReady checks if the next run is due.
+func (t *IntervalTrigger) IsReady() bool {
+	return !t.fireAt.IsZero() && !time.Now().Before(t.fireAt)
+}
+
+// Reset schedules the next run an interval from now, or from the restored
+// last run when catching up.
+func (t *IntervalTrigger) Reset() {
+	base := time.Now()
+	if t.CatchUp && !t.lastRun.IsZero() {
+		base = t.lastRun
+	}
+	t.lastRun = time.Time{}
+	t.fireAt = base.Add(t.Interval).Add(jitter(t.Jitter))
+}
+
+// Restore sets the last run of the task, used by the next Reset.
+func (t *IntervalTrigger) Restore(lastRun time.Time) {
+	t.lastRun = lastRun
+}
+
+// CronTrigger triggers on the occurrences of a standard cron expression.
+//
+// Occurrences missed while the task was busy or the node was suspended are
+// skipped, unless CatchUp is set in which case they are coalesced into a
+// single run as soon as possible. With CatchUp, a run missed while the DMS
+// was down is also made up on start.
+type CronTrigger struct {
+	Expr     string         // Standard cron expression, e.g. "0 3 * * *" or "@hourly".
+	Location *time.Location // Time zone of the expression, defaults to the local time zone.
+	Jitter   time.Duration  // Maximum random delay added to each run.
+	CatchUp  bool           // Run once for the missed occurrences.
+
+	schedule cron.Schedule
+	next     time.Time // Next occurrence of the expression.
+	fireAt   time.Time // Time of the next run, the occurrence delayed by the jitter.
+	lastRun  time.Time // Last run restored from the history of the task.
+}
+
+// IsReady checks if the next occurrence is due.
+func (t *CronTrigger) IsReady() bool {
+	if t.fireAt.IsZero() {
+		t.Reset()
+		return false
+	}
+
+	now := time.Now()
+	if now.Before(t.fireAt) {
+		return false
+	}
+	if !t.CatchUp && !now.Before(t.schedule.Next(t.next)) {
+		// the following occurrence passed as well, skip the missed ones
+		t.Reset()
+		return false
+	}
+	return true
+}
+
+// Reset schedules the next occurrence after now, or after the restored last
+// run when catching up.
+func (t *CronTrigger) Reset() {
+	if t.schedule == nil {
+		schedule, err := cron.ParseStandard(t.Expr)
+		if err != nil {
+			zlog.Sugar().Errorf("Error parsing cron expression %q: %v", t.Expr, err)
+			return
+		}
+		t.schedule = schedule
+	}
+
+	base := time.Now()
+	if t.CatchUp && !t.lastRun.IsZero() {
+		base = t.lastRun
+	}
+	t.lastRun = time.Time{}
+
+	location := t.Location
+	if location == nil {
+		location = time.Local
+	}
+	t.next = t.schedule.Next(base.In(location))
+	t.fireAt = t.next.Add(jitter(t.Jitter))
+}
+
+// Restore sets the last run of the task, used by the next Reset.
+func (t *CronTrigger) Restore(lastRun time.Time) {
+	t.lastRun = lastRun
+}
+
+// jitter returns a random duration in [0, max).
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// EventTrigger triggers based on an external event signaled through a
+// channel, or on the events of a bus matching Filters.
 type EventTrigger struct {
-	Trigger chan bool // Channel to signal an event.
+	Trigger  chan bool       // Channel to signal an event.
+	Bus      *events.Bus     // Bus to subscribe to, nil to only use Trigger.
+	Filters  []events.Filter // Filters selecting the events of the bus.
+	Coalesce bool            // Run once for the events received while the task was busy.
+
+	sub   *events.Subscription
+	event interface{} // Event that made the trigger ready.
 }
 
-// IsReady checks if there is a signal in the trigger channel.
+// IsReady checks if there is a signal in the trigger channel or an event on the bus.
 func (t *EventTrigger) IsReady() bool {
+	if t.Bus != nil {
+		if t.sub == nil {
+			t.sub = t.Bus.Subscribe(events.DefaultBufferSize, t.Filters...)
+		}
+		select {
+		case e, ok := <-t.sub.C:
+			if !ok {
+				return false
+			}
+			t.event = e
+			if t.Coalesce {
+				t.drain()
+			}
+			return true
+		default:
+		}
+	}
+
 	select {
 	case <-t.Trigger:
+		t.event = nil
 		return true
 	default:
 		return false
 	}
 }
 
-// Reset for EventTrigger does nothing as its state is managed externally.
-func (t *EventTrigger) Reset() {}
+// drain keeps the latest of the buffered events.
+func (t *EventTrigger) drain() {
+	for {
+		select {
+		case e, ok := <-t.sub.C:
+			if !ok {
+				return
+			}
+			t.event = e
+		default:
+			return
+		}
+	}
+}
+
+// Reset subscribes to the bus, if any, so that the events published from
+// the registration of the task on are received.
+func (t *EventTrigger) Reset() {
+	if t.Bus != nil && t.sub == nil {
+		t.sub = t.Bus.Subscribe(events.DefaultBufferSize, t.Filters...)
+	}
+}
+
+// Event returns the event that made the trigger ready, an events.Event for
+// the events of the bus.
+func (t *EventTrigger) Event() interface{} {
+	return t.event
+}
+
+// Close unsubscribes from the bus.
+func (t *EventTrigger) Close() {
+	if t.sub != nil {
+		t.sub.Close()
+		t.sub = nil
+	}
+}
 
 // OneTimeTrigger triggers once after a specified delay.
 type OneTimeTrigger struct {
@@ -78,3 +271,43 @@ func (t *OneTimeTrigger) Reset() {
 func (t *OneTimeTrigger) IsReady() bool {
 	return t.registeredAt.Add(t.Delay).Before(time.Now())
 }
+
+// describeTriggers returns a human readable description of the triggers.
+func describeTriggers(triggers []Trigger) []string {
+	descriptions := make([]string, 0, len(triggers))
+	for _, trigger := range triggers {
+		var description string
+		switch t := trigger.(type) {
+		case *PeriodicTrigger:
+			if t.CronExpr != "" {
+				description = fmt.Sprintf("cron %q", t.CronExpr)
+			} else {
+				description = fmt.Sprintf("every %s", t.Interval)
+			}
+		case *IntervalTrigger:
+			description = fmt.Sprintf("every %s", t.Interval)
+			if t.Jitter > 0 {
+				description += fmt.Sprintf(" (jitter %s)", t.Jitter)
+			}
+		case *CronTrigger:
+			description = fmt.Sprintf("cron %q", t.Expr)
+			if t.Location != nil {
+				description += " " + t.Location.String()
+			}
+			if t.Jitter > 0 {
+				description += fmt.Sprintf(" (jitter %s)", t.Jitter)
+			}
+		case *EventTrigger:
+			description = "event"
+			if t.Bus != nil {
+				description = "bus event"
+			}
+		case *OneTimeTrigger:
+			description = fmt.Sprintf("once after %s", t.Delay)
+		default:
+			description = fmt.Sprintf("%T", trigger)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return descriptions
+}
@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
 )
 
 func TestPeriodicTrigger(t *testing.T) {
@@ -45,3 +48,119 @@ func TestOneTimeTrigger(t *testing.T) {
 	time.Sleep(2 * time.Second) // Wait for delay to pass
 	assert.True(t, trigger.IsReady(), "OneTimeTrigger should be ready after the delay")
 }
+
+func TestPeriodicTriggerCronOnly(t *testing.T) {
+	trigger := PeriodicTrigger{CronExpr: "0 0 1 1 *"}
+	trigger.Reset()
+	assert.False(t, trigger.IsReady(), "a cron-only PeriodicTrigger should not fire on every check")
+}
+
+func TestIntervalTrigger(t *testing.T) {
+	trigger := IntervalTrigger{Interval: time.Hour, Jitter: time.Minute}
+	assert.False(t, trigger.IsReady(), "IntervalTrigger should not be ready before Reset")
+
+	before := time.Now()
+	trigger.Reset()
+	assert.False(t, trigger.IsReady())
+	assert.False(t, trigger.fireAt.Before(before.Add(time.Hour)))
+	assert.True(t, trigger.fireAt.Before(before.Add(time.Hour+time.Minute+time.Second)), "the jitter should be bounded")
+
+	trigger.fireAt = time.Now().Add(-time.Second)
+	assert.True(t, trigger.IsReady())
+}
+
+func TestIntervalTriggerCatchUp(t *testing.T) {
+	lastRun := time.Now().Add(-2 * time.Hour)
+
+	trigger := IntervalTrigger{Interval: time.Hour}
+	trigger.Restore(lastRun)
+	trigger.Reset()
+	assert.False(t, trigger.IsReady(), "the last run should be ignored without CatchUp")
+
+	trigger = IntervalTrigger{Interval: time.Hour, CatchUp: true}
+	trigger.Restore(lastRun)
+	trigger.Reset()
+	assert.True(t, trigger.IsReady(), "the elapsed interval should be caught up")
+
+	trigger.Reset()
+	assert.False(t, trigger.IsReady(), "the catch up should run once")
+}
+
+func TestCronTrigger(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	trigger := CronTrigger{Expr: "0 9 * * *", Location: tokyo}
+	trigger.Reset()
+	assert.False(t, trigger.IsReady())
+	next := trigger.next.In(tokyo)
+	assert.Equal(t, 9, next.Hour(), "the expression should be evaluated in its time zone")
+	assert.Equal(t, 0, next.Minute())
+
+	invalid := CronTrigger{Expr: "thewrongcron expression"}
+	invalid.Reset()
+	assert.False(t, invalid.IsReady(), "CronTrigger with a wrong expression should never be ready")
+}
+
+func TestCronTriggerMissedRuns(t *testing.T) {
+	// the task was busy for two occurrences of the hourly expression
+	missed := func(trigger *CronTrigger) {
+		trigger.Reset()
+		trigger.next = time.Now().Add(-2 * time.Hour).Truncate(time.Hour)
+		trigger.fireAt = trigger.next
+	}
+
+	skip := CronTrigger{Expr: "@hourly"}
+	missed(&skip)
+	assert.False(t, skip.IsReady(), "the missed occurrences should be skipped")
+	assert.True(t, skip.next.After(time.Now()), "the trigger should wait for the next occurrence")
+
+	catchUp := CronTrigger{Expr: "@hourly", CatchUp: true}
+	missed(&catchUp)
+	assert.True(t, catchUp.IsReady(), "the missed occurrences should be caught up")
+	catchUp.Reset()
+	assert.False(t, catchUp.IsReady(), "the missed occurrences should be coalesced into one run")
+
+	// a run missed while the DMS was down is made up on start
+	restored := CronTrigger{Expr: "@hourly", CatchUp: true}
+	restored.Restore(time.Now().Add(-3 * time.Hour))
+	restored.Reset()
+	assert.True(t, restored.IsReady())
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	constant := RetryPolicy{Delay: time.Second}
+	assert.Equal(t, time.Second, constant.delay(0))
+	assert.Equal(t, time.Second, constant.delay(5))
+
+	backoff := RetryPolicy{Delay: time.Second, Backoff: 2, MaxDelay: 10 * time.Second}
+	assert.Equal(t, time.Second, backoff.delay(0))
+	assert.Equal(t, 2*time.Second, backoff.delay(1))
+	assert.Equal(t, 8*time.Second, backoff.delay(3))
+	assert.Equal(t, 10*time.Second, backoff.delay(4), "the delay should be capped")
+	assert.Equal(t, 10*time.Second, backoff.delay(1000))
+}
+
+func TestEventTriggerBus(t *testing.T) {
+	bus := events.NewBus()
+	trigger := EventTrigger{Bus: bus, Filters: []events.Filter{events.Types(events.ExecutionFinished)}}
+	trigger.Reset()
+	defer trigger.Close()
+
+	bus.Publish(events.PeerConnected, events.Peer{PeerID: "Qm1"})
+	assert.False(t, trigger.IsReady(), "EventTrigger should ignore the filtered out events")
+
+	bus.Publish(events.ExecutionFinished, events.Execution{ExecutionID: "e1"})
+	bus.Publish(events.ExecutionFinished, events.Execution{ExecutionID: "e2"})
+	require.True(t, trigger.IsReady())
+	assert.Equal(t, "e1", trigger.Event().(events.Event).Payload.(events.Execution).ExecutionID)
+	require.True(t, trigger.IsReady(), "EventTrigger should be ready for each event")
+	assert.False(t, trigger.IsReady())
+
+	trigger.Coalesce = true
+	bus.Publish(events.ExecutionFinished, events.Execution{ExecutionID: "e3"})
+	bus.Publish(events.ExecutionFinished, events.Execution{ExecutionID: "e4"})
+	require.True(t, trigger.IsReady())
+	assert.Equal(t, "e4", trigger.Event().(events.Event).Payload.(events.Execution).ExecutionID)
+	assert.False(t, trigger.IsReady(), "the buffered events should be coalesced")
+}
@@ -1,10 +1,14 @@
 package config
 
 type Config struct {
-	General `mapstructure:"general"`
-	Rest    `mapstructure:"rest"`
-	P2P     `mapstructure:"p2p"`
-	Job     `mapstructure:"job"`
+	General   `mapstructure:"general"`
+	Rest      `mapstructure:"rest"`
+	GRPC      `mapstructure:"grpc"`
+	P2P       `mapstructure:"p2p"`
+	Job       `mapstructure:"job"`
+	Telemetry `mapstructure:"telemetry"`
+	Heartbeat `mapstructure:"heartbeat"`
+	Shell     `mapstructure:"shell"`
 }
 
 type General struct {
@@ -14,16 +18,182 @@ type General struct {
 }
 
 type Rest struct {
-	Port int `mapstructure:"port"`
+	Address    string   `mapstructure:"address"` // IP or host the API listens on, 0.0.0.0 for all interfaces
+	Port       int      `mapstructure:"port"`
+	UnixSocket string   `mapstructure:"unix_socket"` // also serves the API on this socket, whose clients get the admin scope; empty to disable
+	CORS       CORS     `mapstructure:"cors"`
+	Auth       RestAuth `mapstructure:"auth"`
+	TLS        RestTLS  `mapstructure:"tls"`
+}
+
+// CORS configures the origins of the browser applications allowed to call the API
+type CORS struct {
+	AllowOrigins []string `mapstructure:"allow_origins"` // e.g. http://localhost:9991
+}
+
+// RestAuth configures the authentication of the API clients, see the internal/auth package
+type RestAuth struct {
+	Enabled         bool   `mapstructure:"enabled"`
+	CredentialsFile string `mapstructure:"credentials_file"` // admin token of the CLI, defaults to credentials.json under general.metadata_path
+}
+
+// RestTLS serves the API over HTTPS, with a self-signed certificate generated
+// under general.metadata_path/tls unless a certificate and its key are set
+type RestTLS struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	CertFile     string `mapstructure:"cert_file"`
+	KeyFile      string `mapstructure:"key_file"`
+	ClientCAFile string `mapstructure:"client_ca_file"` // authenticates the clients presenting a certificate issued by this CA (mTLS)
+}
+
+// GRPC serves the gRPC control API, see the api/rpc package. It shares the
+// authentication and the TLS settings of the rest section.
+type GRPC struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Address    string `mapstructure:"address"`     // IP or host the gRPC API listens on, 0.0.0.0 for all interfaces
+	Port       int    `mapstructure:"port"`        // 0 to only serve the gRPC API on the Unix socket
+	UnixSocket string `mapstructure:"unix_socket"` // also serves the gRPC API on this socket, whose clients get the admin scope; empty to disable
+}
+
+// Shell configures the interactive shells into the running executions, opened
+// with `nunet shell` by the admins of the API, see executor.Shells
+type Shell struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	AllowedPeers []string `mapstructure:"allowed_peers"` // peers allowed to open shells into the executions of this node, none by default
 }
 
 type P2P struct {
-	ListenAddress  []string `mapstructure:"listen_address"`
-	BootstrapPeers []string `mapstructure:"bootstrap_peers"`
+	ListenAddress    []string       `mapstructure:"listen_address"`
+	BootstrapPeers   []string       `mapstructure:"bootstrap_peers"`
+	PrivateNetwork   PrivateNetwork `mapstructure:"private_network"`
+	StaticPeers      []string       `mapstructure:"static_peers"` // multiaddrs with /p2p/ IDs which are always dialed and kept connected
+	LAN              LAN            `mapstructure:"lan"`
+	PersistPeerstore bool           `mapstructure:"persist_peerstore"` // keep known peers and the routing table under general.data_dir across restarts
+	ConnManager      ConnManager    `mapstructure:"conn_manager"`
+	Bandwidth        Bandwidth      `mapstructure:"bandwidth"`
+	ResourceLimits   string         `mapstructure:"resource_limits_file"` // libp2p resource manager limits (JSON) applied over the defaults scaled to the machine
+}
+
+// ConnManager configures the watermarks of the libp2p connection manager
+type ConnManager struct {
+	LowWater    int `mapstructure:"low_water"`
+	HighWater   int `mapstructure:"high_water"`   // connections are trimmed down to low_water above this number
+	GracePeriod int `mapstructure:"grace_period"` // in seconds, new connections are not trimmed during this period
+}
+
+// Bandwidth caps the throughput of the libp2p streams in bytes per second, 0 means unlimited.
+// The total caps apply to the streams of every protocol used by the DMS, including the DHT.
+type Bandwidth struct {
+	MaxIn     int64               `mapstructure:"max_in"`
+	MaxOut    int64               `mapstructure:"max_out"`
+	Protocols []ProtocolBandwidth `mapstructure:"protocols"`
+}
+
+// ProtocolBandwidth caps the throughput of the streams of a single protocol
+type ProtocolBandwidth struct {
+	Protocol string `mapstructure:"protocol"` // protocol ID, e.g. /nunet/vpn/1.0.0
+	MaxIn    int64  `mapstructure:"max_in"`
+	MaxOut   int64  `mapstructure:"max_out"`
+}
+
+// LAN configures discovery of peers on local networks, allowing nodes to find
+// each other without internet access or bootstrap servers.
+type LAN struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	MDNS        bool     `mapstructure:"mdns"`         // discover peers with multicast DNS
+	ServiceName string   `mapstructure:"service_name"` // mDNS service name, nodes only discover peers using the same name
+	Interfaces  []string `mapstructure:"interfaces"`   // interfaces whose subnets are exempt from the address filters, empty means all
+}
+
+// PrivateNetwork configures a closed fleet of nodes sharing a pre-shared key.
+// When enabled, the public NuNet bootstrap peers, DHT prefix and rendezvous are not used.
+type PrivateNetwork struct {
+	Enabled        bool     `mapstructure:"enabled"`
+	SwarmKeyPath   string   `mapstructure:"swarm_key_path"`  // path to a /key/swarm/psk/1.0.0/ swarm key file
+	DHTPrefix      string   `mapstructure:"dht_prefix"`      // Kademlia protocol prefix, e.g. /my-fleet
+	Rendezvous     string   `mapstructure:"rendezvous"`      // rendezvous string used for peer discovery
+	BootstrapPeers []string `mapstructure:"bootstrap_peers"` // replaces p2p.bootstrap_peers
+	AllowedPeers   []string `mapstructure:"allowed_peers"`   // peer IDs allowed to connect, empty allows every PSK holder
 }
 
 type Job struct {
-	LogUpdateInterval int    `mapstructure:"log_update_interval"` // in minutes
-	TargetPeer        string `mapstructure:"target_peer"`         // specific peer to send deployment requests to - XXX probably not a good idea. Remove after testing stage.
-	CleanupInterval   int    `mapstructure:"cleanup_interval"` // docker container and images clean up interval in days
+	LogUpdateInterval int      `mapstructure:"log_update_interval"` // in minutes
+	TargetPeer        string   `mapstructure:"target_peer"`         // specific peer to send deployment requests to - XXX probably not a good idea. Remove after testing stage.
+	CleanupInterval   int      `mapstructure:"cleanup_interval"`    // docker container and images clean up interval in days
+	AllowedPeers      []string `mapstructure:"allowed_peers"`       // peers allowed to run jobs on this node with nunet job run --node, none by default
+}
+
+// Telemetry configures the observation of the events, see the telemetry package
+type Telemetry struct {
+	ObservabilityLevel string               `mapstructure:"observability_level"` // minimum level of the observed events, overridden by NUNET_OBSERVABILITY_LEVEL
+	Collectors         []TelemetryCollector `mapstructure:"collectors"`          // collectors registered on start
+	Tracing            Tracing              `mapstructure:"tracing"`
+	Metrics            Metrics              `mapstructure:"metrics"`
+	Logging            Logging              `mapstructure:"logging"`
+}
+
+// Logging configures the levels of the package loggers and the log file
+type Logging struct {
+	Level    string         `mapstructure:"level"`    // default level: debug, info, warn or error, debug when general.debug or NUNET_DEBUG is set
+	Packages []PackageLevel `mapstructure:"packages"` // levels of single packages, also applied to their subpackages
+	File     LogFile        `mapstructure:"file"`
+}
+
+// PackageLevel sets the level of a package logger, e.g. network for
+// network.libp2p and the other network packages
+type PackageLevel struct {
+	Package string `mapstructure:"package"`
+	Level   string `mapstructure:"level"`
+}
+
+// LogFile configures the JSON log file and its rotation
+type LogFile struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Path       string `mapstructure:"path"`        // defaults to logs/dms.log under general.data_dir
+	MaxSize    int    `mapstructure:"max_size"`    // in megabytes, the file is rotated when it grows bigger
+	MaxAge     int    `mapstructure:"max_age"`     // in days, older rotated files are removed, 0 keeps them
+	MaxBackups int    `mapstructure:"max_backups"` // number of rotated files kept, 0 keeps them all
+	Compress   bool   `mapstructure:"compress"`    // gzips the rotated files
+}
+
+// Metrics configures the Prometheus metrics endpoint
+type Metrics struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	ListenAddress string `mapstructure:"listen_address"` // serves /metrics on its own address, e.g. 127.0.0.1:9090, instead of the REST server
+}
+
+// TelemetryCollector configures a collector of the observed events
+type TelemetryCollector struct {
+	Type       string   `mapstructure:"type"`       // file, database, opentelemetry or log
+	Level      string   `mapstructure:"level"`      // minimum level of the collected events, empty means all
+	Categories []string `mapstructure:"categories"` // categories of the collected events, empty means all
+	Path       string   `mapstructure:"path"`       // file collector only, defaults to telemetry/events.log under general.data_dir
+}
+
+// Tracing configures the OpenTelemetry tracer provider and the export of the
+// spans to an OTLP collector
+type Tracing struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	Exporter    string   `mapstructure:"exporter"`     // otlp-grpc or otlp-http
+	Endpoint    string   `mapstructure:"endpoint"`     // host:port of the OTLP collector
+	Insecure    bool     `mapstructure:"insecure"`     // disables TLS towards the collector
+	Headers     []string `mapstructure:"headers"`      // key=value headers sent with the exports, e.g. an API key
+	Sampler     string   `mapstructure:"sampler"`      // always_on, always_off or ratio, the decision of a remote parent is always followed
+	SampleRatio float64  `mapstructure:"sample_ratio"` // ratio of the traces sampled by the ratio sampler
+	ServiceName string   `mapstructure:"service_name"`
+	Attributes  []string `mapstructure:"attributes"` // key=value resource attributes added to the peer ID and machine UUID
+}
+
+// Heartbeat configures the heartbeats sent by the node and the detection of
+// the heartbeats missed by the sources sending to it
+type Heartbeat struct {
+	Sinks       []HeartbeatSink `mapstructure:"sinks"`
+	MissedAfter int             `mapstructure:"missed_after"` // number of periods without heartbeat after which a source is reported missed
+}
+
+// HeartbeatSink configures a sink the node sends its heartbeats to
+type HeartbeatSink struct {
+	Type   string `mapstructure:"type"`   // libp2p, file or http
+	Target string `mapstructure:"target"` // peer ID, file path or URL
+	Period int    `mapstructure:"period"` // in seconds
 }
@@ -29,7 +29,22 @@ func setDefaultConfig() *viper.Viper {
 	v.SetDefault("general.metadata_path", "/etc/nunet")
 	v.SetDefault("general.data_dir", "/var/nunet")
 	v.SetDefault("general.debug", false)
+	v.SetDefault("rest.address", "127.0.0.1")
 	v.SetDefault("rest.port", 9999)
+	v.SetDefault("rest.unix_socket", "/etc/nunet/sockets/dms.sock")
+	v.SetDefault("rest.cors.allow_origins", []string{"http://localhost:9991", "http://localhost:9992"})
+	v.SetDefault("rest.auth.enabled", true)
+	v.SetDefault("rest.auth.credentials_file", "")
+	v.SetDefault("rest.tls.enabled", false)
+	v.SetDefault("rest.tls.cert_file", "")
+	v.SetDefault("rest.tls.key_file", "")
+	v.SetDefault("rest.tls.client_ca_file", "")
+	v.SetDefault("grpc.enabled", true)
+	v.SetDefault("grpc.address", "127.0.0.1")
+	v.SetDefault("grpc.port", 9998)
+	v.SetDefault("grpc.unix_socket", "/etc/nunet/sockets/dms-grpc.sock")
+	v.SetDefault("shell.enabled", true)
+	v.SetDefault("shell.allowed_peers", []string{})
 	v.SetDefault("p2p.listen_address", []string{
 		"/ip4/0.0.0.0/tcp/9000",
 		"/ip4/0.0.0.0/udp/9000/quic",
@@ -39,9 +54,57 @@ func setDefaultConfig() *viper.Viper {
 		"/dnsaddr/bootstrap.p2p.nunet.io/p2p/Qmf16N2ecJVWufa29XKLNyiBxKWqVPNZXjbL3JisPcGqTw",
 		"/dnsaddr/bootstrap.p2p.nunet.io/p2p/QmTkWP72uECwCsiiYDpCFeTrVeUM9huGTPsg3m6bHxYQFZ",
 	})
+	v.SetDefault("p2p.private_network.enabled", false)
+	v.SetDefault("p2p.private_network.swarm_key_path", "/etc/nunet/swarm.key")
+	v.SetDefault("p2p.private_network.dht_prefix", "/nunet-private")
+	v.SetDefault("p2p.private_network.rendezvous", "nunet-private")
+	v.SetDefault("p2p.private_network.bootstrap_peers", []string{})
+	v.SetDefault("p2p.private_network.allowed_peers", []string{})
+	v.SetDefault("p2p.static_peers", []string{})
+	v.SetDefault("p2p.lan.enabled", false)
+	v.SetDefault("p2p.lan.mdns", true)
+	v.SetDefault("p2p.lan.service_name", "_nunet-dms._udp")
+	v.SetDefault("p2p.lan.interfaces", []string{})
+	v.SetDefault("p2p.persist_peerstore", true)
+	v.SetDefault("p2p.conn_manager.low_water", 100)
+	v.SetDefault("p2p.conn_manager.high_water", 400)
+	v.SetDefault("p2p.conn_manager.grace_period", 60)
+	v.SetDefault("p2p.bandwidth.max_in", 0)
+	v.SetDefault("p2p.bandwidth.max_out", 0)
+	v.SetDefault("p2p.bandwidth.protocols", []map[string]interface{}{})
+	v.SetDefault("p2p.resource_limits_file", "")
 	v.SetDefault("job.log_update_interval", 2)
 	v.SetDefault("job.target_peer", "")
 	v.SetDefault("job.cleanup_interval", 3)
+	v.SetDefault("job.allowed_peers", []string{})
+	v.SetDefault("telemetry.observability_level", "INFO")
+	v.SetDefault("telemetry.collectors", []map[string]interface{}{
+		{"type": "log", "categories": []string{"LOGGING"}},
+		{"type": "database", "categories": []string{"ACCOUNTING", "AUDIT"}},
+		{"type": "file", "categories": []string{"ACCOUNTING", "AUDIT"}},
+		{"type": "opentelemetry", "categories": []string{"TRACING"}},
+	})
+	v.SetDefault("telemetry.tracing.enabled", false)
+	v.SetDefault("telemetry.tracing.exporter", "otlp-grpc")
+	v.SetDefault("telemetry.tracing.endpoint", "localhost:4317")
+	v.SetDefault("telemetry.tracing.insecure", false)
+	v.SetDefault("telemetry.tracing.headers", []string{})
+	v.SetDefault("telemetry.tracing.sampler", "always_on")
+	v.SetDefault("telemetry.tracing.sample_ratio", 1.0)
+	v.SetDefault("telemetry.tracing.service_name", "nunet-dms")
+	v.SetDefault("telemetry.tracing.attributes", []string{})
+	v.SetDefault("telemetry.metrics.enabled", true)
+	v.SetDefault("telemetry.metrics.listen_address", "")
+	v.SetDefault("telemetry.logging.level", "info")
+	v.SetDefault("telemetry.logging.packages", []map[string]interface{}{})
+	v.SetDefault("telemetry.logging.file.enabled", false)
+	v.SetDefault("telemetry.logging.file.path", "")
+	v.SetDefault("telemetry.logging.file.max_size", 100)
+	v.SetDefault("telemetry.logging.file.max_age", 30)
+	v.SetDefault("telemetry.logging.file.max_backups", 5)
+	v.SetDefault("telemetry.logging.file.compress", true)
+	v.SetDefault("heartbeat.sinks", []map[string]interface{}{})
+	v.SetDefault("heartbeat.missed_after", 3)
 
 	return v
 }
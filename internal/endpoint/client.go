@@ -0,0 +1,117 @@
+package endpoint
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// socketProbeTimeout bounds the connection attempt to the Unix socket
+const socketProbeTimeout = time.Second
+
+// Client connects to the API of an endpoint through its Unix socket when the
+// current user can connect to it, through TCP otherwise
+type Client struct {
+	Endpoint  Endpoint
+	socket    bool
+	tlsConfig *tls.Config
+}
+
+// NewClient returns a client of the endpoint, it fails when the API is served
+// over TLS and its certificate can't be read
+func NewClient(e Endpoint) (*Client, error) {
+	c := &Client{Endpoint: e}
+	if e.UnixSocket != "" {
+		conn, err := net.DialTimeout("unix", e.UnixSocket, socketProbeTimeout)
+		if err == nil {
+			conn.Close()
+			c.socket = true
+			return c, nil
+		}
+	}
+
+	if e.TLS {
+		tlsConfig, err := trusting(e.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		c.tlsConfig = tlsConfig
+	}
+	return c, nil
+}
+
+// ViaSocket tells whether the client connects through the Unix socket
+func (c *Client) ViaSocket() bool {
+	return c.socket
+}
+
+// URL composes the URL of a path of the API, protocol is http or ws and gets
+// its TLS variant when the API is served over TLS
+func (c *Client) URL(protocol, path, query string) (string, error) {
+	if protocol != "http" && protocol != "ws" {
+		return "", fmt.Errorf("invalid protocol: %s", protocol)
+	}
+	if c.Endpoint.Port == 0 && !c.socket {
+		return "", fmt.Errorf("port is not configured")
+	}
+
+	host := c.Endpoint.DialAddress()
+	if c.socket {
+		// the host is ignored by the socket dialer
+		host = "localhost"
+	} else if c.Endpoint.TLS {
+		protocol += "s"
+	}
+
+	u := url.URL{Scheme: protocol, Host: host, Path: path, RawQuery: query}
+	return u.String(), nil
+}
+
+// HTTPClient returns an HTTP client connecting to the API
+func (c *Client) HTTPClient() *http.Client {
+	transport := &http.Transport{TLSClientConfig: c.tlsConfig}
+	if c.socket {
+		transport.DialContext = c.dialSocket
+	}
+	return &http.Client{Transport: transport}
+}
+
+// WebSocketDialer returns a websocket dialer connecting to the API
+func (c *Client) WebSocketDialer() *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = c.tlsConfig
+	if c.socket {
+		dialer.NetDialContext = c.dialSocket
+	}
+	return &dialer
+}
+
+func (c *Client) dialSocket(ctx context.Context, _, _ string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", c.Endpoint.UnixSocket)
+}
+
+// trusting returns a TLS configuration trusting the certificate of the API in
+// addition to the system ones
+func trusting(certFile string) (*tls.Config, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	pem, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("read API certificate: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", certFile)
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
@@ -0,0 +1,72 @@
+package endpoint
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// Endpoint is where the DMS serves its REST API, it is shared by the server
+// listening on it and by the clients of the CLI
+type Endpoint struct {
+	Host       string // host or IP of the TCP listener, empty for all interfaces
+	Port       int
+	TLS        bool
+	CertFile   string
+	KeyFile    string
+	SelfSigned bool   // the certificate is generated by the DMS, see EnsureSelfSigned
+	UnixSocket string // empty when the API isn't served on a Unix socket
+}
+
+// FromConfig returns the endpoint of the rest section of the configuration
+func FromConfig(cfg *config.Config) Endpoint {
+	e := Endpoint{
+		Host:       cfg.Rest.Address,
+		Port:       cfg.Rest.Port,
+		TLS:        cfg.Rest.TLS.Enabled,
+		CertFile:   cfg.Rest.TLS.CertFile,
+		KeyFile:    cfg.Rest.TLS.KeyFile,
+		UnixSocket: cfg.Rest.UnixSocket,
+	}
+	if e.TLS && e.CertFile == "" && e.KeyFile == "" {
+		dir := filepath.Join(cfg.General.MetadataPath, "tls")
+		e.CertFile = filepath.Join(dir, "dms.crt")
+		e.KeyFile = filepath.Join(dir, "dms.key")
+		e.SelfSigned = true
+	}
+	return e
+}
+
+// ListenAddress returns the address of the TCP listener
+func (e Endpoint) ListenAddress() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// DialAddress returns the TCP address the local clients connect to:
+// localhost when the API listens on all interfaces or on a loopback address
+func (e Endpoint) DialAddress() string {
+	host := e.Host
+	if isLocal(host) {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(e.Port))
+}
+
+// Hosts returns the names and IPs the certificate of the endpoint is valid for
+func (e Endpoint) Hosts() []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if !isLocal(e.Host) {
+		hosts = append(hosts, e.Host)
+	}
+	return hosts
+}
+
+func isLocal(host string) bool {
+	if host == "" || host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsUnspecified() || ip.IsLoopback())
+}
@@ -0,0 +1,96 @@
+package endpoint
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.General.MetadataPath = "/etc/nunet"
+	cfg.Rest = config.Rest{Address: "0.0.0.0", Port: 9999, TLS: config.RestTLS{Enabled: true}}
+
+	e := FromConfig(cfg)
+	assert.Equal(t, "0.0.0.0:9999", e.ListenAddress())
+	assert.Equal(t, "localhost:9999", e.DialAddress())
+	assert.True(t, e.SelfSigned)
+	assert.Equal(t, "/etc/nunet/tls/dms.crt", e.CertFile)
+
+	cfg.Rest.Address = "10.0.0.5"
+	cfg.Rest.TLS.CertFile, cfg.Rest.TLS.KeyFile = "/certs/api.crt", "/certs/api.key"
+	e = FromConfig(cfg)
+	assert.Equal(t, "10.0.0.5:9999", e.DialAddress())
+	assert.False(t, e.SelfSigned, "the configured certificate is used as is")
+	assert.Contains(t, e.Hosts(), "10.0.0.5")
+}
+
+func TestEnsureSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	e := Endpoint{Host: "127.0.0.1", TLS: true, CertFile: filepath.Join(dir, "dms.crt"), KeyFile: filepath.Join(dir, "dms.key")}
+
+	require.NoError(t, EnsureSelfSigned(e))
+	cert, err := readCertificate(e.CertFile)
+	require.NoError(t, err)
+	assert.True(t, validFor(cert, e.Hosts()))
+	info, err := os.Stat(e.KeyFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	require.NoError(t, EnsureSelfSigned(e))
+	reused, err := readCertificate(e.CertFile)
+	require.NoError(t, err)
+	assert.Equal(t, cert.SerialNumber, reused.SerialNumber, "a valid certificate is reused")
+
+	e.Host = "10.0.0.5"
+	require.NoError(t, EnsureSelfSigned(e))
+	renewed, err := readCertificate(e.CertFile)
+	require.NoError(t, err)
+	assert.NotEqual(t, cert.SerialNumber, renewed.SerialNumber, "a certificate missing a host is regenerated")
+	assert.NoError(t, renewed.VerifyHostname("10.0.0.5"))
+}
+
+func TestClient(t *testing.T) {
+	dir := t.TempDir()
+	e := Endpoint{Host: "127.0.0.1", Port: 9999, TLS: true, CertFile: filepath.Join(dir, "dms.crt"), KeyFile: filepath.Join(dir, "dms.key")}
+	require.NoError(t, EnsureSelfSigned(e))
+
+	c, err := NewClient(e)
+	require.NoError(t, err)
+	assert.False(t, c.ViaSocket())
+	u, err := c.URL("ws", "/api/v1/peers/chat/join", "streamID=1")
+	require.NoError(t, err)
+	assert.Equal(t, "wss://localhost:9999/api/v1/peers/chat/join?streamID=1", u)
+	_, err = c.URL("ftp", "/", "")
+	assert.Error(t, err)
+
+	// the socket is preferred when the client can connect to it
+	e.UnixSocket = filepath.Join(dir, "dms.sock")
+	listener, err := net.Listen("unix", e.UnixSocket)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	c, err = NewClient(e)
+	require.NoError(t, err)
+	assert.True(t, c.ViaSocket())
+	u, err = c.URL("http", "/api/v1/health", "")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/api/v1/health", u)
+	resp, err := c.HTTPClient().Get(u)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
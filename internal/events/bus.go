@@ -0,0 +1,141 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultBufferSize is the number of events a subscription buffers
+const DefaultBufferSize = 16
+
+// Default is the bus the DMS components publish their events to
+var Default = NewBus()
+
+// Logs is the bus the executions publish their log lines to, apart from
+// Default so that a chatty execution doesn't crowd out the other events
+var Logs = NewBus()
+
+// Filter selects the events delivered to a subscription
+type Filter func(Event) bool
+
+// Types returns a filter matching the events of the given types
+func Types(types ...Type) Filter {
+	return func(e Event) bool {
+		for _, t := range types {
+			if e.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchesTopics reports whether an event type is of one of the topics. A
+// topic is an event type, e.g. "peer.connected", or the category before its
+// dot, e.g. "peer" for all the peer events.
+func MatchesTopics(typ Type, topics ...string) bool {
+	for _, topic := range topics {
+		if string(typ) == topic || strings.HasPrefix(string(typ), topic+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus delivers the published events to the matching subscriptions. Publishing
+// never blocks: the events are dropped for subscribers whose buffer is full.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   map[int]*Subscription
+	nextID int
+}
+
+// NewBus returns an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[int]*Subscription)}
+}
+
+// Subscription receives the events matching its filters on C until it is closed
+type Subscription struct {
+	C <-chan Event
+
+	bus     *Bus
+	id      int
+	ch      chan Event
+	filters []Filter
+	once    sync.Once
+	dropped atomic.Uint64
+}
+
+// Subscribe returns a subscription to the events matching all the filters,
+// buffering up to buffer events
+func (b *Bus) Subscribe(buffer int, filters ...Filter) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBufferSize
+	}
+	ch := make(chan Event, buffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{C: ch, bus: b, id: b.nextID, ch: ch, filters: filters}
+	b.subs[sub.id] = sub
+	b.nextID++
+	return sub
+}
+
+// Publish delivers an event of the given type to the matching subscriptions
+func (b *Bus) Publish(typ Type, payload interface{}) {
+	e := Event{Type: typ, Time: time.Now(), Payload: payload}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, sub := range b.subs {
+		if !sub.matches(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			sub.dropped.Add(1)
+			zlog.Sugar().Debugf("dropping %s event, subscription %d is full", typ, sub.id)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because the buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the delivery of events and closes C
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s.id)
+		s.bus.mu.Unlock()
+		close(s.ch)
+	})
+}
+
+func (s *Subscription) matches(e Event) bool {
+	for _, f := range s.filters {
+		if !f(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// Publish publishes an event on the default bus
+func Publish(typ Type, payload interface{}) {
+	Default.Publish(typ, payload)
+}
+
+// Subscribe subscribes to the events of the default bus
+func Subscribe(buffer int, filters ...Filter) *Subscription {
+	return Default.Subscribe(buffer, filters...)
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	executions := bus.Subscribe(4, Types(ExecutionStarted, ExecutionFinished))
+	defer executions.Close()
+	finished := bus.Subscribe(4, Types(ExecutionFinished), func(e Event) bool {
+		return e.Payload.(Execution).Executor == "docker"
+	})
+	defer finished.Close()
+
+	bus.Publish(PeerConnected, Peer{PeerID: "Qm1"})
+	bus.Publish(ExecutionStarted, Execution{Executor: "docker", ExecutionID: "e1"})
+	bus.Publish(ExecutionFinished, Execution{Executor: "firecracker", ExecutionID: "e2"})
+	bus.Publish(ExecutionFinished, Execution{Executor: "docker", ExecutionID: "e1"})
+
+	require.Len(t, executions.C, 3)
+	e := <-executions.C
+	assert.Equal(t, ExecutionStarted, e.Type)
+	assert.False(t, e.Time.IsZero())
+
+	require.Len(t, finished.C, 1)
+	e = <-finished.C
+	assert.Equal(t, "e1", e.Payload.(Execution).ExecutionID)
+}
+
+func TestBusDropsWhenFull(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(1)
+	defer sub.Close()
+
+	done := make(chan struct{})
+	go func() {
+		bus.Publish(ResourcesChanged, Resources{})
+		bus.Publish(ResourcesChanged, Resources{})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscription")
+	}
+	assert.Len(t, sub.C, 1)
+	assert.Equal(t, uint64(1), sub.Dropped())
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(1)
+	sub.Close()
+	sub.Close()
+
+	bus.Publish(OnboardingChanged, Onboarding{Onboarded: true})
+	_, ok := <-sub.C
+	assert.False(t, ok, "a closed subscription should not receive events")
+}
@@ -0,0 +1,68 @@
+package events
+
+import (
+	"time"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// Type identifies a kind of DMS lifecycle event
+type Type string
+
+const (
+	ExecutionStarted   Type = "execution.started"
+	ExecutionFinished  Type = "execution.finished"
+	ExecutionLog       Type = "execution.log"
+	PeerConnected      Type = "peer.connected"
+	PeerDisconnected   Type = "peer.disconnected"
+	ResourcesChanged   Type = "resources.changed"
+	OnboardingChanged  Type = "onboarding.changed"
+	HeartbeatMissed    Type = "heartbeat.missed"
+	HeartbeatRecovered Type = "heartbeat.recovered"
+)
+
+// Event is published on the bus, Payload holds one of the payload types
+// below depending on Type
+type Event struct {
+	Type    Type
+	Time    time.Time
+	Payload interface{}
+}
+
+// Execution is the payload of ExecutionStarted and ExecutionFinished
+type Execution struct {
+	Executor    string                  `json:"executor"`         // executor running the execution, e.g. "docker"
+	JobID       string                  `json:"job_id"`           // job of the execution
+	ExecutionID string                  `json:"execution_id"`     // ID of the execution
+	Result      *models.ExecutionResult `json:"result,omitempty"` // result of a finished execution
+}
+
+// Log is the payload of ExecutionLog, a line written by an execution
+type Log struct {
+	Executor    string `json:"executor"`
+	JobID       string `json:"job_id"`
+	ExecutionID string `json:"execution_id"`
+	Stream      string `json:"stream"` // stdout or stderr
+	Line        string `json:"line"`   // without the trailing newline
+}
+
+// Peer is the payload of PeerConnected and PeerDisconnected
+type Peer struct {
+	PeerID string `json:"peer_id"`
+}
+
+// Resources is the payload of ResourcesChanged
+type Resources struct {
+	Free      models.FreeResources      `json:"free"`
+	Available models.AvailableResources `json:"available"`
+}
+
+// Onboarding is the payload of OnboardingChanged
+type Onboarding struct {
+	Onboarded bool `json:"onboarded"`
+}
+
+// Heartbeat is the payload of HeartbeatMissed and HeartbeatRecovered
+type Heartbeat struct {
+	Status models.HeartbeatStatus `json:"status"`
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultJournalSize is the number of events a journal retains
+const DefaultJournalSize = 1024
+
+// Record is an event recorded by a journal. The IDs follow each other, a
+// client resumes a stream from the ID of the last record it received. The
+// IDs of the events dropped by the bus are skipped.
+type Record struct {
+	ID      uint64      `json:"id"`
+	Type    Type        `json:"type"`
+	Time    time.Time   `json:"time"`
+	Payload interface{} `json:"data"`
+}
+
+// Journal records the events of a bus with sequential IDs and retains the
+// latest ones so that the clients of the event stream can resume it
+type Journal struct {
+	mu      sync.Mutex
+	records []Record // oldest first
+	size    int
+	lastID  uint64
+	dropped uint64        // events dropped by the bus so far
+	gap     uint64        // IDs skipped before the next record
+	changed chan struct{} // closed when the next event is recorded
+
+	sub  *Subscription
+	done chan struct{}
+}
+
+// NewJournal returns a journal recording the events of bus and retaining up
+// to size of them
+func NewJournal(bus *Bus, size int) *Journal {
+	if size <= 0 {
+		size = DefaultJournalSize
+	}
+	j := &Journal{
+		size:    size,
+		changed: make(chan struct{}),
+		sub:     bus.Subscribe(256),
+		done:    make(chan struct{}),
+	}
+	go j.run()
+	return j
+}
+
+func (j *Journal) run() {
+	for e := range j.sub.C {
+		j.record(e, j.sub.Dropped())
+	}
+	close(j.done)
+}
+
+// record records an event, dropped is the number of events the bus dropped
+// so far. The events are only dropped while the buffer of the subscription is
+// full, so the newly dropped ones follow e: their IDs are skipped after it.
+func (j *Journal) record(e Event, dropped uint64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.lastID += j.gap + 1
+	j.gap = dropped - j.dropped
+	j.dropped = dropped
+	j.records = append(j.records, Record{ID: j.lastID, Type: e.Type, Time: e.Time, Payload: e.Payload})
+	if len(j.records) > j.size {
+		j.records = j.records[len(j.records)-j.size:]
+	}
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// LastID returns the ID of the latest event recorded, 0 before the first one
+func (j *Journal) LastID() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.lastID
+}
+
+// Since returns the retained records following the record of ID after, oldest
+// first. complete is false when some of the following records were already
+// discarded or dropped, or when after is unknown, e.g. from before a restart:
+// all the retained records are then returned. changed is closed when the next
+// event is recorded.
+func (j *Journal) Since(after uint64) (records []Record, complete bool, changed <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if after == j.lastID {
+		return nil, true, j.changed
+	}
+	start := 0
+	complete = after < j.lastID
+	if complete {
+		start = sort.Search(len(j.records), func(i int) bool { return j.records[i].ID > after })
+		next := after + 1
+		for _, record := range j.records[start:] {
+			if record.ID != next {
+				complete = false
+				break
+			}
+			next++
+		}
+	}
+	return append([]Record(nil), j.records[start:]...), complete, j.changed
+}
+
+// Done is closed once the journal is closed
+func (j *Journal) Done() <-chan struct{} {
+	return j.done
+}
+
+// Close stops recording the events
+func (j *Journal) Close() {
+	j.sub.Close()
+	<-j.done
+}
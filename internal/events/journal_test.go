@@ -0,0 +1,87 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	bus := NewBus()
+	j := NewJournal(bus, 2)
+	defer j.Close()
+
+	records, complete, changed := j.Since(0)
+	assert.Empty(t, records)
+	assert.True(t, complete)
+
+	bus.Publish(PeerConnected, Peer{PeerID: "Qm1"})
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("changed was not closed by the next event")
+	}
+	bus.Publish(PeerDisconnected, Peer{PeerID: "Qm1"})
+	bus.Publish(OnboardingChanged, Onboarding{Onboarded: true})
+	require.Eventually(t, func() bool { return j.LastID() == 3 }, time.Second, 10*time.Millisecond)
+
+	records, complete, _ = j.Since(1)
+	assert.True(t, complete)
+	require.Len(t, records, 2)
+	assert.Equal(t, uint64(2), records[0].ID)
+	assert.Equal(t, OnboardingChanged, records[1].Type)
+
+	records, complete, _ = j.Since(0)
+	assert.False(t, complete, "the first record is no longer retained")
+	assert.Len(t, records, 2)
+
+	records, complete, _ = j.Since(3)
+	assert.True(t, complete)
+	assert.Empty(t, records)
+
+	records, complete, _ = j.Since(42)
+	assert.False(t, complete, "42 is from before a restart")
+	assert.Len(t, records, 2)
+}
+
+func TestJournalDrops(t *testing.T) {
+	j := &Journal{size: 8, changed: make(chan struct{})}
+	j.record(Event{Type: PeerConnected}, 0)
+	// the 2 events dropped so far follow the second one
+	j.record(Event{Type: ResourcesChanged}, 2)
+	j.record(Event{Type: PeerDisconnected}, 2)
+	assert.Equal(t, uint64(5), j.LastID())
+
+	records, complete, _ := j.Since(1)
+	assert.False(t, complete, "the dropped events follow record 2")
+	require.Len(t, records, 2)
+	assert.Equal(t, []uint64{2, 5}, []uint64{records[0].ID, records[1].ID})
+
+	records, complete, _ = j.Since(2)
+	assert.False(t, complete)
+	require.Len(t, records, 1)
+	assert.Equal(t, PeerDisconnected, records[0].Type)
+
+	_, complete, _ = j.Since(5)
+	assert.True(t, complete)
+}
+
+func TestJournalClose(t *testing.T) {
+	j := NewJournal(NewBus(), 0)
+	j.Close()
+	select {
+	case <-j.Done():
+	default:
+		t.Fatal("Done is not closed")
+	}
+}
+
+func TestMatchesTopics(t *testing.T) {
+	assert.True(t, MatchesTopics(PeerConnected, "peer"))
+	assert.True(t, MatchesTopics(PeerConnected, "execution", "peer.connected"))
+	assert.False(t, MatchesTopics(PeerConnected, "peer.disconnected"))
+	assert.False(t, MatchesTopics(PeerConnected, "pe"))
+	assert.False(t, MatchesTopics(PeerConnected))
+}
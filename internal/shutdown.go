@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultShutdownTimeout bounds the whole shutdown of the DMS
+	DefaultShutdownTimeout = 30 * time.Second
+
+	// shutdownHookGrace is how long a hook may still take once the shutdown
+	// deadline has passed, giving the remaining components a chance to
+	// release their resources quickly
+	shutdownHookGrace = time.Second
+)
+
+// Shutdown is the DMS-wide shutdown manager. Components register their
+// cleanup with it when they start.
+var Shutdown = NewShutdownManager()
+
+// ShutdownHook releases the resources of a component. It must return once
+// ctx is done, even if the cleanup couldn't complete.
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownHook struct {
+	id   int
+	name string
+	fn   ShutdownHook
+}
+
+// ShutdownManager coordinates the shutdown of the DMS components. Hooks run
+// sequentially in reverse order of registration, so components started last
+// are stopped first.
+type ShutdownManager struct {
+	mu     sync.Mutex
+	hooks  []shutdownHook
+	nextID int
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// NewShutdownManager creates a ShutdownManager without any hook
+func NewShutdownManager() *ShutdownManager {
+	return &ShutdownManager{done: make(chan struct{})}
+}
+
+// Register adds a hook which runs on shutdown. The returned function
+// unregisters it, e.g. when the component is stopped earlier.
+func (m *ShutdownManager) Register(name string, hook ShutdownHook) (unregister func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextID
+	m.nextID++
+	m.hooks = append(m.hooks, shutdownHook{id: id, name: name, fn: hook})
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		for i, h := range m.hooks {
+			if h.id == id {
+				m.hooks = append(m.hooks[:i], m.hooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Shutdown runs every hook and returns their errors joined. Hooks are only
+// run once, later calls wait for the first shutdown and return its result.
+func (m *ShutdownManager) Shutdown(ctx context.Context) error {
+	m.once.Do(func() {
+		defer close(m.done)
+
+		m.mu.Lock()
+		hooks := make([]shutdownHook, len(m.hooks))
+		copy(hooks, m.hooks)
+		m.mu.Unlock()
+
+		var errs []error
+		for i := len(hooks) - 1; i >= 0; i-- {
+			hook := hooks[i]
+			zlog.Sugar().Infof("shutting down %s", hook.name)
+			if err := runShutdownHook(ctx, hook.fn); err != nil {
+				zlog.Sugar().Errorf("failed to shut down %s: %v", hook.name, err)
+				errs = append(errs, fmt.Errorf("%s: %w", hook.name, err))
+			}
+		}
+		m.err = errors.Join(errs...)
+	})
+
+	<-m.done
+	return m.err
+}
+
+// Done is closed once the shutdown has completed
+func (m *ShutdownManager) Done() <-chan struct{} {
+	return m.done
+}
+
+// WaitForSignal blocks until a signal is received on sigs and then shuts
+// down within the given timeout
+func (m *ShutdownManager) WaitForSignal(sigs <-chan os.Signal, timeout time.Duration) error {
+	sig := <-sigs
+	zlog.Sugar().Infof("Shutting down after receiving %v...", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return m.Shutdown(ctx)
+}
+
+// runShutdownHook runs the hook and stops waiting for it shortly after ctx is
+// done so that a stuck component can't block the shutdown of the others
+func runShutdownHook(ctx context.Context, hook ShutdownHook) error {
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errCh <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		errCh <- hook(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(shutdownHookGrace):
+		return ctx.Err()
+	}
+}
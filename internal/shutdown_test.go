@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownManagerOrder(t *testing.T) {
+	m := NewShutdownManager()
+
+	var order []string
+	hook := func(name string) ShutdownHook {
+		return func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	m.Register("network", hook("network"))
+	m.Register("executor", hook("executor"))
+	unregister := m.Register("removed", hook("removed"))
+	m.Register("api", hook("api"))
+	unregister()
+
+	assert.NoError(t, m.Shutdown(context.Background()))
+	assert.Equal(t, []string{"api", "executor", "network"}, order)
+
+	// hooks only run once
+	assert.NoError(t, m.Shutdown(context.Background()))
+	assert.Len(t, order, 3)
+
+	select {
+	case <-m.Done():
+	default:
+		t.Fatal("Done should be closed after shutdown")
+	}
+}
+
+func TestShutdownManagerErrors(t *testing.T) {
+	m := NewShutdownManager()
+
+	ran := false
+	m.Register("last", func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	m.Register("stuck", func(ctx context.Context) error {
+		select {}
+	})
+	m.Register("panics", func(ctx context.Context) error {
+		panic("boom")
+	})
+	m.Register("fails", func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := m.Shutdown(ctx)
+	assert.ErrorContains(t, err, "fails: failed")
+	assert.ErrorContains(t, err, "panics: panic: boom")
+	assert.ErrorContains(t, err, "stuck: context deadline exceeded")
+	assert.True(t, ran, "a failing or stuck hook should not prevent the others from running")
+}
+
+func TestShutdownManagerWaitForSignal(t *testing.T) {
+	m := NewShutdownManager()
+
+	called := false
+	m.Register("component", func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "hooks should get a deadline")
+		called = true
+		return nil
+	})
+
+	sigs := make(chan os.Signal, 1)
+	sigs <- syscall.SIGTERM
+	assert.NoError(t, m.WaitForSignal(sigs, time.Second))
+	assert.True(t, called)
+}
@@ -3,10 +3,19 @@
 package internal
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
 )
 
 // UpgradeConnection is generic protocol upgrader for entire DMS.
@@ -21,50 +30,155 @@ type WebSocketConnection struct {
 	*websocket.Conn
 }
 
-// Command represents a command to be executed
-type Command struct {
-	Command string
-	NodeID  string // ID of the node where command will be executed
-	Result  string
-	Conn    *WebSocketConnection
+// shellWriteTimeout bounds writing a message of a shell session
+const shellWriteTimeout = 10 * time.Second
+
+// ShellMessage is a text message controlling a shell session bridged to a
+// websocket. The input and the output of the command are binary messages.
+type ShellMessage struct {
+	Type     string `json:"type"`                // resize from the client; exit or error from the DMS, sent last
+	Rows     uint16 `json:"rows,omitempty"`      // resize
+	Cols     uint16 `json:"cols,omitempty"`      // resize
+	ExitCode int    `json:"exit_code,omitempty"` // exit
+	Error    string `json:"error,omitempty"`     // error
+}
+
+// shellConn serializes the writes of the goroutines of a session, websocket
+// connections support a single writer
+type shellConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *shellConn) write(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.SetWriteDeadline(time.Now().Add(shellWriteTimeout))
+	return c.WriteMessage(messageType, data)
+}
+
+func (c *shellConn) writeJSON(msg ShellMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.SetWriteDeadline(time.Now().Add(shellWriteTimeout))
+	return c.WriteJSON(msg)
 }
 
-var commandChan = make(chan Command)
-var clients = make(map[WebSocketConnection]string)
+// BridgeShell serves a shell session to the client of a websocket until the
+// command exits, the exit message is then sent and the connection closed. The
+// session is closed when the client closes the connection.
+func BridgeShell(ctx context.Context, ws *websocket.Conn, session executor.ShellSession) {
+	conn := &shellConn{Conn: ws}
+	defer conn.Close()
+	defer session.Close()
 
-// ListenForWs listens to the connected client for any message. It is assumed that
-// every message that is coming is a command to be executed.
-func ListenForWs(conn *WebSocketConnection) {
-	defer func() {
-		if r := recover(); r != nil {
-			zlog.Sugar().Errorf("Error:", fmt.Sprintf("%v", r))
+	go func() {
+		// the command exits once its input is closed
+		defer session.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if messageType == websocket.BinaryMessage {
+				if _, err := session.Write(data); err != nil {
+					return
+				}
+				continue
+			}
+			var msg ShellMessage
+			if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "resize" {
+				zlog.Sugar().Debugf("shell: ignoring invalid control message %q", data)
+				continue
+			}
+			if err := session.Resize(ctx, models.TerminalSize{Rows: msg.Rows, Cols: msg.Cols}); err != nil {
+				zlog.Sugar().Debugf("shell: failed to resize: %v", err)
+			}
 		}
 	}()
 
-	cmd := Command{NodeID: clients[*conn], Conn: conn}
-
+	buf := make([]byte, 32<<10)
 	for {
-		_, msg, err := conn.ReadMessage()
-		if err == nil { // if NO error
-			// logic to send command and fetch the output
-			cmd.Command = string(msg)
-			commandChan <- cmd
+		n, err := session.Read(buf)
+		if n > 0 {
+			if conn.write(websocket.BinaryMessage, buf[:n]) != nil {
+				return
+			}
+		}
+		if err != nil {
+			break
 		}
 	}
+
+	code, err := session.Wait(ctx)
+	if err != nil {
+		_ = conn.writeJSON(ShellMessage{Type: "error", Error: err.Error()})
+	} else {
+		_ = conn.writeJSON(ShellMessage{Type: "exit", ExitCode: code})
+	}
+	_ = conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second))
 }
 
-// SendCommandForExecution work is to send command for execution and fetch the result
-// This function listens for new commands from commandChan
-func SendCommandForExecution() {
-	for {
-		command := <-commandChan
-		zlog.Sugar().Infof("%v", command)
-		// TO BE IMPLEMENTED
-		// send command
+// AttachShell attaches a terminal to the shell session served on a websocket
+// by BridgeShell: stdin is sent as the input of the command, its output is
+// written to stdout and resizes are sent as resize messages. It returns the
+// exit code of the command.
+func AttachShell(ws *websocket.Conn, stdin io.Reader, stdout io.Writer, resizes <-chan models.TerminalSize) (int, error) {
+	conn := &shellConn{Conn: ws}
+	defer conn.Close()
 
-		// fetch result
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case size, ok := <-resizes:
+				if !ok {
+					return
+				}
+				_ = conn.writeJSON(ShellMessage{Type: "resize", Rows: size.Rows, Cols: size.Cols})
+			case <-done:
+				return
+			}
+		}
+	}()
+	go func() {
+		buf := make([]byte, 32<<10)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if conn.write(websocket.BinaryMessage, buf[:n]) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
 
-		// send back result
-		command.Conn.WriteMessage(websocket.TextMessage, []byte(command.Command))
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return 0, fmt.Errorf("shell connection closed before the command exited: %w", err)
+		}
+		if messageType == websocket.BinaryMessage {
+			if _, err := stdout.Write(data); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		var msg ShellMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "exit":
+			return msg.ExitCode, nil
+		case "error":
+			return 0, errors.New(msg.Error)
+		}
 	}
 }
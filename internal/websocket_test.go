@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// upperSession writes its input in upper case until its input is closed, it
+// then exits with the number of rows of its last size
+type upperSession struct {
+	*io.PipeReader
+	w *io.PipeWriter
+
+	mu   sync.Mutex
+	size models.TerminalSize
+}
+
+func (s *upperSession) Write(p []byte) (int, error) {
+	return s.w.Write(bytes.ToUpper(p))
+}
+
+func (s *upperSession) Close() error {
+	return s.w.Close()
+}
+
+func (s *upperSession) Resize(_ context.Context, size models.TerminalSize) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.size = size
+	return nil
+}
+
+func (s *upperSession) Wait(context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int(s.size.Rows), nil
+}
+
+func TestShellBridge(t *testing.T) {
+	r, w := io.Pipe()
+	session := &upperSession{PipeReader: r, w: w}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := UpgradeConnection.Upgrade(rw, req, nil)
+		require.NoError(t, err)
+		BridgeShell(req.Context(), conn, session)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+
+	resizes := make(chan models.TerminalSize, 1)
+	resizes <- models.TerminalSize{Rows: 42, Cols: 80}
+	stdin, input := io.Pipe()
+	output, stdout := io.Pipe()
+
+	exited := make(chan int)
+	go func() {
+		code, err := AttachShell(conn, stdin, stdout, resizes)
+		assert.NoError(t, err)
+		exited <- code
+	}()
+
+	_, err = input.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(output, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", string(buf))
+
+	require.Eventually(t, func() bool {
+		session.mu.Lock()
+		defer session.mu.Unlock()
+		return session.size.Rows == 42
+	}, time.Second, 10*time.Millisecond)
+
+	// the command exits, its exit code is sent last
+	require.NoError(t, session.Close())
+	assert.Equal(t, 42, <-exited)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIToken is a bearer token of the REST API. Only the SHA-256 hash of the
+// token is stored, the token itself is shown once when it is issued. The hash
+// is left empty in the API responses.
+type APIToken struct {
+	gorm.Model
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	Hash      string     `json:"hash,omitempty" gorm:"uniqueIndex"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -40,12 +40,18 @@ type DeploymentRequest struct {
 		Power      int    `json:"power"`
 		Time       int    `json:"time"`
 	} `json:"constraints"`
-	TraceInfo struct {
-		TraceID     string `json:"trace_id"`
-		SpanID      string `json:"span_id"`
-		TraceFlags  string `json:"trace_flags"`
-		TraceStates string `json:"trace_state"`
-	} `json:"traceinfo"`
+	// TraceInfo is filled by /run/request-service. The legacy receiver of
+	// deployment requests doesn't pass it on to the executors yet.
+	TraceInfo TraceInfo `json:"traceinfo"`
+}
+
+// TraceInfo carries a trace context along with a request, so that the
+// receiving DMS and its executors continue the trace of the requester
+type TraceInfo struct {
+	TraceID     string `json:"trace_id"`
+	SpanID      string `json:"span_id"`
+	TraceFlags  string `json:"trace_flags"`
+	TraceStates string `json:"trace_state"`
 }
 
 type DeploymentResponse struct {
@@ -1,5 +1,12 @@
 package models
 
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/nunet/device-management-service/utils/validate"
+)
+
 const (
 	ExecutorTypeDocker      = "docker"
 	ExecutorTypeFirecracker = "firecracker"
@@ -17,6 +24,30 @@ type ExecutionRequest struct {
 	Inputs      []*StorageVolume    // Input volumes for the execution
 	Outputs     []*StorageVolume    // Output volumes for the results
 	ResultsDir  string              // Directory to store the results
+	TraceInfo   TraceInfo           // Trace context of the request starting the execution, e.g. of the service provider
+}
+
+// JobSpec describes a job submitted to the DMS, e.g. with nunet job run
+type JobSpec struct {
+	Engine    *SpecConfig         `json:"engine"`              // engine spec, its type selects the executor, e.g. docker
+	Resources *ExecutionResources `json:"resources,omitempty"` // resources of the execution
+	Inputs    []*StorageVolume    `json:"inputs,omitempty"`    // volumes mounted in the execution
+	Outputs   []*StorageVolume    `json:"outputs,omitempty"`   // volumes receiving the results
+}
+
+// Validate checks that the spec has an engine and that its volumes have a source and a target
+func (s *JobSpec) Validate() error {
+	if err := s.Engine.Validate(); err != nil {
+		return fmt.Errorf("engine: %w", err)
+	}
+	for kind, volumes := range map[string][]*StorageVolume{"input": s.Inputs, "output": s.Outputs} {
+		for i, volume := range volumes {
+			if volume == nil || validate.IsBlank(volume.Source) || validate.IsBlank(volume.Target) {
+				return fmt.Errorf("%s %d: missing source or target", kind, i)
+			}
+		}
+	}
+	return nil
 }
 
 // ExecutionResult is the result of an execution
@@ -54,3 +85,35 @@ type LogStreamRequest struct {
 	Tail        bool   // Tail the logs
 	Follow      bool   // Follow the logs
 }
+
+const (
+	ExecutionStatusRunning  = "running"
+	ExecutionStatusFinished = "finished" // exited with ExecutionStatusCodeSuccess
+	ExecutionStatusFailed   = "failed"
+)
+
+// ExecutionInfo describes an execution of the DMS, running or finished
+type ExecutionInfo struct {
+	ID         string           `json:"id"`
+	JobID      string           `json:"job_id"`
+	Executor   string           `json:"executor"` // e.g. docker
+	Status     string           `json:"status"`   // running, finished or failed
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+	Result     *ExecutionResult `json:"result,omitempty"`
+	Node       string           `json:"node,omitempty"` // peer ID of the node running the execution, empty for this node
+}
+
+// ShellRequest is the request object for running an interactive command in a
+// running execution, see executor.Shell
+type ShellRequest struct {
+	Command []string     `json:"command"` // command to run, the shell of the execution when empty
+	TTY     bool         `json:"tty"`     // allocate a pseudo-terminal, stdout and stderr are then merged
+	Size    TerminalSize `json:"size"`    // initial size of the pseudo-terminal
+}
+
+// TerminalSize is the size of a pseudo-terminal in characters
+type TerminalSize struct {
+	Rows uint16 `json:"rows"`
+	Cols uint16 `json:"cols"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// HeartbeatKind is the kind of entity sending heartbeats
+type HeartbeatKind string
+
+const (
+	NodeHeartbeat       HeartbeatKind = "node"
+	AllocationHeartbeat HeartbeatKind = "allocation"
+)
+
+// Heartbeat is sent periodically by a node or an allocation to a sink to
+// tell that it is alive
+type Heartbeat struct {
+	Source   string           `json:"source"` // peer ID of the node or ID of the allocation
+	Kind     HeartbeatKind    `json:"kind"`
+	Sequence uint64           `json:"sequence"` // incremented with every heartbeat of the source, lost heartbeats leave gaps
+	Time     time.Time        `json:"time"`
+	Period   time.Duration    `json:"period"` // time until the next heartbeat, used by the receiver to detect missed heartbeats
+	Payload  HeartbeatPayload `json:"payload"`
+}
+
+// HeartbeatPayload is the state of the source reported in its heartbeats
+type HeartbeatPayload struct {
+	Resources  *FreeResources       `json:"resources,omitempty"`  // free resources of the node
+	Executions []HeartbeatExecution `json:"executions,omitempty"` // running executions
+}
+
+// HeartbeatExecution is a running execution reported in a heartbeat
+type HeartbeatExecution struct {
+	Executor    string    `json:"executor"`
+	JobID       string    `json:"job_id"`
+	ExecutionID string    `json:"execution_id"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// HeartbeatStatus is the state of a source on the receiving side
+type HeartbeatStatus struct {
+	Peer     string           `json:"peer"` // peer the heartbeats are received from
+	Source   string           `json:"source"`
+	Kind     HeartbeatKind    `json:"kind"`
+	Period   time.Duration    `json:"period"`
+	Sequence uint64           `json:"sequence"` // sequence of the last heartbeat
+	Lost     uint64           `json:"lost"`     // heartbeats missing from the sequence
+	LastSeen time.Time        `json:"last_seen"`
+	Missed   bool             `json:"missed"` // no heartbeat was received within the tolerated periods
+	Payload  HeartbeatPayload `json:"payload"`
+}
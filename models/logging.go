@@ -0,0 +1,8 @@
+package models
+
+// LogLevel is the level of a package logger. The default level of the
+// packages without their own level is reported as the "default" package.
+type LogLevel struct {
+	Package string `json:"package"`
+	Level   string `json:"level"`
+}
@@ -118,9 +118,10 @@ type MachineUUID struct {
 }
 
 type Gpu struct {
-	Name     string `json:"name"`
-	TotVram  uint64 `json:"tot_vram"`
-	FreeVram uint64 `json:"free_vram"`
+	Name     string    `json:"name"`
+	Vendor   GPUVendor `json:"vendor,omitempty"`
+	TotVram  uint64    `json:"tot_vram"`
+	FreeVram uint64    `json:"free_vram"`
 }
 
 type resources struct {
@@ -139,6 +140,7 @@ type PeerData struct {
 	HasGpu               bool          `json:"has_gpu"`
 	AllowCardano         bool          `json:"allow_cardano"`
 	GpuInfo              []Gpu         `json:"gpu_info"`
+	ExecutorTypes        []string      `json:"executor_types,omitempty"`
 	TokenomicsAddress    string        `json:"tokenomics_addrs"`
 	TokenomicsBlockchain string        `json:"tokenomics_blockchain"`
 	AvailableResources   FreeResources `json:"available_resources"`
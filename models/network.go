@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+// NetworkType is the type of network a DMS can join
+type NetworkType string
+
+const (
+	NetP2P NetworkType = "p2p"
+)
+
+// NetConfig holds the configuration of a network implementation
+type NetConfig struct {
+	// NetworkSpec holds the implementation specific parameters
+	NetworkSpec SpecConfig `json:"network_spec"`
+}
+
+// MessageInfo describes a message connection between two hosts
+type MessageInfo struct {
+	LocalAddress  string    `json:"local_address"`
+	RemoteAddress string    `json:"remote_address"`
+	Protocol      string    `json:"protocol"`
+	Direction     string    `json:"direction"`
+	Opened        time.Time `json:"opened"`
+}
+
+// VPNRoute maps a virtual IP of the overlay network to the peer hosting it
+type VPNRoute struct {
+	Address string `json:"address"` // virtual IP of an allocation, e.g. 10.200.0.2
+	PeerID  string `json:"peer_id"` // libp2p peer ID of the DMS running the allocation
+}
+
+// NetStat describes the state of the network of a DMS
+type NetStat struct {
+	ID                string        `json:"id"`
+	ListenAddrs       []string      `json:"listen_addrs"`
+	ObservedAddrs     []string      `json:"observed_addrs"` // own addresses as reported by other peers
+	Reachability      string        `json:"reachability"`   // Unknown, Public or Private
+	NATDeviceType     string        `json:"nat_device_type,omitempty"`
+	RelayReservations []string      `json:"relay_reservations"` // peer IDs of the relays this host has a reservation with
+	Connections       ConnStat      `json:"connections"`
+	Bandwidth         BandwidthStat `json:"bandwidth"`
+}
+
+// ConnStat counts the open connections of a DMS
+type ConnStat struct {
+	Peers    int `json:"peers"`
+	Inbound  int `json:"inbound"`
+	Outbound int `json:"outbound"`
+	Relayed  int `json:"relayed"`
+}
+
+// PeerStat describes a peer known to a DMS
+type PeerStat struct {
+	ID        string        `json:"id"`
+	Addrs     []string      `json:"addrs"`
+	Connected bool          `json:"connected"`
+	Direction string        `json:"direction,omitempty"` // inbound or outbound while connected
+	Relayed   bool          `json:"relayed"`             // connected through a relay
+	Latency   time.Duration `json:"latency"`             // moving average of the round trip time, 0 when unknown
+}
+
+// BandwidthStat holds the bandwidth totals in bytes and current rates in bytes per second
+type BandwidthStat struct {
+	TotalIn  int64   `json:"total_in"`
+	TotalOut int64   `json:"total_out"`
+	RateIn   float64 `json:"rate_in"`
+	RateOut  float64 `json:"rate_out"`
+}
+
+// NetUsage reports the resources used by the libp2p host against its limits
+type NetUsage struct {
+	System    ScopeUsage            `json:"system"`
+	Transient ScopeUsage            `json:"transient"` // resources not yet attached to a peer or protocol
+	Protocols map[string]ScopeUsage `json:"protocols"`
+	Bandwidth BandwidthUsage        `json:"bandwidth"`
+}
+
+// ScopeUsage holds the usage of a resource manager scope
+type ScopeUsage struct {
+	StreamsInbound  Usage `json:"streams_inbound"`
+	StreamsOutbound Usage `json:"streams_outbound"`
+	ConnsInbound    Usage `json:"conns_inbound"`
+	ConnsOutbound   Usage `json:"conns_outbound"`
+	FD              Usage `json:"fd"`
+	Memory          Usage `json:"memory"` // in bytes
+}
+
+// Usage is the current use of a resource, a Limit of 0 means unlimited
+type Usage struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+// BandwidthUsage holds the current rates against the bandwidth caps, in bytes per second
+type BandwidthUsage struct {
+	Total     BandwidthLimit            `json:"total"`
+	Protocols map[string]BandwidthLimit `json:"protocols"`
+}
+
+// BandwidthLimit is the current rate of a stream scope and its caps, a cap of 0 means unlimited
+type BandwidthLimit struct {
+	RateIn  float64 `json:"rate_in"`
+	RateOut float64 `json:"rate_out"`
+	MaxIn   int64   `json:"max_in"`
+	MaxOut  int64   `json:"max_out"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskState is the persisted state of a scheduler task
+type TaskState string
+
+const (
+	TaskEnabled  TaskState = "enabled"
+	TaskDisabled TaskState = "disabled"
+)
+
+// TaskDefinition is the persisted definition of a scheduler task. Task
+// functions are registered in code and matched to their definition by name,
+// the definition keeps the changes made at runtime, such as disabling the
+// task, across restarts.
+type TaskDefinition struct {
+	gorm.Model
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Triggers    string        `json:"triggers"` // description of the triggers
+	State       TaskState     `json:"state"`
+	Priority    int           `json:"priority"`
+	MaxRetries  int           `json:"max_retries"`
+	RetryDelay  time.Duration `json:"retry_delay"`
+}
+
+// TaskExecution records an execution of a scheduler task
+type TaskExecution struct {
+	gorm.Model
+	TaskName  string    `json:"task_name"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error"`
+}
+
+// TaskStatus describes a scheduler task and its latest executions
+type TaskStatus struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Triggers    []string        `json:"triggers"`
+	Enabled     bool            `json:"enabled"`
+	Running     bool            `json:"running"`
+	Priority    int             `json:"priority"`
+	History     []TaskExecution `json:"history,omitempty"`
+}
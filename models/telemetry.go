@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TelemetryEvent records an observed telemetry event, see the telemetry
+// package. Fields holds the JSON encoded fields of the event.
+type TelemetryEvent struct {
+	gorm.Model
+	Name     string    `json:"name"`
+	Category string    `json:"category"`
+	Level    string    `json:"level"`
+	Time     time.Time `json:"time"`
+	Fields   string    `json:"fields"`
+}
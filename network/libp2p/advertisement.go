@@ -0,0 +1,257 @@
+package libp2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
+)
+
+const (
+	// AdTypePeerInfo is the advertisement type used for the machine's PeerData
+	AdTypePeerInfo = "peer_info"
+
+	// advertisementTTL is how long an advertisement is considered valid after being published
+	advertisementTTL = 1 * time.Hour
+
+	// readvertiseInterval must be shorter than advertisementTTL so that records are
+	// refreshed before other peers start rejecting them
+	readvertiseInterval = 20 * time.Minute
+
+	// readvertiseJitter spreads the re-advertisements of the nodes, the
+	// interval plus the jitter stays below advertisementTTL
+	readvertiseJitter = 5 * time.Minute
+)
+
+var (
+	// ErrAdvertisementExpired is returned when an advertisement is past its expiry
+	ErrAdvertisementExpired = errors.New("advertisement expired")
+
+	// ErrNotAdvertised is returned when the record found for a key is a tombstone
+	// written by Unadvertise
+	ErrNotAdvertised = errors.New("peer is not advertising this type")
+)
+
+// Advertisement is a typed and versioned record published on the DHT.
+// Records are keyed by type and peer ID and wrapped in a signed
+// models.KadDHTMachineUpdate before being stored.
+type Advertisement struct {
+	PeerID    string `json:"peer_id"`
+	Type      string `json:"type"`
+	Seq       uint64 `json:"seq"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Expiry    int64  `json:"expiry"`
+	Data      []byte `json:"data"`
+}
+
+// Expired returns true if the advertisement is no longer valid at the given time
+func (a Advertisement) Expired(now time.Time) bool {
+	return a.Expiry <= now.Unix()
+}
+
+// IsTombstone returns true if the advertisement was written by Unadvertise
+func (a Advertisement) IsTombstone() bool {
+	return len(a.Data) == 0
+}
+
+// adRegistry keeps track of the advertisements published by this host so
+// that sequence numbers stay monotonic and records can be re-published
+// before they expire.
+type adRegistry struct {
+	mu  sync.Mutex
+	ads map[string]Advertisement
+}
+
+func newAdRegistry() *adRegistry {
+	return &adRegistry{ads: make(map[string]Advertisement)}
+}
+
+// next builds the next advertisement of the given type, making sure its
+// sequence number is higher than any previously published one. The wall
+// clock is used as a floor so that sequence numbers keep increasing across
+// restarts.
+func (r *adRegistry) next(peerID, adType string, data []byte) Advertisement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	seq := uint64(now.UnixNano())
+	if prev, ok := r.ads[adType]; ok && prev.Seq >= seq {
+		seq = prev.Seq + 1
+	}
+
+	ad := Advertisement{
+		PeerID:    peerID,
+		Type:      adType,
+		Seq:       seq,
+		Timestamp: now.Unix(),
+		Expiry:    now.Add(advertisementTTL).Unix(),
+		Data:      data,
+	}
+	r.ads[adType] = ad
+	return ad
+}
+
+// active returns the non-tombstone advertisements currently held
+func (r *adRegistry) active() map[string][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	active := make(map[string][]byte)
+	for adType, ad := range r.ads {
+		if !ad.IsTombstone() {
+			active[adType] = ad.Data
+		}
+	}
+	return active
+}
+
+// adKey returns the DHT key for an advertisement of the given type by the given peer
+func adKey(adType string, id peer.ID) string {
+	return customNamespace + adType + "/" + id.String()
+}
+
+// parseAdKey splits a DHT key into its advertisement type and peer ID
+func parseAdKey(key string) (string, peer.ID, error) {
+	if !strings.HasPrefix(key, customNamespace) {
+		return "", "", errors.New("invalid key namespace")
+	}
+
+	components := strings.Split(strings.TrimPrefix(key, customNamespace), "/")
+	if len(components) != 2 || components[0] == "" {
+		return "", "", fmt.Errorf("invalid advertisement key: %s", key)
+	}
+
+	id, err := peer.Decode(components[1])
+	if err != nil {
+		return "", "", fmt.Errorf("error decoding peerID: %w", err)
+	}
+	return components[0], id, nil
+}
+
+// signAdvertisement marshals and signs the advertisement, returning the bytes to be stored on the DHT
+func signAdvertisement(priv crypto.PrivKey, ad Advertisement) ([]byte, error) {
+	adBytes, err := json.Marshal(ad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal advertisement: %w", err)
+	}
+
+	signature, err := signData(priv, adBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign advertisement: %w", err)
+	}
+
+	record, err := json.Marshal(models.KadDHTMachineUpdate{
+		Data:      adBytes,
+		Signature: signature,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal signed advertisement: %w", err)
+	}
+	return record, nil
+}
+
+// decodeAdvertisement unwraps a DHT record into an Advertisement without verifying its signature
+func decodeAdvertisement(value []byte) (Advertisement, models.KadDHTMachineUpdate, error) {
+	var record models.KadDHTMachineUpdate
+	if len(value) == 0 {
+		return Advertisement{}, record, errors.New("value cannot be empty")
+	}
+
+	err := json.Unmarshal(value, &record)
+	if err != nil {
+		return Advertisement{}, record, fmt.Errorf("error unmarshalling record: %w", err)
+	}
+
+	var ad Advertisement
+	err = json.Unmarshal(record.Data, &ad)
+	if err != nil {
+		return Advertisement{}, record, fmt.Errorf("error unmarshalling advertisement: %w", err)
+	}
+	return ad, record, nil
+}
+
+// Advertise publishes data on the DHT under the given advertisement type.
+// Every call produces a new record with a higher sequence number and a fresh expiry.
+func (p *Libp2p) Advertise(adId string, data []byte) error {
+	if len(data) == 0 {
+		return errors.New("advertisement data cannot be empty, use Unadvertise instead")
+	}
+	return p.putAdvertisement(context.Background(), adId, data)
+}
+
+// Unadvertise replaces the advertisement of the given type with a tombstone.
+// Values can't be deleted from the DHT, so an empty record with a higher
+// sequence number is published instead and wins over the previous one.
+func (p *Libp2p) Unadvertise(adId string) error {
+	return p.putAdvertisement(context.Background(), adId, nil)
+}
+
+func (p *Libp2p) putAdvertisement(ctx context.Context, adType string, data []byte) error {
+	if p.ads == nil {
+		p.ads = newAdRegistry()
+	}
+
+	ad := p.ads.next(p.Host.ID().String(), adType, data)
+	record, err := signAdvertisement(p.Host.Peerstore().PrivKey(p.Host.ID()), ad)
+	if err != nil {
+		return err
+	}
+
+	err = p.DHT.PutValue(ctx, adKey(adType, p.Host.ID()), record)
+	if err != nil {
+		return fmt.Errorf("failed to put advertisement %s on the DHT: %w", adType, err)
+	}
+
+	// the capability index is refreshed along with the PeerData it points to
+	if adType == AdTypePeerInfo && len(data) > 0 {
+		return p.indexCapabilities(ctx, data)
+	}
+	return nil
+}
+
+// readvertise re-publishes every active advertisement so that it doesn't expire
+func (p *Libp2p) readvertise(ctx context.Context) error {
+	if p.ads == nil {
+		return nil
+	}
+
+	var errs []error
+	for adType, data := range p.ads.active() {
+		if err := p.putAdvertisement(ctx, adType, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetAdvertisement fetches and validates the advertisement of the given type published by a peer
+func (p *Libp2p) GetAdvertisement(ctx context.Context, adType string, id peer.ID) (Advertisement, error) {
+	start := time.Now()
+	value, err := p.DHT.GetValue(ctx, adKey(adType, id))
+	metrics.ObserveDHTLookup("get_value", start, err)
+	if err != nil {
+		return Advertisement{}, err
+	}
+
+	ad, _, err := decodeAdvertisement(value)
+	if err != nil {
+		return Advertisement{}, err
+	}
+	if ad.IsTombstone() {
+		return Advertisement{}, ErrNotAdvertised
+	}
+	if ad.Expired(time.Now()) {
+		return Advertisement{}, ErrAdvertisementExpired
+	}
+	return ad, nil
+}
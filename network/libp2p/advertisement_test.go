@@ -0,0 +1,108 @@
+package libp2p
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIdentity(t *testing.T) (crypto.PrivKey, peer.ID) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	require.NoError(t, err)
+	id, err := peer.IDFromPrivateKey(priv)
+	require.NoError(t, err)
+	return priv, id
+}
+
+func newTestValidator(t *testing.T) dhtValidator {
+	ps, err := pstoremem.NewPeerstore()
+	require.NoError(t, err)
+	return dhtValidator{PS: ps}
+}
+
+func TestAdRegistrySeqIsMonotonic(t *testing.T) {
+	registry := newAdRegistry()
+
+	first := registry.next("peer", AdTypePeerInfo, []byte("a"))
+	second := registry.next("peer", AdTypePeerInfo, []byte("b"))
+	tombstone := registry.next("peer", AdTypePeerInfo, nil)
+
+	assert.Greater(t, second.Seq, first.Seq)
+	assert.Greater(t, tombstone.Seq, second.Seq)
+	assert.True(t, tombstone.IsTombstone())
+	assert.Empty(t, registry.active(), "tombstoned advertisements should not be re-advertised")
+}
+
+func TestParseAdKey(t *testing.T) {
+	_, id := newTestIdentity(t)
+
+	adType, parsedID, err := parseAdKey(adKey(AdTypePeerInfo, id))
+	assert.NoError(t, err)
+	assert.Equal(t, AdTypePeerInfo, adType)
+	assert.Equal(t, id, parsedID)
+
+	_, _, err = parseAdKey("/other-namespace/" + AdTypePeerInfo + "/" + id.String())
+	assert.Error(t, err)
+
+	_, _, err = parseAdKey(customNamespace + id.String())
+	assert.Error(t, err, "keys without an advertisement type should be rejected")
+}
+
+func TestDHTValidatorValidate(t *testing.T) {
+	validator := newTestValidator(t)
+	priv, id := newTestIdentity(t)
+	key := adKey(AdTypePeerInfo, id)
+
+	ad := newAdRegistry().next(id.String(), AdTypePeerInfo, []byte(`{"peer_id":"x"}`))
+	record, err := signAdvertisement(priv, ad)
+	require.NoError(t, err)
+	assert.NoError(t, validator.Validate(key, record))
+
+	// expired records are rejected
+	expired := ad
+	expired.Expiry = time.Now().Add(-time.Minute).Unix()
+	record, err = signAdvertisement(priv, expired)
+	require.NoError(t, err)
+	assert.ErrorIs(t, validator.Validate(key, record), ErrAdvertisementExpired)
+
+	// records signed by another peer are rejected
+	otherPriv, _ := newTestIdentity(t)
+	record, err = signAdvertisement(otherPriv, ad)
+	require.NoError(t, err)
+	assert.Error(t, validator.Validate(key, record))
+
+	// records stored under another type are rejected
+	record, err = signAdvertisement(priv, ad)
+	require.NoError(t, err)
+	assert.Error(t, validator.Validate(adKey("other", id), record))
+}
+
+func TestDHTValidatorSelect(t *testing.T) {
+	validator := newTestValidator(t)
+	priv, id := newTestIdentity(t)
+	key := adKey(AdTypePeerInfo, id)
+	registry := newAdRegistry()
+
+	older, err := signAdvertisement(priv, registry.next(id.String(), AdTypePeerInfo, []byte("old")))
+	require.NoError(t, err)
+	newer, err := signAdvertisement(priv, registry.next(id.String(), AdTypePeerInfo, []byte("new")))
+	require.NoError(t, err)
+
+	expiredAd := registry.next(id.String(), AdTypePeerInfo, []byte("expired"))
+	expiredAd.Expiry = time.Now().Add(-time.Minute).Unix()
+	expired, err := signAdvertisement(priv, expiredAd)
+	require.NoError(t, err)
+
+	idx, err := validator.Select(key, [][]byte{older, expired, newer, []byte("garbage")})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, idx, "newest valid record should be selected")
+
+	_, err = validator.Select(key, [][]byte{expired, []byte("garbage")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,321 @@
+package libp2p
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"golang.org/x/time/rate"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// minBurst is the smallest burst of a rate limiter so that low caps don't
+// split every read and write into tiny chunks
+const minBurst = 4 << 10
+
+// newRateLimiter returns a limiter allowing rate bytes per second, or nil
+// when rate is not positive
+func newRateLimiter(r int64) *rate.Limiter {
+	if r <= 0 {
+		return nil
+	}
+	burst := int(r)
+	if burst < minBurst {
+		burst = minBurst
+	}
+	return rate.NewLimiter(rate.Limit(r), burst)
+}
+
+// directionBuckets holds the rate limiters of a scope for each direction,
+// nil when the direction is not capped
+type directionBuckets struct {
+	in, out *rate.Limiter
+}
+
+func newDirectionBuckets(maxIn, maxOut int64) directionBuckets {
+	return directionBuckets{in: newRateLimiter(maxIn), out: newRateLimiter(maxOut)}
+}
+
+// direction returns the limiter of the direction
+func (b directionBuckets) direction(inbound bool) *rate.Limiter {
+	if inbound {
+		return b.in
+	}
+	return b.out
+}
+
+// bandwidthLimiter enforces the total and per-protocol bandwidth caps on the
+// streams of the host
+type bandwidthLimiter struct {
+	config    config.Bandwidth
+	total     directionBuckets
+	protocols map[protocol.ID]directionBuckets
+}
+
+// newBandwidthLimiter returns the limiter enforcing the configured caps, or
+// nil when no cap is configured
+func newBandwidthLimiter(cfg config.Bandwidth) *bandwidthLimiter {
+	l := &bandwidthLimiter{
+		config:    cfg,
+		total:     newDirectionBuckets(cfg.MaxIn, cfg.MaxOut),
+		protocols: make(map[protocol.ID]directionBuckets),
+	}
+
+	limited := l.total.in != nil || l.total.out != nil
+	for _, p := range cfg.Protocols {
+		if p.Protocol == "" || (p.MaxIn <= 0 && p.MaxOut <= 0) {
+			continue
+		}
+		l.protocols[protocol.ID(p.Protocol)] = newDirectionBuckets(p.MaxIn, p.MaxOut)
+		limited = true
+	}
+
+	if !limited {
+		return nil
+	}
+	return l
+}
+
+// wait blocks until n bytes, at most the chunk of the protocol, can be
+// transferred in the given direction for the protocol. It gives up with
+// os.ErrDeadlineExceeded once the deadline, if any, passes and with
+// net.ErrClosed once closed is closed, the bytes are then given back.
+func (l *bandwidthLimiter) wait(proto protocol.ID, n int, inbound bool, deadline time.Time, closed <-chan struct{}) error {
+	if l == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var (
+		reservations []*rate.Reservation
+		delay        time.Duration
+	)
+	for _, b := range []directionBuckets{l.total, l.protocols[proto]} {
+		limiter := b.direction(inbound)
+		if limiter == nil {
+			continue
+		}
+		r := limiter.ReserveN(now, n)
+		reservations = append(reservations, r)
+		if d := r.DelayFrom(now); d > delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return nil
+	}
+	cancel := func() {
+		for _, r := range reservations {
+			r.Cancel()
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		deadlineTimer := time.NewTimer(time.Until(deadline))
+		defer deadlineTimer.Stop()
+		expired = deadlineTimer.C
+	}
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-expired:
+		cancel()
+		return os.ErrDeadlineExceeded
+	case <-closed:
+		cancel()
+		return net.ErrClosed
+	}
+}
+
+// chunk returns the largest number of bytes to transfer at once for the
+// protocol, the smallest burst of its limiters, or 0 when it is not capped
+func (l *bandwidthLimiter) chunk(proto protocol.ID, inbound bool) int {
+	if l == nil {
+		return 0
+	}
+	size := 0
+	for _, b := range []directionBuckets{l.total, l.protocols[proto]} {
+		limiter := b.direction(inbound)
+		if limiter == nil {
+			continue
+		}
+		if c := limiter.Burst(); size == 0 || c < size {
+			size = c
+		}
+	}
+	return size
+}
+
+// limitedStream throttles the reads and writes of a stream, the throttling
+// respects the deadlines of the stream and stops when it is closed or reset.
+// The bytes are also logged to the reporter, if any.
+type limitedStream struct {
+	network.Stream
+	limiter  *bandwidthLimiter // nil when no cap is configured
+	reporter metrics.Reporter  // nil when the host already meters its streams
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (s *limitedStream) Read(b []byte) (int, error) {
+	proto := s.Protocol()
+	if size := s.limiter.chunk(proto, true); size > 0 && len(b) > size {
+		b = b[:size]
+	}
+
+	n, err := s.Stream.Read(b)
+	if n > 0 && s.reporter != nil {
+		s.reporter.LogRecvMessageStream(int64(n), proto, s.Conn().RemotePeer())
+	}
+	if n > 0 {
+		s.mu.Lock()
+		deadline := s.readDeadline
+		s.mu.Unlock()
+		if werr := s.limiter.wait(proto, n, true, deadline, s.closed); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+func (s *limitedStream) Write(b []byte) (int, error) {
+	proto := s.Protocol()
+	size := s.limiter.chunk(proto, false)
+	if size <= 0 {
+		size = len(b)
+	}
+
+	written := 0
+	for written < len(b) {
+		end := written + size
+		if end > len(b) {
+			end = len(b)
+		}
+		s.mu.Lock()
+		deadline := s.writeDeadline
+		s.mu.Unlock()
+		if err := s.limiter.wait(proto, end-written, false, deadline, s.closed); err != nil {
+			return written, err
+		}
+
+		n, err := s.Stream.Write(b[written:end])
+		if n > 0 && s.reporter != nil {
+			s.reporter.LogSentMessageStream(int64(n), proto, s.Conn().RemotePeer())
+		}
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (s *limitedStream) SetDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.readDeadline, s.writeDeadline = t, t
+	s.mu.Unlock()
+	return s.Stream.SetDeadline(t)
+}
+
+func (s *limitedStream) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.readDeadline = t
+	s.mu.Unlock()
+	return s.Stream.SetReadDeadline(t)
+}
+
+func (s *limitedStream) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	s.writeDeadline = t
+	s.mu.Unlock()
+	return s.Stream.SetWriteDeadline(t)
+}
+
+func (s *limitedStream) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return s.Stream.Close()
+}
+
+func (s *limitedStream) Reset() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return s.Stream.Reset()
+}
+
+// limitedHost applies the bandwidth caps to the streams opened and handled
+// through the host and meters them
+type limitedHost struct {
+	host.Host
+	limiter  *bandwidthLimiter
+	reporter metrics.Reporter
+}
+
+// withBandwidthLimits wraps the host so that its streams respect the caps of
+// the limiter and are metered by the reporter. The reporter is only needed
+// for hosts which don't meter their streams. The host is returned as is
+// when there is neither a limiter nor a reporter.
+func withBandwidthLimits(h host.Host, limiter *bandwidthLimiter, reporter metrics.Reporter) host.Host {
+	if limiter == nil && reporter == nil {
+		return h
+	}
+	return &limitedHost{Host: h, limiter: limiter, reporter: reporter}
+}
+
+func (h *limitedHost) wrap(s network.Stream) network.Stream {
+	return &limitedStream{Stream: s, limiter: h.limiter, reporter: h.reporter, closed: make(chan struct{})}
+}
+
+// Unwrap returns the underlying host
+func (h *limitedHost) Unwrap() host.Host {
+	return h.Host
+}
+
+func (h *limitedHost) NewStream(ctx context.Context, p peer.ID, pids ...protocol.ID) (network.Stream, error) {
+	s, err := h.Host.NewStream(ctx, p, pids...)
+	if err != nil {
+		return nil, err
+	}
+	return h.wrap(s), nil
+}
+
+func (h *limitedHost) SetStreamHandler(pid protocol.ID, handler network.StreamHandler) {
+	h.Host.SetStreamHandler(pid, h.wrapHandler(handler))
+}
+
+func (h *limitedHost) SetStreamHandlerMatch(pid protocol.ID, match func(protocol.ID) bool, handler network.StreamHandler) {
+	h.Host.SetStreamHandlerMatch(pid, match, h.wrapHandler(handler))
+}
+
+func (h *limitedHost) wrapHandler(handler network.StreamHandler) network.StreamHandler {
+	return func(s network.Stream) {
+		handler(h.wrap(s))
+	}
+}
+
+// unwrapHost returns the host created by libp2p beneath the wrappers of this package
+func unwrapHost(h host.Host) host.Host {
+	for {
+		u, ok := h.(interface{ Unwrap() host.Host })
+		if !ok {
+			return h
+		}
+		h = u.Unwrap()
+	}
+}
@@ -0,0 +1,227 @@
+package libp2p
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+const testProtocolID = protocol.ID("/nunet/test/1.0.0")
+
+func TestRateLimiter(t *testing.T) {
+	assert.Nil(t, newRateLimiter(0))
+
+	b := newRateLimiter(10 << 10)
+	now := time.Now()
+	assert.Zero(t, b.ReserveN(now, 10<<10).DelayFrom(now), "the first burst should not wait")
+	delay := b.ReserveN(now, 5<<10).DelayFrom(now)
+	assert.InDelta(t, 500*time.Millisecond, delay, float64(50*time.Millisecond))
+
+	small := newRateLimiter(10)
+	assert.Equal(t, minBurst, small.Burst(), "the burst should not go below minBurst")
+}
+
+func TestNewBandwidthLimiter(t *testing.T) {
+	assert.Nil(t, newBandwidthLimiter(config.Bandwidth{}))
+	assert.Nil(t, newBandwidthLimiter(config.Bandwidth{
+		Protocols: []config.ProtocolBandwidth{{Protocol: string(testProtocolID)}},
+	}), "protocols without caps should be ignored")
+
+	l := newBandwidthLimiter(config.Bandwidth{
+		MaxOut:    64 << 10,
+		Protocols: []config.ProtocolBandwidth{{Protocol: string(testProtocolID), MaxOut: 8 << 10}},
+	})
+	require.NotNil(t, l)
+	assert.Equal(t, 8<<10, l.chunk(testProtocolID, false), "the smallest cap should bound the chunks")
+	assert.Equal(t, 64<<10, l.chunk(RPCProtocolID, false))
+	assert.Zero(t, l.chunk(RPCProtocolID, true))
+}
+
+func TestLimitedHost(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	limiter := newBandwidthLimiter(config.Bandwidth{
+		Protocols: []config.ProtocolBandwidth{{Protocol: string(testProtocolID), MaxOut: 16 << 10}},
+	})
+	sender := withBandwidthLimits(h1, limiter, nil)
+	assert.Equal(t, h1, unwrapHost(sender))
+	assert.Equal(t, h2, withBandwidthLimits(h2, nil, nil), "the host should not be wrapped without caps")
+
+	received := make(chan int, 1)
+	h2.SetStreamHandler(testProtocolID, func(s network.Stream) {
+		defer s.Close()
+		data, _ := io.ReadAll(s)
+		received <- len(data)
+	})
+
+	s, err := sender.NewStream(context.Background(), h2.ID(), testProtocolID)
+	require.NoError(t, err)
+
+	// the first 16KiB are sent in a burst, the next 16KiB take about a second
+	start := time.Now()
+	n, err := s.Write(make([]byte, 32<<10))
+	require.NoError(t, err)
+	require.NoError(t, s.CloseWrite())
+	assert.Equal(t, 32<<10, n)
+	assert.GreaterOrEqual(t, time.Since(start), 800*time.Millisecond)
+
+	select {
+	case size := <-received:
+		assert.Equal(t, 32<<10, size)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the data")
+	}
+}
+
+func TestLimitedStreamDeadline(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	limiter := newBandwidthLimiter(config.Bandwidth{
+		Protocols: []config.ProtocolBandwidth{{Protocol: string(testProtocolID), MaxOut: 4 << 10}},
+	})
+	sender := withBandwidthLimits(h1, limiter, nil)
+	h2.SetStreamHandler(testProtocolID, func(s network.Stream) {
+		defer s.Close()
+		_, _ = io.Copy(io.Discard, s)
+	})
+
+	s, err := sender.NewStream(context.Background(), h2.ID(), testProtocolID)
+	require.NoError(t, err)
+	defer s.Reset()
+
+	// 64KiB take about 15 seconds at 4KiB/s, the write gives up at its deadline
+	require.NoError(t, s.SetWriteDeadline(time.Now().Add(200*time.Millisecond)))
+	start := time.Now()
+	_, err = s.Write(make([]byte, 64<<10))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	require.NoError(t, s.SetWriteDeadline(time.Time{}))
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.Write(make([]byte, 64<<10))
+		result <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, s.Reset())
+
+	select {
+	case err := <-result:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the write was not interrupted by the reset")
+	}
+}
+
+func TestAttachBandwidth(t *testing.T) {
+	withDataDir(t)
+	config.SetConfig("p2p.bandwidth.max_out", 16<<10)
+	t.Cleanup(func() { config.SetConfig("p2p.bandwidth.max_out", 0) })
+
+	h1, h2 := newConnectedHosts(t)
+	received := make(chan int, 1)
+	h2.SetStreamHandler(testProtocolID, func(s network.Stream) {
+		defer s.Close()
+		data, _ := io.ReadAll(s)
+		received <- len(data)
+	})
+
+	p := &Libp2p{}
+	require.NoError(t, p.Attach(h1, nil, Libp2pConfig{}))
+	defer p.Stop()
+
+	s, err := p.Host.NewStream(context.Background(), h2.ID(), testProtocolID)
+	require.NoError(t, err)
+	start := time.Now()
+	_, err = s.Write(make([]byte, 32<<10))
+	require.NoError(t, err)
+	require.NoError(t, s.CloseWrite())
+	assert.GreaterOrEqual(t, time.Since(start), 800*time.Millisecond, "the caps should apply to the attached host")
+	assert.Equal(t, 32<<10, <-received)
+
+	// the meters are updated once per second
+	assert.Eventually(t, func() bool {
+		return p.bandwidth.GetBandwidthForProtocol(testProtocolID).TotalOut == 32<<10
+	}, 3*time.Second, 100*time.Millisecond, "the streams of the attached host should be metered")
+	assert.Equal(t, int64(16<<10), p.Usage().Bandwidth.Total.MaxOut)
+}
+
+func TestCheckAttach(t *testing.T) {
+	assert.NoError(t, CheckAttach(false))
+
+	config.SetConfig("p2p.resource_limits_file", "/etc/nunet/limits.json")
+	t.Cleanup(func() { config.SetConfig("p2p.resource_limits_file", "") })
+	assert.ErrorIs(t, CheckAttach(false), ErrHostOption)
+	assert.ErrorIs(t, (&Libp2p{}).Attach(nil, nil, Libp2pConfig{}), ErrHostOption)
+	config.SetConfig("p2p.resource_limits_file", "")
+
+	config.SetConfig("p2p.private_network.enabled", true)
+	t.Cleanup(func() { config.SetConfig("p2p.private_network.enabled", false) })
+	assert.ErrorIs(t, CheckAttach(false), ErrHostOption, "private network mode should not fall back to the public network")
+	config.SetConfig("p2p.private_network.enabled", false)
+
+	config.SetConfig("p2p.lan.enabled", true)
+	t.Cleanup(func() { config.SetConfig("p2p.lan.enabled", false) })
+	assert.NoError(t, CheckAttach(false), "the addresses are not filtered in client mode")
+	assert.ErrorIs(t, CheckAttach(true), ErrHostOption)
+}
+
+func TestResourceLimiter(t *testing.T) {
+	limiter, err := resourceLimiter("")
+	require.NoError(t, err)
+	assert.Positive(t, limiter.GetSystemLimits().GetStreamTotalLimit())
+
+	path := filepath.Join(t.TempDir(), "limits.json")
+	limits := `{"System": {"Streams": 123}, "Protocol": {"/nunet/vpn/1.0.0": {"StreamsInbound": 7}}}`
+	require.NoError(t, os.WriteFile(path, []byte(limits), 0o600))
+
+	limiter, err = resourceLimiter(path)
+	require.NoError(t, err)
+	assert.Equal(t, 123, limiter.GetSystemLimits().GetStreamTotalLimit())
+	assert.Equal(t, 7, limiter.GetProtocolLimits(VPNProtocolID).GetStreamLimit(network.DirInbound))
+
+	_, err = resourceLimiter(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestUsage(t *testing.T) {
+	h1, _ := newConnectedHosts(t)
+	limits, err := resourceLimiter("")
+	require.NoError(t, err)
+	p := &Libp2p{
+		Host:   h1,
+		limits: limits,
+		limiter: newBandwidthLimiter(config.Bandwidth{
+			MaxIn:     1 << 20,
+			Protocols: []config.ProtocolBandwidth{{Protocol: string(testProtocolID), MaxOut: 1 << 10}},
+		}),
+	}
+
+	usage := p.Usage()
+	assert.Equal(t, int64(1), usage.System.ConnsOutbound.Used)
+	assert.Positive(t, usage.System.ConnsOutbound.Limit)
+	assert.Contains(t, usage.Protocols, string(RPCProtocolID))
+	assert.Contains(t, usage.Protocols, string(testProtocolID))
+
+	assert.Equal(t, int64(1<<20), usage.Bandwidth.Total.MaxIn)
+	assert.Equal(t, int64(1<<10), usage.Bandwidth.Protocols[string(testProtocolID)].MaxOut)
+}
+
+func TestScopeUsageUnlimited(t *testing.T) {
+	limits := rcmgr.NewFixedLimiter(rcmgr.InfiniteLimits)
+	usage := scopeUsage(network.ScopeStat{NumStreamsInbound: 3, Memory: 1 << 40}, limits.GetSystemLimits())
+	assert.Equal(t, models.Usage{Used: 3}, usage.StreamsInbound)
+	assert.Equal(t, models.Usage{Used: 1 << 40}, usage.Memory)
+
+	assert.Equal(t, models.Usage{Used: 1, Limit: 8 << 30}, memoryUsage(1, 8<<30), "large memory limits should be kept")
+}
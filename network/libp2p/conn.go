@@ -2,14 +2,72 @@ package libp2p
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+
+	"gitlab.com/nunet/device-management-service/models"
 )
 
-// Ping pings the given peer and returns the result along with the context cancel function
-func (p Libp2p) Ping(ctx context.Context, targetPeer peer.ID) (<-chan ping.Result, func()) {
+// DefaultPingTimeout is used when Ping is called without a timeout
+const DefaultPingTimeout = 5 * time.Second
+
+// Ping pings the peer given in the "peer_id" param of the address and returns the round trip time
+func (p *Libp2p) Ping(ctx context.Context, address models.SpecConfig, timeout time.Duration) (models.PingResult, error) {
+	target, err := peerIDFromSpec(address)
+	if err != nil {
+		return models.PingResult{Error: err}, err
+	}
+	if target == p.Host.ID() {
+		err = errors.New("can't ping self")
+		return models.PingResult{Error: err}, err
+	}
+
+	if timeout <= 0 {
+		timeout = DefaultPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results, pingCancel := p.pingPeer(ctx, target)
+	defer pingCancel()
+
+	select {
+	case result := <-results:
+		if result.Error != nil {
+			err = fmt.Errorf("failed to ping %s: %w", target.String(), result.Error)
+			return models.PingResult{RTT: result.RTT, Error: err}, err
+		}
+		return models.PingResult{RTT: result.RTT, Success: true}, nil
+	case <-ctx.Done():
+		err = fmt.Errorf("failed to ping %s: %w", target.String(), ctx.Err())
+		return models.PingResult{Error: err}, err
+	}
+}
+
+// pingPeer pings the given peer and returns the result along with the context cancel function
+func (p *Libp2p) pingPeer(ctx context.Context, targetPeer peer.ID) (<-chan ping.Result, func()) {
 	pingCtx, pingCancel := context.WithCancel(ctx)
 	pingResult := ping.Ping(pingCtx, p.Host, targetPeer)
 	return pingResult, pingCancel
 }
+
+// peerIDFromSpec decodes the peer ID of a p2p address
+func peerIDFromSpec(address models.SpecConfig) (peer.ID, error) {
+	if !address.IsType(string(models.NetP2P)) {
+		return "", fmt.Errorf("invalid address type. expected %s, but recieved: %s", models.NetP2P, address.Type)
+	}
+
+	rawID, ok := address.Params["peer_id"].(string)
+	if !ok {
+		return "", errors.New("invalid address: peer_id param is required")
+	}
+	id, err := peer.Decode(rawID)
+	if err != nil {
+		return "", fmt.Errorf("invalid address: %w", err)
+	}
+	return id, nil
+}
@@ -4,9 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"os"
-	"strings"
 	"sync"
 	"time"
 
@@ -18,7 +15,7 @@ import (
 	"gitlab.com/nunet/device-management-service/models"
 )
 
-func (p2p Libp2p) BootstrapNode(ctx context.Context) error {
+func (p2p *Libp2p) BootstrapNode(ctx context.Context) error {
 	return Bootstrap(ctx, p2p.Host, p2p.DHT)
 }
 
@@ -41,7 +38,7 @@ func Bootstrap(ctx context.Context, node host.Host, idht *dht.IpfsDHT) error {
 }
 
 // Cleans up offline peers from DHT
-func (p Libp2p) CleanupOfflinePeers() {
+func (p *Libp2p) CleanupOfflinePeers() {
 	ctx, ctxCancel := context.WithCancel(context.Background())
 	defer ctxCancel()
 	for _, node := range p.Host.Peerstore().Peers() {
@@ -58,29 +55,23 @@ func (p Libp2p) CleanupOfflinePeers() {
 				zlog.Sugar().Errorf("Error decoding peer ID: %v", err)
 				continue
 			}
-			pingResult, pingCancel := p.Ping(ctx, targetPeer)
+			pingResult, pingCancel := p.pingPeer(ctx, targetPeer)
 			result := <-pingResult
 			if result.Error == nil {
-				if _, debugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); debugMode {
-					zlog.Sugar().Infof("Peer is reachable. PeerID: %s", Data.PeerID)
-					pingCancel()
-					continue
-				}
+				zlog.Sugar().Debugf("Peer is reachable. PeerID: %s", Data.PeerID)
 			} else {
-				if _, debugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); debugMode {
-					zlog.Sugar().Infof("Peer - %s is unreachable. Removing from Peerstore.", Data.PeerID)
-					p.Host.Peerstore().Put(node, "peer_info", nil)
-				}
+				zlog.Sugar().Debugf("Peer - %s is unreachable. Removing from Peerstore.", Data.PeerID)
+				p.Host.Peerstore().Put(node, "peer_info", nil)
 			}
 			pingCancel()
 		}
 	}
 }
 
-func (p2p Libp2p) fetchKadDhtContents(ctxt context.Context, resultChan chan models.PeerData) {
+func (p2p *Libp2p) fetchKadDhtContents(ctxt context.Context, resultChan chan models.PeerData) {
 	zlog.Debug("Fetching DHT content for all peers")
 
-	fetchCtx, _ := context.WithTimeout(ctxt, time.Minute)
+	fetchCtx, fetchCancel := context.WithTimeout(ctxt, time.Minute)
 
 	go func() {
 		// Create a wait group to ensure all workers have finished
@@ -106,33 +97,16 @@ func (p2p Libp2p) fetchKadDhtContents(ctxt context.Context, resultChan chan mode
 					zlog.Sugar().Debugf("FetchKadDHTContents: Worker for %s finished", peer.ID.String())
 				}()
 
-				var updates models.KadDHTMachineUpdate
-
-				// Add custom namespace to the key
-				namespacedKey := customNamespace + peer.ID.String()
-				bytes, err := p2p.DHT.GetValue(fetchCtx, namespacedKey)
-
-				if err != nil {
-					if _, debugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); debugMode {
-						zlog.Sugar().Errorf(fmt.Sprintf("Couldn't retrieve dht content for peer: %s", peer.ID.String()))
-					}
-					return
-				}
-
-				err = json.Unmarshal(bytes, &updates)
+				ad, err := p2p.GetAdvertisement(fetchCtx, AdTypePeerInfo, peer.ID)
 				if err != nil {
-					if _, debugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); debugMode {
-						zlog.Sugar().Errorf("Error unmarshalling value: %v", err)
-					}
+					zlog.Sugar().Debugf("Couldn't retrieve dht content for peer: %s - %v", peer.ID.String(), err)
 					return
 				}
 
 				peerInfo := models.PeerData{}
-				err = json.Unmarshal(updates.Data, &peerInfo)
+				err = json.Unmarshal(ad.Data, &peerInfo)
 				if err != nil {
-					if _, debugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); debugMode {
-						zlog.Sugar().Errorf("Error unmarshalling value: %v", err)
-					}
+					zlog.Sugar().Debugf("Error unmarshalling value: %v", err)
 					return
 				}
 
@@ -143,13 +117,14 @@ func (p2p Libp2p) fetchKadDhtContents(ctxt context.Context, resultChan chan mode
 
 		zlog.Debug("FetchKadDHTContents: Waiting for workers to finish")
 		wg.Wait()
+		fetchCancel()
 		zlog.Debug("FetchKadDHTContents: All workers Done. Closing channel")
 		close(resultChan)
 	}()
 }
 
 // Fetches peer info of peers from Kad-DHT and updates Peerstore.
-func (p Libp2p) GetDHTUpdates(ctx context.Context) {
+func (p *Libp2p) GetDHTUpdates(ctx context.Context) {
 	if gettingDHTUpdate {
 		zlog.Debug("GetDHTUpdates: Already Getting DHT Updates")
 		return
@@ -168,20 +143,16 @@ func (p Libp2p) GetDHTUpdates(ctx context.Context) {
 			gettingDHTUpdate = false
 			continue
 		}
-		pingResult, pingCancel := p.Ping(ctx, targetPeer)
+		pingResult, pingCancel := p.pingPeer(ctx, targetPeer)
 		res := <-pingResult
 		if res.Error == nil {
-			if _, verboseDebugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); verboseDebugMode {
-				zlog.Sugar().Info("Peer is reachable.", "PeerID", machine.PeerID)
-			}
+			zlog.Sugar().Debugf("Peer is reachable. PeerID: %s", machine.PeerID)
 			err := p.Host.Peerstore().Put(targetPeer, "peer_info", machine)
 			if err != nil {
 				zlog.Sugar().Errorf("Error putting peer info of %s in peerstore: %v", targetPeer.String(), err)
 			}
 		} else {
-			if _, verboseDebugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); verboseDebugMode {
-				zlog.Sugar().Info("Peer -  ", machine.PeerID, " is unreachable.")
-			}
+			zlog.Sugar().Debugf("Peer - %s is unreachable.", machine.PeerID)
 		}
 		pingCancel()
 	}
@@ -202,44 +173,37 @@ type dhtValidator struct {
 	PS peerstore.Peerstore
 }
 
+// Validate checks that the record is a well-formed advertisement for the
+// type and peer in its key, that it hasn't expired and that it was signed
+// by that peer.
 func (d dhtValidator) Validate(key string, value []byte) error {
-	// Check if the key has the correct namespace
-	if !strings.HasPrefix(key, customNamespace) {
-		return errors.New("invalid key namespace")
-	}
-
-	components := strings.Split(key, "/")
-	key = components[len(components)-1]
-	var dhtUpdate models.KadDHTMachineUpdate
-
-	err := json.Unmarshal(value, &dhtUpdate)
+	adType, remotePeerID, err := parseAdKey(key)
 	if err != nil {
-		zlog.Sugar().Errorf("Error unmarshalling value: %v", err)
 		return err
 	}
 
-	// Extract data and signature fields
-	data := dhtUpdate.Data
-	var peerInfo models.PeerData
-	err = json.Unmarshal(dhtUpdate.Data, &peerInfo)
+	ad, record, err := decodeAdvertisement(value)
 	if err != nil {
-		zlog.Sugar().Errorf("Error unmarshalling value: %v", err)
 		return err
 	}
 
-	signature := dhtUpdate.Signature
-	remotePeerID, err := peer.Decode(key)
-	if err != nil {
-		zlog.Sugar().Errorf("Error decoding peerID: %v", err)
-		return errors.New("error decoding peerID")
+	if ad.PeerID != remotePeerID.String() || ad.Type != adType {
+		return errors.New("advertisement does not match its key")
+	}
+	if ad.Expired(time.Now()) {
+		return ErrAdvertisementExpired
 	}
 
-	// Get the public key of the remote peer from the peerstore
+	// Get the public key of the remote peer from the peerstore, falling back
+	// to the key embedded in the peer ID
 	remotePeerPublicKey := d.PS.PubKey(remotePeerID)
 	if remotePeerPublicKey == nil {
-		return errors.New("public key for remote peer not found in peerstore")
+		remotePeerPublicKey, err = remotePeerID.ExtractPublicKey()
+		if err != nil {
+			return errors.New("public key for remote peer not found in peerstore")
+		}
 	}
-	verify, err := remotePeerPublicKey.Verify(data, signature)
+	verify, err := remotePeerPublicKey.Verify(record.Data, record.Signature)
 	if err != nil {
 		zlog.Sugar().Errorf("Error verifying signature: %v", err)
 		return err
@@ -249,9 +213,30 @@ func (d dhtValidator) Validate(key string, value []byte) error {
 		return errors.New("invalid signature")
 	}
 
-	if len(value) == 0 {
-		return errors.New("value cannot be empty")
-	}
 	return nil
 }
-func (dhtValidator) Select(_ string, _ [][]byte) (int, error) { return 0, nil }
+
+// Select returns the index of the newest valid record, which is the one with
+// the highest sequence number. Ties are broken by the latest expiry.
+func (d dhtValidator) Select(key string, values [][]byte) (int, error) {
+	best := -1
+	var bestAd Advertisement
+	for i, value := range values {
+		if d.Validate(key, value) != nil {
+			continue
+		}
+		ad, _, err := decodeAdvertisement(value)
+		if err != nil {
+			continue
+		}
+		if best == -1 || ad.Seq > bestAd.Seq || (ad.Seq == bestAd.Seq && ad.Expiry > bestAd.Expiry) {
+			best = i
+			bestAd = ad
+		}
+	}
+
+	if best == -1 {
+		return 0, errors.New("no valid advertisement found")
+	}
+	return best, nil
+}
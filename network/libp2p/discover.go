@@ -3,18 +3,23 @@ package libp2p
 import (
 	"context"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/libp2p/go-libp2p/core/discovery"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	drouting "github.com/libp2p/go-libp2p/p2p/discovery/routing"
 	dutil "github.com/libp2p/go-libp2p/p2p/discovery/util"
+
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
 )
 
 var err error
 
 func (p *Libp2p) DiscoverDialPeers(ctx context.Context) error {
+	// static peers don't depend on the DHT, reconnect them first
+	p.connectStaticPeers(ctx)
+
 	p.peers, err = p.findPeers(ctx)
 	if err != nil {
 		return err
@@ -32,7 +37,7 @@ func (p *Libp2p) DiscoverDialPeers(ctx context.Context) error {
 	return nil
 }
 
-func (p2p Libp2p) dialPeers(ctx context.Context) error {
+func (p2p *Libp2p) dialPeers(ctx context.Context) error {
 	for _, p := range p2p.peers {
 		if p.ID == p2p.Host.ID() {
 			continue
@@ -40,31 +45,29 @@ func (p2p Libp2p) dialPeers(ctx context.Context) error {
 		if p2p.Host.Network().Connectedness(p.ID) != network.Connected {
 			_, err := p2p.Host.Network().DialPeer(ctx, p.ID)
 			if err != nil {
-				if _, debugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); debugMode {
-					zlog.Sugar().Debugf("couldn't establish connection with: %s - error: %v", p.ID.String(), err)
-				}
+				zlog.Sugar().Debugf("couldn't establish connection with: %s - error: %v", p.ID.String(), err)
 				continue
 			}
-			if _, debugMode := os.LookupEnv("NUNET_DEBUG_VERBOSE"); debugMode {
-				zlog.Sugar().Debugf("connected with: %s", p.ID.String())
-			}
+			zlog.Sugar().Debugf("connected with: %s", p.ID.String())
 
 		}
 	}
 	return nil
 }
 
-func (p Libp2p) findPeers(ctx context.Context) ([]peer.AddrInfo, error) {
+func (p *Libp2p) findPeers(ctx context.Context) ([]peer.AddrInfo, error) {
 	var routingDiscovery = drouting.NewRoutingDiscovery(p.DHT)
 	dutil.Advertise(ctx, routingDiscovery, p.config.Rendezvous)
 
 	zlog.Debug("Discover - searching for peers")
+	start := time.Now()
 	peers, err := dutil.FindPeers(
 		ctx,
 		routingDiscovery,
 		p.config.Rendezvous,
 		discovery.Limit(40),
 	)
+	metrics.ObserveDHTLookup("find_peers", start, err)
 	if err != nil {
 		return []peer.AddrInfo{}, fmt.Errorf("failed to discover peers: %v", err)
 	}
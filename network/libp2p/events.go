@@ -0,0 +1,50 @@
+package libp2p
+
+import (
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
+)
+
+// peerEventPublisher publishes the connections and disconnections of peers
+// to the DMS event bus
+type peerEventPublisher struct {
+	host host.Host
+	sub  event.Subscription
+	bus  *events.Bus
+}
+
+func newPeerEventPublisher(h host.Host, bus *events.Bus) (*peerEventPublisher, error) {
+	sub, err := h.EventBus().Subscribe(new(event.EvtPeerConnectednessChanged))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &peerEventPublisher{host: h, sub: sub, bus: bus}
+	// the host may already be connected to peers, such as when the node is
+	// attached to a running host
+	metrics.SetPeers(len(h.Network().Peers()))
+	go p.run()
+	return p, nil
+}
+
+func (p *peerEventPublisher) run() {
+	for e := range p.sub.Out() {
+		evt := e.(event.EvtPeerConnectednessChanged)
+		payload := events.Peer{PeerID: evt.Peer.String()}
+		switch evt.Connectedness {
+		case network.Connected:
+			p.bus.Publish(events.PeerConnected, payload)
+		case network.NotConnected:
+			p.bus.Publish(events.PeerDisconnected, payload)
+		}
+		metrics.SetPeers(len(p.host.Network().Peers()))
+	}
+}
+
+func (p *peerEventPublisher) close() error {
+	return p.sub.Close()
+}
@@ -0,0 +1,52 @@
+package libp2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+)
+
+func TestPeerEventPublisher(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	bus := events.NewBus()
+	sub := bus.Subscribe(4, events.Types(events.PeerConnected, events.PeerDisconnected))
+	defer sub.Close()
+
+	publisher, err := newPeerEventPublisher(h1, bus)
+	require.NoError(t, err)
+	defer publisher.close()
+
+	require.NoError(t, h1.Network().ClosePeer(h2.ID()))
+
+	select {
+	case e := <-sub.C:
+		assert.Equal(t, events.PeerDisconnected, e.Type)
+		assert.Equal(t, h2.ID().String(), e.Payload.(events.Peer).PeerID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the disconnection event")
+	}
+}
+
+func TestAttachPublishesPeerEvents(t *testing.T) {
+	withDataDir(t)
+	h1, h2 := newConnectedHosts(t)
+	sub := events.Default.Subscribe(4, events.Types(events.PeerDisconnected))
+	defer sub.Close()
+
+	p := &Libp2p{}
+	require.NoError(t, p.Attach(h1, nil, Libp2pConfig{}))
+	defer p.Stop()
+
+	require.NoError(t, h1.Network().ClosePeer(h2.ID()))
+
+	select {
+	case e := <-sub.C:
+		assert.Equal(t, h2.ID().String(), e.Payload.(events.Peer).PeerID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the disconnection event of the attached host")
+	}
+}
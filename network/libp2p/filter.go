@@ -54,31 +54,70 @@ func PeerPassFilter(peers []peer.AddrInfo, pf PeerFilter) []peer.AddrInfo {
 	return filtered
 }
 
-type filtersConnectionGater multiaddr.Filters
+// connectionGater denies connections to and from filtered addresses and,
+// when an allowlist is set, to and from peers which are not on it
+type connectionGater struct {
+	filters *multiaddr.Filters
+	allowed map[peer.ID]struct{} // nil allows every peer
+}
 
-var _ connmgr.ConnectionGater = (*filtersConnectionGater)(nil)
+var _ connmgr.ConnectionGater = (*connectionGater)(nil)
 
-func (f *filtersConnectionGater) InterceptAddrDial(_ peer.ID, addr multiaddr.Multiaddr) (allow bool) {
-	return !(*multiaddr.Filters)(f).AddrBlocked(addr)
+func (g *connectionGater) allowedPeer(p peer.ID) bool {
+	if g.allowed == nil {
+		return true
+	}
+	_, ok := g.allowed[p]
+	return ok
 }
 
-func (f *filtersConnectionGater) InterceptPeerDial(p peer.ID) (allow bool) {
-	return true
+func (g *connectionGater) InterceptAddrDial(p peer.ID, addr multiaddr.Multiaddr) (allow bool) {
+	return g.allowedPeer(p) && !g.filters.AddrBlocked(addr)
 }
 
-func (f *filtersConnectionGater) InterceptAccept(connAddr network.ConnMultiaddrs) (allow bool) {
-	return !(*multiaddr.Filters)(f).AddrBlocked(connAddr.RemoteMultiaddr())
+func (g *connectionGater) InterceptPeerDial(p peer.ID) (allow bool) {
+	return g.allowedPeer(p)
 }
 
-func (f *filtersConnectionGater) InterceptSecured(_ network.Direction, _ peer.ID, connAddr network.ConnMultiaddrs) (allow bool) {
-	return !(*multiaddr.Filters)(f).AddrBlocked(connAddr.RemoteMultiaddr())
+func (g *connectionGater) InterceptAccept(connAddr network.ConnMultiaddrs) (allow bool) {
+	return !g.filters.AddrBlocked(connAddr.RemoteMultiaddr())
 }
 
-func (f *filtersConnectionGater) InterceptUpgraded(_ network.Conn) (allow bool, reason control.DisconnectReason) {
+func (g *connectionGater) InterceptSecured(_ network.Direction, p peer.ID, connAddr network.ConnMultiaddrs) (allow bool) {
+	return g.allowedPeer(p) && !g.filters.AddrBlocked(connAddr.RemoteMultiaddr())
+}
+
+func (g *connectionGater) InterceptUpgraded(_ network.Conn) (allow bool, reason control.DisconnectReason) {
 	return true, 0
 }
 
-func makeAddrsFactory(announce []string, appendAnnouce []string, noAnnounce []string) func([]multiaddr.Multiaddr) []multiaddr.Multiaddr {
+// serverFilters builds the address filters used in server mode. The subnets in
+// allow are exempt from the default deny filters.
+func serverFilters(allow []string) *multiaddr.Filters {
+	filters := multiaddr.NewFilters()
+	for _, s := range defaultServerFilters {
+		f, err := mafilt.NewMask(s)
+		if err != nil {
+			zlog.Sugar().Errorf("incorrectly formatted address filter in config: %s - %v", s, err)
+			continue
+		}
+		filters.AddFilter(*f, multiaddr.ActionDeny)
+	}
+	// the last matching filter wins, so accept filters must come after the deny ones
+	for _, s := range allow {
+		f, err := mafilt.NewMask(s)
+		if err != nil {
+			zlog.Sugar().Errorf("incorrectly formatted address filter: %s - %v", s, err)
+			continue
+		}
+		filters.AddFilter(*f, multiaddr.ActionAccept)
+	}
+	return filters
+}
+
+// makeAddrsFactory returns an address factory announcing the given addresses.
+// Subnets in allowAnnounce are announced even if they match a noAnnounce mask.
+func makeAddrsFactory(announce []string, appendAnnouce []string, noAnnounce []string, allowAnnounce []string) func([]multiaddr.Multiaddr) []multiaddr.Multiaddr {
 	var err error                     // To assign to the slice in the for loop
 	existing := make(map[string]bool) // To avoid duplicates
 
@@ -118,6 +157,13 @@ func makeAddrsFactory(announce []string, appendAnnouce []string, noAnnounce []st
 		}
 		noAnnAddrs[string(maddr.Bytes())] = true
 	}
+	for _, addr := range allowAnnounce {
+		f, err := mafilt.NewMask(addr)
+		if err != nil {
+			return nil
+		}
+		filters.AddFilter(*f, multiaddr.ActionAccept)
+	}
 
 	return func(allAddrs []multiaddr.Multiaddr) []multiaddr.Multiaddr {
 		var addrs []multiaddr.Multiaddr
@@ -0,0 +1,125 @@
+package libp2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+const (
+	// heartbeatMethod is the RPC method receiving the heartbeats of other
+	// nodes and of their allocations
+	heartbeatMethod = "heartbeat"
+
+	// heartbeatCheckInterval is the interval at which the received heartbeats
+	// are checked for missed ones
+	heartbeatCheckInterval = 5 * time.Second
+)
+
+// HeartbeatSink sends heartbeats to another DMS over RPC
+type HeartbeatSink struct {
+	rpc  *RPC
+	peer peer.ID
+}
+
+// NewHeartbeatSink returns a sink sending heartbeats to the given peer, it
+// can be registered for the allocations running on this node with
+// Scheduler.RegisterHeartbeat
+func NewHeartbeatSink(r *RPC, to peer.ID) *HeartbeatSink {
+	return &HeartbeatSink{rpc: r, peer: to}
+}
+
+// Send calls the heartbeat method of the peer
+func (s *HeartbeatSink) Send(ctx context.Context, heartbeat models.Heartbeat) error {
+	_, err := Call[models.Heartbeat, struct{}](ctx, s.rpc, s.peer, heartbeatMethod, heartbeat)
+	return err
+}
+
+func (s *HeartbeatSink) String() string {
+	return "peer " + s.peer.String()
+}
+
+// startHeartbeats starts receiving the heartbeats of other nodes and sends
+// the heartbeats of this node to the configured sinks
+func (p *Libp2p) startHeartbeats(cfg config.Heartbeat) error {
+	p.heartbeats = bt.NewHeartbeatMonitor(events.Default, cfg.MissedAfter)
+	err := RegisterHandler(p.RPC, heartbeatMethod, func(_ context.Context, from peer.ID, heartbeat models.Heartbeat) (struct{}, error) {
+		if heartbeat.Kind == models.NodeHeartbeat && heartbeat.Source != from.String() {
+			return struct{}{}, fmt.Errorf("heartbeat of node %s sent by %s", heartbeat.Source, from)
+		}
+		p.heartbeats.Receive(from.String(), heartbeat)
+		return struct{}{}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register heartbeat handler: %w", err)
+	}
+
+	p.addTask(&bt.Task{
+		Name:        "Heartbeat Check",
+		Description: "Periodic task to report the peers and allocations whose heartbeats were missed",
+		Function: func(_ context.Context, _ interface{}) error {
+			p.heartbeats.Check()
+			return nil
+		},
+		Triggers: []bt.Trigger{&bt.IntervalTrigger{Interval: heartbeatCheckInterval}},
+	})
+
+	if len(cfg.Sinks) == 0 {
+		return nil
+	}
+	p.nodeState = bt.NewNodeState(events.Default)
+	targets := make([]bt.HeartbeatTarget, 0, len(cfg.Sinks))
+	for _, sinkConfig := range cfg.Sinks {
+		sink, err := p.heartbeatSink(sinkConfig)
+		if err != nil {
+			return err
+		}
+		if sinkConfig.Period <= 0 {
+			return fmt.Errorf("invalid period of heartbeat sink %s: %d", sink, sinkConfig.Period)
+		}
+		targets = append(targets, bt.HeartbeatTarget{Sink: sink, Period: time.Duration(sinkConfig.Period) * time.Second})
+	}
+
+	for _, target := range targets {
+		task := p.config.Scheduler.RegisterHeartbeat(&bt.Heartbeat{
+			Source:  p.Host.ID().String(),
+			Kind:    models.NodeHeartbeat,
+			Period:  target.Period,
+			Sink:    target.Sink,
+			Payload: p.nodeState.Payload,
+		})
+		p.tasks = append(p.tasks, task.ID)
+	}
+	// the executions running on this node send their own heartbeats
+	p.allocations = bt.NewAllocationHeartbeats(events.Default, p.config.Scheduler, targets)
+	return nil
+}
+
+// heartbeatSink creates the sink of the configuration, libp2p sinks target
+// a peer ID
+func (p *Libp2p) heartbeatSink(cfg config.HeartbeatSink) (bt.HeartbeatSink, error) {
+	if cfg.Type != "libp2p" {
+		return bt.NewHeartbeatSink(cfg)
+	}
+	id, err := peer.Decode(cfg.Target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer ID of heartbeat sink %q: %w", cfg.Target, err)
+	}
+	return NewHeartbeatSink(p.RPC, id), nil
+}
+
+// Heartbeats returns the state of the peers and allocations sending their
+// heartbeats to this node
+func (p *Libp2p) Heartbeats() []models.HeartbeatStatus {
+	if p.heartbeats == nil {
+		return nil
+	}
+	return p.heartbeats.Statuses()
+}
@@ -0,0 +1,112 @@
+package libp2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func TestHeartbeatSink(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	receiver := &Libp2p{Host: h2, RPC: NewRPC(h2), config: Libp2pConfig{Scheduler: bt.NewScheduler(1)}}
+	require.NoError(t, receiver.startHeartbeats(config.Heartbeat{}))
+	require.Len(t, receiver.tasks, 1, "the check task is registered")
+
+	sink := NewHeartbeatSink(NewRPC(h1), h2.ID())
+	ctx := context.Background()
+
+	err := sink.Send(ctx, models.Heartbeat{
+		Source:   h1.ID().String(),
+		Kind:     models.NodeHeartbeat,
+		Sequence: 1,
+		Period:   time.Minute,
+		Payload:  models.HeartbeatPayload{Resources: &models.FreeResources{Vcpu: 4}},
+	})
+	require.NoError(t, err)
+	err = sink.Send(ctx, models.Heartbeat{Source: "alloc-1", Kind: models.AllocationHeartbeat, Sequence: 1, Period: time.Minute})
+	require.NoError(t, err)
+
+	statuses := make(map[string]models.HeartbeatStatus)
+	for _, status := range receiver.Heartbeats() {
+		assert.Equal(t, h1.ID().String(), status.Peer)
+		statuses[status.Source] = status
+	}
+	require.Len(t, statuses, 2)
+	assert.Equal(t, models.AllocationHeartbeat, statuses["alloc-1"].Kind)
+	node := statuses[h1.ID().String()]
+	assert.Equal(t, models.NodeHeartbeat, node.Kind)
+	require.NotNil(t, node.Payload.Resources)
+	assert.Equal(t, 4, node.Payload.Resources.Vcpu)
+
+	// a node only sends its own heartbeats
+	err = sink.Send(ctx, models.Heartbeat{Source: h2.ID().String(), Kind: models.NodeHeartbeat})
+	assert.Error(t, err)
+	assert.Len(t, receiver.Heartbeats(), 2)
+}
+
+func TestStartHeartbeatsSinks(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	receiver := &Libp2p{Host: h2, RPC: NewRPC(h2), config: Libp2pConfig{Scheduler: bt.NewScheduler(1)}}
+	require.NoError(t, receiver.startHeartbeats(config.Heartbeat{}))
+
+	scheduler := bt.NewScheduler(2)
+	sender := &Libp2p{Host: h1, RPC: NewRPC(h1), config: Libp2pConfig{Scheduler: scheduler}}
+	err := sender.startHeartbeats(config.Heartbeat{Sinks: []config.HeartbeatSink{
+		{Type: "libp2p", Target: h2.ID().String(), Period: 1},
+	}})
+	require.NoError(t, err)
+	defer sender.shutdown(context.Background())
+	assert.Len(t, sender.tasks, 2)
+
+	scheduler.Start()
+	defer scheduler.Stop()
+
+	assert.Eventually(t, func() bool {
+		statuses := receiver.Heartbeats()
+		return len(statuses) == 1 && statuses[0].Source == h1.ID().String()
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// the executions send their heartbeats while they run
+	execution := events.Execution{Executor: "docker", JobID: "job-1", ExecutionID: "exec-1"}
+	events.Default.Publish(events.ExecutionStarted, execution)
+	assert.Eventually(t, func() bool {
+		for _, status := range receiver.Heartbeats() {
+			if status.Source == "exec-1" && status.Kind == models.AllocationHeartbeat {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+	events.Default.Publish(events.ExecutionFinished, execution)
+	assert.Eventually(t, func() bool {
+		return len(scheduler.Tasks()) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStartHeartbeatsInvalidSink(t *testing.T) {
+	h1, _ := newConnectedHosts(t)
+
+	tests := []struct {
+		description string
+		sink        config.HeartbeatSink
+	}{
+		{description: "invalid peer ID", sink: config.HeartbeatSink{Type: "libp2p", Target: "not-a-peer", Period: 10}},
+		{description: "unknown type", sink: config.HeartbeatSink{Type: "smoke-signal", Target: "hill", Period: 10}},
+		{description: "no period", sink: config.HeartbeatSink{Type: "file", Target: t.TempDir() + "/hb.log"}},
+	}
+
+	for _, tc := range tests {
+		p := &Libp2p{Host: h1, RPC: NewRPC(h1), config: Libp2pConfig{Scheduler: bt.NewScheduler(1)}}
+		err := p.startHeartbeats(config.Heartbeat{Sinks: []config.HeartbeatSink{tc.sink}})
+		assert.Error(t, err, tc.description)
+		p.nodeState.Close()
+	}
+}
@@ -3,6 +3,7 @@ package libp2p
 import (
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
 	"gitlab.com/nunet/device-management-service/internal/config"
@@ -31,7 +32,14 @@ var (
 func init() {
 	zlog = logger.OtelZapLogger("network.libp2p")
 
-	for _, s := range config.GetConfig().P2P.BootstrapPeers {
+	bootstrapPeers := config.GetConfig().P2P.BootstrapPeers
+	if private := config.GetConfig().P2P.PrivateNetwork; private.Enabled {
+		// never fall back to the public NuNet network in private mode
+		bootstrapPeers = private.BootstrapPeers
+		kadPrefix = dht.ProtocolPrefix(protocol.ID(private.DHTPrefix))
+	}
+
+	for _, s := range bootstrapPeers {
 		ma, err := multiaddr.NewMultiaddr(s)
 		if err != nil {
 			panic(err)
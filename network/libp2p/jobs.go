@@ -0,0 +1,327 @@
+package libp2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+const (
+	// the RPC methods running the jobs of other peers and reporting on their executions
+	jobRunMethod        = "job.run"
+	jobExecutionMethod  = "job.execution"
+	jobExecutionsMethod = "job.executions"
+	jobCancelMethod     = "job.cancel"
+	jobLogsMethod       = "job.logs"
+
+	// jobStartTimeout bounds starting a job on a peer, pulling its image included,
+	// when the caller's context has no deadline
+	jobStartTimeout = 10 * time.Minute
+
+	// maxJobLogsChunkSize bounds the output carried by a single response of job.logs
+	maxJobLogsChunkSize = 32 << 10
+)
+
+var (
+	// ErrJobDenied is returned when a peer isn't allowed to run jobs
+	ErrJobDenied = errors.New("peer is not allowed to run jobs")
+
+	// ErrNoPeerFound is returned when no peer accepted a job to run anywhere
+	ErrNoPeerFound = errors.New("no peer accepted the job")
+
+	// jobErrors are the errors of the job methods recognized in the messages of the remote errors
+	jobErrors = []error{
+		ErrJobDenied,
+		executor.ErrExecutionNotFound,
+		executor.ErrExecutionNotRunning,
+		executor.ErrInvalidJobSpec,
+		executor.ErrExecutorUnavailable,
+		executor.ErrLogStreamUnsupported,
+	}
+)
+
+// JobRunner runs the jobs of the peers on this node, it is implemented by executor.Jobs
+type JobRunner interface {
+	Run(ctx context.Context, spec models.JobSpec) (models.ExecutionInfo, error)
+	Execution(id string) (models.ExecutionInfo, bool)
+	Executions() []models.ExecutionInfo
+	Cancel(ctx context.Context, executionID string) error
+	Logs(ctx context.Context, executionID string, follow bool) (io.ReadCloser, error)
+}
+
+// PeerFinder finds the peers advertising the capabilities of a query, it is implemented by Libp2p
+type PeerFinder interface {
+	FindPeers(ctx context.Context, query CapabilityQuery) <-chan models.PeerData
+}
+
+var _ PeerFinder = (*Libp2p)(nil)
+
+// jobRequest is the request of the job methods
+type jobRequest struct {
+	// JSON encoded models.JobSpec, the params of its engine are free-form
+	Spec        []byte `cbor:"1,keyasint,omitempty"`
+	ExecutionID string `cbor:"2,keyasint,omitempty"`
+	Follow      bool   `cbor:"3,keyasint,omitempty"`
+}
+
+// JobService runs the jobs of the allowed peers on this node, and runs jobs
+// on remote peers over RPC
+type JobService struct {
+	rpc     *RPC
+	runner  JobRunner
+	finder  PeerFinder
+	allowed map[peer.ID]bool
+}
+
+// NewJobService creates the job service and registers its methods on r. Only
+// the allowed peers may run jobs, none when allowed is empty. runner may be
+// nil on nodes not running jobs. The peers running jobs anywhere are searched
+// with finder, the connected peers are tried when it is nil.
+func NewJobService(r *RPC, runner JobRunner, finder PeerFinder, allowed []peer.ID) (*JobService, error) {
+	s := &JobService{
+		rpc:     r,
+		runner:  runner,
+		finder:  finder,
+		allowed: make(map[peer.ID]bool, len(allowed)),
+	}
+	for _, id := range allowed {
+		s.allowed[id] = true
+	}
+
+	err := errors.Join(
+		RegisterStreamHandler(r, jobRunMethod, s.handleRun),
+		RegisterHandler(r, jobExecutionMethod, s.handleExecution),
+		RegisterHandler(r, jobExecutionsMethod, s.handleExecutions),
+		RegisterHandler(r, jobCancelMethod, s.handleCancel),
+		RegisterStreamHandler(r, jobLogsMethod, s.handleLogs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register job handlers: %w", err)
+	}
+	return s, nil
+}
+
+// Run runs a job on a remote peer and returns its execution once started
+func (s *JobService) Run(ctx context.Context, to peer.ID, spec models.JobSpec) (models.ExecutionInfo, error) {
+	var info models.ExecutionInfo
+	payload, err := json.Marshal(spec)
+	if err != nil {
+		return info, fmt.Errorf("failed to encode job spec: %w", err)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, jobStartTimeout)
+		defer cancel()
+	}
+
+	// job.run streams its single response so that starting the job isn't
+	// bound by the default timeout of the calls
+	err = CallStream(ctx, s.rpc, to, jobRunMethod, jobRequest{Spec: payload}, func(started models.ExecutionInfo) error {
+		info = started
+		return nil
+	})
+	if err != nil {
+		return info, remoteJobError(err)
+	}
+	if info.ID == "" {
+		return info, fmt.Errorf("peer %s didn't start the job", to)
+	}
+	info.Node = to.String()
+	return info, nil
+}
+
+// RunAnywhere runs a job on the first peer accepting it among the peers
+// advertising the capabilities the spec requires
+func (s *JobService) RunAnywhere(ctx context.Context, spec models.JobSpec) (models.ExecutionInfo, error) {
+	searchCtx, stopSearch := context.WithCancel(ctx)
+	defer stopSearch()
+
+	lastErr := errors.New("no peer matches the job")
+	for _, id := range s.candidates(searchCtx, spec) {
+		info, err := s.Run(ctx, id, spec)
+		if err == nil {
+			return info, nil
+		}
+		zlog.Sugar().Debugf("job: peer %s didn't run the job: %v", id.String(), err)
+		lastErr = err
+	}
+	return models.ExecutionInfo{}, fmt.Errorf("%w: %w", ErrNoPeerFound, lastErr)
+}
+
+// candidates returns the peers matching the capabilities of the spec
+func (s *JobService) candidates(ctx context.Context, spec models.JobSpec) []peer.ID {
+	var candidates []peer.ID
+	if s.finder == nil {
+		for _, id := range s.rpc.host.Network().Peers() {
+			candidates = append(candidates, id)
+		}
+		return candidates
+	}
+
+	for data := range s.finder.FindPeers(ctx, QueryFromJobSpec(spec)) {
+		id, err := peer.Decode(data.PeerID)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, id)
+	}
+	return candidates
+}
+
+// Execution returns an execution of a remote peer
+func (s *JobService) Execution(ctx context.Context, to peer.ID, id string) (models.ExecutionInfo, error) {
+	info, err := Call[jobRequest, models.ExecutionInfo](ctx, s.rpc, to, jobExecutionMethod, jobRequest{ExecutionID: id})
+	if err != nil {
+		return info, remoteJobError(err)
+	}
+	info.Node = to.String()
+	return info, nil
+}
+
+// Executions returns the executions of a remote peer, sorted by start time
+func (s *JobService) Executions(ctx context.Context, to peer.ID) ([]models.ExecutionInfo, error) {
+	executions, err := Call[jobRequest, []models.ExecutionInfo](ctx, s.rpc, to, jobExecutionsMethod, jobRequest{})
+	if err != nil {
+		return nil, remoteJobError(err)
+	}
+	for i := range executions {
+		executions[i].Node = to.String()
+	}
+	return executions, nil
+}
+
+// Cancel cancels a running execution of a remote peer
+func (s *JobService) Cancel(ctx context.Context, to peer.ID, id string) error {
+	_, err := Call[jobRequest, struct{}](ctx, s.rpc, to, jobCancelMethod, jobRequest{ExecutionID: id})
+	return remoteJobError(err)
+}
+
+// Logs writes the output of an execution of a remote peer to w, see executor.Jobs.Logs
+func (s *JobService) Logs(ctx context.Context, to peer.ID, id string, follow bool, w io.Writer) error {
+	err := CallStream(ctx, s.rpc, to, jobLogsMethod, jobRequest{ExecutionID: id, Follow: follow}, func(chunk []byte) error {
+		_, err := w.Write(chunk)
+		return err
+	})
+	return remoteJobError(err)
+}
+
+// authorize returns ErrJobDenied unless the peer is allowed to run jobs on this node
+func (s *JobService) authorize(ctx context.Context, from peer.ID, method string) error {
+	if s.allowed[from] && s.runner != nil {
+		return nil
+	}
+	telemetry.Observe(ctx, telemetry.Audit, telemetry.WarnLevel, "job.denied", telemetry.Fields{
+		"client": "peer " + from.String(),
+		"method": method,
+	})
+	return ErrJobDenied
+}
+
+func (s *JobService) handleRun(ctx context.Context, from peer.ID, req jobRequest, send func(models.ExecutionInfo) error) error {
+	if err := s.authorize(ctx, from, jobRunMethod); err != nil {
+		return err
+	}
+	var spec models.JobSpec
+	if err := json.Unmarshal(req.Spec, &spec); err != nil {
+		return fmt.Errorf("%w: %v", executor.ErrInvalidJobSpec, err)
+	}
+
+	info, err := s.runner.Run(ctx, spec)
+	if err != nil {
+		return err
+	}
+	telemetry.Observe(ctx, telemetry.Audit, telemetry.InfoLevel, "job.started", telemetry.Fields{
+		"client":       "peer " + from.String(),
+		"execution_id": info.ID,
+		"job_id":       info.JobID,
+		"executor":     info.Executor,
+	})
+	return send(info)
+}
+
+func (s *JobService) handleExecution(ctx context.Context, from peer.ID, req jobRequest) (models.ExecutionInfo, error) {
+	if err := s.authorize(ctx, from, jobExecutionMethod); err != nil {
+		return models.ExecutionInfo{}, err
+	}
+	info, ok := s.runner.Execution(req.ExecutionID)
+	if !ok {
+		return info, executor.ErrExecutionNotFound
+	}
+	return info, nil
+}
+
+func (s *JobService) handleExecutions(ctx context.Context, from peer.ID, _ jobRequest) ([]models.ExecutionInfo, error) {
+	if err := s.authorize(ctx, from, jobExecutionsMethod); err != nil {
+		return nil, err
+	}
+	return s.runner.Executions(), nil
+}
+
+func (s *JobService) handleCancel(ctx context.Context, from peer.ID, req jobRequest) (struct{}, error) {
+	if err := s.authorize(ctx, from, jobCancelMethod); err != nil {
+		return struct{}{}, err
+	}
+	return struct{}{}, s.runner.Cancel(ctx, req.ExecutionID)
+}
+
+func (s *JobService) handleLogs(ctx context.Context, from peer.ID, req jobRequest, send func([]byte) error) error {
+	if err := s.authorize(ctx, from, jobLogsMethod); err != nil {
+		return err
+	}
+	logs, err := s.runner.Logs(ctx, req.ExecutionID, req.Follow)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	buf := make([]byte, maxJobLogsChunkSize)
+	for {
+		n, err := logs.Read(buf)
+		if n > 0 {
+			if err := send(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// remoteJobError wraps the error of a job method returned by a peer in the
+// error its message matches, so that callers can tell them apart
+func remoteJobError(err error) error {
+	var remote *RemoteError
+	if !errors.As(err, &remote) {
+		return err
+	}
+	for _, target := range jobErrors {
+		if strings.Contains(remote.Message, target.Error()) {
+			return &jobError{RemoteError: remote, target: target}
+		}
+	}
+	return err
+}
+
+// jobError is a remote error of a job method unwrapping to the error of its message
+type jobError struct {
+	*RemoteError
+	target error
+}
+
+func (e *jobError) Unwrap() error {
+	return e.target
+}
@@ -0,0 +1,192 @@
+package libp2p
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// fakeRunner runs docker jobs whose output is their image
+type fakeRunner struct {
+	mu         sync.Mutex
+	executions map[string]models.ExecutionInfo
+	images     map[string]string
+}
+
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{executions: make(map[string]models.ExecutionInfo), images: make(map[string]string)}
+}
+
+func (r *fakeRunner) Run(_ context.Context, spec models.JobSpec) (models.ExecutionInfo, error) {
+	if err := spec.Validate(); err != nil {
+		return models.ExecutionInfo{}, executor.ErrInvalidJobSpec
+	}
+	if !spec.Engine.IsType(models.ExecutorTypeDocker) {
+		return models.ExecutionInfo{}, executor.ErrExecutorUnavailable
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info := models.ExecutionInfo{
+		ID:        "exec-1",
+		JobID:     "job-1",
+		Executor:  models.ExecutorTypeDocker,
+		Status:    models.ExecutionStatusRunning,
+		StartedAt: time.Now(),
+	}
+	r.executions[info.ID] = info
+	r.images[info.ID], _ = spec.Engine.Params["image"].(string)
+	return info, nil
+}
+
+func (r *fakeRunner) Execution(id string) (models.ExecutionInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, ok := r.executions[id]
+	return info, ok
+}
+
+func (r *fakeRunner) Executions() []models.ExecutionInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var executions []models.ExecutionInfo
+	for _, info := range r.executions {
+		executions = append(executions, info)
+	}
+	return executions
+}
+
+func (r *fakeRunner) Cancel(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, ok := r.executions[id]
+	if !ok {
+		return executor.ErrExecutionNotFound
+	}
+	if info.Status != models.ExecutionStatusRunning {
+		return executor.ErrExecutionNotRunning
+	}
+	info.Status = models.ExecutionStatusFailed
+	r.executions[id] = info
+	return nil
+}
+
+func (r *fakeRunner) Logs(_ context.Context, id string, _ bool) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	image, ok := r.images[id]
+	if !ok {
+		return nil, executor.ErrExecutionNotFound
+	}
+	return io.NopCloser(strings.NewReader(image + "\n")), nil
+}
+
+func TestJobService(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	runner := newFakeRunner()
+	client, err := NewJobService(NewRPC(h1), nil, nil, nil)
+	require.NoError(t, err)
+	_, err = NewJobService(NewRPC(h2), runner, nil, []peer.ID{h1.ID()})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	spec := models.JobSpec{Engine: models.NewSpecConfig(models.ExecutorTypeDocker).WithParam("image", "alpine")}
+	info, err := client.Run(ctx, h2.ID(), spec)
+	require.NoError(t, err)
+	assert.Equal(t, "exec-1", info.ID)
+	assert.Equal(t, h2.ID().String(), info.Node)
+
+	info, err = client.Execution(ctx, h2.ID(), "exec-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExecutionStatusRunning, info.Status)
+	assert.Equal(t, h2.ID().String(), info.Node)
+	executions, err := client.Executions(ctx, h2.ID())
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	assert.Equal(t, h2.ID().String(), executions[0].Node)
+
+	var logs strings.Builder
+	require.NoError(t, client.Logs(ctx, h2.ID(), "exec-1", true, &logs))
+	assert.Equal(t, "alpine\n", logs.String())
+
+	require.NoError(t, client.Cancel(ctx, h2.ID(), "exec-1"))
+	assert.ErrorIs(t, client.Cancel(ctx, h2.ID(), "exec-1"), executor.ErrExecutionNotRunning)
+	_, err = client.Execution(ctx, h2.ID(), "exec-2")
+	assert.ErrorIs(t, err, executor.ErrExecutionNotFound)
+	_, err = client.Run(ctx, h2.ID(), models.JobSpec{Engine: models.NewSpecConfig("wasm")})
+	assert.ErrorIs(t, err, executor.ErrExecutorUnavailable)
+
+	// the server doesn't run the jobs of its clients
+	_, err = client.Run(ctx, h1.ID(), spec)
+	assert.Error(t, err)
+	_, err = NewJobService(NewRPC(h2), runner, nil, nil)
+	require.NoError(t, err, "another RPC registers its own handlers")
+}
+
+func TestJobServiceDenied(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	runner := newFakeRunner()
+	client, err := NewJobService(NewRPC(h1), nil, nil, nil)
+	require.NoError(t, err)
+	_, err = NewJobService(NewRPC(h2), runner, nil, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	spec := models.JobSpec{Engine: models.NewSpecConfig(models.ExecutorTypeDocker)}
+	_, err = client.Run(ctx, h2.ID(), spec)
+	assert.ErrorIs(t, err, ErrJobDenied)
+	assert.Empty(t, runner.Executions(), "a denied peer doesn't reach the runner")
+	_, err = client.Executions(ctx, h2.ID())
+	assert.ErrorIs(t, err, ErrJobDenied)
+
+	_, err = client.RunAnywhere(ctx, spec)
+	assert.ErrorIs(t, err, ErrNoPeerFound)
+	assert.ErrorIs(t, err, ErrJobDenied, "the error of the last peer tried is reported")
+}
+
+// fakeFinder finds the peers it is given
+type fakeFinder struct {
+	peers []models.PeerData
+	query CapabilityQuery
+}
+
+func (f *fakeFinder) FindPeers(_ context.Context, query CapabilityQuery) <-chan models.PeerData {
+	f.query = query
+	results := make(chan models.PeerData, len(f.peers))
+	for _, data := range f.peers {
+		results <- data
+	}
+	close(results)
+	return results
+}
+
+func TestJobServiceRunAnywhere(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	finder := &fakeFinder{peers: []models.PeerData{{PeerID: "not-a-peer"}, {PeerID: h2.ID().String()}}}
+	client, err := NewJobService(NewRPC(h1), nil, finder, nil)
+	require.NoError(t, err)
+	_, err = NewJobService(NewRPC(h2), newFakeRunner(), nil, []peer.ID{h1.ID()})
+	require.NoError(t, err)
+
+	spec := models.JobSpec{
+		Engine:    models.NewSpecConfig(models.ExecutorTypeDocker).WithParam("image", "alpine"),
+		Resources: &models.ExecutionResources{Memory: 2 << 30, GPUs: []models.GPU{{Vendor: models.GPUVendorNvidia}}},
+	}
+	info, err := client.RunAnywhere(context.Background(), spec)
+	require.NoError(t, err)
+	assert.Equal(t, h2.ID().String(), info.Node)
+	assert.Equal(t, CapabilityQuery{
+		MinRAM:        2048,
+		GPUVendor:     models.GPUVendorNvidia,
+		ExecutorTypes: []string{models.ExecutorTypeDocker},
+	}, finder.query)
+}
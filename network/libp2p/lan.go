@@ -0,0 +1,160 @@
+package libp2p
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
+	"github.com/multiformats/go-multiaddr"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+const (
+	// staticPeerTag protects connections to static peers from the connection manager
+	staticPeerTag = "nunet-static-peer"
+
+	// lanDialTimeout bounds dialing a peer found with mDNS or a static peer
+	lanDialTimeout = 15 * time.Second
+)
+
+// configuredStaticPeers returns the static peers set in the config
+func configuredStaticPeers() ([]peer.AddrInfo, error) {
+	return parseStaticPeers(config.GetConfig().P2P.StaticPeers)
+}
+
+// parseStaticPeers decodes the static peer multiaddrs. Multiple addresses of
+// the same peer are merged into a single AddrInfo.
+func parseStaticPeers(addrs []string) ([]peer.AddrInfo, error) {
+	var maddrs []multiaddr.Multiaddr
+	for _, s := range addrs {
+		ma, err := multiaddr.NewMultiaddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid static peer address %s: %w", s, err)
+		}
+		maddrs = append(maddrs, ma)
+	}
+
+	infos, err := peer.AddrInfosFromP2pAddrs(maddrs...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid static peer address: %w", err)
+	}
+	return infos, nil
+}
+
+// lanSubnets returns the subnets of the given network interfaces as multiaddr
+// masks, e.g. /ip4/192.168.1.0/ipcidr/24. All interfaces are used when none
+// are given.
+func lanSubnets(interfaces []string) ([]string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list network interfaces: %w", err)
+	}
+
+	var subnets []string
+	for _, iface := range ifaces {
+		if len(interfaces) > 0 && !containsFold(interfaces, iface.Name) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			zlog.Sugar().Warnf("unable to list addresses of interface %s: %v", iface.Name, err)
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			subnets = append(subnets, ipNetMask(ipnet))
+		}
+	}
+	return subnets, nil
+}
+
+// ipNetMask formats the network of ipnet in the format understood by
+// multiaddr-filter
+func ipNetMask(ipnet *net.IPNet) string {
+	ones, _ := ipnet.Mask.Size()
+	if ip4 := ipnet.IP.To4(); ip4 != nil {
+		return fmt.Sprintf("/ip4/%s/ipcidr/%d", ip4.Mask(ipnet.Mask), ones)
+	}
+	return fmt.Sprintf("/ip6/%s/ipcidr/%d", ipnet.IP.Mask(ipnet.Mask), ones)
+}
+
+// lanFilterExceptions returns the subnets which must not be blocked by the
+// server filters, which is none unless LAN mode is enabled
+func lanFilterExceptions(lan config.LAN) []string {
+	if !lan.Enabled {
+		return nil
+	}
+	subnets, err := lanSubnets(lan.Interfaces)
+	if err != nil {
+		zlog.Sugar().Errorf("LAN mode: %v", err)
+		return nil
+	}
+	zlog.Sugar().Infof("LAN mode: allowing local subnets %v", subnets)
+	return subnets
+}
+
+// connectStaticPeers adds the static peers to the peerstore permanently,
+// protects them from being trimmed and dials the ones not yet connected
+func (p *Libp2p) connectStaticPeers(ctx context.Context) {
+	for _, info := range p.staticPeers {
+		if info.ID == p.Host.ID() {
+			continue
+		}
+		p.Host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+		p.Host.ConnManager().Protect(info.ID, staticPeerTag)
+		p.connectLANPeer(ctx, info)
+	}
+}
+
+// connectLANPeer dials a peer found without the DHT unless already connected
+func (p *Libp2p) connectLANPeer(ctx context.Context, info peer.AddrInfo) {
+	if p.Host.Network().Connectedness(info.ID) == network.Connected {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lanDialTimeout)
+	defer cancel()
+
+	if err := p.Host.Connect(ctx, info); err != nil {
+		zlog.Sugar().Debugf("couldn't establish connection with LAN peer %s: %v", info.ID.String(), err)
+		return
+	}
+	zlog.Sugar().Infof("Connected to LAN peer %s", info.ID.String())
+}
+
+// startMDNS starts announcing this host and discovering other hosts on the
+// local network with multicast DNS
+func (p *Libp2p) startMDNS(ctx context.Context, serviceName string) error {
+	p.mdns = mdns.NewMdnsService(p.Host, serviceName, &mdnsNotifee{ctx: ctx, p: p})
+	if err := p.mdns.Start(); err != nil {
+		p.mdns = nil
+		return fmt.Errorf("failed to start mDNS discovery: %w", err)
+	}
+	zlog.Sugar().Infof("mDNS discovery started with service name %s", serviceName)
+	return nil
+}
+
+// mdnsNotifee connects to the peers found by the mDNS service
+type mdnsNotifee struct {
+	ctx context.Context
+	p   *Libp2p
+}
+
+func (n *mdnsNotifee) HandlePeerFound(info peer.AddrInfo) {
+	if info.ID == n.p.Host.ID() {
+		return
+	}
+	zlog.Sugar().Debugf("mDNS - found peer %s", info.ID.String())
+
+	// the notifee is called from the mDNS resolver loop, don't block it
+	go n.p.connectLANPeer(n.ctx, info)
+}
@@ -0,0 +1,85 @@
+package libp2p
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStaticPeers(t *testing.T) {
+	_, id := newTestIdentity(t)
+
+	infos, err := parseStaticPeers([]string{
+		"/ip4/192.168.1.10/tcp/9000/p2p/" + id.String(),
+		"/ip4/192.168.1.10/udp/9000/quic/p2p/" + id.String(),
+	})
+	require.NoError(t, err)
+	require.Len(t, infos, 1, "addresses of the same peer should be merged")
+	assert.Equal(t, id, infos[0].ID)
+	assert.Len(t, infos[0].Addrs, 2)
+
+	_, err = parseStaticPeers([]string{"/ip4/192.168.1.10/tcp/9000"})
+	assert.Error(t, err, "static peers without a peer ID should be rejected")
+
+	_, err = parseStaticPeers([]string{"not-a-multiaddr"})
+	assert.Error(t, err)
+}
+
+func TestIPNetMask(t *testing.T) {
+	_, ipnet, err := net.ParseCIDR("192.168.1.17/24")
+	require.NoError(t, err)
+	assert.Equal(t, "/ip4/192.168.1.0/ipcidr/24", ipNetMask(ipnet))
+
+	_, ipnet, err = net.ParseCIDR("fd00::1/64")
+	require.NoError(t, err)
+	assert.Equal(t, "/ip6/fd00::/ipcidr/64", ipNetMask(ipnet))
+}
+
+func TestServerFiltersLANExceptions(t *testing.T) {
+	lanAddr := multiaddr.StringCast("/ip4/192.168.1.10/tcp/9000")
+	otherAddr := multiaddr.StringCast("/ip4/192.168.2.10/tcp/9000")
+	publicAddr := multiaddr.StringCast("/ip4/8.8.8.8/tcp/9000")
+
+	filters := serverFilters(nil)
+	assert.True(t, filters.AddrBlocked(lanAddr))
+	assert.False(t, filters.AddrBlocked(publicAddr))
+
+	filters = serverFilters([]string{"/ip4/192.168.1.0/ipcidr/24"})
+	assert.False(t, filters.AddrBlocked(lanAddr), "LAN subnet should be allowed")
+	assert.True(t, filters.AddrBlocked(otherAddr), "other private subnets should stay blocked")
+
+	factory := makeAddrsFactory(nil, nil, defaultServerFilters, []string{"/ip4/192.168.1.0/ipcidr/24"})
+	assert.Equal(t,
+		[]multiaddr.Multiaddr{lanAddr, publicAddr},
+		factory([]multiaddr.Multiaddr{lanAddr, otherAddr, publicAddr}),
+	)
+}
+
+func TestConnectStaticPeers(t *testing.T) {
+	newTestHost := func() *Libp2p {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		require.NoError(t, err)
+		t.Cleanup(func() { h.Close() })
+		return &Libp2p{Host: h}
+	}
+
+	p1, p2 := newTestHost(), newTestHost()
+	var addrs []string
+	for _, addr := range p2.Host.Addrs() {
+		addrs = append(addrs, addr.String()+"/p2p/"+p2.Host.ID().String())
+	}
+
+	var err error
+	p1.staticPeers, err = parseStaticPeers(addrs)
+	require.NoError(t, err)
+
+	p1.connectStaticPeers(context.Background())
+	assert.Equal(t, network.Connected, p1.Host.Network().Connectedness(p2.Host.ID()))
+	assert.True(t, p1.Host.ConnManager().IsProtected(p2.Host.ID(), staticPeerTag))
+}
@@ -0,0 +1,138 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-msgio"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// MessageProtocolID is the libp2p protocol used by Message connections
+const MessageProtocolID = protocol.ID("/nunet/message/1.0.0")
+
+// Message implements network.Message over a single libp2p stream using the
+// same length-prefixed framing as RPC. A Message is either created with
+// NewMessage and dialed, or accepted through AcceptMessages.
+type Message struct {
+	host   host.Host
+	opened time.Time
+
+	mu     sync.Mutex
+	stream network.Stream
+	writer msgio.WriteCloser
+}
+
+// NewMessage creates an unconnected Message on the given host
+func NewMessage(h host.Host) *Message {
+	return &Message{host: h}
+}
+
+// AcceptMessages sets a stream handler on the host which calls onMessage
+// for every incoming Message connection
+func AcceptMessages(h host.Host, onMessage func(*Message)) {
+	h.SetStreamHandler(MessageProtocolID, func(s network.Stream) {
+		m := &Message{host: h}
+		m.attach(s)
+		onMessage(m)
+	})
+}
+
+func (m *Message) attach(s network.Stream) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stream = s
+	m.writer = msgio.NewVarintWriter(s)
+	m.opened = time.Now()
+}
+
+// Dial opens a stream to the peer given in the "peer_id" param of the address
+func (m *Message) Dial(address models.SpecConfig) error {
+	id, err := peerIDFromSpec(address)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultRPCTimeout)
+	defer cancel()
+
+	s, err := m.host.NewStream(ctx, id, MessageProtocolID)
+	if err != nil {
+		return fmt.Errorf("failed to open message stream to %s: %w", id.String(), err)
+	}
+	m.attach(s)
+	return nil
+}
+
+// Send writes the data as a single frame
+func (m *Message) Send(data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stream == nil {
+		return errors.New("message is not connected")
+	}
+	return m.writer.WriteMsg(data)
+}
+
+// ReceiveHandler starts reading frames from the stream and calls handler for
+// each of them until the stream is closed
+func (m *Message) ReceiveHandler(handler func(data []byte)) error {
+	m.mu.Lock()
+	s := m.stream
+	m.mu.Unlock()
+
+	if s == nil {
+		return errors.New("message is not connected")
+	}
+
+	go func() {
+		reader := msgio.NewVarintReaderSize(s, maxRPCFrameSize)
+		for {
+			msg, err := reader.ReadMsg()
+			if err != nil {
+				return
+			}
+			handler(msg)
+		}
+	}()
+	return nil
+}
+
+// Close closes the underlying stream
+func (m *Message) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stream == nil {
+		return nil
+	}
+	err := m.stream.Close()
+	m.stream = nil
+	return err
+}
+
+// Info returns the information about the connection
+func (m *Message) Info() models.MessageInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	info := models.MessageInfo{
+		Protocol: string(MessageProtocolID),
+		Opened:   m.opened,
+	}
+	if m.stream != nil {
+		conn := m.stream.Conn()
+		info.LocalAddress = conn.LocalMultiaddr().String() + "/p2p/" + conn.LocalPeer().String()
+		info.RemoteAddress = conn.RemoteMultiaddr().String() + "/p2p/" + conn.RemotePeer().String()
+		info.Direction = m.stream.Stat().Direction.String()
+	}
+	return info
+}
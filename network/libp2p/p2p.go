@@ -3,32 +3,39 @@ package libp2p
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	ds "github.com/ipfs/go-datastore"
 	"github.com/libp2p/go-libp2p"
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/core/crypto"
 	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/metrics"
 	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/peerstore"
-	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/core/routing"
+	"github.com/libp2p/go-libp2p/p2p/discovery/mdns"
 	"github.com/libp2p/go-libp2p/p2p/host/autorelay"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoreds"
 	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoremem"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
 	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	"github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/relay"
 	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
 	"github.com/libp2p/go-libp2p/p2p/security/noise"
 	libp2ptls "github.com/libp2p/go-libp2p/p2p/security/tls"
 	"github.com/multiformats/go-multiaddr"
-	mafilt "github.com/whyrusleeping/multiaddr-filter"
 
 	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
 	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/events"
 	"gitlab.com/nunet/device-management-service/models"
+	dmsNetwork "gitlab.com/nunet/device-management-service/network"
 	"gitlab.com/nunet/device-management-service/utils/validate"
 )
 
@@ -37,10 +44,35 @@ type Libp2p struct {
 	Host   host.Host
 	DHT    *dht.IpfsDHT
 	PS     peerstore.Peerstore
+	RPC    *RPC
 	peers  []peer.AddrInfo
 	config Libp2pConfig
+
+	ads         *adRegistry
+	searchCache *searchCache
+	staticPeers []peer.AddrInfo
+	mdns        mdns.Service
+
+	datastore    ds.Batching         // persists the peerstore, nil when kept in memory
+	storedPeers  peerstore.Peerstore // persisted peerstore of an attached host, copied on shutdown
+	routingSeeds []peer.AddrInfo     // routing table snapshot of the previous run
+
+	limits       rcmgr.Limiter
+	limiter      *bandwidthLimiter // nil when no bandwidth cap is configured
+	bandwidth    *metrics.BandwidthCounter
+	reachability *reachabilityTracker
+	peerEvents   *peerEventPublisher
+	heartbeats   *bt.HeartbeatMonitor     // heartbeats received from other nodes and their allocations
+	nodeState    *bt.NodeState            // state reported in the heartbeats of this node, nil without sinks
+	allocations  *bt.AllocationHeartbeats // heartbeats of the executions running on this node, nil without sinks
+
+	tasks    []int // IDs of the tasks registered on the scheduler
+	stopOnce sync.Once
+	stopErr  error
 }
 
+var _ dmsNetwork.Network = (*Libp2p)(nil)
+
 type Libp2pConfig struct {
 	PrivateKey     crypto.PrivKey
 	ListenAddr     []string
@@ -50,109 +82,217 @@ type Libp2pConfig struct {
 	Scheduler      *bt.Scheduler
 }
 
-func (p *Libp2p) Init(config models.NetConfig) error {
-	libp2pConfig, err := DecodeSpec(&config.NetworkSpec)
+func (p *Libp2p) Init(netConfig models.NetConfig) error {
+	libp2pConfig, err := DecodeSpec(&netConfig.NetworkSpec)
 	if err != nil {
 		return fmt.Errorf("failed to decode libp2p config: %v", err)
 	}
 
-	host, dht, err := newHost(context.Background(), libp2pConfig.PrivateKey, libp2pConfig.Server)
+	staticPeers, err := configuredStaticPeers()
 	if err != nil {
 		return err
 	}
-	p.Host = host
-	p.DHT = dht
-	p.PS = host.Peerstore()
-
-	return nil
-}
 
-func (p *Libp2p) Start(ctx context.Context) error {
-	err := p.BootstrapNode(ctx)
+	ctx := context.Background()
+	p.datastore = openDatastore()
+	p.routingSeeds, err = loadRoutingTable(ctx, p.datastore)
 	if err != nil {
-		return fmt.Errorf("bootstraping failed: %v", err)
+		zlog.Sugar().Warnf("ignoring routing table snapshot: %v", err)
 	}
 
-	p.Host.SetStreamHandler(protocol.ID("/ipfs/ping/1.0.0"), PingHandler)
-
-	err = p.DiscoverDialPeers(ctx)
+	p2pConfig := config.GetConfig().P2P
+	limits, err := resourceLimiter(p2pConfig.ResourceLimits)
 	if err != nil {
+		p.closeDatastore()
 		return err
 	}
-
-	// register period peer discoveryTask task
-	discoveryTask := &bt.Task{
-		Name:        "Peer Discovery",
-		Description: "Periodic task to discover new peers every 15 minutes",
-		Function: func(args interface{}) error {
-			return p.DiscoverDialPeers(ctx)
-		},
-		Triggers: []bt.Trigger{&bt.PeriodicTrigger{Interval: 15 * time.Minute}},
+	limiter := newBandwidthLimiter(p2pConfig.Bandwidth)
+
+	bandwidth := metrics.NewBandwidthCounter()
+	host, dht, err := newHost(ctx, libp2pConfig.PrivateKey, hostOptions{
+		server:         libp2pConfig.Server,
+		bandwidth:      bandwidth,
+		store:          p.datastore,
+		bootstrapPeers: p.bootstrapPeers,
+		limits:         limits,
+		limiter:        limiter,
+	})
+	if err != nil {
+		p.closeDatastore()
+		return err
 	}
-	p.config.Scheduler.AddTask(discoveryTask)
-
-	// register period offline peer cleanup task
-	cleanupTask := &bt.Task{
-		Name:        "Peer Discovery",
-		Description: "Periodic task to discover new peers every 15 minutes",
-		Function: func(args interface{}) error {
-			return p.DiscoverDialPeers(ctx)
-		},
-		Triggers: []bt.Trigger{&bt.PeriodicTrigger{Interval: 5 * time.Minute}},
+	p.limits = limits
+	p.limiter = limiter
+	p.bandwidth = bandwidth
+	if err := p.attach(host, dht, libp2pConfig, staticPeers); err != nil {
+		host.Close()
+		p.closeDatastore()
+		return err
 	}
-	p.config.Scheduler.AddTask(cleanupTask)
-
 	return nil
 }
 
-type Advertisement struct {
-	PeerID    string `json:"peer_id"`
-	Timestamp int64  `json:"timestamp,omitempty"`
-	Data      []byte `json:"data"`
+// ErrHostOption is returned by Attach when the configuration needs an option
+// which only applies to the hosts created by the node
+var ErrHostOption = errors.New("option only applies to a host created by the node")
+
+// CheckAttach returns an ErrHostOption error when the configuration needs
+// options which Attach can't apply to a running host, server tells whether
+// the host runs in server mode
+func CheckAttach(server bool) error {
+	p2pConfig := config.GetConfig().P2P
+	if p2pConfig.ResourceLimits != "" {
+		return fmt.Errorf("%w: p2p.resource_limits_file", ErrHostOption)
+	}
+	// the pre-shared key, the allowlist and the DHT prefix are options of
+	// the host and of its DHT, the running host would stay public
+	if p2pConfig.PrivateNetwork.Enabled {
+		return fmt.Errorf("%w: p2p.private_network", ErrHostOption)
+	}
+	// in server mode the host filters the private addresses, the LAN
+	// subnets are only exempt from the filters of the hosts created by the
+	// node and local peers could neither be dialed nor accepted
+	if server && p2pConfig.LAN.Enabled {
+		return fmt.Errorf("%w: p2p.lan in server mode", ErrHostOption)
+	}
+	return nil
 }
 
-func (p *Libp2p) Advertise(adId string, data []byte) error {
-	pubData := Advertisement{}
-	pubData.PeerID = p.Host.ID().String()
-	peerData, err := p.Host.Peerstore().Get(peer.ID(pubData.PeerID), adId)
+// Attach sets the node up on a running host and its DHT instead of creating
+// them as Init does, such as the host started by the onboarding. The node
+// takes them over and closes them on shutdown. The peerstore of the host
+// stays in memory: the persisted peers are added to it and it is persisted
+// again on shutdown. The bandwidth caps apply to the streams of the node,
+// which are metered by the node, and the resource manager of the host keeps
+// the libp2p defaults. The configuration is checked with CheckAttach.
+func (p *Libp2p) Attach(h host.Host, idht *dht.IpfsDHT, libp2pConfig Libp2pConfig) error {
+	if err := CheckAttach(libp2pConfig.Server); err != nil {
+		return err
+	}
+	staticPeers, err := configuredStaticPeers()
 	if err != nil {
-		zlog.Sugar().Errorf("Advertise error: unable to read data from self store: %v", err)
+		return err
 	}
 
-	if adData, ok := peerData.(Advertisement); ok {
-		pubData = adData
+	// the host has the default limits of libp2p, which are the limits
+	// without a limits file
+	p.limits, err = resourceLimiter("")
+	if err != nil {
+		return err
 	}
+	p.limiter = newBandwidthLimiter(config.GetConfig().P2P.Bandwidth)
+	p.bandwidth = metrics.NewBandwidthCounter()
+	h = withBandwidthLimits(h, p.limiter, p.bandwidth)
 
-	signature, err := signData(p.Host.Peerstore().PrivKey(p.Host.ID()), data)
+	ctx := context.Background()
+	p.datastore = openDatastore()
+	p.routingSeeds, err = loadRoutingTable(ctx, p.datastore)
 	if err != nil {
-		zlog.Sugar().Infof("Unable to sign data to be advertised: %v", err)
+		zlog.Sugar().Warnf("ignoring routing table snapshot: %v", err)
 	}
-	type update struct {
-		Data      []byte `json:"data"`
-		Signature []byte `json:"signature"`
+	if p.datastore != nil {
+		p.storedPeers, err = restorePeerstore(ctx, p.datastore, h.Peerstore())
+		if err != nil {
+			zlog.Sugar().Warnf("ignoring persisted peerstore: %v", err)
+		}
 	}
-	dhtUpdate := update{
-		Data:      data,
-		Signature: signature,
+
+	if err := p.attach(h, idht, libp2pConfig, staticPeers); err != nil {
+		p.closeDatastore()
+		return err
 	}
+	return nil
+}
 
-	dht, err := json.Marshal(dhtUpdate)
+// attach sets up the components of the node running on its host
+func (p *Libp2p) attach(h host.Host, idht *dht.IpfsDHT, libp2pConfig Libp2pConfig, staticPeers []peer.AddrInfo) error {
+	reachability, err := newReachabilityTracker(h)
 	if err != nil {
-		zlog.Sugar().Infof("UpdateDHT error: %v", err)
+		return fmt.Errorf("failed to subscribe to reachability events: %w", err)
 	}
-
-	// Add custom namespace to the key
-	namespacedKey := customNamespace + p.Host.ID().String()
-
-	err = p.DHT.PutValue(context.Background(), namespacedKey, dht)
+	peerEvents, err := newPeerEventPublisher(h, events.Default)
 	if err != nil {
-		zlog.Sugar().Infof("UpdateDHT error: %v", err)
+		reachability.close()
+		return fmt.Errorf("failed to subscribe to connectedness events: %w", err)
 	}
+	p.config = withPrivateNetworkConfig(libp2pConfig)
+	p.Host = h
+	p.DHT = idht
+	p.PS = h.Peerstore()
+	p.RPC = NewRPC(h)
+	p.ads = newAdRegistry()
+	p.searchCache = newSearchCache()
+	p.staticPeers = staticPeers
+	p.reachability = reachability
+	p.peerEvents = peerEvents
+
 	return nil
 }
 
-func (p *Libp2p) Unadvertise(adId string) error {
-	return p.DHT.PutValue(context.Background(), adId, nil)
+func (p *Libp2p) Start(ctx context.Context) error {
+	p.seedRoutingTable(ctx)
+
+	err := p.BootstrapNode(ctx)
+	if err != nil {
+		return fmt.Errorf("bootstraping failed: %v", err)
+	}
+
+	p.Host.SetStreamHandler(pingProtocolID, PingHandler)
+
+	if lan := config.GetConfig().P2P.LAN; lan.Enabled && lan.MDNS {
+		if err := p.startMDNS(ctx, lan.ServiceName); err != nil {
+			return err
+		}
+	}
+	p.connectStaticPeers(ctx)
+
+	// the discovery task below retries, e.g. when the routing table is
+	// still empty
+	if err := p.DiscoverDialPeers(ctx); err != nil {
+		zlog.Sugar().Warnf("initial peer discovery failed: %v", err)
+	}
+
+	// register period peer discoveryTask task
+	discoveryTask := &bt.Task{
+		Name:        "Peer Discovery",
+		Description: "Periodic task to discover new peers every 15 minutes",
+		Function: func(ctx context.Context, _ interface{}) error {
+			return p.DiscoverDialPeers(ctx)
+		},
+		Triggers: []bt.Trigger{&bt.IntervalTrigger{Interval: 15 * time.Minute, Jitter: 5 * time.Minute}},
+		RetryPolicy: bt.RetryPolicy{
+			MaxRetries: 3,
+			Delay:      10 * time.Second,
+			Backoff:    2,
+			MaxDelay:   time.Minute,
+		},
+	}
+	p.addTask(discoveryTask)
+
+	// register period offline peer cleanup task
+	cleanupTask := &bt.Task{
+		Name:        "Peer Cleanup",
+		Description: "Periodic task to remove offline peers every 5 minutes",
+		Function: func(_ context.Context, _ interface{}) error {
+			p.CleanupOfflinePeers()
+			return nil
+		},
+		Triggers: []bt.Trigger{&bt.IntervalTrigger{Interval: 5 * time.Minute, Jitter: time.Minute}},
+	}
+	p.addTask(cleanupTask)
+
+	// register periodic re-advertise task so our DHT records don't expire
+	readvertiseTask := &bt.Task{
+		Name:        "Re-advertise",
+		Description: "Periodic task to refresh this node's DHT advertisements before they expire",
+		Function: func(ctx context.Context, _ interface{}) error {
+			return p.readvertise(ctx)
+		},
+		Triggers: []bt.Trigger{&bt.IntervalTrigger{Interval: readvertiseInterval, Jitter: readvertiseJitter}},
+	}
+	p.addTask(readvertiseTask)
+
+	return p.startHeartbeats(config.GetConfig().Heartbeat)
 }
 
 func (p *Libp2p) Publish(topic string, data []byte) error {
@@ -163,10 +303,6 @@ func (p *Libp2p) Subscribe(topic string, handler func(data []byte)) error {
 	return nil
 }
 
-func (p *Libp2p) Stop() error {
-	return nil
-}
-
 func PingHandler(s network.Stream) {
 	// TODO any ping handling logic should be here
 	pingSrv := ping.PingService{}
@@ -213,14 +349,26 @@ func DecodeSpec(spec *models.SpecConfig) (Libp2pConfig, error) {
 	return *libp2pConfig, libp2pConfig.Validate()
 }
 
-func newHost(ctx context.Context, priv crypto.PrivKey, server bool) (host.Host, *dht.IpfsDHT, error) {
+// hostOptions holds the settings of the host created by newHost
+type hostOptions struct {
+	server         bool
+	bandwidth      metrics.Reporter
+	store          ds.Batching            // persists the peerstore, nil keeps it in memory
+	bootstrapPeers func() []peer.AddrInfo // used to refill an empty routing table
+	limits         rcmgr.Limiter
+	limiter        *bandwidthLimiter // nil when no bandwidth cap is configured
+}
 
+// newHost creates the libp2p host and its DHT. The returned host applies the
+// bandwidth caps to the streams it opens and handles.
+func newHost(ctx context.Context, priv crypto.PrivKey, opts hostOptions) (host.Host, *dht.IpfsDHT, error) {
 	var idht *dht.IpfsDHT
 
+	p2pConfig := config.GetConfig().P2P
 	connmgr, err := connmgr.NewConnManager(
-		100, // Lowwater
-		400, // HighWater,
-		connmgr.WithGracePeriod(time.Minute),
+		p2pConfig.ConnManager.LowWater,
+		p2pConfig.ConnManager.HighWater,
+		connmgr.WithGracePeriod(time.Duration(p2pConfig.ConnManager.GracePeriod)*time.Second),
 	)
 
 	if err != nil {
@@ -228,16 +376,44 @@ func newHost(ctx context.Context, priv crypto.PrivKey, server bool) (host.Host,
 		return nil, nil, err
 	}
 
-	filter := multiaddr.NewFilters()
-	for _, s := range defaultServerFilters {
-		f, err := mafilt.NewMask(s)
+	resourceManager, err := rcmgr.NewResourceManager(opts.limits)
+	if err != nil {
+		zlog.Sugar().Errorf("Error Creating Resource Manager: %v", err)
+		return nil, nil, err
+	}
+	listenAddrs := p2pConfig.ListenAddress
+	transportOpts := []libp2p.Option{libp2p.DefaultTransports}
+	var allowedPeers map[peer.ID]struct{}
+	if p2pConfig.PrivateNetwork.Enabled {
+		zlog.Sugar().Infof("Joining private network with DHT prefix %s", p2pConfig.PrivateNetwork.DHTPrefix)
+		transportOpts, err = privateNetworkOptions(p2pConfig.PrivateNetwork)
+		if err != nil {
+			zlog.Sugar().Errorf("Error configuring private network: %v", err)
+			return nil, nil, err
+		}
+		listenAddrs = privateListenAddrs(listenAddrs)
+
+		allowedPeers, err = parseAllowedPeers(p2pConfig.PrivateNetwork)
 		if err != nil {
-			zlog.Sugar().Errorf("incorrectly formatted address filter in config: %s - %v", s, err)
+			zlog.Sugar().Errorf("Error configuring private network: %v", err)
+			return nil, nil, err
 		}
-		filter.AddFilter(*f, multiaddr.ActionDeny)
 	}
 
-	ps, err := pstoremem.NewPeerstore()
+	// in LAN mode the local subnets must stay reachable in server mode
+	lanAllowed := lanFilterExceptions(p2pConfig.LAN)
+
+	filter := multiaddr.NewFilters()
+	if opts.server {
+		filter = serverFilters(lanAllowed)
+	}
+
+	var ps peerstore.Peerstore
+	if opts.store != nil {
+		ps, err = pstoreds.NewPeerstore(ctx, opts.store, pstoreds.DefaultOpts())
+	} else {
+		ps, err = pstoremem.NewPeerstore()
+	}
 	if err != nil {
 		zlog.Sugar().Errorf("Couldn't Create Peerstore: %v", err)
 		return nil, nil, err
@@ -248,22 +424,24 @@ func newHost(ctx context.Context, priv crypto.PrivKey, server bool) (host.Host,
 		kadPrefix,
 		dht.NamespacedValidator(strings.ReplaceAll(customNamespace, "/", ""), dhtValidator{PS: ps}),
 		dht.Mode(dht.ModeServer),
+		dht.BootstrapPeersFunc(opts.bootstrapPeers),
 	}
 
-	libp2pOpts = append(libp2pOpts, libp2p.ListenAddrStrings(
-		config.GetConfig().P2P.ListenAddress...),
+	libp2pOpts = append(libp2pOpts, transportOpts...)
+	libp2pOpts = append(libp2pOpts, libp2p.ListenAddrStrings(listenAddrs...),
 		libp2p.Identity(priv),
 		libp2p.Routing(func(h host.Host) (routing.PeerRouting, error) {
-			idht, err = dht.New(ctx, h, baseOpts...)
+			idht, err = dht.New(ctx, withBandwidthLimits(h, opts.limiter, nil), baseOpts...)
 			return idht, err
 		}),
 		libp2p.Peerstore(ps),
 		libp2p.EnableNATService(),
 		libp2p.Security(libp2ptls.ID, libp2ptls.New),
 		libp2p.Security(noise.ID, noise.New),
-		libp2p.DefaultTransports,
 		libp2p.EnableNATService(),
 		libp2p.ConnectionManager(connmgr),
+		libp2p.ResourceManager(resourceManager),
+		libp2p.BandwidthReporter(opts.bandwidth),
 		libp2p.EnableRelay(),
 		libp2p.EnableHolePunching(),
 		libp2p.EnableRelayService(
@@ -309,12 +487,14 @@ func newHost(ctx context.Context, priv crypto.PrivKey, server bool) (host.Host,
 		),
 	)
 
-	if server {
-		libp2pOpts = append(libp2pOpts, libp2p.AddrsFactory(makeAddrsFactory([]string{}, []string{}, defaultServerFilters)))
-		libp2pOpts = append(libp2pOpts, libp2p.ConnectionGater((*filtersConnectionGater)(filter)))
+	if opts.server {
+		libp2pOpts = append(libp2pOpts, libp2p.AddrsFactory(makeAddrsFactory([]string{}, []string{}, defaultServerFilters, lanAllowed)))
 	} else {
 		libp2pOpts = append(libp2pOpts, libp2p.NATPortMap())
 	}
+	if opts.server || allowedPeers != nil {
+		libp2pOpts = append(libp2pOpts, libp2p.ConnectionGater(&connectionGater{filters: filter, allowed: allowedPeers}))
+	}
 
 	host, err := libp2p.New(libp2pOpts...)
 
@@ -325,5 +505,5 @@ func newHost(ctx context.Context, priv crypto.PrivKey, server bool) (host.Host,
 
 	zlog.Sugar().Infof("Self Peer Info %s -> %s", host.ID().String(), host.Addrs())
 
-	return host, idht, nil
+	return withBandwidthLimits(host, opts.limiter, nil), idht, nil
 }
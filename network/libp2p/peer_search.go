@@ -0,0 +1,388 @@
+package libp2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/discovery"
+	"github.com/libp2p/go-libp2p/core/peer"
+	drouting "github.com/libp2p/go-libp2p/p2p/discovery/routing"
+
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
+)
+
+const (
+	// peerSearchConcurrency is the number of DHT lookups running in parallel during a search
+	peerSearchConcurrency = 16
+
+	// peerSearchTimeout bounds a whole search when the caller's context has no deadline
+	peerSearchTimeout = time.Minute
+
+	// peerSearchCacheTTL is how long the results of a completed search are reused
+	peerSearchCacheTTL = time.Minute
+
+	// peerSearchMaxCandidates bounds the providers of a capability looked up in a search
+	peerSearchMaxCandidates = 1000
+
+	// capabilityIndexPrefix is appended to the rendezvous to build the namespace of a capability
+	capabilityIndexPrefix = "/capability/"
+)
+
+// CapabilityQuery describes the capabilities a peer must advertise in its
+// PeerData to be returned by FindPeers. Zero values mean "no constraint".
+type CapabilityQuery struct {
+	MinCPU            int              `json:"min_cpu,omitempty"`    // total CPU in MHz
+	MinRAM            int              `json:"min_ram,omitempty"`    // RAM in MB
+	GPUVendor         models.GPUVendor `json:"gpu_vendor,omitempty"` // required GPU vendor
+	MinVRAM           uint64           `json:"min_vram,omitempty"`   // VRAM of a single GPU, same unit as models.Gpu.TotVram
+	ExecutorTypes     []string         `json:"executor_types,omitempty"`
+	MaxPricePerMinute float64          `json:"max_price_per_minute,omitempty"` // price ceiling in NTX
+	RequireCardano    bool             `json:"require_cardano,omitempty"`
+	Limit             int              `json:"limit,omitempty"` // stop after this many matches
+}
+
+// QueryFromJobSpec builds a CapabilityQuery from the engine and the resources
+// of a job spec. The CPU of the spec isn't matched, peers advertise it in MHz.
+func QueryFromJobSpec(spec models.JobSpec) CapabilityQuery {
+	var query CapabilityQuery
+	if spec.Engine != nil {
+		query.ExecutorTypes = []string{spec.Engine.Type}
+	}
+	if resources := spec.Resources; resources != nil {
+		query.MinRAM = int(resources.Memory >> 20)
+		if len(resources.GPUs) > 0 {
+			query.GPUVendor = resources.GPUs[0].Vendor
+		}
+	}
+	return query
+}
+
+// Matches returns true if the peer satisfies every constraint of the query
+func (q CapabilityQuery) Matches(data models.PeerData) bool {
+	if !data.IsAvailable {
+		return false
+	}
+
+	resources := data.AvailableResources
+	if resources.TotCpuHz < q.MinCPU || resources.Ram < q.MinRAM {
+		return false
+	}
+	if q.MaxPricePerMinute > 0 && resources.NTXPricePerMinute > q.MaxPricePerMinute {
+		return false
+	}
+	if q.RequireCardano && !data.AllowCardano {
+		return false
+	}
+
+	for _, executorType := range q.ExecutorTypes {
+		if !containsFold(data.ExecutorTypes, executorType) {
+			return false
+		}
+	}
+
+	if q.GPUVendor != "" || q.MinVRAM > 0 {
+		return q.matchesGPU(data.GpuInfo)
+	}
+	return true
+}
+
+// matchesGPU returns true if at least one GPU satisfies both the vendor and VRAM constraints
+func (q CapabilityQuery) matchesGPU(gpus []models.Gpu) bool {
+	for _, gpu := range gpus {
+		if gpu.TotVram < q.MinVRAM {
+			continue
+		}
+		if q.GPUVendor != "" && gpuVendor(gpu) != q.GPUVendor {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// key returns a stable identifier of the query used for caching
+func (q CapabilityQuery) key() string {
+	b, _ := json.Marshal(q)
+	return string(b)
+}
+
+// gpuVendor returns the vendor of the GPU, inferring it from the name for
+// peers that advertise PeerData without a vendor
+func gpuVendor(gpu models.Gpu) models.GPUVendor {
+	if gpu.Vendor != "" {
+		return gpu.Vendor
+	}
+
+	name := strings.ToUpper(gpu.Name)
+	switch {
+	case strings.Contains(name, "NVIDIA"):
+		return models.GPUVendorNvidia
+	case strings.Contains(name, "AMD"), strings.Contains(name, "RADEON"):
+		return models.GPUVendorAMDATI
+	case strings.Contains(name, "INTEL"):
+		return models.GPUVendorIntel
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchCache keeps the results of recent searches
+type searchCache struct {
+	mu      sync.Mutex
+	entries map[string]searchCacheEntry
+}
+
+type searchCacheEntry struct {
+	results  []models.PeerData
+	storedAt time.Time
+}
+
+func newSearchCache() *searchCache {
+	return &searchCache{entries: make(map[string]searchCacheEntry)}
+}
+
+func (c *searchCache) get(key string) ([]models.PeerData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.storedAt) > peerSearchCacheTTL {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.results, true
+}
+
+func (c *searchCache) put(key string, results []models.PeerData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// drop expired entries so the cache doesn't grow with every distinct query
+	for k, entry := range c.entries {
+		if time.Since(entry.storedAt) > peerSearchCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = searchCacheEntry{results: results, storedAt: time.Now()}
+}
+
+// capabilityKeys returns the keys of the capability index a peer is provided
+// under for its PeerData. Unavailable peers aren't indexed.
+func capabilityKeys(data models.PeerData) []string {
+	if !data.IsAvailable {
+		return nil
+	}
+
+	keys := []string{"available"}
+	for _, executorType := range data.ExecutorTypes {
+		keys = appendKey(keys, "executor/"+strings.ToLower(executorType))
+	}
+	for _, gpu := range data.GpuInfo {
+		if vendor := gpuVendor(gpu); vendor != "" {
+			keys = appendKey(keys, "gpu/"+strings.ToLower(string(vendor)))
+		}
+	}
+	if data.AllowCardano {
+		keys = append(keys, "cardano")
+	}
+	return keys
+}
+
+// indexKey returns the key of the capability index whose providers are the
+// candidates of the query, the most selective constraint is used
+func (q CapabilityQuery) indexKey() string {
+	switch {
+	case q.GPUVendor != "":
+		return "gpu/" + strings.ToLower(string(q.GPUVendor))
+	case q.RequireCardano:
+		return "cardano"
+	case len(q.ExecutorTypes) > 0:
+		return "executor/" + strings.ToLower(q.ExecutorTypes[0])
+	}
+	return "available"
+}
+
+func appendKey(keys []string, key string) []string {
+	for _, k := range keys {
+		if k == key {
+			return keys
+		}
+	}
+	return append(keys, key)
+}
+
+// capabilityNamespace returns the DHT namespace of a capability index key
+func (p *Libp2p) capabilityNamespace(key string) string {
+	return p.config.Rendezvous + capabilityIndexPrefix + key
+}
+
+// indexCapabilities provides this host on the DHT under the capability index
+// keys of its PeerData. Provider records can't be withdrawn, they expire when
+// they aren't provided again, so the PeerData of the candidates is always
+// matched against the query.
+func (p *Libp2p) indexCapabilities(ctx context.Context, data []byte) error {
+	var peerData models.PeerData
+	if err := json.Unmarshal(data, &peerData); err != nil {
+		return fmt.Errorf("failed to decode peer data: %w", err)
+	}
+
+	routingDiscovery := drouting.NewRoutingDiscovery(p.DHT)
+	var errs []error
+	for _, key := range capabilityKeys(peerData) {
+		start := time.Now()
+		_, err := routingDiscovery.Advertise(ctx, p.capabilityNamespace(key))
+		metrics.ObserveDHTLookup("provide", start, err)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to index capability %s: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FindPeers searches the DHT for peers whose advertised PeerData matches the query.
+// Only the providers of the most selective capability of the query in the
+// capability index are candidates, their PeerData is then fetched and matched.
+// Matching peers are sent on the returned channel as soon as they are found and
+// the channel is closed when the search completes or the context is done.
+// Results of completed searches are cached for a short period. Callers must
+// either drain the channel or cancel the context.
+func (p *Libp2p) FindPeers(ctx context.Context, query CapabilityQuery) <-chan models.PeerData {
+	results := make(chan models.PeerData)
+
+	if p.searchCache == nil {
+		p.searchCache = newSearchCache()
+	}
+
+	if cached, ok := p.searchCache.get(query.key()); ok {
+		go func() {
+			defer close(results)
+			for _, data := range cached {
+				select {
+				case results <- data:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return results
+	}
+
+	searchCtx, stopSearch := context.WithCancel(ctx)
+	if _, ok := ctx.Deadline(); !ok {
+		searchCtx, stopSearch = context.WithTimeout(ctx, peerSearchTimeout)
+	}
+
+	go func() {
+		defer close(results)
+		defer stopSearch()
+
+		var (
+			wg           sync.WaitGroup
+			mu           sync.Mutex
+			matches      []models.PeerData
+			limitReached bool
+		)
+		workerPool := make(chan struct{}, peerSearchConcurrency)
+
+		candidates, err := p.searchCandidates(searchCtx, query)
+		if err != nil {
+			zlog.Sugar().Warnf("FindPeers: %v", err)
+			return
+		}
+
+	search:
+		for info := range candidates {
+			id := info.ID
+			if id == p.Host.ID() {
+				continue
+			}
+			select {
+			case workerPool <- struct{}{}:
+			case <-searchCtx.Done():
+				break search
+			}
+
+			wg.Add(1)
+			go func(id peer.ID) {
+				defer func() {
+					<-workerPool
+					wg.Done()
+				}()
+
+				data, err := p.peerData(searchCtx, id)
+				if err != nil {
+					zlog.Sugar().Debugf("FindPeers: couldn't retrieve peer data for %s: %v", id.String(), err)
+					return
+				}
+				if !query.Matches(data) {
+					return
+				}
+
+				mu.Lock()
+				if query.Limit > 0 && len(matches) >= query.Limit {
+					mu.Unlock()
+					return
+				}
+				matches = append(matches, data)
+				if query.Limit > 0 && len(matches) >= query.Limit {
+					limitReached = true
+					stopSearch()
+				}
+				mu.Unlock()
+
+				select {
+				case results <- data:
+				case <-ctx.Done():
+				}
+			}(id)
+		}
+		wg.Wait()
+
+		// only cache complete answers
+		if ctx.Err() == nil && (searchCtx.Err() == nil || limitReached) {
+			p.searchCache.put(query.key(), matches)
+		}
+	}()
+
+	return results
+}
+
+// searchCandidates looks up the providers of the capability index key of the query
+func (p *Libp2p) searchCandidates(ctx context.Context, query CapabilityQuery) (<-chan peer.AddrInfo, error) {
+	routingDiscovery := drouting.NewRoutingDiscovery(p.DHT)
+	candidates, err := routingDiscovery.FindPeers(ctx, p.capabilityNamespace(query.indexKey()), discovery.Limit(peerSearchMaxCandidates))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the capability index: %w", err)
+	}
+	return candidates, nil
+}
+
+// peerData fetches the PeerData advertised by the given peer
+func (p *Libp2p) peerData(ctx context.Context, id peer.ID) (models.PeerData, error) {
+	var data models.PeerData
+
+	ad, err := p.GetAdvertisement(ctx, AdTypePeerInfo, id)
+	if err != nil {
+		return data, err
+	}
+
+	err = json.Unmarshal(ad.Data, &data)
+	return data, err
+}
@@ -0,0 +1,158 @@
+package libp2p
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func testPeerData() models.PeerData {
+	return models.PeerData{
+		PeerID:        "peer",
+		IsAvailable:   true,
+		AllowCardano:  true,
+		ExecutorTypes: []string{models.ExecutorTypeDocker},
+		GpuInfo: []models.Gpu{
+			{Name: "NVIDIA GeForce RTX 3060", TotVram: 12288},
+		},
+		AvailableResources: models.FreeResources{
+			TotCpuHz:          8000,
+			Ram:               16000,
+			NTXPricePerMinute: 2,
+		},
+	}
+}
+
+func TestCapabilityQueryMatches(t *testing.T) {
+	data := testPeerData()
+
+	tests := []struct {
+		name    string
+		query   CapabilityQuery
+		matches bool
+	}{
+		{"empty query", CapabilityQuery{}, true},
+		{"enough resources", CapabilityQuery{MinCPU: 4000, MinRAM: 8000}, true},
+		{"not enough cpu", CapabilityQuery{MinCPU: 10000}, false},
+		{"not enough ram", CapabilityQuery{MinRAM: 32000}, false},
+		{"gpu vendor inferred from name", CapabilityQuery{GPUVendor: models.GPUVendorNvidia, MinVRAM: 8192}, true},
+		{"wrong gpu vendor", CapabilityQuery{GPUVendor: models.GPUVendorAMDATI}, false},
+		{"not enough vram", CapabilityQuery{MinVRAM: 24576}, false},
+		{"executor type", CapabilityQuery{ExecutorTypes: []string{"Docker"}}, true},
+		{"missing executor type", CapabilityQuery{ExecutorTypes: []string{models.ExecutorTypeFirecracker}}, false},
+		{"under price ceiling", CapabilityQuery{MaxPricePerMinute: 5}, true},
+		{"over price ceiling", CapabilityQuery{MaxPricePerMinute: 1}, false},
+		{"cardano", CapabilityQuery{RequireCardano: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.query.Matches(data))
+		})
+	}
+
+	data.IsAvailable = false
+	assert.False(t, CapabilityQuery{}.Matches(data), "unavailable peers should never match")
+}
+
+func TestSearchCache(t *testing.T) {
+	cache := newSearchCache()
+	query := CapabilityQuery{MinCPU: 1000}
+
+	_, ok := cache.get(query.key())
+	assert.False(t, ok)
+
+	cache.put(query.key(), []models.PeerData{testPeerData()})
+	results, ok := cache.get(query.key())
+	assert.True(t, ok)
+	assert.Len(t, results, 1)
+
+	_, ok = cache.get(CapabilityQuery{MinCPU: 2000}.key())
+	assert.False(t, ok, "different queries should not share cache entries")
+
+	cache.entries[query.key()] = searchCacheEntry{storedAt: time.Now().Add(-2 * peerSearchCacheTTL)}
+	_, ok = cache.get(query.key())
+	assert.False(t, ok, "expired entries should not be returned")
+}
+
+func TestCapabilityKeys(t *testing.T) {
+	data := testPeerData()
+	assert.Equal(t, []string{"available", "executor/docker", "gpu/nvidia", "cardano"}, capabilityKeys(data))
+
+	for _, query := range []CapabilityQuery{
+		{},
+		{GPUVendor: models.GPUVendorNvidia, ExecutorTypes: []string{"Docker"}},
+		{RequireCardano: true},
+		{ExecutorTypes: []string{"Docker"}},
+	} {
+		assert.Contains(t, capabilityKeys(data), query.indexKey())
+	}
+
+	data.IsAvailable = false
+	assert.Empty(t, capabilityKeys(data), "unavailable peers should not be indexed")
+}
+
+// newSearchNodes returns two nodes whose DHTs are connected to each other
+func newSearchNodes(t *testing.T) (*Libp2p, *Libp2p) {
+	ctx := context.Background()
+	h1, h2 := newConnectedHosts(t)
+
+	newNode := func(h host.Host) *Libp2p {
+		d, err := dht.New(ctx, h, kadPrefix, dht.Mode(dht.ModeServer),
+			dht.NamespacedValidator(strings.ReplaceAll(customNamespace, "/", ""), dhtValidator{PS: h.Peerstore()}))
+		require.NoError(t, err)
+		t.Cleanup(func() { d.Close() })
+		return &Libp2p{Host: h, DHT: d, config: Libp2pConfig{Rendezvous: "nunet-test"}}
+	}
+	n1, n2 := newNode(h1), newNode(h2)
+
+	// the DHTs were started after the hosts connected, reconnect so that
+	// identify advertises the DHT protocol
+	require.NoError(t, h1.Network().ClosePeer(h2.ID()))
+	require.NoError(t, h1.Connect(ctx, peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	require.Eventually(t, func() bool {
+		return n1.DHT.RoutingTable().Find(h2.ID()) != "" && n2.DHT.RoutingTable().Find(h1.ID()) != ""
+	}, 5*time.Second, 50*time.Millisecond)
+	return n1, n2
+}
+
+func TestFindPeers(t *testing.T) {
+	searcher, provider := newSearchNodes(t)
+
+	data := testPeerData()
+	data.PeerID = provider.Host.ID().String()
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	require.NoError(t, provider.Advertise(AdTypePeerInfo, raw))
+
+	find := func(query CapabilityQuery) []models.PeerData {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		var results []models.PeerData
+		for result := range searcher.FindPeers(ctx, query) {
+			results = append(results, result)
+		}
+		return results
+	}
+
+	results := find(CapabilityQuery{ExecutorTypes: []string{models.ExecutorTypeDocker}, MinRAM: 8000})
+	require.Len(t, results, 1)
+	assert.Equal(t, data.PeerID, results[0].PeerID)
+
+	// the provider is connected but isn't indexed under the capability
+	assert.Empty(t, find(CapabilityQuery{ExecutorTypes: []string{models.ExecutorTypeFirecracker}}))
+	assert.Empty(t, find(CapabilityQuery{GPUVendor: models.GPUVendorAMDATI}))
+
+	// indexed candidates are still matched against their PeerData
+	assert.Empty(t, find(CapabilityQuery{ExecutorTypes: []string{models.ExecutorTypeDocker}, MinRAM: 32000}))
+}
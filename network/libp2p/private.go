@@ -0,0 +1,97 @@
+package libp2p
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/pnet"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	"github.com/libp2p/go-libp2p/p2p/transport/websocket"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// withPrivateNetworkConfig overrides the rendezvous of the libp2p config
+// when running in private network mode
+func withPrivateNetworkConfig(lc Libp2pConfig) Libp2pConfig {
+	private := config.GetConfig().P2P.PrivateNetwork
+	if private.Enabled && private.Rendezvous != "" {
+		lc.Rendezvous = private.Rendezvous
+	}
+	return lc
+}
+
+// loadSwarmKey reads a pre-shared key in the /key/swarm/psk/1.0.0/ format
+func loadSwarmKey(path string) (pnet.PSK, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open swarm key: %w", err)
+	}
+	defer f.Close()
+
+	psk, err := pnet.DecodeV1PSK(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode swarm key %s: %w", path, err)
+	}
+	return psk, nil
+}
+
+// privateNetworkOptions returns the libp2p options for joining the private
+// network described by the config. QUIC based transports don't support
+// pre-shared keys, so only TCP and websocket are enabled.
+func privateNetworkOptions(cfg config.PrivateNetwork) ([]libp2p.Option, error) {
+	psk, err := loadSwarmKey(cfg.SwarmKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return []libp2p.Option{
+		libp2p.PrivateNetwork(psk),
+		libp2p.Transport(tcp.NewTCPTransport),
+		libp2p.Transport(websocket.New),
+	}, nil
+}
+
+// privateListenAddrs drops the listen addresses of transports which
+// can't be used in a private network
+func privateListenAddrs(addrs []string) []string {
+	var filtered []string
+	for _, addr := range addrs {
+		if strings.Contains(addr, "/quic") || strings.Contains(addr, "/webtransport") || strings.Contains(addr, "/webrtc") {
+			zlog.Sugar().Warnf("ignoring listen address %s: transport not supported in private network mode", addr)
+			continue
+		}
+		filtered = append(filtered, addr)
+	}
+	return filtered
+}
+
+// parseAllowedPeers decodes the allowlist of the private network. The
+// bootstrap peers are always allowed so that the node can join the network.
+// A nil map is returned when no allowlist is configured.
+func parseAllowedPeers(cfg config.PrivateNetwork) (map[peer.ID]struct{}, error) {
+	if len(cfg.AllowedPeers) == 0 {
+		return nil, nil
+	}
+
+	allowed := make(map[peer.ID]struct{})
+	for _, s := range cfg.AllowedPeers {
+		id, err := peer.Decode(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid peer ID in allowed_peers %s: %w", s, err)
+		}
+		allowed[id] = struct{}{}
+	}
+
+	for _, ma := range NuNetBootstrapPeers {
+		info, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			continue
+		}
+		allowed[info.ID] = struct{}{}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,81 @@
+package libp2p
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+func writeSwarmKey(t *testing.T) string {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "swarm.key")
+	content := "/key/swarm/psk/1.0.0/\n/base16/\n" + hex.EncodeToString(key)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestPrivateNetworkHosts(t *testing.T) {
+	cfg := config.PrivateNetwork{Enabled: true, SwarmKeyPath: writeSwarmKey(t)}
+
+	newPrivateHost := func(cfg config.PrivateNetwork) host.Host {
+		opts, err := privateNetworkOptions(cfg)
+		require.NoError(t, err)
+		h, err := libp2p.New(append(opts, libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))...)
+		require.NoError(t, err)
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+
+	h1, h2 := newPrivateHost(cfg), newPrivateHost(cfg)
+	err := h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()})
+	assert.NoError(t, err, "hosts sharing the swarm key should connect")
+
+	outsider := newPrivateHost(config.PrivateNetwork{Enabled: true, SwarmKeyPath: writeSwarmKey(t)})
+	err = outsider.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()})
+	assert.Error(t, err, "hosts with a different swarm key should not connect")
+
+	_, err = privateNetworkOptions(config.PrivateNetwork{SwarmKeyPath: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
+
+func TestPrivateListenAddrs(t *testing.T) {
+	addrs := privateListenAddrs([]string{
+		"/ip4/0.0.0.0/tcp/9000",
+		"/ip4/0.0.0.0/udp/9000/quic",
+		"/ip4/0.0.0.0/udp/9000/quic-v1/webtransport",
+	})
+	assert.Equal(t, []string{"/ip4/0.0.0.0/tcp/9000"}, addrs)
+}
+
+func TestConnectionGaterAllowlist(t *testing.T) {
+	_, allowedID := newTestIdentity(t)
+	_, otherID := newTestIdentity(t)
+
+	allowed, err := parseAllowedPeers(config.PrivateNetwork{AllowedPeers: []string{allowedID.String()}})
+	require.NoError(t, err)
+
+	gater := &connectionGater{filters: multiaddr.NewFilters(), allowed: allowed}
+	assert.True(t, gater.InterceptPeerDial(allowedID))
+	assert.False(t, gater.InterceptPeerDial(otherID))
+
+	open := &connectionGater{filters: multiaddr.NewFilters()}
+	assert.True(t, open.InterceptPeerDial(otherID), "gater without allowlist should allow every peer")
+
+	_, err = parseAllowedPeers(config.PrivateNetwork{AllowedPeers: []string{"not-a-peer-id"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package libp2p
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	rcmgr "github.com/libp2p/go-libp2p/p2p/host/resource-manager"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// resourceLimiter returns the limits of the resource manager: the libp2p
+// defaults scaled to the machine, overridden by the limits file if any
+func resourceLimiter(path string) (rcmgr.Limiter, error) {
+	limits := rcmgr.DefaultLimits
+	libp2p.SetDefaultServiceLimits(&limits)
+	scaled := limits.AutoScale()
+	if path == "" {
+		return rcmgr.NewFixedLimiter(scaled), nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open resource limits file: %w", err)
+	}
+	defer f.Close()
+
+	limiter, err := rcmgr.NewLimiterFromJSON(f, scaled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse resource limits file %s: %w", path, err)
+	}
+	return limiter, nil
+}
+
+// Usage returns the streams, connections, file descriptors and memory used
+// by the host and its DMS protocols, and the bandwidth used against the caps
+func (p *Libp2p) Usage() models.NetUsage {
+	usage := models.NetUsage{
+		Protocols: make(map[string]models.ScopeUsage),
+		Bandwidth: p.bandwidthUsage(),
+	}
+
+	rm := p.Host.Network().ResourceManager()
+	limits := p.limits
+	if limits == nil {
+		limits = rcmgr.NewFixedLimiter(rcmgr.InfiniteLimits)
+	}
+
+	_ = rm.ViewSystem(func(s network.ResourceScope) error {
+		usage.System = scopeUsage(s.Stat(), limits.GetSystemLimits())
+		return nil
+	})
+	_ = rm.ViewTransient(func(s network.ResourceScope) error {
+		usage.Transient = scopeUsage(s.Stat(), limits.GetTransientLimits())
+		return nil
+	})
+	for _, proto := range p.reportedProtocols() {
+		_ = rm.ViewProtocol(proto, func(s network.ProtocolScope) error {
+			usage.Protocols[string(proto)] = scopeUsage(s.Stat(), limits.GetProtocolLimits(proto))
+			return nil
+		})
+	}
+
+	return usage
+}
+
+// reportedProtocols returns the DMS protocols and the protocols with a bandwidth cap
+func (p *Libp2p) reportedProtocols() []protocol.ID {
+	seen := make(map[protocol.ID]struct{})
+	var protocols []protocol.ID
+	add := func(proto protocol.ID) {
+		if _, ok := seen[proto]; ok {
+			return
+		}
+		seen[proto] = struct{}{}
+		protocols = append(protocols, proto)
+	}
+
+	for _, proto := range dmsProtocols {
+		add(proto)
+	}
+	if p.limiter != nil {
+		for proto := range p.limiter.protocols {
+			add(proto)
+		}
+	}
+	sort.Slice(protocols, func(i, j int) bool { return protocols[i] < protocols[j] })
+	return protocols
+}
+
+// bandwidthUsage returns the current rates against the bandwidth caps
+func (p *Libp2p) bandwidthUsage() models.BandwidthUsage {
+	usage := models.BandwidthUsage{Protocols: make(map[string]models.BandwidthLimit)}
+
+	var caps map[protocol.ID][2]int64
+	if p.limiter != nil {
+		usage.Total.MaxIn, usage.Total.MaxOut = p.limiter.config.MaxIn, p.limiter.config.MaxOut
+		caps = make(map[protocol.ID][2]int64)
+		for _, c := range p.limiter.config.Protocols {
+			caps[protocol.ID(c.Protocol)] = [2]int64{c.MaxIn, c.MaxOut}
+		}
+	}
+
+	var byProtocol map[protocol.ID]metrics.Stats
+	if p.bandwidth != nil {
+		total := p.bandwidth.GetBandwidthTotals()
+		usage.Total.RateIn, usage.Total.RateOut = total.RateIn, total.RateOut
+		byProtocol = p.bandwidth.GetBandwidthByProtocol()
+	}
+
+	for _, proto := range p.reportedProtocols() {
+		stats := byProtocol[proto]
+		c := caps[proto]
+		usage.Protocols[string(proto)] = models.BandwidthLimit{
+			RateIn:  stats.RateIn,
+			RateOut: stats.RateOut,
+			MaxIn:   c[0],
+			MaxOut:  c[1],
+		}
+	}
+	return usage
+}
+
+// scopeUsage converts a resource manager scope stat and its limits, the
+// unlimited values of libp2p are reported as 0
+func scopeUsage(stat network.ScopeStat, limit rcmgr.Limit) models.ScopeUsage {
+	return models.ScopeUsage{
+		StreamsInbound:  usageOf(stat.NumStreamsInbound, limit.GetStreamLimit(network.DirInbound)),
+		StreamsOutbound: usageOf(stat.NumStreamsOutbound, limit.GetStreamLimit(network.DirOutbound)),
+		ConnsInbound:    usageOf(stat.NumConnsInbound, limit.GetConnLimit(network.DirInbound)),
+		ConnsOutbound:   usageOf(stat.NumConnsOutbound, limit.GetConnLimit(network.DirOutbound)),
+		FD:              usageOf(stat.NumFD, limit.GetFDLimit()),
+		Memory:          memoryUsage(stat.Memory, limit.GetMemoryLimit()),
+	}
+}
+
+func usageOf(used, limit int) models.Usage {
+	if limit == math.MaxInt {
+		limit = 0
+	}
+	return models.Usage{Used: int64(used), Limit: int64(limit)}
+}
+
+func memoryUsage(used, limit int64) models.Usage {
+	if limit == math.MaxInt64 {
+		limit = 0
+	}
+	return models.Usage{Used: used, Limit: limit}
+}
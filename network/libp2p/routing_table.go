@@ -0,0 +1,200 @@
+package libp2p
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	leveldb "github.com/ipfs/go-ds-leveldb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoreds"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+const (
+	// maxRoutingTableSnapshot bounds the number of peers kept in the snapshot
+	maxRoutingTableSnapshot = 200
+
+	// seedDialTimeout bounds the dials to the peers of the snapshot
+	seedDialTimeout = 15 * time.Second
+)
+
+// routingTableKey is the datastore key of the routing table snapshot
+var routingTableKey = ds.NewKey("/dms/routing-table")
+
+// peerstoreDir returns the directory of the persistent peerstore, or an empty
+// string when the peerstore is kept in memory
+func peerstoreDir() string {
+	cfg := config.GetConfig()
+	if !cfg.P2P.PersistPeerstore || cfg.General.DataDir == "" {
+		return ""
+	}
+	return filepath.Join(cfg.General.DataDir, "p2p", "peerstore")
+}
+
+// openDatastore opens the persistent datastore of the node. The node falls
+// back to an in-memory peerstore when it can't be opened.
+func openDatastore() ds.Batching {
+	dir := peerstoreDir()
+	if dir == "" {
+		return nil
+	}
+
+	store, err := newDatastore(dir)
+	if err != nil {
+		zlog.Sugar().Warnf("using an in-memory peerstore: %v", err)
+		return nil
+	}
+	zlog.Sugar().Infof("Using persistent peerstore at %s", dir)
+	return store
+}
+
+// newDatastore opens or creates the leveldb database in dir
+func newDatastore(dir string) (*leveldb.Datastore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create datastore directory: %w", err)
+	}
+	store, err := leveldb.NewDatastore(dir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open datastore: %w", err)
+	}
+	return store, nil
+}
+
+// restorePeerstore adds the peers persisted in the datastore to the
+// peerstore of a host the node didn't create. The persisted peerstore is
+// returned to be updated from the host with savePeerstore.
+func restorePeerstore(ctx context.Context, store ds.Batching, ps peerstore.Peerstore) (peerstore.Peerstore, error) {
+	stored, err := pstoreds.NewPeerstore(ctx, store, pstoreds.DefaultOpts())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open persisted peerstore: %w", err)
+	}
+	copyPeers(stored, ps)
+	return stored, nil
+}
+
+// savePeerstore persists the peers of the peerstore of an attached host, it
+// must be called before the host is closed
+func (p *Libp2p) savePeerstore() {
+	if p.storedPeers == nil {
+		return
+	}
+	copyPeers(p.Host.Peerstore(), p.storedPeers)
+}
+
+// copyPeers adds the addresses and public keys known by from to to
+func copyPeers(from, to peerstore.Peerstore) {
+	for _, id := range from.PeersWithAddrs() {
+		to.AddAddrs(id, from.Addrs(id), peerstore.AddressTTL)
+	}
+	for _, id := range from.PeersWithKeys() {
+		if pub := from.PubKey(id); pub != nil {
+			_ = to.AddPubKey(id, pub)
+		}
+	}
+}
+
+// saveRoutingTable stores the peers of the routing table with their known
+// addresses, so that they can be dialed on the next start even after their
+// addresses expired from the peerstore
+func (p *Libp2p) saveRoutingTable(ctx context.Context) error {
+	if p.datastore == nil || p.DHT == nil {
+		return nil
+	}
+
+	var snapshot []peer.AddrInfo
+	for _, id := range p.DHT.RoutingTable().ListPeers() {
+		addrs := p.Host.Peerstore().Addrs(id)
+		if len(addrs) == 0 {
+			continue
+		}
+		snapshot = append(snapshot, peer.AddrInfo{ID: id, Addrs: addrs})
+		if len(snapshot) == maxRoutingTableSnapshot {
+			break
+		}
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode routing table snapshot: %w", err)
+	}
+	if err := p.datastore.Put(ctx, routingTableKey, data); err != nil {
+		return fmt.Errorf("failed to store routing table snapshot: %w", err)
+	}
+	zlog.Sugar().Infof("Saved %d routing table peers", len(snapshot))
+	return p.datastore.Sync(ctx, routingTableKey)
+}
+
+// loadRoutingTable returns the routing table snapshot stored on the last shutdown
+func loadRoutingTable(ctx context.Context, store ds.Batching) ([]peer.AddrInfo, error) {
+	if store == nil {
+		return nil, nil
+	}
+
+	data, err := store.Get(ctx, routingTableKey)
+	if errors.Is(err, ds.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing table snapshot: %w", err)
+	}
+
+	var snapshot []peer.AddrInfo
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode routing table snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// seedRoutingTable dials the peers of the routing table snapshot. Connected
+// peers speaking the DHT protocol are added to the routing table, so the node
+// rejoins the network even when the bootstrap peers are unreachable.
+func (p *Libp2p) seedRoutingTable(ctx context.Context) {
+	if len(p.routingSeeds) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, info := range p.routingSeeds {
+		if info.ID == p.Host.ID() {
+			continue
+		}
+		p.Host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.RecentlyConnectedAddrTTL)
+
+		wg.Add(1)
+		go func(info peer.AddrInfo) {
+			defer wg.Done()
+			dialCtx, cancel := context.WithTimeout(ctx, seedDialTimeout)
+			defer cancel()
+			if err := p.Host.Connect(dialCtx, info); err != nil {
+				zlog.Sugar().Debugf("failed to reconnect to %s: %v", info.ID, err)
+			}
+		}(info)
+	}
+	wg.Wait()
+
+	zlog.Sugar().Infof("Reconnected to %d peers, routing table size %d",
+		len(p.Host.Network().Peers()), p.DHT.RoutingTable().Size())
+}
+
+// bootstrapPeers returns the peers used by the DHT to refill an empty
+// routing table: the previous routing table followed by the bootstrap peers
+func (p *Libp2p) bootstrapPeers() []peer.AddrInfo {
+	peers := append([]peer.AddrInfo{}, p.routingSeeds...)
+	for _, ma := range NuNetBootstrapPeers {
+		info, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			continue
+		}
+		peers = append(peers, *info)
+	}
+	return peers
+}
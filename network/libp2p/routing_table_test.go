@@ -0,0 +1,156 @@
+package libp2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ds "github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/libp2p/go-libp2p"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/libp2p/go-libp2p/p2p/host/peerstore/pstoreds"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// withDataDir points the data dir of the DMS to a temporary directory for
+// the duration of the test and returns it
+func withDataDir(t *testing.T) string {
+	dir := t.TempDir()
+	previous := config.GetConfig().General.DataDir
+	config.SetConfig("general.data_dir", dir)
+	t.Cleanup(func() { config.SetConfig("general.data_dir", previous) })
+	return dir
+}
+
+func TestDatastore(t *testing.T) {
+	ctx := context.Background()
+	store, err := newDatastore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Get(ctx, ds.NewKey("/missing"))
+	assert.ErrorIs(t, err, ds.ErrNotFound)
+
+	require.NoError(t, store.Put(ctx, ds.NewKey("/peers/a"), []byte("a")))
+	require.NoError(t, store.Put(ctx, ds.NewKey("/peersx"), []byte("x")))
+
+	batch, err := store.Batch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, batch.Put(ctx, ds.NewKey("/peers/b"), []byte("bb")))
+	require.NoError(t, batch.Commit(ctx))
+
+	has, err := store.Has(ctx, ds.NewKey("/peers/b"))
+	require.NoError(t, err)
+	assert.True(t, has)
+	size, err := store.GetSize(ctx, ds.NewKey("/peers/b"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, size)
+
+	results, err := store.Query(ctx, query.Query{Prefix: "/peers"})
+	require.NoError(t, err)
+	entries, err := results.Rest()
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "the prefix should only match child keys")
+	assert.Equal(t, "/peers/a", entries[0].Key)
+	assert.Equal(t, []byte("bb"), entries[1].Value)
+
+	require.NoError(t, store.Delete(ctx, ds.NewKey("/peers/a")))
+	has, err = store.Has(ctx, ds.NewKey("/peers/a"))
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestPersistentPeerstore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	h1, h2 := newConnectedHosts(t)
+	addr := multiaddr.StringCast("/ip4/192.0.2.1/tcp/9000")
+
+	store, err := newDatastore(dir)
+	require.NoError(t, err)
+	ps, err := pstoreds.NewPeerstore(ctx, store, pstoreds.DefaultOpts())
+	require.NoError(t, err)
+	ps.AddAddr(h2.ID(), addr, peerstore.PermanentAddrTTL)
+	require.NoError(t, ps.Close())
+	require.NoError(t, store.Close())
+
+	store, err = newDatastore(dir)
+	require.NoError(t, err)
+	defer store.Close()
+	ps, err = pstoreds.NewPeerstore(ctx, store, pstoreds.DefaultOpts())
+	require.NoError(t, err)
+	defer ps.Close()
+	assert.Equal(t, []multiaddr.Multiaddr{addr}, ps.Addrs(h2.ID()))
+	assert.Empty(t, ps.Addrs(h1.ID()))
+}
+
+func TestAttachPersistsPeerstore(t *testing.T) {
+	withDataDir(t)
+	h1, h2 := newConnectedHosts(t)
+	addr := multiaddr.StringCast("/ip4/192.0.2.1/tcp/9000")
+	h1.Peerstore().AddAddr(h2.ID(), addr, peerstore.AddressTTL)
+
+	p := &Libp2p{}
+	require.NoError(t, p.Attach(h1, nil, Libp2pConfig{}))
+	require.NotNil(t, p.datastore, "the datastore should be opened on the attach path")
+	require.NoError(t, p.Stop())
+
+	// the peers of the host are restored into the peerstore of the next host
+	h3, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	next := &Libp2p{}
+	require.NoError(t, next.Attach(h3, nil, Libp2pConfig{}))
+	defer next.Stop()
+	assert.Contains(t, h3.Peerstore().Addrs(h2.ID()), addr)
+	assert.NotNil(t, h3.Peerstore().PubKey(h2.ID()))
+}
+
+func TestRoutingTableSnapshot(t *testing.T) {
+	ctx := context.Background()
+	h1, h2 := newConnectedHosts(t)
+
+	newDHT := func(p *Libp2p) *dht.IpfsDHT {
+		d, err := dht.New(ctx, p.Host, kadPrefix, dht.Mode(dht.ModeServer))
+		require.NoError(t, err)
+		t.Cleanup(func() { d.Close() })
+		return d
+	}
+
+	store, err := newDatastore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	p := &Libp2p{Host: h1, datastore: store}
+	p.DHT = newDHT(p)
+	newDHT(&Libp2p{Host: h2})
+
+	// the DHTs were started after the hosts connected, reconnect so that
+	// identify advertises the DHT protocol
+	require.NoError(t, h1.Network().ClosePeer(h2.ID()))
+	require.NoError(t, h1.Connect(ctx, peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	require.Eventually(t, func() bool {
+		return p.DHT.RoutingTable().Find(h2.ID()) != ""
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, p.saveRoutingTable(ctx))
+
+	snapshot, err := loadRoutingTable(ctx, store)
+	require.NoError(t, err)
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, h2.ID(), snapshot[0].ID)
+	assert.NotEmpty(t, snapshot[0].Addrs)
+
+	p.routingSeeds = snapshot
+	assert.Equal(t, h2.ID(), p.bootstrapPeers()[0].ID, "the snapshot should be tried before the bootstrap peers")
+
+	snapshot, err = loadRoutingTable(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, snapshot)
+}
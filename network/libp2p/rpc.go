@@ -0,0 +1,453 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-msgio"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+const (
+	// RPCProtocolID is the libp2p protocol used for request/response calls between DMSes
+	RPCProtocolID = protocol.ID("/nunet/rpc/1.0.0")
+
+	// DefaultRPCTimeout is used for calls whose context has no deadline
+	DefaultRPCTimeout = 30 * time.Second
+
+	// maxRPCFrameSize bounds the size of a single length-prefixed frame
+	maxRPCFrameSize = 4 << 20 // 4 MiB
+)
+
+var (
+	// ErrUnknownMethod is returned when the remote peer has no handler for the called method
+	ErrUnknownMethod = errors.New("unknown rpc method")
+
+	// ErrHandlerExists is returned when registering a method twice
+	ErrHandlerExists = errors.New("rpc handler already registered")
+
+	// tracer starts the spans of the calls, it follows the global tracer provider
+	tracer = otel.Tracer("gitlab.com/nunet/device-management-service/network/libp2p")
+)
+
+// envelopeKind identifies the type of frame sent over an RPC stream
+type envelopeKind uint8
+
+const (
+	envelopeRequest envelopeKind = iota
+	envelopeResponse
+	envelopeStreamItem
+	envelopeStreamEnd
+	envelopeError
+)
+
+// rpcEnvelope is the CBOR encoded frame exchanged over RPC streams.
+// Each call uses its own stream: the caller writes a single request
+// envelope and the callee answers with either a response, a sequence of
+// stream items terminated by a stream end, or an error. The caller of a
+// streaming method keeps its side of the stream open until it is done, the
+// handler is cancelled as soon as the caller closes or resets it. Requests carry the
+// trace context of the caller so that the spans of the handler join its trace.
+type rpcEnvelope struct {
+	ID      uint64            `cbor:"1,keyasint"`
+	Kind    envelopeKind      `cbor:"2,keyasint"`
+	Method  string            `cbor:"3,keyasint,omitempty"`
+	Payload []byte            `cbor:"4,keyasint,omitempty"`
+	Error   string            `cbor:"5,keyasint,omitempty"`
+	Trace   map[string]string `cbor:"6,keyasint,omitempty"`
+}
+
+// RemoteError is an error returned by the handler on the remote peer
+type RemoteError struct {
+	Method  string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("rpc %s failed on remote peer: %s", e.Method, e.Message)
+}
+
+// rpcHandler handles a decoded request. send is only used by streaming handlers.
+type rpcHandler func(ctx context.Context, from peer.ID, payload []byte, send func(item []byte) error) ([]byte, error)
+
+type rpcRoute struct {
+	handler   rpcHandler
+	streaming bool
+}
+
+// RPC implements a generic request/response protocol over libp2p streams.
+// Handlers are registered per method with RegisterHandler or
+// RegisterStreamHandler and remote methods are called with Call or CallStream.
+type RPC struct {
+	host   host.Host
+	nextID atomic.Uint64
+
+	// ctx is the parent of the contexts of the handlers, cancelled by Close
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	routes map[string]rpcRoute
+}
+
+// NewRPC creates a new RPC service and sets its stream handler on the host
+func NewRPC(h host.Host) *RPC {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &RPC{
+		host:   h,
+		ctx:    ctx,
+		cancel: cancel,
+		routes: make(map[string]rpcRoute),
+	}
+	h.SetStreamHandler(RPCProtocolID, r.handleStream)
+	return r
+}
+
+// Close removes the RPC stream handler from the host and cancels the
+// running handlers
+func (r *RPC) Close() {
+	r.host.RemoveStreamHandler(RPCProtocolID)
+	r.cancel()
+}
+
+func (r *RPC) register(method string, route rpcRoute) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.routes[method]; ok {
+		return fmt.Errorf("%w: %s", ErrHandlerExists, method)
+	}
+	r.routes[method] = route
+	return nil
+}
+
+// RegisterHandler registers a typed handler answering calls to the given method with a single response
+func RegisterHandler[Req, Resp any](
+	r *RPC,
+	method string,
+	handler func(ctx context.Context, from peer.ID, req Req) (Resp, error),
+) error {
+	return r.register(method, rpcRoute{
+		handler: func(ctx context.Context, from peer.ID, payload []byte, _ func([]byte) error) ([]byte, error) {
+			var req Req
+			if err := cbor.Unmarshal(payload, &req); err != nil {
+				return nil, fmt.Errorf("failed to decode request: %w", err)
+			}
+
+			resp, err := handler(ctx, from, req)
+			if err != nil {
+				return nil, err
+			}
+			return cbor.Marshal(resp)
+		},
+	})
+}
+
+// RegisterStreamHandler registers a typed handler that may send any number of responses for a single call
+func RegisterStreamHandler[Req, Resp any](
+	r *RPC,
+	method string,
+	handler func(ctx context.Context, from peer.ID, req Req, send func(Resp) error) error,
+) error {
+	return r.register(method, rpcRoute{
+		streaming: true,
+		handler: func(ctx context.Context, from peer.ID, payload []byte, send func([]byte) error) ([]byte, error) {
+			var req Req
+			if err := cbor.Unmarshal(payload, &req); err != nil {
+				return nil, fmt.Errorf("failed to decode request: %w", err)
+			}
+
+			return nil, handler(ctx, from, req, func(resp Resp) error {
+				item, err := cbor.Marshal(resp)
+				if err != nil {
+					return fmt.Errorf("failed to encode response: %w", err)
+				}
+				return send(item)
+			})
+		},
+	})
+}
+
+// Call calls the method on the remote peer and waits for its response.
+// If ctx has no deadline, DefaultRPCTimeout is applied.
+func Call[Req, Resp any](ctx context.Context, r *RPC, to peer.ID, method string, req Req) (resp Resp, err error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+
+	ctx, span := startRPCSpan(ctx, method, to, trace.SpanKindClient)
+	defer func() { endRPCSpan(span, err) }()
+
+	s, id, err := r.openCall(ctx, to, method, req, true)
+	if err != nil {
+		return resp, err
+	}
+	defer s.Close()
+
+	env, err := readEnvelope(msgio.NewVarintReaderSize(s, maxRPCFrameSize))
+	if err != nil {
+		return resp, fmt.Errorf("failed to read rpc response: %w", err)
+	}
+	if err := checkEnvelope(env, id, method, envelopeResponse); err != nil {
+		return resp, err
+	}
+
+	if err := cbor.Unmarshal(env.Payload, &resp); err != nil {
+		return resp, fmt.Errorf("failed to decode rpc response: %w", err)
+	}
+	return resp, nil
+}
+
+// CallStream calls a streaming method on the remote peer and invokes onResponse
+// for every response until the remote handler returns. Returning an error from
+// onResponse or cancelling ctx aborts the call. Unlike Call, no default
+// timeout is applied since streams such as logs may be long lived.
+func CallStream[Req, Resp any](
+	ctx context.Context,
+	r *RPC,
+	to peer.ID,
+	method string,
+	req Req,
+	onResponse func(Resp) error,
+) (err error) {
+	ctx, span := startRPCSpan(ctx, method, to, trace.SpanKindClient)
+	defer func() { endRPCSpan(span, err) }()
+
+	// the write side stays open, closing it would cancel the handler
+	s, id, err := r.openCall(ctx, to, method, req, false)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Reset()
+		case <-done:
+		}
+	}()
+
+	reader := msgio.NewVarintReaderSize(s, maxRPCFrameSize)
+	for {
+		env, err := readEnvelope(reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read rpc response: %w", err)
+		}
+		if env.Kind == envelopeStreamEnd && env.ID == id {
+			return nil
+		}
+		if err := checkEnvelope(env, id, method, envelopeStreamItem); err != nil {
+			return err
+		}
+
+		var resp Resp
+		if err := cbor.Unmarshal(env.Payload, &resp); err != nil {
+			return fmt.Errorf("failed to decode rpc response: %w", err)
+		}
+		if err := onResponse(resp); err != nil {
+			s.Reset()
+			return err
+		}
+	}
+}
+
+// openCall opens a new stream to the peer and writes the request envelope,
+// the write side of the stream is closed after it with closeWrite
+func (r *RPC) openCall(ctx context.Context, to peer.ID, method string, req any, closeWrite bool) (network.Stream, uint64, error) {
+	payload, err := cbor.Marshal(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode rpc request: %w", err)
+	}
+
+	s, err := r.host.NewStream(ctx, to, RPCProtocolID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open rpc stream to %s: %w", to.String(), err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.SetDeadline(deadline)
+	}
+
+	id := r.nextID.Add(1)
+	err = writeEnvelope(msgio.NewVarintWriter(s), rpcEnvelope{
+		ID:      id,
+		Kind:    envelopeRequest,
+		Method:  method,
+		Payload: payload,
+		Trace:   telemetry.InjectTrace(ctx),
+	})
+	if err != nil {
+		s.Reset()
+		return nil, 0, fmt.Errorf("failed to send rpc request: %w", err)
+	}
+	if !closeWrite {
+		return s, id, nil
+	}
+	if err := s.CloseWrite(); err != nil {
+		s.Reset()
+		return nil, 0, fmt.Errorf("failed to send rpc request: %w", err)
+	}
+	return s, id, nil
+}
+
+// handleStream serves a single incoming call
+func (r *RPC) handleStream(s network.Stream) {
+	defer s.Close()
+	_ = s.SetDeadline(time.Now().Add(DefaultRPCTimeout))
+
+	reader := msgio.NewVarintReaderSize(s, maxRPCFrameSize)
+	writer := msgio.NewVarintWriter(s)
+
+	req, err := readEnvelope(reader)
+	if err != nil || req.Kind != envelopeRequest {
+		zlog.Sugar().Debugf("rpc: invalid request from %s: %v", s.Conn().RemotePeer().String(), err)
+		s.Reset()
+		return
+	}
+
+	r.mu.RLock()
+	route, ok := r.routes[req.Method]
+	r.mu.RUnlock()
+
+	reply := func(env rpcEnvelope) {
+		env.ID = req.ID
+		if err := writeEnvelope(writer, env); err != nil {
+			zlog.Sugar().Debugf("rpc: failed to reply to %s: %v", req.Method, err)
+		}
+	}
+
+	if !ok {
+		reply(rpcEnvelope{Kind: envelopeError, Error: ErrUnknownMethod.Error()})
+		return
+	}
+
+	// streaming handlers may run for as long as the caller keeps reading,
+	// so only single response handlers are bound by the default timeout
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if route.streaming {
+		ctx, cancel = context.WithCancel(r.ctx)
+		_ = s.SetDeadline(time.Time{})
+		// the caller sends nothing after its request, the read only returns
+		// once it closes or resets the stream or the connection is lost
+		go func() {
+			_, _ = s.Read(make([]byte, 1))
+			cancel()
+		}()
+	} else {
+		ctx, cancel = context.WithTimeout(r.ctx, DefaultRPCTimeout)
+	}
+	defer cancel()
+
+	ctx = telemetry.ExtractTrace(ctx, req.Trace)
+	ctx, span := startRPCSpan(ctx, req.Method, s.Conn().RemotePeer(), trace.SpanKindServer)
+
+	send := func(item []byte) error {
+		if !route.streaming {
+			return errors.New("method does not stream responses")
+		}
+		_ = s.SetWriteDeadline(time.Now().Add(DefaultRPCTimeout))
+		err := writeEnvelope(writer, rpcEnvelope{ID: req.ID, Kind: envelopeStreamItem, Payload: item})
+		if err != nil {
+			// the caller went away, stop the handler
+			cancel()
+		}
+		return err
+	}
+
+	payload, err := route.handler(ctx, s.Conn().RemotePeer(), req.Payload, send)
+	endRPCSpan(span, err)
+	switch {
+	case err != nil:
+		reply(rpcEnvelope{Kind: envelopeError, Error: err.Error()})
+	case route.streaming:
+		reply(rpcEnvelope{Kind: envelopeStreamEnd})
+	default:
+		reply(rpcEnvelope{Kind: envelopeResponse, Payload: payload})
+	}
+}
+
+// startRPCSpan starts the span of a call, on the client side for outgoing
+// calls and on the server side for the handled ones
+func startRPCSpan(ctx context.Context, method string, remote peer.ID, kind trace.SpanKind) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "rpc "+method,
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "libp2p"),
+			attribute.String("rpc.method", method),
+			attribute.String("net.peer.id", remote.String()),
+		),
+	)
+}
+
+func endRPCSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// checkEnvelope validates a response envelope and converts remote errors
+func checkEnvelope(env rpcEnvelope, id uint64, method string, expected envelopeKind) error {
+	if env.ID != id {
+		return fmt.Errorf("rpc response id mismatch: expected %d, got %d", id, env.ID)
+	}
+	if env.Kind == envelopeError {
+		if env.Error == ErrUnknownMethod.Error() {
+			return fmt.Errorf("%w: %s", ErrUnknownMethod, method)
+		}
+		return &RemoteError{Method: method, Message: env.Error}
+	}
+	if env.Kind != expected {
+		return fmt.Errorf("unexpected rpc frame kind %d", env.Kind)
+	}
+	return nil
+}
+
+func readEnvelope(reader msgio.ReadCloser) (rpcEnvelope, error) {
+	var env rpcEnvelope
+
+	msg, err := reader.ReadMsg()
+	if err != nil {
+		return env, err
+	}
+
+	err = cbor.Unmarshal(msg, &env)
+	return env, err
+}
+
+func writeEnvelope(writer msgio.WriteCloser, env rpcEnvelope) error {
+	msg, err := cbor.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return writer.WriteMsg(msg)
+}
+
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultRPCTimeout)
+}
@@ -0,0 +1,275 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+type echoRequest struct {
+	Text  string
+	Count int
+}
+
+type echoResponse struct {
+	Text  string
+	Index int
+}
+
+// newConnectedHosts creates two in-process hosts listening on localhost and connects them
+func newConnectedHosts(t *testing.T) (host.Host, host.Host) {
+	newTestHost := func() host.Host {
+		h, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+		require.NoError(t, err)
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+
+	h1, h2 := newTestHost(), newTestHost()
+	err := h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()})
+	require.NoError(t, err)
+	return h1, h2
+}
+
+func TestRPCCall(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	client, server := NewRPC(h1), NewRPC(h2)
+
+	err := RegisterHandler(server, "echo", func(_ context.Context, from peer.ID, req echoRequest) (echoResponse, error) {
+		assert.Equal(t, h1.ID(), from)
+		return echoResponse{Text: req.Text}, nil
+	})
+	require.NoError(t, err)
+	err = RegisterHandler(server, "fail", func(_ context.Context, _ peer.ID, _ echoRequest) (echoResponse, error) {
+		return echoResponse{}, errors.New("boom")
+	})
+	require.NoError(t, err)
+
+	resp, err := Call[echoRequest, echoResponse](context.Background(), client, h2.ID(), "echo", echoRequest{Text: "hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", resp.Text)
+
+	_, err = Call[echoRequest, echoResponse](context.Background(), client, h2.ID(), "fail", echoRequest{})
+	var remoteErr *RemoteError
+	assert.ErrorAs(t, err, &remoteErr)
+	assert.Equal(t, "boom", remoteErr.Message)
+
+	_, err = Call[echoRequest, echoResponse](context.Background(), client, h2.ID(), "missing", echoRequest{})
+	assert.ErrorIs(t, err, ErrUnknownMethod)
+
+	err = RegisterHandler(server, "echo", func(_ context.Context, _ peer.ID, _ echoRequest) (echoResponse, error) {
+		return echoResponse{}, nil
+	})
+	assert.ErrorIs(t, err, ErrHandlerExists)
+}
+
+func TestRPCCallTimeout(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	client, server := NewRPC(h1), NewRPC(h2)
+
+	err := RegisterHandler(server, "slow", func(ctx context.Context, _ peer.ID, _ echoRequest) (echoResponse, error) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+		}
+		return echoResponse{}, nil
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err = Call[echoRequest, echoResponse](ctx, client, h2.ID(), "slow", echoRequest{})
+	assert.Error(t, err)
+}
+
+func TestRPCCallStream(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	client, server := NewRPC(h1), NewRPC(h2)
+
+	err := RegisterStreamHandler(server, "count", func(_ context.Context, _ peer.ID, req echoRequest, send func(echoResponse) error) error {
+		for i := 0; i < req.Count; i++ {
+			if err := send(echoResponse{Text: req.Text, Index: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var received []echoResponse
+	err = CallStream(context.Background(), client, h2.ID(), "count", echoRequest{Text: "tick", Count: 3},
+		func(resp echoResponse) error {
+			received = append(received, resp)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	require.Len(t, received, 3)
+	for i, resp := range received {
+		assert.Equal(t, i, resp.Index)
+	}
+}
+
+func TestRPCCallStreamCallerGone(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	client, server := NewRPC(h1), NewRPC(h2)
+
+	started, stopped := make(chan struct{}), make(chan struct{})
+	err := RegisterStreamHandler(server, "wait", func(ctx context.Context, _ peer.ID, _ echoRequest, _ func(echoResponse) error) error {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- CallStream(ctx, client, h2.ID(), "wait", echoRequest{}, func(echoResponse) error { return nil })
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not called")
+	}
+	cancel()
+	assert.Error(t, <-result)
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not cancelled once the caller went away")
+	}
+}
+
+func TestRPCCloseCancelsHandlers(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	client, server := NewRPC(h1), NewRPC(h2)
+
+	started := make(chan struct{})
+	err := RegisterStreamHandler(server, "wait", func(ctx context.Context, _ peer.ID, _ echoRequest, _ func(echoResponse) error) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- CallStream(context.Background(), client, h2.ID(), "wait", echoRequest{}, func(echoResponse) error { return nil })
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not called")
+	}
+	server.Close()
+
+	select {
+	case err := <-result:
+		assert.ErrorContains(t, err, context.Canceled.Error())
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not cancelled by Close")
+	}
+}
+
+func TestRPCTracePropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	h1, h2 := newConnectedHosts(t)
+	client, server := NewRPC(h1), NewRPC(h2)
+
+	handled := make(chan trace.SpanContext, 1)
+	err := RegisterHandler(server, "deploy", func(ctx context.Context, _ peer.ID, req echoRequest) (echoResponse, error) {
+		handled <- trace.SpanContextFromContext(ctx)
+		if req.Text == "" {
+			return echoResponse{}, errors.New("nothing to deploy")
+		}
+		return echoResponse{Text: req.Text}, nil
+	})
+	require.NoError(t, err)
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "deployment")
+	_, err = Call[echoRequest, echoResponse](ctx, client, h2.ID(), "deploy", echoRequest{Text: "job"})
+	require.NoError(t, err)
+	root.End()
+
+	handler := <-handled
+	assert.Equal(t, root.SpanContext().TraceID(), handler.TraceID(), "the handler joins the trace of the caller")
+
+	spans := make(map[trace.SpanKind]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.SpanKind()] = span
+	}
+	require.Contains(t, spans, trace.SpanKindClient)
+	require.Contains(t, spans, trace.SpanKindServer)
+	assert.Equal(t, "rpc deploy", spans[trace.SpanKindServer].Name())
+	assert.Equal(t, root.SpanContext().SpanID(), spans[trace.SpanKindClient].Parent().SpanID())
+	assert.Equal(t, spans[trace.SpanKindClient].SpanContext().SpanID(), spans[trace.SpanKindServer].Parent().SpanID())
+
+	// failed calls are reported on both sides
+	_, err = Call[echoRequest, echoResponse](context.Background(), client, h2.ID(), "deploy", echoRequest{})
+	require.Error(t, err)
+	<-handled
+	var failed int
+	for _, span := range recorder.Ended() {
+		if span.Status().Code == codes.Error {
+			failed++
+		}
+	}
+	assert.Equal(t, 2, failed)
+}
+
+func TestMessage(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+
+	received := make(chan []byte, 1)
+	AcceptMessages(h2, func(m *Message) {
+		assert.NoError(t, m.ReceiveHandler(func(data []byte) {
+			received <- data
+		}))
+	})
+
+	m := NewMessage(h1)
+	address := models.NewSpecConfig(string(models.NetP2P)).WithParam("peer_id", h2.ID().String())
+	require.NoError(t, m.Dial(*address))
+	defer m.Close()
+
+	assert.NoError(t, m.Send([]byte("hello")))
+	select {
+	case data := <-received:
+		assert.Equal(t, "hello", string(data))
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not received")
+	}
+
+	info := m.Info()
+	assert.Equal(t, string(MessageProtocolID), info.Protocol)
+	assert.Contains(t, info.RemoteAddress, h2.ID().String())
+}
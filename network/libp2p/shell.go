@@ -0,0 +1,343 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-msgio"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
+)
+
+const (
+	// ShellProtocolID is the libp2p protocol carrying the shell sessions into the executions of a peer
+	ShellProtocolID = protocol.ID("/nunet/shell/1.0.0")
+
+	// maxShellDataSize bounds the input or the output carried by a single frame
+	maxShellDataSize = 32 << 10
+
+	// shellOpenTimeout bounds opening a session, the session itself has no deadline
+	shellOpenTimeout = 30 * time.Second
+)
+
+// ErrShellDenied is returned when a peer isn't allowed to open shells
+var ErrShellDenied = errors.New("peer is not allowed to open shells")
+
+// ShellOpener opens the shell sessions into the local executions on behalf of
+// a client, it is implemented by executor.Shells
+type ShellOpener interface {
+	Open(ctx context.Context, client, executionID string, request models.ShellRequest) (executor.ShellSession, error)
+}
+
+// shellFrameKind identifies the type of frame sent over a shell stream
+type shellFrameKind uint8
+
+const (
+	shellFrameOpen   shellFrameKind = iota // caller: the execution and the request
+	shellFrameOpened                       // callee: the session is open, or the error
+	shellFrameData                         // both: the input or the output of the command
+	shellFrameResize                       // caller: the new size of the terminal
+	shellFrameExit                         // callee: the exit code, or the error, last frame
+)
+
+// shellFrame is the CBOR encoded frame exchanged over shell streams. Each
+// session uses its own stream: the caller writes an open frame and the callee
+// answers with an opened frame, then the input and the output of the command
+// flow both ways until the callee sends the exit frame.
+type shellFrame struct {
+	Kind        shellFrameKind       `cbor:"1,keyasint"`
+	ExecutionID string               `cbor:"2,keyasint,omitempty"`
+	Request     *models.ShellRequest `cbor:"3,keyasint,omitempty"`
+	Data        []byte               `cbor:"4,keyasint,omitempty"`
+	Size        *models.TerminalSize `cbor:"5,keyasint,omitempty"`
+	ExitCode    int                  `cbor:"6,keyasint,omitempty"`
+	Error       string               `cbor:"7,keyasint,omitempty"`
+}
+
+// ShellService serves the shells into the local executions to the allowed
+// peers, and opens shells into the executions of remote peers
+type ShellService struct {
+	host    host.Host
+	opener  ShellOpener
+	allowed map[peer.ID]bool
+}
+
+// NewShellService creates the shell service and sets its stream handler on
+// the host. Only the allowed peers may open shells, none when allowed is empty.
+// opener may be nil on nodes not serving shells.
+func NewShellService(h host.Host, opener ShellOpener, allowed []peer.ID) *ShellService {
+	s := &ShellService{
+		host:    h,
+		opener:  opener,
+		allowed: make(map[peer.ID]bool, len(allowed)),
+	}
+	for _, id := range allowed {
+		s.allowed[id] = true
+	}
+	h.SetStreamHandler(ShellProtocolID, s.handleStream)
+	return s
+}
+
+// Close removes the shell stream handler from the host
+func (s *ShellService) Close() {
+	s.host.RemoveStreamHandler(ShellProtocolID)
+}
+
+// Open opens a shell session into an execution of a remote peer
+func (s *ShellService) Open(
+	ctx context.Context,
+	to peer.ID,
+	executionID string,
+	request models.ShellRequest,
+) (executor.ShellSession, error) {
+	openCtx, cancel := context.WithTimeout(ctx, shellOpenTimeout)
+	defer cancel()
+
+	stream, err := s.host.NewStream(openCtx, to, ShellProtocolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shell stream to %s: %w", to, err)
+	}
+	_ = stream.SetDeadline(time.Now().Add(shellOpenTimeout))
+
+	session := &remoteShellSession{
+		stream: stream,
+		writer: msgio.NewVarintWriter(stream),
+		exited: make(chan struct{}),
+	}
+	reader := msgio.NewVarintReaderSize(stream, 2*maxShellDataSize)
+	err = session.writeFrame(shellFrame{Kind: shellFrameOpen, ExecutionID: executionID, Request: &request})
+	if err != nil {
+		stream.Reset()
+		return nil, err
+	}
+	opened, err := readShellFrame(reader)
+	if err != nil {
+		stream.Reset()
+		return nil, fmt.Errorf("failed to open shell on %s: %w", to, err)
+	}
+	if opened.Kind != shellFrameOpened || opened.Error != "" {
+		stream.Reset()
+		return nil, fmt.Errorf("failed to open shell on %s: %s", to, opened.Error)
+	}
+	_ = stream.SetDeadline(time.Time{})
+
+	r, w := io.Pipe()
+	session.output = r
+	go session.readFrames(reader, w)
+	return session, nil
+}
+
+// handleStream serves a single shell session
+func (s *ShellService) handleStream(stream network.Stream) {
+	defer stream.Close()
+	_ = stream.SetDeadline(time.Now().Add(shellOpenTimeout))
+
+	remote := stream.Conn().RemotePeer()
+	reader := msgio.NewVarintReaderSize(stream, 2*maxShellDataSize)
+	writer := &shellWriter{writer: msgio.NewVarintWriter(stream)}
+
+	open, err := readShellFrame(reader)
+	if err != nil || open.Kind != shellFrameOpen || open.Request == nil {
+		zlog.Sugar().Debugf("shell: invalid open frame from %s: %v", remote.String(), err)
+		stream.Reset()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := "peer " + remote.String()
+	if !s.allowed[remote] || s.opener == nil {
+		telemetry.Observe(ctx, telemetry.Audit, telemetry.WarnLevel, "shell.denied", telemetry.Fields{
+			"client":       client,
+			"execution_id": open.ExecutionID,
+		})
+		_ = writer.write(shellFrame{Kind: shellFrameOpened, Error: ErrShellDenied.Error()})
+		return
+	}
+
+	session, err := s.opener.Open(ctx, client, open.ExecutionID, *open.Request)
+	if err != nil {
+		_ = writer.write(shellFrame{Kind: shellFrameOpened, Error: err.Error()})
+		return
+	}
+	defer session.Close()
+	if err := writer.write(shellFrame{Kind: shellFrameOpened}); err != nil {
+		return
+	}
+	_ = stream.SetDeadline(time.Time{})
+
+	// the input is forwarded until the caller closes the stream, the session
+	// is then closed so that the command exits
+	go func() {
+		defer session.Close()
+		for {
+			frame, err := readShellFrame(reader)
+			if err != nil {
+				return
+			}
+			switch frame.Kind {
+			case shellFrameData:
+				if _, err := session.Write(frame.Data); err != nil {
+					return
+				}
+			case shellFrameResize:
+				if frame.Size != nil {
+					_ = session.Resize(ctx, *frame.Size)
+				}
+			}
+		}
+	}()
+
+	buf := make([]byte, maxShellDataSize)
+	for {
+		n, err := session.Read(buf)
+		if n > 0 {
+			if writer.write(shellFrame{Kind: shellFrameData, Data: buf[:n]}) != nil {
+				return
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	exit := shellFrame{Kind: shellFrameExit}
+	code, err := session.Wait(ctx)
+	if err != nil {
+		exit.Error = err.Error()
+	}
+	exit.ExitCode = code
+	_ = writer.write(exit)
+}
+
+// shellWriter serializes the frames written by the goroutines of a session
+type shellWriter struct {
+	mu     sync.Mutex
+	writer msgio.WriteCloser
+}
+
+func (w *shellWriter) write(frame shellFrame) error {
+	msg, err := cbor.Marshal(frame)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer.WriteMsg(msg)
+}
+
+func readShellFrame(reader msgio.ReadCloser) (shellFrame, error) {
+	var frame shellFrame
+
+	msg, err := reader.ReadMsg()
+	if err != nil {
+		return frame, err
+	}
+	defer reader.ReleaseMsg(msg)
+
+	err = cbor.Unmarshal(msg, &frame)
+	return frame, err
+}
+
+// remoteShellSession is a shell session into an execution of a remote peer,
+// see executor.ShellSession
+type remoteShellSession struct {
+	stream network.Stream
+	output *io.PipeReader
+
+	mu     sync.Mutex
+	writer msgio.WriteCloser
+
+	exited chan struct{}
+	exit   shellFrame
+	err    error
+}
+
+// readFrames copies the output of the command until it exits or the stream
+// is closed
+func (s *remoteShellSession) readFrames(reader msgio.ReadCloser, output *io.PipeWriter) {
+	defer close(s.exited)
+
+	for {
+		frame, err := readShellFrame(reader)
+		if err != nil {
+			s.err = fmt.Errorf("shell stream closed before the command exited: %w", err)
+			output.CloseWithError(err)
+			return
+		}
+		switch frame.Kind {
+		case shellFrameData:
+			if _, err := output.Write(frame.Data); err != nil {
+				s.err = errors.New("session closed before the command exited")
+				return
+			}
+		case shellFrameExit:
+			s.exit = frame
+			if frame.Error != "" {
+				s.err = &RemoteError{Method: "shell", Message: frame.Error}
+			}
+			output.Close()
+			return
+		}
+	}
+}
+
+func (s *remoteShellSession) writeFrame(frame shellFrame) error {
+	msg, err := cbor.Marshal(frame)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.WriteMsg(msg)
+}
+
+func (s *remoteShellSession) Read(p []byte) (int, error) {
+	return s.output.Read(p)
+}
+
+func (s *remoteShellSession) Write(p []byte) (int, error) {
+	for sent := 0; sent < len(p); {
+		n := len(p) - sent
+		if n > maxShellDataSize {
+			n = maxShellDataSize
+		}
+		if err := s.writeFrame(shellFrame{Kind: shellFrameData, Data: p[sent : sent+n]}); err != nil {
+			return sent, err
+		}
+		sent += n
+	}
+	return len(p), nil
+}
+
+// Close closes the stream, the remote peer then closes the session
+func (s *remoteShellSession) Close() error {
+	s.output.Close()
+	return s.stream.Close()
+}
+
+func (s *remoteShellSession) Resize(_ context.Context, size models.TerminalSize) error {
+	return s.writeFrame(shellFrame{Kind: shellFrameResize, Size: &size})
+}
+
+// Wait waits for the exit frame of the remote peer
+func (s *remoteShellSession) Wait(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-s.exited:
+		return s.exit.ExitCode, s.err
+	}
+}
@@ -0,0 +1,115 @@
+package libp2p
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/executor"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// catSession echoes its input until it is closed, the command then exits
+// with the number of resizes
+type catSession struct {
+	*io.PipeReader
+	w *io.PipeWriter
+
+	mu      sync.Mutex
+	resizes int
+}
+
+func (s *catSession) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+func (s *catSession) Close() error {
+	return s.w.Close()
+}
+
+func (s *catSession) Resize(context.Context, models.TerminalSize) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resizes++
+	return nil
+}
+
+func (s *catSession) Wait(context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.resizes, nil
+}
+
+type catOpener struct {
+	mu      sync.Mutex
+	clients []string
+}
+
+func (o *catOpener) Open(_ context.Context, client, executionID string, _ models.ShellRequest) (executor.ShellSession, error) {
+	o.mu.Lock()
+	o.clients = append(o.clients, client)
+	o.mu.Unlock()
+	if executionID != "exec-1" {
+		return nil, executor.ErrExecutionNotFound
+	}
+	r, w := io.Pipe()
+	return &catSession{PipeReader: r, w: w}, nil
+}
+
+func TestShell(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	opener := &catOpener{}
+	client := NewShellService(h1, nil, nil)
+	server := NewShellService(h2, opener, []peer.ID{h1.ID()})
+	t.Cleanup(client.Close)
+	t.Cleanup(server.Close)
+	ctx := context.Background()
+
+	session, err := client.Open(ctx, h2.ID(), "exec-1", models.ShellRequest{TTY: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"peer " + h1.ID().String()}, opener.clients)
+
+	_, err = session.Write([]byte("hello"))
+	require.NoError(t, err)
+	output := make([]byte, 5)
+	_, err = io.ReadFull(session, output)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(output))
+
+	require.NoError(t, session.Resize(ctx, models.TerminalSize{Rows: 24, Cols: 80}))
+	// the resize frame is handled before the input that follows it
+	_, err = session.Write([]byte("!"))
+	require.NoError(t, err)
+	_, err = io.ReadFull(session, output[:1])
+	require.NoError(t, err)
+
+	// closing the input makes the server close the session, the command exits
+	require.NoError(t, session.(*remoteShellSession).stream.CloseWrite())
+	code, err := session.Wait(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, code)
+	_, err = session.Read(output)
+	assert.ErrorIs(t, err, io.EOF)
+	require.NoError(t, session.Close())
+
+	_, err = client.Open(ctx, h2.ID(), "exec-2", models.ShellRequest{})
+	assert.ErrorContains(t, err, executor.ErrExecutionNotFound.Error())
+}
+
+func TestShellDenied(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	opener := &catOpener{}
+	client := NewShellService(h1, opener, nil)
+	server := NewShellService(h2, opener, nil)
+	t.Cleanup(client.Close)
+	t.Cleanup(server.Close)
+
+	_, err := client.Open(context.Background(), h2.ID(), "exec-1", models.ShellRequest{})
+	assert.ErrorContains(t, err, ErrShellDenied.Error())
+	assert.Empty(t, opener.clients, "a denied peer doesn't reach the opener")
+}
@@ -0,0 +1,200 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+)
+
+const (
+	// StopTimeout bounds Stop, including the time given to in-flight streams to complete
+	StopTimeout = 15 * time.Second
+
+	// drainPollInterval is how often the open streams are checked while draining
+	drainPollInterval = 100 * time.Millisecond
+
+	pingProtocolID = protocol.ID("/ipfs/ping/1.0.0")
+)
+
+// dmsProtocols are the protocols whose streams are drained on shutdown
+var dmsProtocols = []protocol.ID{RPCProtocolID, MessageProtocolID, VPNProtocolID, pingProtocolID}
+
+// addTask registers a task on the scheduler and keeps its ID to remove it on shutdown
+func (p *Libp2p) addTask(task *bt.Task) {
+	p.tasks = append(p.tasks, p.config.Scheduler.AddTask(task).ID)
+}
+
+// Stop gracefully shuts down the node within StopTimeout
+func (p *Libp2p) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), StopTimeout)
+	defer cancel()
+	return p.Shutdown(ctx)
+}
+
+// Shutdown gracefully shuts down the node:
+//  1. the periodic tasks are removed from the scheduler and local discovery stops
+//  2. no new streams are accepted for the DMS protocols
+//  3. the advertisements of this node are withdrawn from the DHT
+//  4. the RPC handlers are cancelled and the other in-flight streams are
+//     given until ctx is done, at most StopTimeout, to complete, then reset
+//  5. the routing table, and the peerstore of an attached host, are saved to
+//     seed the next start
+//  6. the DHT, the host and its peerstore are closed
+//
+// Only the first call shuts down the node, later calls return its result.
+func (p *Libp2p) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		p.stopErr = p.shutdown(ctx)
+	})
+	return p.stopErr
+}
+
+func (p *Libp2p) shutdown(ctx context.Context) error {
+	var errs []error
+
+	if p.config.Scheduler != nil {
+		for _, id := range p.tasks {
+			p.config.Scheduler.RemoveTask(id)
+		}
+		p.tasks = nil
+	}
+
+	if p.mdns != nil {
+		if err := p.mdns.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop mDNS discovery: %w", err))
+		}
+	}
+	if p.reachability != nil {
+		_ = p.reachability.close()
+	}
+	if p.peerEvents != nil {
+		_ = p.peerEvents.close()
+	}
+	if p.nodeState != nil {
+		p.nodeState.Close()
+	}
+	if p.allocations != nil {
+		p.allocations.Close()
+	}
+
+	if p.Host == nil {
+		return errors.Join(errs...)
+	}
+
+	for _, id := range dmsProtocols {
+		p.Host.RemoveStreamHandler(id)
+	}
+
+	if p.DHT != nil {
+		if err := p.unadvertiseAll(ctx); err != nil {
+			zlog.Sugar().Warnf("failed to withdraw advertisements: %v", err)
+		}
+	}
+
+	// long-lived RPC streams such as log streams only end once their
+	// handlers are cancelled
+	if p.RPC != nil {
+		p.RPC.Close()
+	}
+	// the drain has its own bound so that the shutdown hooks running after
+	// the node keep some of the shutdown time
+	drainCtx, cancel := context.WithTimeout(ctx, StopTimeout)
+	p.drainStreams(drainCtx)
+	cancel()
+
+	if err := p.saveRoutingTable(ctx); err != nil {
+		zlog.Sugar().Warnf("failed to save routing table: %v", err)
+	}
+	p.savePeerstore()
+
+	if p.DHT != nil {
+		if err := p.DHT.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close DHT: %w", err))
+		}
+	}
+	if err := p.Host.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close host: %w", err))
+	}
+	if err := p.closeDatastore(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close datastore: %w", err))
+	}
+
+	zlog.Info("libp2p node stopped")
+	return errors.Join(errs...)
+}
+
+// closeDatastore closes the datastore persisting the peerstore, it must be
+// called once the host is closed
+func (p *Libp2p) closeDatastore() error {
+	if p.storedPeers != nil {
+		_ = p.storedPeers.Close()
+		p.storedPeers = nil
+	}
+	if p.datastore == nil {
+		return nil
+	}
+	err := p.datastore.Close()
+	p.datastore = nil
+	return err
+}
+
+// unadvertiseAll replaces every active advertisement of this node by a tombstone
+func (p *Libp2p) unadvertiseAll(ctx context.Context) error {
+	if p.ads == nil {
+		return nil
+	}
+
+	var errs []error
+	for adType := range p.ads.active() {
+		if err := p.putAdvertisement(ctx, adType, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// drainStreams waits for the open streams of the DMS protocols to be closed
+// and resets the remaining ones once ctx is done
+func (p *Libp2p) drainStreams(ctx context.Context) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		open := p.openStreams()
+		if len(open) == 0 {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			zlog.Sugar().Warnf("resetting %d streams still open on shutdown", len(open))
+			for _, s := range open {
+				_ = s.Reset()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// openStreams returns the open streams of the DMS protocols
+func (p *Libp2p) openStreams() []network.Stream {
+	var open []network.Stream
+	for _, conn := range p.Host.Network().Conns() {
+		for _, s := range conn.GetStreams() {
+			for _, id := range dmsProtocols {
+				if s.Protocol() == id {
+					open = append(open, s)
+					break
+				}
+			}
+		}
+	}
+	return open
+}
@@ -0,0 +1,116 @@
+package libp2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bt "gitlab.com/nunet/device-management-service/internal/background_tasks"
+)
+
+func TestShutdownDrainsStreams(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	scheduler := bt.NewScheduler(1)
+	p := &Libp2p{Host: h1, RPC: NewRPC(h1), config: Libp2pConfig{Scheduler: scheduler}}
+
+	p.addTask(&bt.Task{
+		Name:     "Test Task",
+		Function: func(_ context.Context, _ interface{}) error { return nil },
+		Triggers: []bt.Trigger{&bt.IntervalTrigger{Interval: time.Hour}},
+	})
+	require.Len(t, p.tasks, 1)
+
+	started := make(chan struct{})
+	err := RegisterHandler(p.RPC, "slow", func(_ context.Context, _ peer.ID, req echoRequest) (echoResponse, error) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		return echoResponse{Text: req.Text}, nil
+	})
+	require.NoError(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		resp, err := Call[echoRequest, echoResponse](context.Background(), NewRPC(h2), h1.ID(), "slow", echoRequest{Text: "done"})
+		if err == nil {
+			assert.Equal(t, "done", resp.Text)
+		}
+		result <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, p.Shutdown(ctx))
+
+	assert.NoError(t, <-result, "in-flight calls should complete before the host is closed")
+	assert.Empty(t, p.tasks)
+	assert.Empty(t, p.openStreams())
+	assert.Empty(t, h1.Network().Conns(), "host should be closed")
+
+	assert.NoError(t, p.Stop(), "stopping twice should be a no-op")
+}
+
+func TestShutdownResetsStuckStreams(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	p := &Libp2p{Host: h1, RPC: NewRPC(h1)}
+
+	started := make(chan struct{})
+	err := RegisterStreamHandler(p.RPC, "stuck", func(ctx context.Context, _ peer.ID, _ echoRequest, _ func(echoResponse) error) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- CallStream[echoRequest, echoResponse](context.Background(), NewRPC(h2), h1.ID(), "stuck", echoRequest{}, func(echoResponse) error {
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.NoError(t, p.Shutdown(ctx))
+
+	select {
+	case err := <-result:
+		assert.Error(t, err, "streams still open after the deadline should be reset")
+	case <-time.After(5 * time.Second):
+		t.Fatal("stuck call was not interrupted by the shutdown")
+	}
+}
+
+func TestShutdownCancelsRPCHandlers(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	p := &Libp2p{Host: h1, RPC: NewRPC(h1)}
+
+	started := make(chan struct{})
+	err := RegisterStreamHandler(p.RPC, "logs", func(ctx context.Context, _ peer.ID, _ echoRequest, _ func(echoResponse) error) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- CallStream[echoRequest, echoResponse](context.Background(), NewRPC(h2), h1.ID(), "logs", echoRequest{}, func(echoResponse) error {
+			return nil
+		})
+	}()
+	<-started
+
+	// the handler ends as soon as it is cancelled, the drain doesn't wait for the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, p.Shutdown(ctx))
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.ErrorContains(t, <-result, context.Canceled.Error())
+}
@@ -0,0 +1,193 @@
+package libp2p
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+	"github.com/multiformats/go-multiaddr"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// reachabilityTracker keeps the latest reachability and NAT type reported
+// on the host's event bus by AutoNAT
+type reachabilityTracker struct {
+	mu           sync.RWMutex
+	reachability network.Reachability
+	natTypes     map[network.NATTransportProtocol]network.NATDeviceType
+
+	sub event.Subscription
+}
+
+func newReachabilityTracker(h host.Host) (*reachabilityTracker, error) {
+	sub, err := h.EventBus().Subscribe([]interface{}{
+		new(event.EvtLocalReachabilityChanged),
+		new(event.EvtNATDeviceTypeChanged),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t := &reachabilityTracker{
+		natTypes: make(map[network.NATTransportProtocol]network.NATDeviceType),
+		sub:      sub,
+	}
+	go t.run()
+	return t, nil
+}
+
+func (t *reachabilityTracker) run() {
+	for e := range t.sub.Out() {
+		t.mu.Lock()
+		switch evt := e.(type) {
+		case event.EvtLocalReachabilityChanged:
+			t.reachability = evt.Reachability
+		case event.EvtNATDeviceTypeChanged:
+			t.natTypes[evt.TransportProtocol] = evt.NatDeviceType
+		}
+		t.mu.Unlock()
+	}
+}
+
+func (t *reachabilityTracker) status() (network.Reachability, string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	// UDP hole punching is the most relevant, fall back to TCP
+	natType, ok := t.natTypes[network.NATTransportUDP]
+	if !ok {
+		natType, ok = t.natTypes[network.NATTransportTCP]
+	}
+	if !ok {
+		return t.reachability, ""
+	}
+	return t.reachability, natType.String()
+}
+
+func (t *reachabilityTracker) close() error {
+	return t.sub.Close()
+}
+
+// Stat returns the addresses, reachability, relay reservations, connections
+// and bandwidth usage of the host
+func (p *Libp2p) Stat() models.NetStat {
+	stat := models.NetStat{
+		ID:                p.Host.ID().String(),
+		ListenAddrs:       multiaddrsToStrings(p.Host.Network().ListenAddresses()),
+		ObservedAddrs:     []string{},
+		Reachability:      network.ReachabilityUnknown.String(),
+		RelayReservations: relayReservations(p.Host.Addrs()),
+		Connections:       connStat(p.Host.Network()),
+	}
+
+	if ids, ok := unwrapHost(p.Host).(interface{ IDService() identify.IDService }); ok {
+		stat.ObservedAddrs = multiaddrsToStrings(ids.IDService().OwnObservedAddrs())
+	}
+	if p.reachability != nil {
+		reachability, natType := p.reachability.status()
+		stat.Reachability = reachability.String()
+		stat.NATDeviceType = natType
+	}
+	if p.bandwidth != nil {
+		totals := p.bandwidth.GetBandwidthTotals()
+		stat.Bandwidth = models.BandwidthStat{
+			TotalIn:  totals.TotalIn,
+			TotalOut: totals.TotalOut,
+			RateIn:   totals.RateIn,
+			RateOut:  totals.RateOut,
+		}
+	}
+	return stat
+}
+
+// Peers returns the peers of the peerstore with an address, connected or not,
+// sorted by ID
+func (p *Libp2p) Peers() []models.PeerStat {
+	ps := p.Host.Peerstore()
+	n := p.Host.Network()
+
+	peers := []models.PeerStat{}
+	for _, id := range ps.PeersWithAddrs() {
+		if id == p.Host.ID() {
+			continue
+		}
+		stat := models.PeerStat{
+			ID:        id.String(),
+			Addrs:     multiaddrsToStrings(ps.Addrs(id)),
+			Connected: n.Connectedness(id) == network.Connected,
+			Latency:   ps.LatencyEWMA(id),
+		}
+		for _, conn := range n.ConnsToPeer(id) {
+			connStat := conn.Stat()
+			stat.Direction = strings.ToLower(connStat.Direction.String())
+			if connStat.Transient || isRelayAddr(conn.RemoteMultiaddr()) {
+				stat.Relayed = true
+				continue
+			}
+			// prefer describing a direct connection
+			stat.Relayed = false
+			break
+		}
+		peers = append(peers, stat)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+	return peers
+}
+
+func connStat(n network.Network) models.ConnStat {
+	stat := models.ConnStat{Peers: len(n.Peers())}
+	for _, conn := range n.Conns() {
+		connStat := conn.Stat()
+		switch connStat.Direction {
+		case network.DirInbound:
+			stat.Inbound++
+		case network.DirOutbound:
+			stat.Outbound++
+		}
+		if connStat.Transient || isRelayAddr(conn.RemoteMultiaddr()) {
+			stat.Relayed++
+		}
+	}
+	return stat
+}
+
+// relayReservations returns the IDs of the relays found in the host's circuit addresses
+func relayReservations(addrs []multiaddr.Multiaddr) []string {
+	seen := make(map[string]bool)
+	relays := []string{}
+	for _, addr := range addrs {
+		var relay string
+		multiaddr.ForEach(addr, func(c multiaddr.Component) bool {
+			switch c.Protocol().Code {
+			case multiaddr.P_P2P:
+				relay = c.Value()
+			case multiaddr.P_CIRCUIT:
+				if relay != "" && !seen[relay] {
+					seen[relay] = true
+					relays = append(relays, relay)
+				}
+				return false
+			}
+			return true
+		})
+	}
+	return relays
+}
+
+func isRelayAddr(addr multiaddr.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT)
+	return err == nil
+}
+
+func multiaddrsToStrings(addrs []multiaddr.Multiaddr) []string {
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		out = append(out, addr.String())
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package libp2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/metrics"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func p2pAddress(id peer.ID) models.SpecConfig {
+	return models.SpecConfig{
+		Type:   string(models.NetP2P),
+		Params: map[string]interface{}{"peer_id": id.String()},
+	}
+}
+
+func TestPing(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	p := &Libp2p{Host: h1}
+
+	result, err := p.Ping(context.Background(), p2pAddress(h2.ID()), time.Second)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Greater(t, result.RTT, time.Duration(0))
+
+	_, err = p.Ping(context.Background(), p2pAddress(h1.ID()), 0)
+	assert.Error(t, err, "pinging self should fail")
+
+	_, err = p.Ping(context.Background(), models.SpecConfig{Type: "tcp"}, 0)
+	assert.Error(t, err)
+
+	_, otherID := newTestIdentity(t)
+	result, err = p.Ping(context.Background(), p2pAddress(otherID), 100*time.Millisecond)
+	assert.Error(t, err, "unreachable peers should time out")
+	assert.False(t, result.Success)
+}
+
+func TestAttach(t *testing.T) {
+	withDataDir(t)
+	h1, h2 := newConnectedHosts(t)
+	p := &Libp2p{}
+	require.NoError(t, p.Attach(h1, nil, Libp2pConfig{}))
+
+	result, err := p.Ping(context.Background(), p2pAddress(h2.ID()), time.Second)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, h1.ID().String(), p.Stat().ID)
+
+	require.NoError(t, p.Stop())
+	assert.Empty(t, h1.Network().Conns(), "the attached host should be closed with the node")
+}
+
+func TestStat(t *testing.T) {
+	bandwidth := metrics.NewBandwidthCounter()
+	h1, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"), libp2p.BandwidthReporter(bandwidth))
+	require.NoError(t, err)
+	t.Cleanup(func() { h1.Close() })
+	h2, err := libp2p.New(libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0"))
+	require.NoError(t, err)
+	t.Cleanup(func() { h2.Close() })
+
+	reachability, err := newReachabilityTracker(h1)
+	require.NoError(t, err)
+	t.Cleanup(func() { reachability.close() })
+
+	p := &Libp2p{Host: h1, bandwidth: bandwidth, reachability: reachability}
+	require.NoError(t, h1.Connect(context.Background(), peer.AddrInfo{ID: h2.ID(), Addrs: h2.Addrs()}))
+	_, err = p.Ping(context.Background(), p2pAddress(h2.ID()), time.Second)
+	require.NoError(t, err)
+
+	stat := p.Stat()
+	assert.Equal(t, h1.ID().String(), stat.ID)
+	assert.NotEmpty(t, stat.ListenAddrs)
+	assert.Equal(t, "Unknown", stat.Reachability)
+	assert.Equal(t, 1, stat.Connections.Peers)
+	assert.Equal(t, 1, stat.Connections.Outbound)
+	assert.Zero(t, stat.Connections.Relayed)
+
+	// the bandwidth meters are updated once per second
+	assert.Eventually(t, func() bool {
+		return p.Stat().Bandwidth.TotalOut > 0
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestPeers(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	p := &Libp2p{Host: h1}
+
+	_, otherID := newTestIdentity(t)
+	h1.Peerstore().AddAddr(otherID, multiaddr.StringCast("/ip4/127.0.0.1/tcp/1"), time.Hour)
+
+	peers := p.Peers()
+	require.Len(t, peers, 2)
+	for _, peer := range peers {
+		switch peer.ID {
+		case h2.ID().String():
+			assert.True(t, peer.Connected)
+			assert.Equal(t, "outbound", peer.Direction)
+			assert.False(t, peer.Relayed)
+			assert.NotEmpty(t, peer.Addrs)
+		case otherID.String():
+			assert.False(t, peer.Connected)
+			assert.Empty(t, peer.Direction)
+		default:
+			t.Fatalf("unexpected peer %s", peer.ID)
+		}
+	}
+	assert.Less(t, peers[0].ID, peers[1].ID)
+}
+
+func TestRelayReservations(t *testing.T) {
+	_, relayID := newTestIdentity(t)
+	_, selfID := newTestIdentity(t)
+
+	addrs := []multiaddr.Multiaddr{
+		multiaddr.StringCast("/ip4/1.2.3.4/tcp/9000"),
+		multiaddr.StringCast("/ip4/5.6.7.8/tcp/9000/p2p/" + relayID.String() + "/p2p-circuit"),
+		multiaddr.StringCast("/ip4/5.6.7.8/udp/9000/quic-v1/p2p/" + relayID.String() + "/p2p-circuit/p2p/" + selfID.String()),
+	}
+	assert.Equal(t, []string{relayID.String()}, relayReservations(addrs))
+	assert.True(t, isRelayAddr(addrs[1]))
+	assert.False(t, isRelayAddr(addrs[0]))
+}
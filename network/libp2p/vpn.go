@@ -0,0 +1,427 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-msgio"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+const (
+	// VPNProtocolID is the libp2p protocol carrying the IP packets of the overlay
+	VPNProtocolID = protocol.ID("/nunet/vpn/1.0.0")
+
+	// DefaultVPNSubnet is the subnet virtual IPs are allocated from
+	DefaultVPNSubnet = "10.200.0.0/16"
+
+	// maxVPNPacketSize bounds a single packet, the largest possible IP packet
+	maxVPNPacketSize = 1 << 16
+
+	// vpnDialTimeout bounds opening a stream to a peer of the overlay
+	vpnDialTimeout = 10 * time.Second
+)
+
+var (
+	// ErrVPNStarted is returned when starting a VPN twice
+	ErrVPNStarted = errors.New("vpn already started")
+
+	// ErrVPNNotStarted is returned when changing the routes of a stopped VPN
+	ErrVPNNotStarted = errors.New("vpn not started")
+
+	// ErrAddressPoolExhausted is returned when no virtual IP is left in the subnet
+	ErrAddressPoolExhausted = errors.New("vpn address pool exhausted")
+)
+
+// VPN implements network.VPN as an IP overlay over libp2p streams. Every
+// participating allocation has a virtual IP mapped to the peer running it.
+// Packets read from the device are sent to the peer owning the destination
+// address, using relayed connections when no direct connection is possible.
+type VPN struct {
+	host   host.Host
+	device VPNDevice
+
+	mu      sync.RWMutex
+	routes  map[netip.Addr]peer.ID
+	streams map[peer.ID]*vpnStream
+	started bool
+	wg      sync.WaitGroup
+}
+
+// vpnStream is an outgoing packet stream to a peer
+type vpnStream struct {
+	stream network.Stream
+	writer msgio.WriteCloser
+}
+
+// NewVPN creates a VPN on the given host exchanging packets with the device
+func NewVPN(h host.Host, device VPNDevice) *VPN {
+	return &VPN{
+		host:    h,
+		device:  device,
+		routes:  make(map[netip.Addr]peer.ID),
+		streams: make(map[peer.ID]*vpnStream),
+	}
+}
+
+// Start sets the initial routing table and starts forwarding packets
+func (v *VPN) Start(routes []models.VPNRoute) error {
+	table := make(map[netip.Addr]peer.ID, len(routes))
+	for _, route := range routes {
+		addr, id, err := parseVPNRoute(route)
+		if err != nil {
+			return err
+		}
+		table[addr] = id
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.started {
+		return ErrVPNStarted
+	}
+	v.routes = table
+	v.started = true
+
+	v.host.SetStreamHandler(VPNProtocolID, v.handleStream)
+
+	v.wg.Add(1)
+	go v.forward()
+	return nil
+}
+
+// AddPeer adds or replaces the route of a virtual IP
+func (v *VPN) AddPeer(route models.VPNRoute) error {
+	addr, id, err := parseVPNRoute(route)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.started {
+		return ErrVPNNotStarted
+	}
+
+	previous, ok := v.routes[addr]
+	v.routes[addr] = id
+	if ok && previous != id {
+		v.closeUnusedStreamLocked(previous)
+	}
+	return nil
+}
+
+// RemovePeer removes the route of a virtual IP and closes the stream to its
+// peer when no other route uses it
+func (v *VPN) RemovePeer(address string) error {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return fmt.Errorf("invalid vpn address %s: %w", address, err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.started {
+		return ErrVPNNotStarted
+	}
+
+	id, ok := v.routes[addr]
+	if !ok {
+		return nil
+	}
+	delete(v.routes, addr)
+	v.closeUnusedStreamLocked(id)
+	return nil
+}
+
+// Stop stops forwarding packets, closes every stream and the device
+func (v *VPN) Stop() error {
+	v.mu.Lock()
+	if !v.started {
+		v.mu.Unlock()
+		return nil
+	}
+	v.started = false
+	v.host.RemoveStreamHandler(VPNProtocolID)
+	for id, s := range v.streams {
+		_ = s.stream.Close()
+		delete(v.streams, id)
+	}
+	v.mu.Unlock()
+
+	err := v.device.Close()
+	v.wg.Wait()
+	return err
+}
+
+// Routes returns the current routing table
+func (v *VPN) Routes() []models.VPNRoute {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	routes := make([]models.VPNRoute, 0, len(v.routes))
+	for addr, id := range v.routes {
+		routes = append(routes, models.VPNRoute{Address: addr.String(), PeerID: id.String()})
+	}
+	return routes
+}
+
+// forward sends the packets read from the device until it is closed
+func (v *VPN) forward() {
+	defer v.wg.Done()
+	for {
+		packet, err := v.device.ReadPacket()
+		if err != nil {
+			if !errors.Is(err, ErrDeviceClosed) {
+				zlog.Sugar().Errorf("vpn: failed to read from device: %v", err)
+			}
+			return
+		}
+		if err := v.route(packet); err != nil {
+			zlog.Sugar().Debugf("vpn: dropping packet: %v", err)
+		}
+	}
+}
+
+// route sends the packet to the peer owning its destination address
+func (v *VPN) route(packet []byte) error {
+	_, dst, err := packetAddrs(packet)
+	if err != nil {
+		return err
+	}
+
+	v.mu.RLock()
+	id, ok := v.routes[dst]
+	v.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no route to %s", dst)
+	}
+
+	if id == v.host.ID() {
+		return v.device.WritePacket(packet)
+	}
+	return v.send(id, packet)
+}
+
+// send writes the packet on the stream to the peer, reopening the stream once if it broke
+func (v *VPN) send(id peer.ID, packet []byte) error {
+	s, err := v.stream(id)
+	if err != nil {
+		return err
+	}
+	if err = s.writer.WriteMsg(packet); err == nil {
+		return nil
+	}
+
+	v.dropStream(id, s)
+	s, err = v.stream(id)
+	if err != nil {
+		return err
+	}
+	if err = s.writer.WriteMsg(packet); err != nil {
+		v.dropStream(id, s)
+		return fmt.Errorf("failed to send packet to %s: %w", id.String(), err)
+	}
+	return nil
+}
+
+// stream returns the outgoing stream to the peer, opening it if needed
+func (v *VPN) stream(id peer.ID) (*vpnStream, error) {
+	v.mu.RLock()
+	s, ok := v.streams[id]
+	v.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	// allow relayed connections so that peers behind NATs stay reachable
+	ctx, cancel := context.WithTimeout(context.Background(), vpnDialTimeout)
+	defer cancel()
+	ctx = network.WithUseTransient(ctx, "vpn")
+
+	stream, err := v.host.NewStream(ctx, id, VPNProtocolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vpn stream to %s: %w", id.String(), err)
+	}
+	s = &vpnStream{stream: stream, writer: msgio.NewVarintWriter(stream)}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.started {
+		_ = stream.Reset()
+		return nil, ErrVPNNotStarted
+	}
+	if existing, ok := v.streams[id]; ok {
+		_ = stream.Close()
+		return existing, nil
+	}
+	v.streams[id] = s
+	return s, nil
+}
+
+func (v *VPN) dropStream(id peer.ID, s *vpnStream) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.streams[id] == s {
+		delete(v.streams, id)
+	}
+	_ = s.stream.Reset()
+}
+
+// closeUnusedStreamLocked closes the stream to a peer which no route points to anymore
+func (v *VPN) closeUnusedStreamLocked(id peer.ID) {
+	for _, routed := range v.routes {
+		if routed == id {
+			return
+		}
+	}
+	if s, ok := v.streams[id]; ok {
+		_ = s.stream.Close()
+		delete(v.streams, id)
+	}
+}
+
+// handleStream writes the packets received from a peer to the device. Packets
+// are only accepted if their source address is routed to the sending peer and
+// their destination address is local.
+func (v *VPN) handleStream(s network.Stream) {
+	defer s.Close()
+
+	remote := s.Conn().RemotePeer()
+	reader := msgio.NewVarintReaderSize(s, maxVPNPacketSize)
+	for {
+		packet, err := reader.ReadMsg()
+		if err != nil {
+			return
+		}
+
+		src, dst, err := packetAddrs(packet)
+		if err != nil {
+			zlog.Sugar().Debugf("vpn: dropping packet from %s: %v", remote.String(), err)
+			continue
+		}
+
+		v.mu.RLock()
+		srcPeer, srcOK := v.routes[src]
+		dstPeer, dstOK := v.routes[dst]
+		v.mu.RUnlock()
+
+		if !srcOK || srcPeer != remote {
+			zlog.Sugar().Debugf("vpn: dropping packet from %s: source %s is not routed to it", remote.String(), src)
+			continue
+		}
+		if !dstOK || dstPeer != v.host.ID() {
+			zlog.Sugar().Debugf("vpn: dropping packet from %s: destination %s is not local", remote.String(), dst)
+			continue
+		}
+
+		if err := v.device.WritePacket(packet); err != nil {
+			return
+		}
+	}
+}
+
+func parseVPNRoute(route models.VPNRoute) (netip.Addr, peer.ID, error) {
+	addr, err := netip.ParseAddr(route.Address)
+	if err != nil {
+		return netip.Addr{}, "", fmt.Errorf("invalid vpn address %s: %w", route.Address, err)
+	}
+	id, err := peer.Decode(route.PeerID)
+	if err != nil {
+		return netip.Addr{}, "", fmt.Errorf("invalid peer ID for vpn address %s: %w", route.Address, err)
+	}
+	return addr, id, nil
+}
+
+// packetAddrs returns the source and destination addresses of an IPv4 or IPv6 packet
+func packetAddrs(packet []byte) (src, dst netip.Addr, err error) {
+	if len(packet) == 0 {
+		return src, dst, errors.New("empty packet")
+	}
+
+	switch packet[0] >> 4 {
+	case 4:
+		if len(packet) < 20 {
+			return src, dst, errors.New("truncated ipv4 header")
+		}
+		src = netip.AddrFrom4([4]byte(packet[12:16]))
+		dst = netip.AddrFrom4([4]byte(packet[16:20]))
+	case 6:
+		if len(packet) < 40 {
+			return src, dst, errors.New("truncated ipv6 header")
+		}
+		src = netip.AddrFrom16([16]byte(packet[8:24]))
+		dst = netip.AddrFrom16([16]byte(packet[24:40]))
+	default:
+		return src, dst, fmt.Errorf("unsupported ip version %d", packet[0]>>4)
+	}
+	return src, dst, nil
+}
+
+// VPNAddressPool hands out the virtual IPs of a subnet by allocation ID. It
+// only keeps track of the addresses, the routes of the overlay are given to
+// the VPN separately.
+type VPNAddressPool struct {
+	mu      sync.Mutex
+	prefix  netip.Prefix
+	byAlloc map[string]netip.Addr
+	used    map[netip.Addr]struct{}
+}
+
+// NewVPNAddressPool creates a pool allocating addresses from the given subnet
+func NewVPNAddressPool(subnet string) (*VPNAddressPool, error) {
+	prefix, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vpn subnet %s: %w", subnet, err)
+	}
+	return &VPNAddressPool{
+		prefix:  prefix.Masked(),
+		byAlloc: make(map[string]netip.Addr),
+		used:    make(map[netip.Addr]struct{}),
+	}, nil
+}
+
+// Allocate returns the virtual IP of the allocation, assigning a free one on
+// the first call. The network address of the subnet is never assigned.
+func (p *VPNAddressPool) Allocate(allocationID string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if addr, ok := p.byAlloc[allocationID]; ok {
+		return addr.String(), nil
+	}
+
+	for addr := p.prefix.Addr().Next(); p.prefix.Contains(addr); addr = addr.Next() {
+		if _, ok := p.used[addr]; ok {
+			continue
+		}
+		// skip the IPv4 broadcast address
+		if addr.Is4() && !p.prefix.Contains(addr.Next()) {
+			break
+		}
+		p.used[addr] = struct{}{}
+		p.byAlloc[allocationID] = addr
+		return addr.String(), nil
+	}
+	return "", ErrAddressPoolExhausted
+}
+
+// Release frees the virtual IP of the allocation
+func (p *VPNAddressPool) Release(allocationID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if addr, ok := p.byAlloc[allocationID]; ok {
+		delete(p.used, addr)
+		delete(p.byAlloc, allocationID)
+	}
+}
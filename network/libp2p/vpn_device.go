@@ -0,0 +1,97 @@
+package libp2p
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrDeviceClosed is returned when reading from or writing to a closed VPN device
+var ErrDeviceClosed = errors.New("vpn device closed")
+
+// VPNDevice is the source and sink of the raw IP packets of the overlay. The
+// VPN reads the packets to send to remote peers from it and writes the
+// packets received from them to it.
+type VPNDevice interface {
+	// ReadPacket blocks until an outgoing IP packet is available
+	ReadPacket() ([]byte, error)
+
+	// WritePacket delivers an IP packet received from the overlay
+	WritePacket(packet []byte) error
+
+	// Close unblocks pending reads and releases the device
+	Close() error
+}
+
+// ChannelDevice is an in-memory VPNDevice queueing raw IP packets on
+// channels. It has no network stack: its owner builds the packets sent with
+// Inject and parses the packets received on Packets itself.
+type ChannelDevice struct {
+	outbound chan []byte
+	inbound  chan []byte
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var _ VPNDevice = (*ChannelDevice)(nil)
+
+// NewChannelDevice creates a ChannelDevice buffering up to size packets in each direction
+func NewChannelDevice(size int) *ChannelDevice {
+	return &ChannelDevice{
+		outbound: make(chan []byte, size),
+		inbound:  make(chan []byte, size),
+		closed:   make(chan struct{}),
+	}
+}
+
+// Inject queues a packet to send over the overlay
+func (d *ChannelDevice) Inject(packet []byte) error {
+	select {
+	case <-d.closed:
+		return ErrDeviceClosed
+	default:
+	}
+
+	select {
+	case d.outbound <- packet:
+		return nil
+	case <-d.closed:
+		return ErrDeviceClosed
+	}
+}
+
+// Packets returns the packets received from the overlay
+func (d *ChannelDevice) Packets() <-chan []byte {
+	return d.inbound
+}
+
+func (d *ChannelDevice) ReadPacket() ([]byte, error) {
+	select {
+	case packet := <-d.outbound:
+		return packet, nil
+	case <-d.closed:
+		return nil, ErrDeviceClosed
+	}
+}
+
+// WritePacket delivers the packet, dropping it when the inbound buffer is
+// full like a congested network interface would
+func (d *ChannelDevice) WritePacket(packet []byte) error {
+	select {
+	case <-d.closed:
+		return ErrDeviceClosed
+	default:
+	}
+
+	select {
+	case d.inbound <- packet:
+	default:
+		zlog.Sugar().Debugf("vpn device: inbound buffer full, dropping packet")
+	}
+	return nil
+}
+
+func (d *ChannelDevice) Close() error {
+	d.closeOnce.Do(func() { close(d.closed) })
+	return nil
+}
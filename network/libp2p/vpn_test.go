@@ -0,0 +1,133 @@
+package libp2p
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// testIPv4Packet builds a minimal IPv4 packet with the given addresses
+func testIPv4Packet(src, dst string, payload string) []byte {
+	packet := make([]byte, 20, 20+len(payload))
+	packet[0] = 0x45
+	s, d := netip.MustParseAddr(src).As4(), netip.MustParseAddr(dst).As4()
+	copy(packet[12:16], s[:])
+	copy(packet[16:20], d[:])
+	return append(packet, payload...)
+}
+
+func receivePacket(t *testing.T, device *ChannelDevice) []byte {
+	select {
+	case packet := <-device.Packets():
+		return packet
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for packet")
+		return nil
+	}
+}
+
+func assertNoPacket(t *testing.T, device *ChannelDevice) {
+	select {
+	case packet := <-device.Packets():
+		t.Fatalf("unexpected packet: %v", packet)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestPacketAddrs(t *testing.T) {
+	src, dst, err := packetAddrs(testIPv4Packet("10.200.0.1", "10.200.0.2", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "10.200.0.1", src.String())
+	assert.Equal(t, "10.200.0.2", dst.String())
+
+	packet := make([]byte, 40)
+	packet[0] = 0x60
+	packet[23], packet[39] = 1, 2
+	src, dst, err = packetAddrs(packet)
+	require.NoError(t, err)
+	assert.Equal(t, "::1", src.String())
+	assert.Equal(t, "::2", dst.String())
+
+	_, _, err = packetAddrs([]byte{0x45, 0})
+	assert.Error(t, err)
+	_, _, err = packetAddrs(nil)
+	assert.Error(t, err)
+}
+
+func TestVPNAddressPool(t *testing.T) {
+	pool, err := NewVPNAddressPool("10.200.0.0/30")
+	require.NoError(t, err)
+
+	a, err := pool.Allocate("alloc-a")
+	require.NoError(t, err)
+	assert.Equal(t, "10.200.0.1", a)
+
+	again, err := pool.Allocate("alloc-a")
+	require.NoError(t, err)
+	assert.Equal(t, a, again, "allocating twice should return the same address")
+
+	b, err := pool.Allocate("alloc-b")
+	require.NoError(t, err)
+	assert.Equal(t, "10.200.0.2", b)
+
+	_, err = pool.Allocate("alloc-c")
+	assert.ErrorIs(t, err, ErrAddressPoolExhausted, "the broadcast address should not be assigned")
+
+	pool.Release("alloc-a")
+	c, err := pool.Allocate("alloc-c")
+	require.NoError(t, err)
+	assert.Equal(t, a, c, "released addresses should be reused")
+
+	_, err = NewVPNAddressPool("not-a-subnet")
+	assert.Error(t, err)
+}
+
+func TestVPNForwarding(t *testing.T) {
+	h1, h2 := newConnectedHosts(t)
+	dev1, dev2 := NewChannelDevice(8), NewChannelDevice(8)
+	vpn1, vpn2 := NewVPN(h1, dev1), NewVPN(h2, dev2)
+
+	routes := []models.VPNRoute{
+		{Address: "10.200.0.1", PeerID: h1.ID().String()},
+		{Address: "10.200.0.2", PeerID: h2.ID().String()},
+	}
+	require.NoError(t, vpn1.Start(routes))
+	require.NoError(t, vpn2.Start(routes))
+	t.Cleanup(func() {
+		vpn1.Stop()
+		vpn2.Stop()
+	})
+	assert.ErrorIs(t, vpn1.Start(routes), ErrVPNStarted)
+
+	packet := testIPv4Packet("10.200.0.1", "10.200.0.2", "hello")
+	require.NoError(t, dev1.Inject(packet))
+	assert.Equal(t, packet, receivePacket(t, dev2))
+
+	// packets between local allocations don't leave the host
+	local := testIPv4Packet("10.200.0.2", "10.200.0.2", "loopback")
+	require.NoError(t, dev2.Inject(local))
+	assert.Equal(t, local, receivePacket(t, dev2))
+
+	// a peer can't send packets on behalf of an address it doesn't own
+	require.NoError(t, dev1.Inject(testIPv4Packet("10.200.0.3", "10.200.0.2", "spoofed")))
+	assertNoPacket(t, dev2)
+
+	// new peers are reachable once added to the routing table
+	require.NoError(t, vpn1.AddPeer(models.VPNRoute{Address: "10.200.0.3", PeerID: h1.ID().String()}))
+	require.NoError(t, vpn2.AddPeer(models.VPNRoute{Address: "10.200.0.3", PeerID: h1.ID().String()}))
+	packet = testIPv4Packet("10.200.0.3", "10.200.0.2", "added")
+	require.NoError(t, dev1.Inject(packet))
+	assert.Equal(t, packet, receivePacket(t, dev2))
+
+	require.NoError(t, vpn1.RemovePeer("10.200.0.2"))
+	require.NoError(t, dev1.Inject(testIPv4Packet("10.200.0.1", "10.200.0.2", "removed")))
+	assertNoPacket(t, dev2)
+	assert.Len(t, vpn1.Routes(), 2)
+
+	assert.Error(t, vpn1.AddPeer(models.VPNRoute{Address: "not-an-ip", PeerID: h2.ID().String()}))
+}
@@ -7,6 +7,8 @@ import (
 	"gitlab.com/nunet/device-management-service/models"
 )
 
+// Network defines an interface provided by DMS to be implemented by various
+// network providers. This could be libp2p, or other p2p providers.
 type Network interface {
 	// Init initializes the network with the given configuration
 	Init(config models.NetConfig) error
@@ -17,6 +19,12 @@ type Network interface {
 	// Stat returns the network information
 	Stat() models.NetStat
 
+	// Usage returns the resources used by the network against its limits
+	Usage() models.NetUsage
+
+	// Peers returns the peers known to the network, connected or not
+	Peers() []models.PeerStat
+
 	// Ping pings the given address and returns the PingResult
 	// default timeout is 5 seconds
 	Ping(ctx context.Context, address models.SpecConfig, timeout time.Duration) (models.PingResult, error)
@@ -9,6 +9,7 @@ import (
 
 	"gitlab.com/nunet/device-management-service/models"
 	"gitlab.com/nunet/device-management-service/storage"
+	"gitlab.com/nunet/device-management-service/telemetry/metrics"
 	"gitlab.com/nunet/device-management-service/utils"
 )
 
@@ -106,6 +107,12 @@ func (vc *BasicVolumeController) LockVolume(pathToVol string, opts ...storage.Lo
 		return fmt.Errorf("failed to update storage volume with path %s - Error: %w", pathToVol, err)
 	}
 
+	// the data is fully written once locked, it is sized before the
+	// permissions change as the directory can't be walked afterwards
+	if size, err := utils.GetDirectorySize(vc.FS, vol.Path); err == nil {
+		metrics.AddVolumeBytes(size)
+	}
+
 	// change file permissions
 	if err := vc.FS.Chmod(vol.Path, 0400); err != nil {
 		return fmt.Errorf("failed to make storage volume read-only (path: %s): %w", vol.Path, err)
@@ -0,0 +1,261 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// Collector is a data sink collecting the observed events.
+type Collector interface {
+	Observes(e Event) bool                      // Returns true if the collector collects the event.
+	Collect(ctx context.Context, e Event) error // Processes the event.
+	Close() error                               // Releases the resources of the collector.
+}
+
+// Filter selects the events collected by a collector.
+type Filter struct {
+	Level      ObservabilityLevel // Minimum level of the collected events.
+	Categories []EventCategory    // Categories of the collected events, empty means all.
+}
+
+// Observes checks the level and the category of the event.
+func (f Filter) Observes(e Event) bool {
+	if e.Level() < f.Level {
+		return false
+	}
+	if len(f.Categories) == 0 {
+		return true
+	}
+	for _, category := range f.Categories {
+		if category == e.Category() {
+			return true
+		}
+	}
+	return false
+}
+
+// record is the serialized form of an event.
+type record struct {
+	Time     string             `json:"time"`
+	Name     string             `json:"name"`
+	Category EventCategory      `json:"category"`
+	Level    ObservabilityLevel `json:"level"`
+	Fields   Fields             `json:"fields,omitempty"`
+}
+
+func newRecord(e Event) record {
+	return record{
+		Time:     e.Time().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		Name:     e.Name(),
+		Category: e.Category(),
+		Level:    e.Level(),
+		Fields:   e.Fields(),
+	}
+}
+
+// sortedFields returns the names of the fields in order.
+func sortedFields(fields Fields) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FileCollector collects events into a local file, one JSON object per line.
+type FileCollector struct {
+	Filter
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileCollector opens the file, creating it and its directory if needed,
+// and appends the collected events to it.
+func NewFileCollector(path string, filter Filter) (*FileCollector, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create telemetry directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open telemetry file: %w", err)
+	}
+	return &FileCollector{Filter: filter, file: file}, nil
+}
+
+// Collect appends the event to the file.
+func (c *FileCollector) Collect(_ context.Context, e Event) error {
+	data, err := json.Marshal(newRecord(e))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (c *FileCollector) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Close()
+}
+
+// DatabaseCollector collects events into the local database.
+type DatabaseCollector struct {
+	Filter
+
+	repo repositories.TelemetryEventRepository
+}
+
+// NewDatabaseCollector returns a collector storing the events in the repository.
+func NewDatabaseCollector(repo repositories.TelemetryEventRepository, filter Filter) *DatabaseCollector {
+	return &DatabaseCollector{Filter: filter, repo: repo}
+}
+
+// Collect stores the event.
+func (c *DatabaseCollector) Collect(ctx context.Context, e Event) error {
+	var fields []byte
+	if len(e.Fields()) > 0 {
+		var err error
+		if fields, err = json.Marshal(e.Fields()); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.repo.Create(ctx, models.TelemetryEvent{
+		Name:     e.Name(),
+		Category: string(e.Category()),
+		Level:    e.Level().String(),
+		Time:     e.Time().UTC(),
+		Fields:   string(fields),
+	})
+	return err
+}
+
+// Close does nothing, the database is owned by the caller.
+func (c *DatabaseCollector) Close() error {
+	return nil
+}
+
+// OpenTelemetryCollector sends the events to the open telemetry tracer
+// provider. An event observed within a recording span is added to the span,
+// otherwise it is sent as a span of its own.
+type OpenTelemetryCollector struct {
+	Filter
+
+	tracer trace.Tracer
+}
+
+// NewOpenTelemetryCollector returns a collector using the global tracer
+// provider, which can be set after the collector is created.
+func NewOpenTelemetryCollector(filter Filter) *OpenTelemetryCollector {
+	return &OpenTelemetryCollector{
+		Filter: filter,
+		tracer: otel.Tracer("gitlab.com/nunet/device-management-service/telemetry"),
+	}
+}
+
+// Collect adds the event to the span of the context, or to a new span.
+func (c *OpenTelemetryCollector) Collect(ctx context.Context, e Event) error {
+	attributes := []attribute.KeyValue{
+		attribute.String("category", string(e.Category())),
+		attribute.String("level", e.Level().String()),
+	}
+	for _, name := range sortedFields(e.Fields()) {
+		attributes = append(attributes, otelAttribute(name, e.Fields()[name]))
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		span.AddEvent(e.Name(), trace.WithTimestamp(e.Time()), trace.WithAttributes(attributes...))
+		return nil
+	}
+
+	_, span = c.tracer.Start(ctx, e.Name(), trace.WithTimestamp(e.Time()), trace.WithAttributes(attributes...))
+	if e.Level() >= ErrorLevel {
+		span.SetStatus(codes.Error, e.Name())
+	}
+	span.End(trace.WithTimestamp(e.Time()))
+	return nil
+}
+
+// Close does nothing, the tracer provider is shut down by its owner.
+func (c *OpenTelemetryCollector) Close() error {
+	return nil
+}
+
+func otelAttribute(name string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(name, v)
+	case bool:
+		return attribute.Bool(name, v)
+	case int:
+		return attribute.Int(name, v)
+	case int64:
+		return attribute.Int64(name, v)
+	case float64:
+		return attribute.Float64(name, v)
+	case fmt.Stringer:
+		return attribute.String(name, v.String())
+	default:
+		return attribute.String(name, fmt.Sprint(v))
+	}
+}
+
+// LogCollector writes the events to the zap logs, it is meant for the
+// events of the LOGGING category so that the other categories don't end up
+// mixed with the logs.
+type LogCollector struct {
+	Filter
+}
+
+// NewLogCollector returns a collector writing to the logs of the telemetry package.
+func NewLogCollector(filter Filter) *LogCollector {
+	return &LogCollector{Filter: filter}
+}
+
+// Collect writes the event at the log level matching its level. FATAL events
+// are logged as errors, observing an event never exits.
+func (c *LogCollector) Collect(ctx context.Context, e Event) error {
+	fields := []zap.Field{zap.String("category", string(e.Category()))}
+	for _, name := range sortedFields(e.Fields()) {
+		fields = append(fields, zap.Any(name, e.Fields()[name]))
+	}
+
+	log := zlog.Ctx(ctx)
+	switch {
+	case e.Level() <= DebugLevel:
+		log.Debug(e.Name(), fields...)
+	case e.Level() == InfoLevel:
+		log.Info(e.Name(), fields...)
+	case e.Level() == WarnLevel:
+		log.Warn(e.Name(), fields...)
+	default:
+		log.Error(e.Name(), fields...)
+	}
+	return nil
+}
+
+// Close does nothing.
+func (c *LogCollector) Close() error {
+	return nil
+}
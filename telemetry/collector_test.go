@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	repositories_gorm "gitlab.com/nunet/device-management-service/db/repositories/gorm"
+	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func TestFileCollector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry", "events.log")
+	collector, err := NewFileCollector(path, Filter{})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, collector.Collect(ctx, NewEvent(Accounting, InfoLevel, "execution.started", Fields{"job_id": "job-1"})))
+	require.NoError(t, collector.Collect(ctx, NewEvent(Accounting, InfoLevel, "execution.finished", nil)))
+	require.NoError(t, collector.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "execution.started", records[0].Name)
+	assert.Equal(t, Accounting, records[0].Category)
+	assert.Equal(t, InfoLevel, records[0].Level)
+	assert.Equal(t, "job-1", records[0].Fields["job_id"])
+}
+
+func TestDatabaseCollector(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.TelemetryEvent{}))
+	repo := repositories_gorm.NewTelemetryEventRepository(db)
+
+	collector := NewDatabaseCollector(repo, Filter{})
+	event := NewEvent(Accounting, InfoLevel, "withdraw.succeeded", Fields{"tx_hash": "abc"})
+	require.NoError(t, collector.Collect(context.Background(), event))
+
+	stored, err := repo.FindAll(context.Background(), repo.GetQuery())
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "withdraw.succeeded", stored[0].Name)
+	assert.Equal(t, "ACCOUNTING", stored[0].Category)
+	assert.Equal(t, "INFO", stored[0].Level)
+	assert.JSONEq(t, `{"tx_hash":"abc"}`, stored[0].Fields)
+}
+
+func TestOpenTelemetryCollector(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	collector := &OpenTelemetryCollector{tracer: provider.Tracer("test")}
+
+	// without a span, the event is sent as a span of its own
+	event := NewEvent(Tracing, ErrorLevel, "deployment.failed", Fields{"attempt": 2})
+	require.NoError(t, collector.Collect(context.Background(), event))
+
+	// within a span, the event is added to the span
+	ctx, span := provider.Tracer("test").Start(context.Background(), "deployment")
+	require.NoError(t, collector.Collect(ctx, NewEvent(Tracing, InfoLevel, "deployment.accepted", nil)))
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "deployment.failed", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("attempt", 2))
+	assert.Equal(t, "deployment", spans[1].Name())
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "deployment.accepted", spans[1].Events()[0].Name)
+}
+
+func TestRegisterCollectors(t *testing.T) {
+	resetTelemetry(t)
+	t.Setenv(LevelEnv, "debug")
+
+	dataDir := t.TempDir()
+	err := RegisterCollectors(config.Telemetry{
+		ObservabilityLevel: "WARN",
+		Collectors: []config.TelemetryCollector{
+			{Type: "log", Categories: []string{"LOGGING"}},
+			{Type: "file", Level: "info", Categories: []string{"ACCOUNTING"}},
+			{Type: "opentelemetry"},
+		},
+	}, dataDir, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, DebugLevel, Level(), "the environment overrides the configuration")
+	collectors := Collectors()
+	require.Len(t, collectors, 3)
+	assert.IsType(t, &LogCollector{}, collectors[0])
+	assert.IsType(t, &FileCollector{}, collectors[1])
+	assert.Equal(t, Filter{Level: InfoLevel, Categories: []EventCategory{Accounting}}, collectors[1].(*FileCollector).Filter)
+	assert.FileExists(t, filepath.Join(dataDir, "telemetry", "events.log"))
+	assert.IsType(t, &OpenTelemetryCollector{}, collectors[2])
+}
+
+func TestRegisterCollectorsErrors(t *testing.T) {
+	resetTelemetry(t)
+
+	tests := []struct {
+		description string
+		collector   config.TelemetryCollector
+	}{
+		{description: "unknown type", collector: config.TelemetryCollector{Type: "kafka"}},
+		{description: "database without repository", collector: config.TelemetryCollector{Type: "database"}},
+		{description: "unknown level", collector: config.TelemetryCollector{Type: "log", Level: "verbose"}},
+		{description: "unknown category", collector: config.TelemetryCollector{Type: "log", Categories: []string{"billing"}}},
+	}
+
+	for _, tc := range tests {
+		err := RegisterCollectors(config.Telemetry{Collectors: []config.TelemetryCollector{tc.collector}}, t.TempDir(), nil)
+		assert.Error(t, err, tc.description)
+	}
+	assert.Empty(t, Collectors())
+}
@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/nunet/device-management-service/db/repositories"
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// LevelEnv is the environment variable overriding the observability level
+// of the configuration.
+const LevelEnv = "NUNET_OBSERVABILITY_LEVEL"
+
+// RegisterCollectors sets the observability level and registers the
+// collectors of the configuration as the default collectors. The database
+// collectors store the events in repo and the file collectors default to a
+// file under dataDir.
+func RegisterCollectors(cfg config.Telemetry, dataDir string, repo repositories.TelemetryEventRepository) error {
+	levelName := cfg.ObservabilityLevel
+	if env, ok := os.LookupEnv(LevelEnv); ok {
+		levelName = env
+	}
+	if levelName != "" {
+		l, err := ParseLevel(levelName)
+		if err != nil {
+			return err
+		}
+		SetLevel(l)
+	}
+
+	registered := make([]Collector, 0, len(cfg.Collectors))
+	for _, collectorConfig := range cfg.Collectors {
+		collector, err := newCollector(collectorConfig, dataDir, repo)
+		if err != nil {
+			for _, c := range registered {
+				c.Close()
+			}
+			return fmt.Errorf("failed to create %s collector: %w", collectorConfig.Type, err)
+		}
+		registered = append(registered, collector)
+	}
+	Register(registered...)
+	return nil
+}
+
+func newCollector(cfg config.TelemetryCollector, dataDir string, repo repositories.TelemetryEventRepository) (Collector, error) {
+	filter, err := newFilter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case "file":
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(dataDir, "telemetry", "events.log")
+		}
+		return NewFileCollector(path, filter)
+	case "database":
+		if repo == nil {
+			return nil, fmt.Errorf("no database")
+		}
+		return NewDatabaseCollector(repo, filter), nil
+	case "opentelemetry":
+		return NewOpenTelemetryCollector(filter), nil
+	case "log":
+		return NewLogCollector(filter), nil
+	default:
+		return nil, fmt.Errorf("unknown collector type")
+	}
+}
+
+func newFilter(cfg config.TelemetryCollector) (Filter, error) {
+	var filter Filter
+	if cfg.Level != "" {
+		l, err := ParseLevel(cfg.Level)
+		if err != nil {
+			return filter, err
+		}
+		filter.Level = l
+	}
+	for _, name := range cfg.Categories {
+		category, err := ParseCategory(name)
+		if err != nil {
+			return filter, err
+		}
+		filter.Categories = append(filter.Categories, category)
+	}
+	return filter, nil
+}
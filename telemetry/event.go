@@ -0,0 +1,167 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Fields are the data of an event, e.g. the ID of the execution an
+// accounting event is about.
+type Fields map[string]interface{}
+
+// Event defines the data of an event eligible to observation.
+type Event interface {
+	Name() string
+	Category() EventCategory
+	Level() ObservabilityLevel
+	Time() time.Time
+	Fields() Fields
+}
+
+// Observable defines how an event is observed by collectors.
+type Observable interface {
+	ObserveEvent(ctx context.Context)
+}
+
+// GEvent is the generic event, joining Event and Observable: it carries the
+// data of the event and the collectors it is directed to.
+type GEvent struct {
+	name       string
+	category   EventCategory
+	level      ObservabilityLevel
+	time       time.Time
+	fields     Fields
+	collectors []Collector
+}
+
+var (
+	_ Event      = (*GEvent)(nil)
+	_ Observable = (*GEvent)(nil)
+)
+
+var (
+	// level is the observability level of the DMS, events of a lower level
+	// are not observed
+	level atomic.Int32
+
+	mu sync.RWMutex
+	// collectors are the collectors registered in every new event
+	collectors []Collector
+)
+
+func init() {
+	level.Store(int32(InfoLevel))
+}
+
+// NewEvent returns an event with the default collectors registered in it.
+func NewEvent(category EventCategory, level ObservabilityLevel, name string, fields Fields) *GEvent {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return &GEvent{
+		name:       name,
+		category:   category,
+		level:      level,
+		time:       time.Now(),
+		fields:     fields,
+		collectors: append([]Collector(nil), collectors...),
+	}
+}
+
+// Name returns the name of the event, e.g. execution.finished.
+func (e *GEvent) Name() string {
+	return e.name
+}
+
+// Category returns the category of the event.
+func (e *GEvent) Category() EventCategory {
+	return e.category
+}
+
+// Level returns the level of the event.
+func (e *GEvent) Level() ObservabilityLevel {
+	return e.level
+}
+
+// Time returns the time the event was created at.
+func (e *GEvent) Time() time.Time {
+	return e.time
+}
+
+// Fields returns the data of the event.
+func (e *GEvent) Fields() Fields {
+	return e.fields
+}
+
+// ObserveEvent directs the event to the registered collectors observing its
+// level and category. Nothing is collected when the level of the event is
+// lower than the observability level.
+func (e *GEvent) ObserveEvent(ctx context.Context) {
+	if e.level < Level() {
+		return
+	}
+	for _, collector := range e.collectors {
+		if !collector.Observes(e) {
+			continue
+		}
+		if err := collector.Collect(ctx, e); err != nil {
+			zlog.Sugar().Warnf("failed to collect event %s: %v", e.name, err)
+		}
+	}
+}
+
+// RegisterCollector returns a copy of the event with the collector registered
+// in it, in addition to the collectors already registered.
+func RegisterCollector(e *GEvent, collector Collector) *GEvent {
+	event := *e
+	event.collectors = append(append([]Collector(nil), e.collectors...), collector)
+	return &event
+}
+
+// Observe creates an event with the default collectors and observes it.
+func Observe(ctx context.Context, category EventCategory, level ObservabilityLevel, name string, fields Fields) {
+	NewEvent(category, level, name, fields).ObserveEvent(ctx)
+}
+
+// Register adds collectors to the default collectors, registered in the
+// events created from now on.
+func Register(c ...Collector) {
+	mu.Lock()
+	defer mu.Unlock()
+	collectors = append(collectors, c...)
+}
+
+// Collectors returns the default collectors.
+func Collectors() []Collector {
+	mu.RLock()
+	defer mu.RUnlock()
+	return append([]Collector(nil), collectors...)
+}
+
+// Close closes and unregisters the default collectors.
+func Close() error {
+	mu.Lock()
+	closing := collectors
+	collectors = nil
+	mu.Unlock()
+
+	var firstErr error
+	for _, collector := range closing {
+		if err := collector.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Level returns the observability level of the DMS.
+func Level() ObservabilityLevel {
+	return ObservabilityLevel(level.Load())
+}
+
+// SetLevel sets the observability level of the DMS.
+func SetLevel(l ObservabilityLevel) {
+	level.Store(int32(l))
+}
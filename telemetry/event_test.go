@@ -0,0 +1,122 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingCollector keeps the events it collects.
+type recordingCollector struct {
+	Filter
+
+	mu     sync.Mutex
+	events []Event
+	closed bool
+}
+
+func (c *recordingCollector) Collect(_ context.Context, e Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *recordingCollector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *recordingCollector) names() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, 0, len(c.events))
+	for _, e := range c.events {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// resetTelemetry restores the default collectors and level after the test.
+func resetTelemetry(t *testing.T) {
+	t.Helper()
+	previous := Level()
+	t.Cleanup(func() {
+		Close()
+		SetLevel(previous)
+	})
+}
+
+func TestObserveEventRouting(t *testing.T) {
+	resetTelemetry(t)
+	SetLevel(DebugLevel)
+
+	accounting := &recordingCollector{Filter: Filter{Categories: []EventCategory{Accounting}}}
+	logs := &recordingCollector{Filter: Filter{Level: InfoLevel, Categories: []EventCategory{Logging}}}
+	all := &recordingCollector{}
+	Register(accounting, logs, all)
+
+	ctx := context.Background()
+	Observe(ctx, Accounting, InfoLevel, "execution.finished", Fields{"execution_id": "exec-1"})
+	Observe(ctx, Logging, InfoLevel, "node started", nil)
+	Observe(ctx, Logging, DebugLevel, "peer dialed", nil)
+	Observe(ctx, Tracing, TraceLevel, "below the observability level", nil)
+
+	assert.Equal(t, []string{"execution.finished"}, accounting.names())
+	assert.Equal(t, []string{"node started"}, logs.names())
+	assert.Equal(t, []string{"execution.finished", "node started", "peer dialed"}, all.names())
+}
+
+func TestRegisterCollector(t *testing.T) {
+	resetTelemetry(t)
+
+	defaultCollector := &recordingCollector{}
+	Register(defaultCollector)
+
+	event := NewEvent(Accounting, InfoLevel, "reward.requested", Fields{"tx_hash": "abc"})
+	custom := &recordingCollector{}
+	withCustom := RegisterCollector(event, custom)
+
+	event.ObserveEvent(context.Background())
+	assert.Len(t, defaultCollector.names(), 1)
+	assert.Empty(t, custom.names(), "the original event is not changed")
+
+	withCustom.ObserveEvent(context.Background())
+	assert.Len(t, defaultCollector.names(), 2)
+	assert.Equal(t, []string{"reward.requested"}, custom.names())
+	assert.Equal(t, "abc", custom.events[0].Fields()["tx_hash"])
+}
+
+func TestClose(t *testing.T) {
+	resetTelemetry(t)
+
+	collector := &recordingCollector{}
+	Register(collector)
+	require.NoError(t, Close())
+
+	assert.True(t, collector.closed)
+	assert.Empty(t, Collectors())
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	require.NoError(t, err)
+	assert.Equal(t, WarnLevel, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+
+	category, err := ParseCategory("accounting")
+	require.NoError(t, err)
+	assert.Equal(t, Accounting, category)
+
+	category, err = ParseCategory("Audit")
+	require.NoError(t, err)
+	assert.Equal(t, Audit, category)
+
+	_, err = ParseCategory("billing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,7 @@
+package telemetry
+
+import (
+	"gitlab.com/nunet/device-management-service/telemetry/logger"
+)
+
+var zlog = logger.OtelZapLogger("telemetry")
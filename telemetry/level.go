@@ -0,0 +1,86 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ObservabilityLevel is the level of an event, constructed similarly to log
+// levels. An event is observed when its level is at least the observability
+// level of the DMS, and collected by the collectors observing its level.
+type ObservabilityLevel int
+
+const (
+	TraceLevel ObservabilityLevel = iota
+	DebugLevel
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+	FatalLevel
+)
+
+var levelNames = map[ObservabilityLevel]string{
+	TraceLevel: "TRACE",
+	DebugLevel: "DEBUG",
+	InfoLevel:  "INFO",
+	WarnLevel:  "WARN",
+	ErrorLevel: "ERROR",
+	FatalLevel: "FATAL",
+}
+
+func (l ObservabilityLevel) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// MarshalText encodes the level by its name.
+func (l ObservabilityLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes a level name.
+func (l *ObservabilityLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// ParseLevel returns the level of a case insensitive level name, e.g. "info".
+func ParseLevel(name string) (ObservabilityLevel, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return TraceLevel, fmt.Errorf("unknown observability level %q", name)
+}
+
+// EventCategory is the reason why an event is observed, as opposed to its
+// level which tells how important it is. Collectors usually collect the
+// events of a few categories, e.g. accounting events are stored while log
+// events are written to the logs.
+type EventCategory string
+
+const (
+	Accounting EventCategory = "ACCOUNTING"
+	Logging    EventCategory = "LOGGING"
+	Tracing    EventCategory = "TRACING"
+	Heartbeat  EventCategory = "HEARTBEAT"
+	Audit      EventCategory = "AUDIT" // security relevant actions, e.g. the shell sessions
+)
+
+// ParseCategory returns the category of a case insensitive category name,
+// e.g. "accounting".
+func ParseCategory(name string) (EventCategory, error) {
+	category := EventCategory(strings.ToUpper(name))
+	switch category {
+	case Accounting, Logging, Tracing, Heartbeat, Audit:
+		return category, nil
+	}
+	return "", fmt.Errorf("unknown event category %q", name)
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+// fileWriter writes the logs of every package to the rotated log file, it
+// discards them while no file is configured
+type fileWriter struct {
+	mu  sync.Mutex
+	out *lumberjack.Logger
+}
+
+// open replaces the log file with the one of the configuration
+func (w *fileWriter) open(cfg config.LogFile, dataDir string) error {
+	var out *lumberjack.Logger
+	if cfg.Enabled {
+		path := cfg.Path
+		if path == "" {
+			path = filepath.Join(dataDir, "logs", "dms.log")
+		}
+		out = &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    cfg.MaxSize,
+			MaxAge:     cfg.MaxAge,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+			LocalTime:  true,
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	previous := w.out
+	w.out = out
+	if previous != nil {
+		return previous.Close()
+	}
+	return nil
+}
+
+func (w *fileWriter) enabled() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.out != nil
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil {
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// Sync does nothing, lumberjack writes to the file without buffering
+func (w *fileWriter) Sync() error {
+	return nil
+}
+
+// fileEncoderConfig encodes the file entries as JSON with RFC 3339 timestamps
+func fileEncoderConfig() zapcore.EncoderConfig {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(time.RFC3339Nano)
+	return encoderConfig
+}
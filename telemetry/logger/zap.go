@@ -1,7 +1,10 @@
 package logger
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
@@ -9,50 +12,184 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/models"
 )
 
+// DefaultPackage names the default level in SetLevel and Levels, it applies
+// to the packages without their own level
+const DefaultPackage = "default"
+
 var (
-	err    error
-	once   sync.Once
-	logger *otelzap.Logger
+	once sync.Once
+	mu   sync.Mutex
+
+	development  bool
+	console      = zapcore.Lock(os.Stderr)
+	file         = &fileWriter{}
+	defaultLevel = zapcore.InfoLevel
+	overrides    = make(map[string]zapcore.Level)   // levels set for packages and their subpackages
+	packages     = make(map[string]zap.AtomicLevel) // levels of the package loggers
 )
 
 type Logger struct {
 	*zap.Logger
 }
 
-func (l *Logger) init() error {
-	if _, debug := os.LookupEnv("NUNET_DEBUG"); debug || config.GetConfig().General.Debug {
-		zapConfig := zap.NewDevelopmentConfig()
-		zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-		l.Logger, _ = zapConfig.Build()
+// New takes in a package to initlialize the new Logger in. The level of the
+// logger is the one set for the package, or for the closest parent package
+// of a dotted name such as network.libp2p, and the default level otherwise.
+func New(pkg string) *Logger {
+	once.Do(setup)
+
+	mu.Lock()
+	level, ok := packages[pkg]
+	if !ok {
+		level = zap.NewAtomicLevelAt(levelFor(pkg))
+		packages[pkg] = level
+	}
+	mu.Unlock()
 
-	} else {
-		l.Logger, err = zap.NewProduction()
+	consoleEncoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	opts := []zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)}
+	if development {
+		encoderConfig := zap.NewDevelopmentEncoderConfig()
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		consoleEncoder = zapcore.NewConsoleEncoder(encoderConfig)
+		opts = []zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.WarnLevel), zap.Development()}
 	}
 
-	return err
+	core := zapcore.NewTee(
+		zapcore.NewCore(consoleEncoder, console, level),
+		zapcore.NewCore(
+			zapcore.NewJSONEncoder(fileEncoderConfig()),
+			file,
+			zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+				return file.enabled() && level.Enabled(l)
+			}),
+		),
+	)
+
+	return &Logger{zap.New(core, opts...).With(zap.String("package", pkg))}
 }
 
-// New takes in a package to initlialize the new Logger in.
-func New(pkg string) *Logger {
-	Log := &Logger{}
-	err = Log.init()
+// OtelZapLogger returns the logger of the package wrapped to also record the
+// logs on the span of the context, see otelzap.Logger.Ctx
+func OtelZapLogger(pkg string) otelzap.Logger {
+	return *otelzap.New(New(pkg).Logger)
+}
+
+// setup applies the configuration loaded when the first logger is created
+func setup() {
+	cfg := config.GetConfig()
+	_, debug := os.LookupEnv("NUNET_DEBUG")
+	development = debug || cfg.General.Debug
+
+	if err := Configure(cfg.Telemetry.Logging, cfg.General.DataDir); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid logging configuration: %v\n", err)
+	}
+}
+
+// Configure sets the levels and the log file of the configuration, replacing
+// the levels set at runtime
+func Configure(cfg config.Logging, dataDir string) error {
+	level := zapcore.DebugLevel
+	if !development {
+		var err error
+		if level, err = parseLevel(cfg.Level); err != nil {
+			return err
+		}
+	}
+
+	levels := make(map[string]zapcore.Level, len(cfg.Packages))
+	for _, pkg := range cfg.Packages {
+		l, err := parseLevel(pkg.Level)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", pkg.Package, err)
+		}
+		levels[pkg.Package] = l
+	}
+
+	if err := file.open(cfg.File, dataDir); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	defaultLevel = level
+	overrides = levels
+	updateLevels()
+	return nil
+}
+
+// SetLevel changes the level of the package and its subpackages at runtime,
+// DefaultPackage changes the level of the packages without their own level
+func SetLevel(pkg, level string) error {
+	l, err := parseLevel(level)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	Log.Logger = Log.Logger.With(
-		zap.String("package", pkg),
-	)
+	mu.Lock()
+	defer mu.Unlock()
+	if pkg == DefaultPackage {
+		defaultLevel = l
+	} else {
+		overrides[pkg] = l
+	}
+	updateLevels()
+	return nil
+}
 
-	return Log
+// ResetLevel removes the level set for the package, which then follows the
+// level of its parent package or the default level
+func ResetLevel(pkg string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(overrides, pkg)
+	updateLevels()
 }
 
-func OtelZapLogger(pkg string) otelzap.Logger {
-	once.Do(func() {
-		l := New(pkg)
-		logger = otelzap.New(l.Logger)
+// Levels returns the default level followed by the level of every package
+// logger, sorted by package
+func Levels() []models.LogLevel {
+	mu.Lock()
+	defer mu.Unlock()
+
+	levels := make([]models.LogLevel, 0, len(packages))
+	for pkg, level := range packages {
+		levels = append(levels, models.LogLevel{Package: pkg, Level: level.Level().String()})
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Package < levels[j].Package
 	})
-	return *logger
+	return append([]models.LogLevel{{Package: DefaultPackage, Level: defaultLevel.String()}}, levels...)
+}
+
+// levelFor returns the level of the package, the caller must hold mu
+func levelFor(pkg string) zapcore.Level {
+	level, found := defaultLevel, ""
+	for name, l := range overrides {
+		if (pkg == name || strings.HasPrefix(pkg, name+".")) && len(name) > len(found) {
+			level, found = l, name
+		}
+	}
+	return level
+}
+
+// updateLevels applies the levels to the package loggers, the caller must hold mu
+func updateLevels() {
+	for pkg, level := range packages {
+		level.SetLevel(levelFor(pkg))
+	}
+}
+
+func parseLevel(text string) (zapcore.Level, error) {
+	if text == "" {
+		return zapcore.InfoLevel, nil
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(strings.ToLower(text))); err != nil {
+		return level, fmt.Errorf("invalid log level %q", text)
+	}
+	return level, nil
 }
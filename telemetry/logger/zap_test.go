@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+func TestSetLevel(t *testing.T) {
+	network := New("network")
+	libp2p := New("network.libp2p")
+	api := New("api")
+	t.Cleanup(func() {
+		ResetLevel("network")
+		ResetLevel("network.libp2p")
+	})
+
+	require.NoError(t, SetLevel("network", "debug"))
+	assert.True(t, network.Core().Enabled(zapcore.DebugLevel))
+	assert.True(t, libp2p.Core().Enabled(zapcore.DebugLevel), "subpackages follow the level of their parent")
+	assert.Equal(t, api.Core().Enabled(zapcore.DebugLevel), levelOf(t, DefaultPackage) == "debug")
+
+	require.NoError(t, SetLevel("network.libp2p", "ERROR"))
+	assert.False(t, libp2p.Core().Enabled(zapcore.WarnLevel))
+	assert.True(t, network.Core().Enabled(zapcore.DebugLevel))
+	assert.Equal(t, "error", levelOf(t, "network.libp2p"))
+
+	ResetLevel("network.libp2p")
+	assert.Equal(t, "debug", levelOf(t, "network.libp2p"))
+
+	assert.Error(t, SetLevel("network", "verbose"))
+}
+
+func TestLevels(t *testing.T) {
+	New("zeta")
+	New("alpha")
+
+	levels := Levels()
+	require.NotEmpty(t, levels)
+	assert.Equal(t, DefaultPackage, levels[0].Package)
+
+	var names []string
+	for _, l := range levels[1:] {
+		names = append(names, l.Package)
+	}
+	assert.IsIncreasing(t, names)
+	assert.Contains(t, names, "alpha")
+	assert.Contains(t, names, "zeta")
+}
+
+func TestConfigure(t *testing.T) {
+	log := New("storage")
+	path := filepath.Join(t.TempDir(), "dms.log")
+	t.Cleanup(func() {
+		require.NoError(t, Configure(config.Logging{Level: "info"}, ""))
+	})
+
+	err := Configure(config.Logging{
+		Level:    "warn",
+		Packages: []config.PackageLevel{{Package: "storage", Level: "debug"}},
+		File:     config.LogFile{Enabled: true, Path: path, MaxSize: 1},
+	}, "")
+	require.NoError(t, err)
+
+	log.Debug("volume locked", zap.String("volume", "vol-1"))
+	New("api").Info("not written, below the default level")
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 1)
+	assert.Equal(t, "volume locked", entries[0]["msg"])
+	assert.Equal(t, "storage", entries[0]["package"])
+	assert.Equal(t, "vol-1", entries[0]["volume"])
+
+	err = Configure(config.Logging{Packages: []config.PackageLevel{{Package: "storage", Level: "loud"}}}, "")
+	assert.Error(t, err)
+}
+
+func levelOf(t *testing.T, pkg string) string {
+	t.Helper()
+	for _, l := range Levels() {
+		if l.Package == pkg {
+			return l.Level
+		}
+	}
+	t.Fatalf("no level for package %s", pkg)
+	return ""
+}
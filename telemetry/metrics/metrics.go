@@ -0,0 +1,161 @@
+// Package metrics exports the Prometheus metrics of the DMS. The labels only
+// take values from small fixed sets, job, execution and peer IDs are never
+// used as labels so that the number of series stays bounded.
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "nunet_dms"
+
+// Registry holds the metrics of the DMS. The handler also serves the default
+// registry, which holds the Go runtime, process and libp2p metrics.
+var Registry = prometheus.NewRegistry()
+
+// executors are the values of the executor label, others are reported as "other"
+var executors = map[string]bool{"docker": true, "firecracker": true}
+
+var (
+	executions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "executions_total",
+		Help:      "Executions by executor and state: started, succeeded or failed.",
+	}, []string{"executor", "state"})
+
+	executionsRunning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "executions_running",
+		Help:      "Executions currently running by executor.",
+	}, []string{"executor"})
+
+	executionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "execution_duration_seconds",
+		Help:      "Duration of the finished executions by executor and state.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10), // 1s to ~3 days
+	}, []string{"executor", "state"})
+
+	imagePullDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "image_pull_duration_seconds",
+		Help:      "Duration of the image pulls by executor and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 12), // 0.5s to ~17min
+	}, []string{"executor", "result"})
+
+	volumeBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "volume_bytes_total",
+		Help:      "Bytes written to the storage volumes, counted when the volumes are locked.",
+	})
+
+	peers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "peers_connected",
+		Help:      "Peers connected to the node.",
+	})
+
+	dhtLookupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dht_lookup_duration_seconds",
+		Help:      "Duration of the DHT lookups by operation and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10), // 50ms to ~25s
+	}, []string{"operation", "result"})
+
+	taskRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_task_runs_total",
+		Help:      "Runs of the scheduled tasks by status: success, failed or cancelled.",
+	}, []string{"status"})
+
+	taskDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scheduler_task_duration_seconds",
+		Help:      "Duration of the runs of the scheduled tasks, retries included.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10), // 10ms to ~45min
+	})
+
+	resourcesFree = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "resources_free",
+		Help:      "Onboarded resources not used by the executions: cpu_hz, vcpu, ram (MB) and disk.",
+	}, []string{"resource"})
+
+	resourcesAvailable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "resources_available",
+		Help:      "Resources onboarded to NuNet: cpu_hz, vcpu, ram (MB) and disk.",
+	}, []string{"resource"})
+)
+
+func init() {
+	Registry.MustRegister(
+		executions,
+		executionsRunning,
+		executionDuration,
+		imagePullDuration,
+		volumeBytes,
+		peers,
+		dhtLookupDuration,
+		taskRuns,
+		taskDuration,
+		resourcesFree,
+		resourcesAvailable,
+	)
+}
+
+// Handler serves the metrics in the OpenMetrics or Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(
+		prometheus.Gatherers{prometheus.DefaultGatherer, Registry},
+		promhttp.HandlerOpts{EnableOpenMetrics: true},
+	)
+}
+
+// ObserveImagePull records the duration of an image pull started at start
+func ObserveImagePull(executor string, start time.Time, err error) {
+	imagePullDuration.WithLabelValues(executorLabel(executor), resultLabel(err)).Observe(time.Since(start).Seconds())
+}
+
+// ObserveDHTLookup records the duration of a DHT lookup started at start,
+// operation is a constant such as "get_value" or "find_peers"
+func ObserveDHTLookup(operation string, start time.Time, err error) {
+	dhtLookupDuration.WithLabelValues(operation, resultLabel(err)).Observe(time.Since(start).Seconds())
+}
+
+// AddVolumeBytes counts the bytes of a volume whose data was written
+func AddVolumeBytes(n int64) {
+	if n > 0 {
+		volumeBytes.Add(float64(n))
+	}
+}
+
+// SetPeers records the number of connected peers
+func SetPeers(n int) {
+	peers.Set(float64(n))
+}
+
+// ObserveTaskRun records a run of a scheduled task with its final status
+func ObserveTaskRun(status string, duration time.Duration) {
+	taskRuns.WithLabelValues(strings.ToLower(status)).Inc()
+	taskDuration.Observe(duration.Seconds())
+}
+
+func executorLabel(executor string) string {
+	if executors[executor] {
+		return executor
+	}
+	return "other"
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func TestWatcherExecutions(t *testing.T) {
+	bus := events.NewBus()
+	watcher := Watch(bus)
+
+	started := testutil.ToFloat64(executions.WithLabelValues("docker", "started"))
+	failed := testutil.ToFloat64(executions.WithLabelValues("docker", "failed"))
+	succeeded := testutil.ToFloat64(executions.WithLabelValues("other", "succeeded"))
+
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", ExecutionID: "exec-1"})
+	bus.Publish(events.ExecutionStarted, events.Execution{Executor: "docker", ExecutionID: "exec-2"})
+	bus.Publish(events.ExecutionFinished, events.Execution{
+		Executor:    "docker",
+		ExecutionID: "exec-1",
+		Result:      &models.ExecutionResult{ExitCode: 1},
+	})
+	// unknown executors share a single label value
+	bus.Publish(events.ExecutionFinished, events.Execution{
+		Executor:    "wasm",
+		ExecutionID: "exec-3",
+		Result:      &models.ExecutionResult{},
+	})
+	watcher.Close()
+
+	assert.Equal(t, started+2, testutil.ToFloat64(executions.WithLabelValues("docker", "started")))
+	assert.Equal(t, failed+1, testutil.ToFloat64(executions.WithLabelValues("docker", "failed")))
+	assert.Equal(t, succeeded+1, testutil.ToFloat64(executions.WithLabelValues("other", "succeeded")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(executionsRunning.WithLabelValues("docker")))
+	assert.Equal(t, 3, testutil.CollectAndCount(executions), "no series for the wasm executor")
+}
+
+func TestWatcherResources(t *testing.T) {
+	bus := events.NewBus()
+	watcher := Watch(bus)
+
+	bus.Publish(events.ResourcesChanged, events.Resources{
+		Free:      models.FreeResources{Vcpu: 2, Ram: 1024},
+		Available: models.AvailableResources{Vcpu: 4, Ram: 4096},
+	})
+	watcher.Close()
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(resourcesFree.WithLabelValues("vcpu")))
+	assert.Equal(t, float64(1024), testutil.ToFloat64(resourcesFree.WithLabelValues("ram")))
+	assert.Equal(t, float64(4096), testutil.ToFloat64(resourcesAvailable.WithLabelValues("ram")))
+}
+
+func TestObserve(t *testing.T) {
+	runs := testutil.ToFloat64(taskRuns.WithLabelValues("failed"))
+	ObserveTaskRun("FAILED", time.Second)
+	assert.Equal(t, runs+1, testutil.ToFloat64(taskRuns.WithLabelValues("failed")))
+
+	ObserveImagePull("docker", time.Now(), errors.New("not found"))
+	ObserveDHTLookup("get_value", time.Now(), nil)
+	assert.Equal(t, 1, testutil.CollectAndCount(imagePullDuration, "nunet_dms_image_pull_duration_seconds"))
+	assert.Equal(t, 1, testutil.CollectAndCount(dhtLookupDuration, "nunet_dms_dht_lookup_duration_seconds"))
+
+	bytes := testutil.ToFloat64(volumeBytes)
+	AddVolumeBytes(512)
+	AddVolumeBytes(-1)
+	assert.Equal(t, bytes+512, testutil.ToFloat64(volumeBytes))
+
+	SetPeers(3)
+	assert.Equal(t, float64(3), testutil.ToFloat64(peers))
+}
+
+func TestHandler(t *testing.T) {
+	SetPeers(1)
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "nunet_dms_peers_connected 1")
+	assert.Contains(t, string(body), "go_goroutines", "the runtime metrics of the default registry are served")
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"gitlab.com/nunet/device-management-service/internal/events"
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// Watcher derives the execution and resource metrics from the events of a bus
+type Watcher struct {
+	sub  *events.Subscription
+	done chan struct{}
+
+	mu      sync.Mutex
+	started map[string]time.Time // start of the running executions by ID
+}
+
+// Watch subscribes to the bus, the metrics follow the events published from
+// now on until the watcher is closed
+func Watch(bus *events.Bus) *Watcher {
+	w := &Watcher{
+		sub: bus.Subscribe(
+			events.DefaultBufferSize,
+			events.Types(events.ExecutionStarted, events.ExecutionFinished, events.ResourcesChanged),
+		),
+		done:    make(chan struct{}),
+		started: make(map[string]time.Time),
+	}
+	go w.follow()
+	return w
+}
+
+func (w *Watcher) follow() {
+	defer close(w.done)
+	for e := range w.sub.C {
+		w.apply(e)
+	}
+}
+
+func (w *Watcher) apply(e events.Event) {
+	switch payload := e.Payload.(type) {
+	case events.Execution:
+		w.execution(e.Type, e.Time, payload)
+	case events.Resources:
+		setResources(resourcesFree, float64(payload.Free.TotCpuHz), float64(payload.Free.Vcpu),
+			float64(payload.Free.Ram), payload.Free.Disk)
+		setResources(resourcesAvailable, float64(payload.Available.TotCpuHz), float64(payload.Available.Vcpu),
+			float64(payload.Available.Ram), payload.Available.Disk)
+	}
+}
+
+func (w *Watcher) execution(typ events.Type, at time.Time, payload events.Execution) {
+	executor := executorLabel(payload.Executor)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if typ == events.ExecutionStarted {
+		w.started[payload.ExecutionID] = at
+		executions.WithLabelValues(executor, "started").Inc()
+		executionsRunning.WithLabelValues(executor).Inc()
+		return
+	}
+
+	state := executionState(payload.Result)
+	executions.WithLabelValues(executor, state).Inc()
+	// executions failing to start finish without having started
+	if start, ok := w.started[payload.ExecutionID]; ok {
+		delete(w.started, payload.ExecutionID)
+		executionsRunning.WithLabelValues(executor).Dec()
+		executionDuration.WithLabelValues(executor, state).Observe(at.Sub(start).Seconds())
+	}
+}
+
+// Close unsubscribes from the bus
+func (w *Watcher) Close() {
+	w.sub.Close()
+	<-w.done
+}
+
+func executionState(result *models.ExecutionResult) string {
+	if result == nil || result.ErrorMsg != "" || result.ExitCode != 0 {
+		return "failed"
+	}
+	return "succeeded"
+}
+
+func setResources(gauge *prometheus.GaugeVec, cpuHz, vcpu, ram, disk float64) {
+	gauge.WithLabelValues("cpu_hz").Set(cpuHz)
+	gauge.WithLabelValues("vcpu").Set(vcpu)
+	gauge.WithLabelValues("ram").Set(ram)
+	gauge.WithLabelValues("disk").Set(disk)
+}
@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/hex"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+// InjectTrace returns the trace context of ctx encoded by the global
+// propagator, it is meant to be sent along with a message to another DMS.
+// The carrier is empty if ctx has no span or no propagator is set.
+func InjectTrace(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// ExtractTrace returns a copy of ctx carrying the trace context received
+// with a message, spans started from it are children of the remote span.
+func ExtractTrace(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// TraceInfo returns the span context of ctx in the form carried by the
+// deployment requests, it is empty if ctx has no valid span context.
+func TraceInfo(ctx context.Context) models.TraceInfo {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return models.TraceInfo{}
+	}
+	return models.TraceInfo{
+		TraceID:     sc.TraceID().String(),
+		SpanID:      sc.SpanID().String(),
+		TraceFlags:  sc.TraceFlags().String(),
+		TraceStates: sc.TraceState().String(),
+	}
+}
+
+// ContextWithTraceInfo returns a copy of ctx whose remote parent is the span
+// described by info. ctx is returned unchanged if info is empty or invalid.
+func ContextWithTraceInfo(ctx context.Context, info models.TraceInfo) context.Context {
+	traceID, err := trace.TraceIDFromHex(info.TraceID)
+	if err != nil {
+		return ctx
+	}
+	spanID, err := trace.SpanIDFromHex(info.SpanID)
+	if err != nil {
+		return ctx
+	}
+
+	config := trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, Remote: true}
+	if flags, err := hex.DecodeString(info.TraceFlags); err == nil && len(flags) == 1 {
+		config.TraceFlags = trace.TraceFlags(flags[0])
+	}
+	if state, err := trace.ParseTraceState(info.TraceStates); err == nil {
+		config.TraceState = state
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(config))
+}
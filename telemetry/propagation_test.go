@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/nunet/device-management-service/models"
+)
+
+func TestInjectExtractTrace(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "deploy")
+	defer span.End()
+
+	carrier := InjectTrace(ctx)
+	require.Contains(t, carrier, "traceparent")
+
+	remote := trace.SpanContextFromContext(ExtractTrace(context.Background(), carrier))
+	assert.True(t, remote.IsRemote())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+
+	assert.Empty(t, InjectTrace(context.Background()), "no span, nothing to propagate")
+	assert.Equal(t, context.Background(), ExtractTrace(context.Background(), nil))
+}
+
+func TestTraceInfo(t *testing.T) {
+	assert.Equal(t, models.TraceInfo{}, TraceInfo(context.Background()))
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "deploy")
+	defer span.End()
+
+	info := TraceInfo(ctx)
+	assert.Equal(t, span.SpanContext().TraceID().String(), info.TraceID)
+	assert.Equal(t, "01", info.TraceFlags)
+
+	remote := trace.SpanContextFromContext(ContextWithTraceInfo(context.Background(), info))
+	assert.True(t, remote.IsRemote())
+	assert.True(t, remote.IsSampled())
+	assert.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), remote.SpanID())
+
+	invalid := ContextWithTraceInfo(context.Background(), models.TraceInfo{TraceID: "nope"})
+	assert.False(t, trace.SpanContextFromContext(invalid).IsValid())
+}
@@ -0,0 +1,7 @@
+package tracing
+
+import (
+	"gitlab.com/nunet/device-management-service/telemetry/logger"
+)
+
+var zlog = logger.OtelZapLogger("tracing")
@@ -0,0 +1,119 @@
+// Package tracing configures the OpenTelemetry tracing pipeline of the DMS:
+// the tracer provider, its sampler and resource, the OTLP exporter and the
+// propagation of the trace context to the other DMSes.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+const (
+	// PeerIDKey is the resource attribute identifying the node by its peer ID
+	PeerIDKey = attribute.Key("nunet.peer.id")
+
+	// MachineUUIDKey is the resource attribute identifying the node by its machine UUID
+	MachineUUIDKey = attribute.Key("nunet.machine.uuid")
+)
+
+// Setup installs the propagator of the trace context and, if tracing is
+// enabled, a tracer provider exporting the spans to the OTLP collector of the
+// configuration. attributes are added to the resource of the spans, e.g. the
+// peer ID and the machine UUID. The returned function flushes the pending
+// spans and stops the exporter, it does nothing when tracing is disabled.
+func Setup(ctx context.Context, cfg config.Tracing, attributes ...attribute.KeyValue) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	sampler, err := newSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(cfg, attributes)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	zlog.Sugar().Infof("exporting traces to %s with %s", cfg.Endpoint, cfg.Exporter)
+
+	return provider.Shutdown, nil
+}
+
+// newSampler returns the sampler of the root spans, the spans whose parent
+// is known, including remote ones, follow the decision of their parent so
+// that a trace is complete across the peers.
+func newSampler(cfg config.Tracing) (sdktrace.Sampler, error) {
+	var root sdktrace.Sampler
+	switch cfg.Sampler {
+	case "", "always_on":
+		root = sdktrace.AlwaysSample()
+	case "always_off":
+		root = sdktrace.NeverSample()
+	case "ratio":
+		if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+			return nil, fmt.Errorf("invalid sample ratio %v, expected a value between 0 and 1", cfg.SampleRatio)
+		}
+		root = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	default:
+		return nil, fmt.Errorf("unsupported sampler %q", cfg.Sampler)
+	}
+	return sdktrace.ParentBased(root), nil
+}
+
+// newResource describes the node emitting the spans
+func newResource(cfg config.Tracing, attributes []attribute.KeyValue) (*resource.Resource, error) {
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = "nunet-dms"
+	}
+	attrs := append([]attribute.KeyValue{semconv.ServiceNameKey.String(serviceName)}, attributes...)
+
+	pairs, err := parsePairs(cfg.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource attributes: %w", err)
+	}
+	for key, value := range pairs {
+		attrs = append(attrs, attribute.String(key, value))
+	}
+
+	return resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attrs...))
+}
+
+// parsePairs parses a list of key=value pairs
+func parsePairs(pairs []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		parsed[key] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
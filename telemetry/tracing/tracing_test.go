@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.com/nunet/device-management-service/internal/config"
+)
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.Tracing{Enabled: false})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	fields := otel.GetTextMapPropagator().Fields()
+	assert.Contains(t, fields, "traceparent", "the trace context is propagated even if spans are not exported")
+	assert.Contains(t, fields, "baggage")
+}
+
+func TestSetupInvalidConfig(t *testing.T) {
+	tests := []struct {
+		description string
+		cfg         config.Tracing
+	}{
+		{description: "unknown sampler", cfg: config.Tracing{Enabled: true, Sampler: "sometimes"}},
+		{description: "ratio out of range", cfg: config.Tracing{Enabled: true, Sampler: "ratio", SampleRatio: 2}},
+		{description: "invalid attribute", cfg: config.Tracing{Enabled: true, Attributes: []string{"region"}}},
+		{description: "invalid header", cfg: config.Tracing{Enabled: true, Headers: []string{"=token"}}},
+		{description: "unknown exporter", cfg: config.Tracing{Enabled: true, Exporter: "carrier-pigeon"}},
+	}
+
+	for _, tc := range tests {
+		_, err := Setup(context.Background(), tc.cfg)
+		assert.Error(t, err, tc.description)
+	}
+}
+
+func TestNewSampler(t *testing.T) {
+	sampled := func(sampler sdktrace.Sampler, parent trace.SpanContext) bool {
+		ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+		result := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx, TraceID: trace.TraceID{1}})
+		return result.Decision == sdktrace.RecordAndSample
+	}
+	sampledParent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+
+	sampler, err := newSampler(config.Tracing{Sampler: "always_off"})
+	require.NoError(t, err)
+	assert.False(t, sampled(sampler, trace.SpanContext{}))
+	assert.True(t, sampled(sampler, sampledParent), "the decision of the remote parent is followed")
+
+	sampler, err = newSampler(config.Tracing{Sampler: "ratio", SampleRatio: 1})
+	require.NoError(t, err)
+	assert.True(t, sampled(sampler, trace.SpanContext{}))
+}
+
+func TestNewResource(t *testing.T) {
+	res, err := newResource(
+		config.Tracing{Attributes: []string{"deployment.environment = testnet"}},
+		[]attribute.KeyValue{PeerIDKey.String("12D3KooW"), MachineUUIDKey.String("machine-1")},
+	)
+	require.NoError(t, err)
+
+	attrs := make(map[attribute.Key]string)
+	for _, kv := range res.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	assert.Equal(t, "nunet-dms", attrs["service.name"])
+	assert.Equal(t, "12D3KooW", attrs[PeerIDKey])
+	assert.Equal(t, "machine-1", attrs[MachineUUIDKey])
+	assert.Equal(t, "testnet", attrs["deployment.environment"])
+}
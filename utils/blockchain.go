@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -15,6 +16,7 @@ import (
 	"github.com/fivebinaries/go-cardano-serialization/address"
 	"gitlab.com/nunet/device-management-service/db"
 	"gitlab.com/nunet/device-management-service/models"
+	"gitlab.com/nunet/device-management-service/telemetry"
 )
 
 // KoiosEndpoint type for Koios rest api endpoints
@@ -110,7 +112,11 @@ func RequestReward(claim ClaimCardanoTokenBody) (*rewardRespToCPD, error) {
 		return nil, fmt.Errorf("unknown tx hash: %w", err)
 	}
 
-	zlog.Sugar().Infof("service found from txHash: %+v", service)
+	telemetry.Observe(context.Background(), telemetry.Accounting, telemetry.InfoLevel, "reward.requested", telemetry.Fields{
+		"tx_hash":          service.TxHash,
+		"transaction_type": service.TransactionType,
+		"job_status":       service.JobStatus,
+	})
 	if service.JobStatus == "running" {
 		return nil, fmt.Errorf("job is still running")
 		// c.JSON(503, gin.H{"error": "the job is still running"})
@@ -132,7 +138,9 @@ func RequestReward(claim ClaimCardanoTokenBody) (*rewardRespToCPD, error) {
 
 func SendStatus(status models.BlockchainTxStatus) string {
 	if status.TransactionStatus == "success" {
-		zlog.Sugar().Infof("withdraw transaction successful - updating DB")
+		telemetry.Observe(context.Background(), telemetry.Accounting, telemetry.InfoLevel, "withdraw.succeeded", telemetry.Fields{
+			"tx_hash": status.TxHash,
+		})
 		// Partial deletion of entry
 		var service models.Services
 		err := db.DB.Where("tx_hash = ?", status.TxHash).Find(&service).Error
@@ -5,10 +5,19 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
+	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"gitlab.com/nunet/device-management-service/internal/auth"
 	"gitlab.com/nunet/device-management-service/internal/config"
+	"gitlab.com/nunet/device-management-service/internal/endpoint"
+)
+
+var (
+	apiClientOnce sync.Once
+	apiClient     *endpoint.Client
+	apiClientErr  error
 )
 
 // InternalAPIURL is a helper method to compose API URLs
@@ -17,23 +26,11 @@ func InternalAPIURL(protocol, endpoint, query string) (string, error) {
 		return "", fmt.Errorf("protocol and endpoint values must be specified")
 	}
 
-	if protocol != "http" && protocol != "ws" {
-		return "", fmt.Errorf("invalid protocol: %s", protocol)
-	}
-
-	port := config.GetConfig().Rest.Port
-	if port == 0 {
-		return "", fmt.Errorf("port is not configured")
-	}
-
-	serverURL := url.URL{
-		Scheme:   protocol,
-		Host:     fmt.Sprintf("localhost:%d", port),
-		Path:     endpoint,
-		RawQuery: query,
+	client, err := internalClient()
+	if err != nil {
+		return "", err
 	}
-
-	return serverURL.String(), nil
+	return client.URL(protocol, endpoint, query)
 }
 
 // MakeInternalRequest is a helper method to make call to DMS's own API
@@ -52,8 +49,12 @@ func MakeInternalRequest(c *gin.Context, methodType, internalEndpoint, query str
 		return nil, err
 	}
 
-	client := http.Client{}
+	client, err := InternalHTTPClient()
+	if err != nil {
+		return nil, err
+	}
 
+	req.Header = AuthorizationHeader()
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	req.Header.Set("Accept", "application/json")
 
@@ -74,6 +75,45 @@ func MakeInternalRequest(c *gin.Context, methodType, internalEndpoint, query str
 	return resp, nil
 }
 
+// AuthorizationHeader returns a header authenticating the requests to DMS's
+// own API with the token of the NUNET_API_TOKEN environment variable or of the
+// credentials file, see the internal/auth package
+func AuthorizationHeader() http.Header {
+	header := http.Header{}
+	if token := auth.ClientToken(config.GetConfig()); token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return header
+}
+
+// InternalHTTPClient returns an HTTP client connecting to DMS's own API
+// through the endpoint of the configuration, see the internal/endpoint package
+func InternalHTTPClient() (*http.Client, error) {
+	client, err := internalClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.HTTPClient(), nil
+}
+
+// InternalWebSocketDialer returns a websocket dialer connecting to DMS's own API
+func InternalWebSocketDialer() (*websocket.Dialer, error) {
+	client, err := internalClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.WebSocketDialer(), nil
+}
+
+// internalClient discovers the endpoint of the API once per process, e.g.
+// whether the current user can connect to its Unix socket
+func internalClient() (*endpoint.Client, error) {
+	apiClientOnce.Do(func() {
+		apiClient, apiClientErr = endpoint.NewClient(endpoint.FromConfig(config.GetConfig()))
+	})
+	return apiClient, apiClientErr
+}
+
 func MakeRequest(c *gin.Context, client *http.Client, uri string, body []byte, errMsg string) error {
 	// set the HTTP method, url, and request body
 	req, err := http.NewRequest(http.MethodPut, uri, bytes.NewBuffer(body))